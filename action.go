@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"golang.org/x/xerrors"
+)
+
+// useActionToken makes the "token" input available as GITHUB_TOKEN to the GitHub reporters.
+func useActionToken() {
+	if token := os.Getenv("INPUT_TOKEN"); token != "" && os.Getenv("GITHUB_TOKEN") == "" {
+		os.Setenv("GITHUB_TOKEN", token)
+	}
+}
+
+func appendToFile(path, s string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return xerrors.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+	if _, err = f.WriteString(s); err != nil {
+		return xerrors.Errorf("failed to write to %s: %w", path, err)
+	}
+	return nil
+}
+
+// generateActionOutputs returns the step outputs in the format of $GITHUB_OUTPUT.
+func generateActionOutputs(results []result, threshold float64, comparedScore comparedScore, added, removed []string) string {
+	var regressions int
+	for _, r := range results {
+		if isDegression(r, threshold, comparedScore) {
+			regressions++
+		}
+	}
+	summary := &bytes.Buffer{}
+	showSummaryLine(summary, results, threshold, comparedScore, added, removed)
+	return fmt.Sprintf("degression=%t\nregressions=%d\nsummary=%s", regressions > 0, regressions, summary.String())
+}
+
+// writeActionOutputs sets the step outputs and adds the comparison to the job summary when running on
+// GitHub Actions.
+func writeActionOutputs(outputs, markdown string) error {
+	if path := os.Getenv("GITHUB_OUTPUT"); path != "" {
+		if err := appendToFile(path, outputs); err != nil {
+			return err
+		}
+	}
+	if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+		if err := appendToFile(path, markdown); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_generateActionOutputs(t *testing.T) {
+	compare := comparedScore{nsPerOp: true, allocedBytesPerOp: true}
+	results := []result{
+		{Name: "BenchmarkA", RatioNsPerOp: 0.5},
+		{Name: "BenchmarkB", RatioNsPerOp: -0.1},
+	}
+	want := "degression=true\nregressions=1\nsummary=cob: 1 regressed, 1 improved, 0 unchanged, worst=+50.0% (BenchmarkA)\n"
+	assert.Equal(t, want, generateActionOutputs(results, 0.2, compare, nil, nil))
+}
+
+func Test_writeActionOutputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	output, summary := filepath.Join(dir, "output"), filepath.Join(dir, "summary")
+	require.NoError(t, ioutil.WriteFile(output, []byte("previous=1\n"), 0644))
+	os.Setenv("GITHUB_OUTPUT", output)
+	os.Setenv("GITHUB_STEP_SUMMARY", summary)
+	defer os.Unsetenv("GITHUB_OUTPUT")
+	defer os.Unsetenv("GITHUB_STEP_SUMMARY")
+
+	require.NoError(t, writeActionOutputs("degression=false\n", "## Benchmark comparison\n"))
+
+	b, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "previous=1\ndegression=false\n", string(b))
+	b, err = ioutil.ReadFile(summary)
+	require.NoError(t, err)
+	assert.Equal(t, "## Benchmark comparison\n", string(b))
+}
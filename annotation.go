@@ -0,0 +1,91 @@
+package main
+
+import (
+	"go/ast"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// annotationPrefix starts the magic comments above a Benchmark function, e.g. "//cob:threshold=0.25". Like
+// "//go:" directives, there is no space after the slashes, so that prose mentioning cob is not mistaken for one.
+const annotationPrefix = "//cob:"
+
+// benchmarkAnnotation is the configuration of a benchmark in its magic comments.
+type benchmarkAnnotation struct {
+	// threshold overrides -threshold for the benchmark and its sub-benchmarks.
+	threshold *float64
+	// skip removes the benchmark from the comparison, like -exclude.
+	skip bool
+}
+
+// parseAnnotation parses the magic comments in the doc comment of a Benchmark function. A line can have
+// several, e.g. "//cob:threshold=0.25 //cob:skip".
+func parseAnnotation(doc *ast.CommentGroup) (benchmarkAnnotation, error) {
+	var a benchmarkAnnotation
+	if doc == nil {
+		return a, nil
+	}
+	for _, c := range doc.List {
+		if !strings.HasPrefix(c.Text, annotationPrefix) {
+			continue
+		}
+		for _, field := range strings.Fields(c.Text) {
+			directive := strings.TrimPrefix(field, annotationPrefix)
+			if directive == field {
+				return benchmarkAnnotation{}, xerrors.Errorf("invalid annotation '%s': every field must start with %s", c.Text, annotationPrefix)
+			}
+			name, value := directive, ""
+			if i := strings.Index(directive, "="); i >= 0 {
+				name, value = directive[:i], directive[i+1:]
+			}
+			switch {
+			case name == "threshold" && value != "":
+				threshold, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return benchmarkAnnotation{}, xerrors.Errorf("invalid annotation '%s': %w", field, err)
+				}
+				a.threshold = &threshold
+			case name == "skip" && value == "":
+				a.skip = true
+			default:
+				return benchmarkAnnotation{}, xerrors.Errorf("unknown annotation '%s': it must be %sthreshold=N or %sskip", field, annotationPrefix, annotationPrefix)
+			}
+		}
+	}
+	return a, nil
+}
+
+// benchmarkAnnotations returns the annotations of the benchmarks under root by function name. Those without
+// any are left out. An annotation which can't be parsed only prints a warning, and the benchmark is compared as
+// without it.
+func benchmarkAnnotations(root string) (map[string]benchmarkAnnotation, error) {
+	funcs, err := findBenchmarkFuncs(root)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	annotations := map[string]benchmarkAnnotation{}
+	for _, name := range names {
+		fn := funcs[name]
+		if fn.AnnotationErr != nil {
+			warnf("%s", fn.AnnotationErr)
+			continue
+		}
+		if fn.Annotation != (benchmarkAnnotation{}) {
+			annotations[name] = fn.Annotation
+		}
+	}
+	return annotations, nil
+}
+
+// annotationOf returns the annotation of the function which defines the benchmark, e.g. "BenchmarkFoo/bar-8".
+func annotationOf(annotations map[string]benchmarkAnnotation, name string) benchmarkAnnotation {
+	return annotations[benchmarkFuncName(name)]
+}
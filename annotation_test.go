@@ -0,0 +1,81 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseAnnotation(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		want    benchmarkAnnotation
+		wantErr string
+	}{
+		{name: "none", doc: "// BenchmarkFoo measures foo, see cob:skip in the README."},
+		{name: "threshold", doc: "//cob:threshold=0.25", want: benchmarkAnnotation{threshold: floatPtr(0.25)}},
+		{name: "skip", doc: "//cob:skip", want: benchmarkAnnotation{skip: true}},
+		{
+			name: "both on a line",
+			doc:  "// BenchmarkFoo is noisy.\n//cob:threshold=0.5 //cob:skip",
+			want: benchmarkAnnotation{threshold: floatPtr(0.5), skip: true},
+		},
+		{name: "invalid threshold", doc: "//cob:threshold=high", wantErr: "invalid annotation '//cob:threshold=high'"},
+		{name: "unknown", doc: "//cob:count=10", wantErr: "unknown annotation '//cob:count=10'"},
+		{name: "prose after it", doc: "//cob:skip flaky on CI", wantErr: "every field must start with //cob:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := parser.ParseFile(token.NewFileSet(), "foo_test.go", "package foo\n\n"+tt.doc+"\nfunc BenchmarkFoo() {}\n", parser.ParseComments)
+			require.NoError(t, err)
+			got, err := parseAnnotation(f.Decls[0].(*ast.FuncDecl).Doc)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_benchmarkAnnotations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "foo_test.go"), []byte(`package foo
+
+import "testing"
+
+func BenchmarkFoo(b *testing.B) {}
+
+// BenchmarkNoisy depends on the network.
+//cob:threshold=0.5
+func BenchmarkNoisy(b *testing.B) {}
+
+//cob:skip
+func BenchmarkBroken(b *testing.B) {}
+
+//cob:threshold=high
+func BenchmarkInvalid(b *testing.B) {}
+`), 0600))
+
+	got, err := benchmarkAnnotations(dir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]benchmarkAnnotation{
+		"BenchmarkNoisy":  {threshold: floatPtr(0.5)},
+		"BenchmarkBroken": {skip: true},
+	}, got)
+	assert.Equal(t, benchmarkAnnotation{threshold: floatPtr(0.5)}, annotationOf(got, "BenchmarkNoisy/dns-8"))
+	assert.Equal(t, benchmarkAnnotation{}, annotationOf(got, "BenchmarkFoo-8"))
+}
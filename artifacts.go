@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+// circleCIReportDir is where the reports are written on CircleCI unless -report-dir is given.
+const circleCIReportDir = "/tmp/cob"
+
+// reportDir returns the directory the reports are written to, or an empty string if they are not written.
+func reportDir(dir string) string {
+	if dir == "" && os.Getenv("CIRCLECI") == "true" {
+		return circleCIReportDir
+	}
+	return dir
+}
+
+// writeReports writes the HTML and JSON reports, and a JUnit XML report under "junit/" so that the directory
+// can be given to CircleCI's store_artifacts and store_test_results as is.
+func writeReports(dir string, rep report.Report, verdict string) error {
+	if err := os.MkdirAll(filepath.Join(dir, "junit"), 0755); err != nil {
+		return xerrors.Errorf("failed to create the report directory: %w", err)
+	}
+
+	page := rep
+	page.Profiles = relativeFlamegraphs(rep.Profiles, dir)
+	html, err := generateHTML(page, verdict)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "report.html"), []byte(html), 0644); err != nil {
+		return xerrors.Errorf("failed to write the HTML report: %w", err)
+	}
+
+	f, err := os.Create(filepath.Join(dir, "report.json"))
+	if err != nil {
+		return xerrors.Errorf("failed to write the JSON report: %w", err)
+	}
+	defer f.Close()
+	if err = report.Encode(f, rep); err != nil {
+		return err
+	}
+
+	if err = writeJUnitFile(filepath.Join(dir, "junit", "cob.xml"), rep); err != nil {
+		return err
+	}
+	infof("Wrote the reports to %s", dir)
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_reportDir(t *testing.T) {
+	os.Setenv("CIRCLECI", "true")
+	defer os.Unsetenv("CIRCLECI")
+	assert.Equal(t, circleCIReportDir, reportDir(""))
+	assert.Equal(t, "out", reportDir("out"))
+
+	os.Unsetenv("CIRCLECI")
+	assert.Equal(t, "", reportDir(""))
+}
+
+func Test_writeReports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rep := report.Report{Benchmarks: []report.Benchmark{{Name: "BenchmarkA", Status: report.StatusOK}}}
+	require.NoError(t, writeReports(filepath.Join(dir, "reports"), rep, "PASS: no benchmarks got worse"))
+	for _, name := range []string{"report.html", "report.json", "junit/cob.xml"} {
+		b, err := ioutil.ReadFile(filepath.Join(dir, "reports", name))
+		require.NoError(t, err, name)
+		assert.Contains(t, string(b), "BenchmarkA", name)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+const azureStorageAPIVersion = "2021-08-06"
+
+// azureBlobStorage is a container of Azure Blob Storage.
+type azureBlobStorage struct {
+	client *http.Client
+	// containerURL is e.g. "https://account.blob.core.windows.net/container".
+	containerURL string
+	tokens       *tokenSource
+}
+
+// newAzureBlobStorage finds the account in AZURE_STORAGE_ACCOUNT. AZURE_STORAGE_BLOB_ENDPOINT overrides the
+// endpoint of the account, e.g. for sovereign clouds.
+func newAzureBlobStorage(client *http.Client, container string) (*azureBlobStorage, error) {
+	endpoint := os.Getenv("AZURE_STORAGE_BLOB_ENDPOINT")
+	if endpoint == "" {
+		account := os.Getenv("AZURE_STORAGE_ACCOUNT")
+		if account == "" {
+			return nil, xerrors.New("AZURE_STORAGE_ACCOUNT is not set")
+		}
+		endpoint = "https://" + account + ".blob.core.windows.net"
+	}
+	return &azureBlobStorage{
+		client:       client,
+		containerURL: strings.TrimSuffix(endpoint, "/") + "/" + url.PathEscape(container),
+		tokens:       newAzureTokenSource(client, azureStorageResource),
+	}, nil
+}
+
+func (s *azureBlobStorage) do(method, u string, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create a request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	token, err := s.tokens.accessToken()
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Ms-Version", azureStorageAPIVersion)
+
+	debugf("azblob: %s %s", method, req.URL.Path)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to send a request to Blob Storage: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, errObjectNotFound
+	}
+	// Blob Storage returns 409 if the blob exists despite "If-None-Match: *".
+	if resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict && isConditional(header) {
+		resp.Body.Close()
+		return nil, errObjectChanged
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		return nil, xerrors.Errorf("Blob Storage returned %s for %s %s: %s", resp.Status, method, req.URL.Path, strings.TrimSpace(string(b)))
+	}
+	return resp, nil
+}
+
+func (s *azureBlobStorage) blobURL(key string) string {
+	return s.containerURL + "/" + strings.Replace(url.PathEscape(key), "%2F", "/", -1)
+}
+
+func (s *azureBlobStorage) putObject(key string, body []byte) error {
+	resp, err := s.do(http.MethodPut, s.blobURL(key), body, http.Header{
+		"X-Ms-Blob-Type": {"BlockBlob"},
+		"Content-Type":   {"application/json"},
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *azureBlobStorage) putObjectIf(key string, body []byte, version string) error {
+	header := conditionalHeader(version)
+	header.Set("X-Ms-Blob-Type", "BlockBlob")
+	header.Set("Content-Type", "application/json")
+	resp, err := s.do(http.MethodPut, s.blobURL(key), body, header)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *azureBlobStorage) getObject(key string) ([]byte, error) {
+	b, _, err := s.getObjectVersion(key)
+	return b, err
+}
+
+func (s *azureBlobStorage) getObjectVersion(key string) ([]byte, string, error) {
+	resp, err := s.do(http.MethodGet, s.blobURL(key), nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	return b, resp.Header.Get("ETag"), err
+}
+
+func (s *azureBlobStorage) listObjects(prefix string) ([]string, error) {
+	var keys []string
+	var marker string
+	for {
+		query := url.Values{"restype": {"container"}, "comp": {"list"}, "prefix": {prefix}}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		resp, err := s.do(http.MethodGet, s.containerURL+"?"+query.Encode(), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Blobs []struct {
+				Name string `xml:"Name"`
+			} `xml:"Blobs>Blob"`
+			NextMarker string `xml:"NextMarker"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode the blob list: %w", err)
+		}
+		for _, b := range result.Blobs {
+			keys = append(keys, b.Name)
+		}
+		if result.NextMarker == "" {
+			return keys, nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+func (s *azureBlobStorage) deleteObject(key string) error {
+	resp, err := s.do(http.MethodDelete, s.blobURL(key), nil, nil)
+	if xerrors.Is(err, errObjectNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_newAzureTokenSource_clientSecret(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/tenant/oauth2/v2.0/token", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "client", r.PostForm.Get("client_id"))
+		assert.Equal(t, "secret", r.PostForm.Get("client_secret"))
+		assert.Equal(t, "https://storage.azure.com/.default", r.PostForm.Get("scope"))
+		_, _ = w.Write([]byte(`{"token_type":"Bearer","expires_in":"3599","access_token":"token"}`))
+	}))
+	defer ts.Close()
+
+	for k, v := range map[string]string{
+		"AZURE_AUTHORITY_HOST": ts.URL,
+		"AZURE_TENANT_ID":      "tenant",
+		"AZURE_CLIENT_ID":      "client",
+		"AZURE_CLIENT_SECRET":  "secret",
+	} {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	token, err := newAzureTokenSource(ts.Client(), azureStorageResource).accessToken()
+	require.NoError(t, err)
+	assert.Equal(t, "token", token)
+}
+
+func Test_parseAzureCLIToken(t *testing.T) {
+	token, err := parseAzureCLIToken([]byte(`{"accessToken":"token","expiresOn":"2020-01-02 03:04:05.000000","expires_on":1577934245}`))
+	require.NoError(t, err)
+	assert.Equal(t, oauthToken{AccessToken: "token", Expiry: time.Unix(1577934245, 0)}, token)
+
+	token, err = parseAzureCLIToken([]byte(`{"accessToken":"token","expiresOn":"2020-01-02 03:04:05.000000"}`))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local), token.Expiry)
+}
+
+// fakeBlobStorage serves a container of Blob Storage from memory.
+type fakeBlobStorage struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func (f *fakeBlobStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("X-Ms-Version") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/container/")
+	switch {
+	case r.Method == http.MethodPut:
+		if r.Header.Get("X-Ms-Blob-Type") != "BlockBlob" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		current, exists := f.blobs[name]
+		if r.Header.Get("If-None-Match") == "*" && exists {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		if !fakeConditionMet(r, current, exists) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		f.blobs[name] = b
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet && r.URL.Query().Get("comp") == "list":
+		type blob struct {
+			Name string `xml:"Name"`
+		}
+		var result struct {
+			XMLName xml.Name `xml:"EnumerationResults"`
+			Blobs   []blob   `xml:"Blobs>Blob"`
+		}
+		var names []string
+		for k := range f.blobs {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				names = append(names, k)
+			}
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			result.Blobs = append(result.Blobs, blob{n})
+		}
+		_ = xml.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodGet:
+		b, ok := f.blobs[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", fakeETag(b))
+		_, _ = w.Write(b)
+	}
+}
+
+func Test_objectStore_azureBlob(t *testing.T) {
+	fake := &fakeBlobStorage{blobs: map[string][]byte{}}
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	tokens := &tokenSource{
+		fetch: func() (oauthToken, error) {
+			return oauthToken{AccessToken: "token", Expiry: time.Now().Add(time.Hour)}, nil
+		},
+		now: time.Now,
+	}
+	s := objectStore{storage: &azureBlobStorage{client: ts.Client(), containerURL: ts.URL + "/container", tokens: tokens}, prefix: "cob"}
+
+	run := historyRun{
+		Time:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Report: report.Report{SchemaVersion: 1, Head: report.Commit{Hash: "aaaaaaaa"}, Benchmarks: []report.Benchmark{{Name: "BenchmarkA"}}},
+	}
+	require.NoError(t, s.put(run))
+	assert.Contains(t, fake.blobs, "cob/commits/aaaaaaaa.json")
+
+	runs, err := s.runs()
+	require.NoError(t, err)
+	assert.Equal(t, []historyRun{run}, runs)
+
+	latest, err := s.latest("aaaaaaaa", "")
+	require.NoError(t, err)
+	assert.Equal(t, &run, latest)
+	latest, err = s.latest("bbbbbbbb", "")
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const azureStorageResource = "https://storage.azure.com"
+
+// newAzureTokenSource looks up credentials in the same order as DefaultAzureCredential of the Azure SDKs:
+// a service principal in environment variables, workload identity, managed identity and the Azure CLI.
+func newAzureTokenSource(client *http.Client, resource string) *tokenSource {
+	ts := &tokenSource{now: time.Now}
+	authority := strings.TrimSuffix(os.Getenv("AZURE_AUTHORITY_HOST"), "/")
+	if authority == "" {
+		authority = "https://login.microsoftonline.com"
+	}
+	tenant, clientID := os.Getenv("AZURE_TENANT_ID"), os.Getenv("AZURE_CLIENT_ID")
+	tokenURL := authority + "/" + url.PathEscape(tenant) + "/oauth2/v2.0/token"
+	scope := resource + "/.default"
+
+	if secret := os.Getenv("AZURE_CLIENT_SECRET"); tenant != "" && clientID != "" && secret != "" {
+		ts.fetch = func() (oauthToken, error) {
+			return postTokenRequest(client, tokenURL, url.Values{
+				"grant_type":    {"client_credentials"},
+				"client_id":     {clientID},
+				"client_secret": {secret},
+				"scope":         {scope},
+			})
+		}
+		return ts
+	}
+	if file := os.Getenv("AZURE_FEDERATED_TOKEN_FILE"); tenant != "" && clientID != "" && file != "" {
+		ts.fetch = func() (oauthToken, error) {
+			// The token file is rotated, so it is read every time.
+			assertion, err := ioutil.ReadFile(file)
+			if err != nil {
+				return oauthToken{}, xerrors.Errorf("failed to read the federated token: %w", err)
+			}
+			return postTokenRequest(client, tokenURL, url.Values{
+				"grant_type":            {"client_credentials"},
+				"client_id":             {clientID},
+				"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+				"client_assertion":      {strings.TrimSpace(string(assertion))},
+				"scope":                 {scope},
+			})
+		}
+		return ts
+	}
+	if endpoint := os.Getenv("IDENTITY_ENDPOINT"); endpoint != "" {
+		ts.fetch = func() (oauthToken, error) {
+			return azureManagedIdentityToken(client, endpoint, "2019-08-01", resource,
+				http.Header{"X-Identity-Header": {os.Getenv("IDENTITY_HEADER")}})
+		}
+		return ts
+	}
+
+	// Outside of Azure the instance metadata service doesn't respond, so don't wait long for it.
+	probe := *client
+	probe.Timeout = 2 * time.Second
+	ts.fetch = func() (oauthToken, error) {
+		token, err := azureManagedIdentityToken(&probe, "http://169.254.169.254/metadata/identity/oauth2/token",
+			"2018-02-01", resource, http.Header{"Metadata": {"true"}})
+		if err == nil {
+			return token, nil
+		}
+		debugf("azure: managed identity is not available: %s", err)
+		if _, err := exec.LookPath("az"); err != nil {
+			return oauthToken{}, xerrors.New("no Azure credentials found: set AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET, or run 'az login'")
+		}
+		return azureCLIToken(resource)
+	}
+	return ts
+}
+
+func azureManagedIdentityToken(client *http.Client, endpoint, apiVersion, resource string, header http.Header) (oauthToken, error) {
+	query := url.Values{"api-version": {apiVersion}, "resource": {resource}}
+	if id := os.Getenv("AZURE_CLIENT_ID"); id != "" {
+		query.Set("client_id", id)
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return oauthToken{}, xerrors.Errorf("failed to create a request: %w", err)
+	}
+	req.Header = header
+	resp, err := client.Do(req)
+	if err != nil {
+		return oauthToken{}, xerrors.Errorf("failed to get a managed identity token: %w", err)
+	}
+	return decodeToken(resp)
+}
+
+func azureCLIToken(resource string) (oauthToken, error) {
+	debugf("exec: az account get-access-token --resource %s", resource)
+	out, err := exec.Command("az", "account", "get-access-token", "--resource", resource, "--output", "json").Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok {
+			return oauthToken{}, xerrors.Errorf("az account get-access-token failed: %s", strings.TrimSpace(string(ee.Stderr)))
+		}
+		return oauthToken{}, xerrors.Errorf("failed to run the Azure CLI: %w", err)
+	}
+	return parseAzureCLIToken(out)
+}
+
+func parseAzureCLIToken(b []byte) (oauthToken, error) {
+	var token struct {
+		AccessToken string `json:"accessToken"`
+		// ExpiresOn is a Unix time. Older versions of the CLI only have "expiresOn" in local time.
+		ExpiresOn    int64  `json:"expires_on"`
+		ExpiresOnOld string `json:"expiresOn"`
+	}
+	if err := json.Unmarshal(b, &token); err != nil {
+		return oauthToken{}, xerrors.Errorf("failed to decode the Azure CLI token: %w", err)
+	}
+	expiry := time.Unix(token.ExpiresOn, 0)
+	if token.ExpiresOn == 0 {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05.999999", token.ExpiresOnOld, time.Local)
+		if err != nil {
+			return oauthToken{}, xerrors.Errorf("invalid expiry of the Azure CLI token: %w", err)
+		}
+		expiry = t
+	}
+	return oauthToken{AccessToken: token.AccessToken, Expiry: expiry}, nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+const azureAPIVersion = "6.0"
+
+// azureClient talks to Azure DevOps Services or Server on behalf of a repository.
+type azureClient struct {
+	restClient
+	repositoryID string
+}
+
+type azureThread struct {
+	Comments []azureComment `json:"comments"`
+	Status   int            `json:"status"`
+}
+
+type azureComment struct {
+	ParentCommentID int    `json:"parentCommentId"`
+	Content         string `json:"content"`
+	CommentType     int    `json:"commentType"`
+}
+
+type azureStatus struct {
+	State       string             `json:"state"`
+	Description string             `json:"description"`
+	TargetURL   string             `json:"targetUrl,omitempty"`
+	Context     azureStatusContext `json:"context"`
+}
+
+type azureStatusContext struct {
+	Name  string `json:"name"`
+	Genre string `json:"genre"`
+}
+
+func newAzureClient(collectionURI, project, repositoryID string, header http.Header) *azureClient {
+	baseURL := strings.TrimSuffix(collectionURI, "/") + "/" + url.PathEscape(project) + "/_apis/git/repositories/" + url.PathEscape(repositoryID)
+	return &azureClient{
+		restClient:   newRESTClient("Azure DevOps", baseURL, header),
+		repositoryID: repositoryID,
+	}
+}
+
+// newAzureClientFromEnv creates a client from predefined variables of Azure Pipelines.
+// SYSTEM_ACCESSTOKEN has to be mapped explicitly in the pipeline; AZURE_DEVOPS_TOKEN (a PAT) is used otherwise.
+func newAzureClientFromEnv() (*azureClient, error) {
+	header := http.Header{}
+	if token := os.Getenv("SYSTEM_ACCESSTOKEN"); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	} else if pat := os.Getenv("AZURE_DEVOPS_TOKEN"); pat != "" {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(":"+pat)))
+	} else {
+		return nil, xerrors.New("SYSTEM_ACCESSTOKEN or AZURE_DEVOPS_TOKEN must be set")
+	}
+
+	for _, key := range []string{"SYSTEM_COLLECTIONURI", "SYSTEM_TEAMPROJECT", "BUILD_REPOSITORY_ID"} {
+		if os.Getenv(key) == "" {
+			return nil, xerrors.Errorf("%s is not set", key)
+		}
+	}
+	return newAzureClient(os.Getenv("SYSTEM_COLLECTIONURI"), os.Getenv("SYSTEM_TEAMPROJECT"),
+		os.Getenv("BUILD_REPOSITORY_ID"), header), nil
+}
+
+func (c *azureClient) createThread(prID, body string) error {
+	thread := azureThread{
+		// commentType 1 is "text" and status 1 is "active"
+		Comments: []azureComment{{ParentCommentID: 0, Content: body, CommentType: 1}},
+		Status:   1,
+	}
+	path := fmt.Sprintf("/pullRequests/%s/threads?api-version=%s", url.PathEscape(prID), azureAPIVersion)
+	if err := c.do(http.MethodPost, path, thread, nil); err != nil {
+		return xerrors.Errorf("failed to create a pull request thread: %w", err)
+	}
+	return nil
+}
+
+func (c *azureClient) createCommitStatus(commit string, status azureStatus) error {
+	path := fmt.Sprintf("/commits/%s/statuses?api-version=%s", commit, azureAPIVersion)
+	if err := c.do(http.MethodPost, path, status, nil); err != nil {
+		return xerrors.Errorf("failed to create a commit status: %w", err)
+	}
+	return nil
+}
+
+// azureBuildURL returns the URL of the current build, or an empty string outside Azure Pipelines.
+func azureBuildURL() string {
+	collection, project, build := os.Getenv("SYSTEM_COLLECTIONURI"), os.Getenv("SYSTEM_TEAMPROJECT"), os.Getenv("BUILD_BUILDID")
+	if collection == "" || project == "" || build == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/_build/results?buildId=%s", strings.TrimSuffix(collection, "/"), url.PathEscape(project), build)
+}
+
+func postAzurePRThread(body string) error {
+	c, err := newAzureClientFromEnv()
+	if err != nil {
+		return err
+	}
+	prID := os.Getenv("SYSTEM_PULLREQUEST_PULLREQUESTID")
+	if prID == "" {
+		return xerrors.New("SYSTEM_PULLREQUEST_PULLREQUESTID is not set: run cob in a pull request build")
+	}
+	if err = c.createThread(prID, body); err != nil {
+		return err
+	}
+	infof("Posted the result to the pull request %s", prID)
+	return nil
+}
+
+func postAzureCommitStatus(commit string, results []result, threshold float64, comparedScore comparedScore) error {
+	c, err := newAzureClientFromEnv()
+	if err != nil {
+		return err
+	}
+
+	state := "succeeded"
+	for _, r := range results {
+		if isDegression(r, threshold, comparedScore) {
+			state = "failed"
+			break
+		}
+	}
+	status := azureStatus{
+		State:       state,
+		Description: generateStatusDescription(results, threshold, comparedScore),
+		TargetURL:   azureBuildURL(),
+		Context:     azureStatusContext{Name: "benchmarks", Genre: "cob"},
+	}
+	if err = c.createCommitStatus(commit, status); err != nil {
+		return err
+	}
+	infof("Set the commit status 'cob/benchmarks' to %s", state)
+	return nil
+}
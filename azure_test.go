@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_azureClient(t *testing.T) {
+	var requests []string
+	var thread azureThread
+	var status azureStatus
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, azureAPIVersion, r.URL.Query().Get("api-version"))
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/threads") {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&thread))
+			return
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&status))
+	}))
+	defer ts.Close()
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret")
+	c := newAzureClient(ts.URL+"/org/", "My Project", "repo-id", header)
+	require.NoError(t, c.createThread("5", "result"))
+	require.NoError(t, c.createCommitStatus("abc", azureStatus{State: "failed", Context: azureStatusContext{Name: "benchmarks", Genre: "cob"}}))
+
+	assert.Equal(t, []string{
+		"POST /org/My Project/_apis/git/repositories/repo-id/pullRequests/5/threads",
+		"POST /org/My Project/_apis/git/repositories/repo-id/commits/abc/statuses",
+	}, requests)
+	assert.Equal(t, azureThread{Comments: []azureComment{{Content: "result", CommentType: 1}}, Status: 1}, thread)
+	assert.Equal(t, "failed", status.State)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+// githubArtifact is an artifact uploaded by a workflow run.
+type githubArtifact struct {
+	ID                 int64     `json:"id"`
+	Name               string    `json:"name"`
+	Expired            bool      `json:"expired"`
+	CreatedAt          time.Time `json:"created_at"`
+	ArchiveDownloadURL string    `json:"archive_download_url"`
+	WorkflowRun        struct {
+		HeadBranch string `json:"head_branch"`
+		HeadSHA    string `json:"head_sha"`
+	} `json:"workflow_run"`
+}
+
+// parseBaselineArtifact splits "name@branch". Without a branch, the base branch of the pull request is used.
+func parseBaselineArtifact(s string) (string, string, error) {
+	name, branch := s, os.Getenv("GITHUB_BASE_REF")
+	if i := strings.LastIndex(s, "@"); i >= 0 {
+		name, branch = s[:i], s[i+1:]
+	}
+	if name == "" || branch == "" {
+		return "", "", xerrors.Errorf("invalid artifact: '%s': it must be name@branch", s)
+	}
+	return name, branch, nil
+}
+
+// fetchArtifactBaseline downloads the latest artifact given as name@branch which a workflow run on the
+// branch uploaded, and returns the JSON report in it.
+func fetchArtifactBaseline(spec string) (report.Report, error) {
+	name, branch, err := parseBaselineArtifact(spec)
+	if err != nil {
+		return report.Report{}, err
+	}
+	client, err := newGitHubClientFromEnv()
+	if err != nil {
+		return report.Report{}, err
+	}
+	owner, repo, err := splitGitHubRepository(os.Getenv("GITHUB_REPOSITORY"))
+	if err != nil {
+		return report.Report{}, err
+	}
+	artifact, err := client.findArtifact(owner, repo, name, branch)
+	if err != nil {
+		return report.Report{}, err
+	}
+	debugf("github: artifact %d of %s was uploaded at %s", artifact.ID, artifact.WorkflowRun.HeadSHA, artifact.CreatedAt)
+	b, err := client.download(artifact.ArchiveDownloadURL)
+	if err != nil {
+		return report.Report{}, xerrors.Errorf("failed to download the artifact: %w", err)
+	}
+	rep, err := readZippedReport(b)
+	if err != nil {
+		return report.Report{}, xerrors.Errorf("invalid artifact %s: %w", name, err)
+	}
+	if rep.Head.Hash == "" {
+		rep.Head.Hash = artifact.WorkflowRun.HeadSHA
+	}
+	return rep, nil
+}
+
+// findArtifact returns the latest unexpired artifact with the name which a workflow run on the branch uploaded.
+func (c *githubClient) findArtifact(owner, repo, name, branch string) (githubArtifact, error) {
+	var latest githubArtifact
+	for page := 1; ; page++ {
+		var resp struct {
+			Artifacts []githubArtifact `json:"artifacts"`
+		}
+		p := fmt.Sprintf("/repos/%s/%s/actions/artifacts?name=%s&per_page=100&page=%d", owner, repo, url.QueryEscape(name), page)
+		if err := c.do(http.MethodGet, p, nil, &resp); err != nil {
+			return githubArtifact{}, xerrors.Errorf("failed to list the artifacts: %w", err)
+		}
+		for _, a := range resp.Artifacts {
+			if a.Name != name || a.Expired || a.WorkflowRun.HeadBranch != branch {
+				continue
+			}
+			if latest.ID == 0 || a.CreatedAt.After(latest.CreatedAt) {
+				latest = a
+			}
+		}
+		if len(resp.Artifacts) < 100 {
+			break
+		}
+	}
+	if latest.ID == 0 {
+		return githubArtifact{}, xerrors.Errorf("no artifact named %s on %s", name, branch)
+	}
+	return latest, nil
+}
+
+// download gets the archive of an artifact. GitHub redirects to the storage with a signed URL, which the
+// token must not be sent to.
+func (c *githubClient) download(u string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create a request: %w", err)
+	}
+	if auth := c.header.Get("Authorization"); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	client := *c.client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return xerrors.New("stopped after 10 redirects")
+		}
+		if req.URL.Host != via[0].URL.Host {
+			req.Header.Del("Authorization")
+		}
+		return nil
+	}
+	debugf("github: GET %s", u)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, xerrors.Errorf("GitHub returned %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return b, nil
+}
+
+// readZippedReport returns report.json in the archive, e.g. one written by -report-dir, or else the only other
+// JSON file which is a report.
+func readZippedReport(b []byte) (report.Report, error) {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return report.Report{}, xerrors.Errorf("failed to open the archive: %w", err)
+	}
+	var reports []report.Report
+	for _, f := range zr.File {
+		if path.Ext(f.Name) != ".json" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return report.Report{}, xerrors.Errorf("failed to open %s: %w", f.Name, err)
+		}
+		rep, err := report.Decode(rc)
+		rc.Close()
+		if path.Base(f.Name) == "report.json" {
+			if err != nil {
+				return report.Report{}, xerrors.Errorf("invalid %s: %w", f.Name, err)
+			}
+			return rep, nil
+		}
+		if err == nil {
+			reports = append(reports, rep)
+		}
+	}
+	if len(reports) == 0 {
+		return report.Report{}, xerrors.New("no JSON report in the archive")
+	} else if len(reports) > 1 {
+		return report.Report{}, xerrors.Errorf("%d JSON reports in the archive: name one report.json", len(reports))
+	}
+	return reports[0], nil
+}
+
+// fetchURLBaseline downloads the JSON report at the URL. headers are "Name: value" pairs sent with the
+// request, and COB_BASELINE_TOKEN is sent as a bearer token if it is set. Credentials can also be given as
+// user info in the URL.
+func fetchURLBaseline(client *http.Client, u string, headers []string) (report.Report, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return report.Report{}, xerrors.Errorf("failed to create a request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "cob")
+	if token := os.Getenv("COB_BASELINE_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for _, h := range headers {
+		name, value, err := parseHeader(h)
+		if err != nil {
+			return report.Report{}, err
+		}
+		req.Header.Set(name, value)
+	}
+
+	debugf("baseline: GET %s", displayURL(u))
+	resp, err := client.Do(req)
+	if err != nil {
+		return report.Report{}, xerrors.Errorf("failed to download the baseline: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return report.Report{}, xerrors.Errorf("the baseline URL returned %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return report.Decode(resp.Body)
+}
+
+// parseHeader splits "Name: value".
+func parseHeader(s string) (string, string, error) {
+	i := strings.Index(s, ":")
+	if i <= 0 {
+		return "", "", xerrors.Errorf("invalid header: '%s': it must be 'Name: value'", s)
+	}
+	return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:]), nil
+}
+
+// displayURL drops the credentials and the query, which may hold a token, from the URL so that it can be shown.
+func displayURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	u.User = nil
+	u.RawQuery = ""
+	return u.String()
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+// bencherMetric is a metric in the Bencher Metric Format (BMF).
+type bencherMetric struct {
+	Value float64 `json:"value"`
+}
+
+// generateBencherMetrics converts the HEAD results into BMF, keyed by the benchmark name and then the measure.
+func generateBencherMetrics(rep report.Report) map[string]map[string]bencherMetric {
+	bmf := map[string]map[string]bencherMetric{}
+	for _, b := range rep.Benchmarks {
+		bmf[b.Name] = map[string]bencherMetric{
+			"latency":       {Value: b.Head.NsPerOp},
+			"bytes-per-op":  {Value: float64(b.Head.AllocedBytesPerOp)},
+			"allocs-per-op": {Value: float64(b.Head.AllocsPerOp)},
+		}
+	}
+	return bmf
+}
+
+type bencherReport struct {
+	Branch    string          `json:"branch"`
+	Hash      string          `json:"hash,omitempty"`
+	Testbed   string          `json:"testbed"`
+	StartTime time.Time       `json:"start_time"`
+	EndTime   time.Time       `json:"end_time"`
+	Results   []string        `json:"results"`
+	Settings  bencherSettings `json:"settings"`
+}
+
+type bencherSettings struct {
+	Adapter string `json:"adapter"`
+}
+
+// newBencherClient returns a client for the Bencher API at BENCHER_HOST (default: Bencher Cloud),
+// authenticated with BENCHER_API_TOKEN.
+func newBencherClient() (restClient, error) {
+	token := os.Getenv("BENCHER_API_TOKEN")
+	if token == "" {
+		return restClient{}, xerrors.New("BENCHER_API_TOKEN is not set")
+	}
+	host := os.Getenv("BENCHER_HOST")
+	if host == "" {
+		host = "https://api.bencher.dev"
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+	return newRESTClient("Bencher", host, header), nil
+}
+
+// postBencherReport creates a report in the project, so that Bencher applies its thresholds and alerts to it.
+func postBencherReport(c restClient, project, testbed string, rep report.Report, start, end time.Time) error {
+	bmf, err := json.Marshal(generateBencherMetrics(rep))
+	if err != nil {
+		return xerrors.Errorf("failed to encode the metrics: %w", err)
+	}
+	branch := rep.Head.Branch
+	if branch == "" {
+		branch = "main"
+	}
+	in := bencherReport{
+		Branch:    branch,
+		Hash:      rep.Head.Hash,
+		Testbed:   testbed,
+		StartTime: start.UTC(),
+		EndTime:   end.UTC(),
+		Results:   []string{string(bmf)},
+		Settings:  bencherSettings{Adapter: "json"},
+	}
+	if err = c.do(http.MethodPost, "/v0/projects/"+project+"/reports", in, nil); err != nil {
+		return err
+	}
+	infof("Submitted the result to the Bencher project %s", project)
+	return nil
+}
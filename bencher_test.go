@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_postBencherReport(t *testing.T) {
+	rep := report.Report{
+		Head:       report.Commit{Hash: "599a552", Branch: "feature"},
+		Benchmarks: []report.Benchmark{{Name: "BenchmarkA", Head: report.Measurement{NsPerOp: 150, AllocedBytesPerOp: 16, AllocsPerOp: 1}}},
+	}
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v0/projects/myproject/reports", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var got bencherReport
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		assert.Equal(t, bencherReport{
+			Branch:    "feature",
+			Hash:      "599a552",
+			Testbed:   "ci",
+			StartTime: start,
+			EndTime:   start.Add(time.Minute),
+			Results:   []string{`{"BenchmarkA":{"allocs-per-op":{"value":1},"bytes-per-op":{"value":16},"latency":{"value":150}}}`},
+			Settings:  bencherSettings{Adapter: "json"},
+		}, got)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret")
+	c := newRESTClient("Bencher", ts.URL, header)
+	require.NoError(t, postBencherReport(c, "myproject", "ci", rep, start, start.Add(time.Minute)))
+}
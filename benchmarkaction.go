@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/knqyf263/cob/pkg/report"
+)
+
+// benchmarkActionResult is a result of benchmark-action/github-action-benchmark, which reads a JSON array of
+// them with the "customSmallerIsBetter" tool.
+type benchmarkActionResult struct {
+	Name  string  `json:"name"`
+	Unit  string  `json:"unit"`
+	Value float64 `json:"value"`
+	Extra string  `json:"extra,omitempty"`
+}
+
+// generateBenchmarkActionResults converts the HEAD results. As with the "go" tool of the action, each metric
+// of a benchmark is a separate result named like "BenchmarkA - ns/op".
+func generateBenchmarkActionResults(rep report.Report) []benchmarkActionResult {
+	results := []benchmarkActionResult{}
+	for _, b := range rep.Benchmarks {
+		for _, m := range []struct {
+			unit  string
+			value float64
+			base  float64
+			ratio float64
+		}{
+			{"ns/op", b.Head.NsPerOp, b.Base.NsPerOp, b.Ratio.NsPerOp},
+			{"B/op", float64(b.Head.AllocedBytesPerOp), float64(b.Base.AllocedBytesPerOp), b.Ratio.AllocedBytesPerOp},
+			{"allocs/op", float64(b.Head.AllocsPerOp), float64(b.Base.AllocsPerOp), b.Ratio.AllocsPerOp},
+		} {
+			results = append(results, benchmarkActionResult{
+				Name:  fmt.Sprintf("%s - %s", b.Name, m.unit),
+				Unit:  m.unit,
+				Value: m.value,
+				Extra: fmt.Sprintf("%d times\n%s: %g %s (%+.2f%%)", b.Head.Iterations, rep.Base.Ref, m.base, m.unit, 100*m.ratio),
+			})
+		}
+	}
+	return results
+}
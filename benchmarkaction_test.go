@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_generateBenchmarkActionResults(t *testing.T) {
+	rep := report.Report{
+		Base: report.Commit{Ref: "HEAD~1"},
+		Benchmarks: []report.Benchmark{{
+			Name:  "BenchmarkA",
+			Base:  report.Measurement{NsPerOp: 100, AllocedBytesPerOp: 16},
+			Head:  report.Measurement{Iterations: 1000, NsPerOp: 150, AllocedBytesPerOp: 16},
+			Ratio: report.Ratio{NsPerOp: 0.5},
+		}},
+	}
+	got := generateBenchmarkActionResults(rep)
+	require.Len(t, got, 3)
+	assert.Equal(t, benchmarkActionResult{
+		Name:  "BenchmarkA - ns/op",
+		Unit:  "ns/op",
+		Value: 150,
+		Extra: "1000 times\nHEAD~1: 100 ns/op (+50.00%)",
+	}, got[0])
+	assert.Equal(t, "BenchmarkA - B/op", got[1].Name)
+	assert.Equal(t, "BenchmarkA - allocs/op", got[2].Name)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/cob"
+	"golang.org/x/tools/benchmark/parse"
+)
+
+// compareSets compares the benchmarks which ran at both commits in the order of their names, and returns the
+// results with the rows of HEAD and the base commit for the result table. Excluded benchmarks and those skipped
+// by their annotations are left out.
+func compareSets(prevSet, headSet parse.Set, excludes []*regexp.Regexp, annotations map[string]benchmarkAnnotation, alpha float64, cols columns) ([]result, [][]string) {
+	compared := parse.Set{}
+	thresholds := map[string]float64{}
+	for benchName, benchmarks := range headSet {
+		if isExcluded(benchName, excludes) {
+			debugf("%s is excluded", benchName)
+			continue
+		}
+		annotation := annotationOf(annotations, benchName)
+		if annotation.skip {
+			debugf("%s is skipped by its annotation", benchName)
+			continue
+		}
+		if _, ok := prevSet[benchName]; !ok {
+			debugf("%s is not found in the baseline", benchName)
+			continue
+		}
+		if annotation.threshold != nil {
+			thresholds[benchName] = *annotation.threshold
+		}
+		compared[benchName] = benchmarks
+	}
+
+	ratios := cob.Compare(prevSet, compared, cob.CompareOptions{Thresholds: thresholds, Alpha: alpha})
+	var rows [][]string
+	for _, r := range ratios {
+		rows = append(rows, generateRow("HEAD", r.Head, cols))
+		rows = append(rows, generateRow("HEAD@{1}", r.Prev, cols))
+	}
+	return ratios, rows
+}
+
+// removedBenchmarks returns the benchmarks of the baseline which didn't run at HEAD, e.g. because they were
+// deleted or renamed, so that a regression can't be hidden that way without being noticed. Excluded and skipped benchmarks are left
+// out, because they are not compared anyway.
+func removedBenchmarks(prevSet, headSet parse.Set, excludes []*regexp.Regexp, annotations map[string]benchmarkAnnotation) []string {
+	var removed []string
+	for name, benchmarks := range prevSet {
+		if len(benchmarks) == 0 || len(headSet[name]) > 0 {
+			continue
+		}
+		if isExcluded(name, excludes) || annotationOf(annotations, name).skip {
+			continue
+		}
+		removed = append(removed, name)
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// addedBenchmarks returns the benchmarks which only ran at HEAD, which have no baseline to be compared with.
+func addedBenchmarks(prevSet, headSet parse.Set, excludes []*regexp.Regexp, annotations map[string]benchmarkAnnotation) []string {
+	return removedBenchmarks(headSet, prevSet, excludes, annotations)
+}
+
+func showAddedAndRemoved(w io.Writer, added, removed []string) {
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nNew and removed benchmarks")
+	fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 26))
+	for _, line := range generateAddedAndRemovedLines(added, removed) {
+		fmt.Fprintln(w, line)
+	}
+}
+
+func generateAddedAndRemovedLines(added, removed []string) []string {
+	var lines []string
+	for _, name := range added {
+		lines = append(lines, fmt.Sprintf("%s is new, so it has no baseline", name))
+	}
+	for _, name := range removed {
+		lines = append(lines, fmt.Sprintf("%s is removed, so it is not compared", name))
+	}
+	return lines
+}
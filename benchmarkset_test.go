@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/benchmark/parse"
+)
+
+func Test_removedBenchmarks(t *testing.T) {
+	prevSet := parse.Set{
+		"BenchmarkA-8":       {{Name: "BenchmarkA-8"}},
+		"BenchmarkRenamed-8": {{Name: "BenchmarkRenamed-8"}},
+		"BenchmarkDeleted-8": {{Name: "BenchmarkDeleted-8"}},
+		"BenchmarkNoisy-8":   {{Name: "BenchmarkNoisy-8"}},
+		"BenchmarkSkipped-8": {{Name: "BenchmarkSkipped-8"}},
+	}
+	headSet := parse.Set{
+		"BenchmarkA-8":        {{Name: "BenchmarkA-8"}},
+		"BenchmarkRenamed2-8": {{Name: "BenchmarkRenamed2-8"}},
+	}
+	excludes := []*regexp.Regexp{regexp.MustCompile("Noisy")}
+	annotations := map[string]benchmarkAnnotation{"BenchmarkSkipped": {skip: true}}
+	assert.Equal(t, []string{"BenchmarkDeleted-8", "BenchmarkRenamed-8"}, removedBenchmarks(prevSet, headSet, excludes, annotations))
+	assert.Empty(t, removedBenchmarks(headSet, headSet, nil, nil))
+	assert.Equal(t, []string{"BenchmarkRenamed2-8"}, addedBenchmarks(prevSet, headSet, excludes, annotations))
+}
+
+func Test_showAddedAndRemoved(t *testing.T) {
+	w := &bytes.Buffer{}
+	showAddedAndRemoved(w, []string{"BenchmarkNew-8"}, []string{"BenchmarkOld-8"})
+	assert.Equal(t, `
+New and removed benchmarks
+==========================
+
+BenchmarkNew-8 is new, so it has no baseline
+BenchmarkOld-8 is removed, so it is not compared
+`, w.String())
+
+	w.Reset()
+	showAddedAndRemoved(w, nil, nil)
+	assert.Empty(t, w.String())
+}
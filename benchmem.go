@@ -0,0 +1,53 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/benchmark/parse"
+)
+
+// needsBenchmem returns whether the memory of the benchmarks is compared or shown, which needs -benchmem.
+func needsBenchmem(score comparedScore, cols columns) bool {
+	return score.allocedBytesPerOp || cols.allocedBytesPerOp || cols.allocsPerOp
+}
+
+// addBenchmem adds -benchmem to the arguments of "go test" which don't have it, and returns whether it did.
+// The arguments of other commands, e.g. "make bench", are returned as they are.
+func addBenchmem(cmd string, args []string) ([]string, bool) {
+	if !isGoTest(cmd, args) || hasGoTestFlag(args, "benchmem") {
+		return args, false
+	}
+	return append([]string{args[0], "-benchmem"}, args[1:]...), true
+}
+
+// isGoTest returns whether the command is "go test".
+func isGoTest(cmd string, args []string) bool {
+	return strings.TrimSuffix(filepath.Base(cmd), ".exe") == "go" && len(args) > 0 && args[0] == "test"
+}
+
+// hasGoTestFlag returns whether the arguments of "go test" have the flag, e.g. "-count=5", "--count", or
+// "-test.count" which is passed to the test binary.
+func hasGoTestFlag(args []string, name string) bool {
+	for _, a := range args {
+		flag := strings.TrimPrefix(strings.TrimLeft(a, "-"), "test.")
+		if flag == name || strings.HasPrefix(flag, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutMemoryStats returns the benchmarks which reported no B/op, e.g. because a custom -bench-cmd doesn't
+// pass -benchmem. Their memory would be compared as 0.
+func withoutMemoryStats(set parse.Set) []string {
+	var names []string
+	for name, benchmarks := range set {
+		if len(benchmarks) > 0 && benchmarks[0].Measured&parse.AllocedBytesPerOp == 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/benchmark/parse"
+)
+
+func Test_addBenchmem(t *testing.T) {
+	tests := []struct {
+		name      string
+		cmd       string
+		args      string
+		wantArgs  string
+		wantAdded bool
+	}{
+		{name: "go test", cmd: "go", args: "test -run ^$ -bench . ./...", wantArgs: "test -benchmem -run ^$ -bench . ./...", wantAdded: true},
+		{name: "path to go", cmd: "/usr/local/go/bin/go", args: "test -bench .", wantArgs: "test -benchmem -bench .", wantAdded: true},
+		{name: "already", cmd: "go", args: "test -bench . -benchmem ./...", wantArgs: "test -bench . -benchmem ./..."},
+		{name: "explicitly disabled", cmd: "go", args: "test -bench . -benchmem=false", wantArgs: "test -bench . -benchmem=false"},
+		{name: "flag of the test binary", cmd: "go", args: "test -bench . -test.benchmem", wantArgs: "test -bench . -test.benchmem"},
+		{name: "other command", cmd: "make", args: "bench", wantArgs: "bench"},
+		{name: "not go test", cmd: "go", args: "run ./bench", wantArgs: "run ./bench"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, added := addBenchmem(tt.cmd, strings.Fields(tt.args))
+			assert.Equal(t, strings.Fields(tt.wantArgs), got)
+			assert.Equal(t, tt.wantAdded, added)
+		})
+	}
+}
+
+func Test_needsBenchmem(t *testing.T) {
+	assert.True(t, needsBenchmem(comparedScore{allocedBytesPerOp: true}, columns{}))
+	assert.True(t, needsBenchmem(comparedScore{nsPerOp: true}, columns{allocsPerOp: true}))
+	assert.False(t, needsBenchmem(comparedScore{nsPerOp: true}, columns{name: true, nsPerOp: true}))
+}
+
+func Test_withoutMemoryStats(t *testing.T) {
+	set := parse.Set{
+		"BenchmarkA-8": {{Name: "BenchmarkA-8", Measured: parse.NsPerOp | parse.AllocedBytesPerOp | parse.AllocsPerOp}},
+		"BenchmarkB-8": {{Name: "BenchmarkB-8", Measured: parse.NsPerOp}},
+	}
+	assert.Equal(t, []string{"BenchmarkB-8"}, withoutMemoryStats(set))
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	defaultBitbucketAPIURL = "https://api.bitbucket.org/2.0"
+
+	bitbucketBuildStatusKey = "cob-benchmarks"
+)
+
+// bitbucketClient talks to Bitbucket Cloud, or to Bitbucket Server if server is true.
+type bitbucketClient struct {
+	restClient
+	server bool
+	// owner is the workspace on Bitbucket Cloud and the project key on Bitbucket Server.
+	owner string
+	repo  string
+}
+
+type bitbucketBuildStatus struct {
+	State       string `json:"state"`
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+// newBitbucketClientFromEnv creates a client from variables of Bitbucket Pipelines.
+// BITBUCKET_SERVER_URL switches to Bitbucket Server, where BITBUCKET_PROJECT_KEY is required as well.
+func newBitbucketClientFromEnv() (*bitbucketClient, error) {
+	header := http.Header{}
+	if token := os.Getenv("BITBUCKET_TOKEN"); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	} else if user, password := os.Getenv("BITBUCKET_USERNAME"), os.Getenv("BITBUCKET_APP_PASSWORD"); user != "" && password != "" {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user+":"+password)))
+	} else {
+		return nil, xerrors.New("BITBUCKET_TOKEN, or BITBUCKET_USERNAME and BITBUCKET_APP_PASSWORD must be set")
+	}
+
+	repo := os.Getenv("BITBUCKET_REPO_SLUG")
+	if repo == "" {
+		return nil, xerrors.New("BITBUCKET_REPO_SLUG is not set")
+	}
+
+	if serverURL := os.Getenv("BITBUCKET_SERVER_URL"); serverURL != "" {
+		project := os.Getenv("BITBUCKET_PROJECT_KEY")
+		if project == "" {
+			return nil, xerrors.New("BITBUCKET_PROJECT_KEY is not set")
+		}
+		return newBitbucketClient(serverURL, header, true, project, repo), nil
+	}
+
+	workspace := os.Getenv("BITBUCKET_WORKSPACE")
+	if workspace == "" {
+		return nil, xerrors.New("BITBUCKET_WORKSPACE is not set")
+	}
+	return newBitbucketClient(defaultBitbucketAPIURL, header, false, workspace, repo), nil
+}
+
+func newBitbucketClient(baseURL string, header http.Header, server bool, owner, repo string) *bitbucketClient {
+	return &bitbucketClient{
+		restClient: newRESTClient("Bitbucket", baseURL, header),
+		server:     server,
+		owner:      owner,
+		repo:       repo,
+	}
+}
+
+func (c *bitbucketClient) createPRComment(prID, body string) error {
+	var path string
+	var in interface{}
+	if c.server {
+		path = fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/pull-requests/%s/comments",
+			url.PathEscape(c.owner), url.PathEscape(c.repo), url.PathEscape(prID))
+		in = map[string]string{"text": body}
+	} else {
+		path = fmt.Sprintf("/repositories/%s/%s/pullrequests/%s/comments",
+			url.PathEscape(c.owner), url.PathEscape(c.repo), url.PathEscape(prID))
+		in = map[string]map[string]string{"content": {"raw": body}}
+	}
+	if err := c.do(http.MethodPost, path, in, nil); err != nil {
+		return xerrors.Errorf("failed to create a comment: %w", err)
+	}
+	return nil
+}
+
+func (c *bitbucketClient) createBuildStatus(commit string, status bitbucketBuildStatus) error {
+	path := fmt.Sprintf("/repositories/%s/%s/commit/%s/statuses/build", url.PathEscape(c.owner), url.PathEscape(c.repo), commit)
+	if c.server {
+		path = fmt.Sprintf("/rest/build-status/1.0/commits/%s", commit)
+	}
+	if err := c.do(http.MethodPost, path, status, nil); err != nil {
+		return xerrors.Errorf("failed to create a build status: %w", err)
+	}
+	return nil
+}
+
+// bitbucketPipelinesURL returns the URL of the current pipeline, or an empty string outside Bitbucket Pipelines.
+func bitbucketPipelinesURL() string {
+	origin, build := os.Getenv("BITBUCKET_GIT_HTTP_ORIGIN"), os.Getenv("BITBUCKET_BUILD_NUMBER")
+	if origin == "" || build == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/addon/pipelines/home#!/results/%s", origin, build)
+}
+
+func postBitbucketPRComment(body string) error {
+	c, err := newBitbucketClientFromEnv()
+	if err != nil {
+		return err
+	}
+	prID := os.Getenv("BITBUCKET_PR_ID")
+	if prID == "" {
+		return xerrors.New("BITBUCKET_PR_ID is not set: run cob in a pull request pipeline")
+	}
+	if err = c.createPRComment(prID, body); err != nil {
+		return err
+	}
+	infof("Posted the result to the pull request #%s", prID)
+	return nil
+}
+
+func postBitbucketBuildStatus(commit string, results []result, threshold float64, comparedScore comparedScore) error {
+	c, err := newBitbucketClientFromEnv()
+	if err != nil {
+		return err
+	}
+
+	state := "SUCCESSFUL"
+	for _, r := range results {
+		if isDegression(r, threshold, comparedScore) {
+			state = "FAILED"
+			break
+		}
+	}
+	// The URL is mandatory for build statuses
+	statusURL := bitbucketPipelinesURL()
+	if statusURL == "" {
+		statusURL = c.baseURL
+	}
+	status := bitbucketBuildStatus{
+		State:       state,
+		Key:         bitbucketBuildStatusKey,
+		Name:        checkRunName,
+		URL:         statusURL,
+		Description: generateStatusDescription(results, threshold, comparedScore),
+	}
+	if err = c.createBuildStatus(commit, status); err != nil {
+		return err
+	}
+	infof("Set the build status '%s' to %s", bitbucketBuildStatusKey, state)
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_bitbucketClient(t *testing.T) {
+	tests := []struct {
+		name            string
+		server          bool
+		owner           string
+		wantCommentPath string
+		wantComment     string
+		wantStatusPath  string
+	}{
+		{
+			name:            "cloud",
+			owner:           "workspace",
+			wantCommentPath: "/repositories/workspace/cob/pullrequests/7/comments",
+			wantComment:     `{"content":{"raw":"result"}}`,
+			wantStatusPath:  "/repositories/workspace/cob/commit/abc/statuses/build",
+		},
+		{
+			name:            "server",
+			server:          true,
+			owner:           "PROJ",
+			wantCommentPath: "/rest/api/1.0/projects/PROJ/repos/cob/pull-requests/7/comments",
+			wantComment:     `{"text":"result"}`,
+			wantStatusPath:  "/rest/build-status/1.0/commits/abc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			var bodies []json.RawMessage
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+				var body json.RawMessage
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				paths = append(paths, r.URL.Path)
+				bodies = append(bodies, body)
+			}))
+			defer ts.Close()
+
+			header := http.Header{}
+			header.Set("Authorization", "Bearer secret")
+			c := newBitbucketClient(ts.URL, header, tt.server, tt.owner, "cob")
+			require.NoError(t, c.createPRComment("7", "result"))
+			require.NoError(t, c.createBuildStatus("abc", bitbucketBuildStatus{State: "FAILED", Key: bitbucketBuildStatusKey}))
+
+			assert.Equal(t, []string{tt.wantCommentPath, tt.wantStatusPath}, paths, tt.name)
+			assert.JSONEq(t, tt.wantComment, string(bodies[0]), tt.name)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+// publishBranch commits the report as results/<commit>.json, and optionally as index.html, to the branch and
+// pushes it to the remote. The branch is created as an orphan branch if it doesn't exist. The commit is built
+// with a temporary index, so the worktree and the checked out branch are left alone.
+func publishBranch(g gitCLI, branch, remote string, rep report.Report, withHTML bool) error {
+	ref := "refs/heads/" + branch
+	if remote != "" {
+		if _, err := g.run(nil, "fetch", "--quiet", remote, "+"+ref+":"+ref); err != nil {
+			debugf("git: failed to fetch %s, creating it: %s", branch, err)
+		}
+	}
+	var parent string
+	if out, err := g.run(nil, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err == nil {
+		parent = strings.TrimSpace(string(out))
+	}
+
+	files := map[string][]byte{}
+	b := &bytes.Buffer{}
+	if err := report.Encode(b, rep); err != nil {
+		return err
+	}
+	files["results/"+rep.Head.Hash+".json"] = b.Bytes()
+	if withHTML {
+		_, message := reportVerdict(rep)
+		page, err := generateHTML(rep, message)
+		if err != nil {
+			return err
+		}
+		files["index.html"] = []byte(page)
+	}
+
+	f, err := ioutil.TempFile("", "cob-index")
+	if err != nil {
+		return xerrors.Errorf("failed to create a temporary index: %w", err)
+	}
+	f.Close()
+	// git refuses an empty file as an index, but creates it if it doesn't exist.
+	os.Remove(f.Name())
+	defer os.Remove(f.Name())
+	gi := g.withEnv("GIT_INDEX_FILE=" + f.Name())
+
+	if parent != "" {
+		_, err = gi.run(nil, "read-tree", parent)
+	} else {
+		_, err = gi.run(nil, "read-tree", "--empty")
+	}
+	if err != nil {
+		return xerrors.Errorf("failed to read the tree of %s: %w", branch, err)
+	}
+	var paths []string
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		blob, err := gi.run(files[path], "hash-object", "-w", "--stdin")
+		if err != nil {
+			return xerrors.Errorf("failed to write %s: %w", path, err)
+		}
+		if _, err = gi.run(nil, "update-index", "--add", "--cacheinfo", "100644,"+strings.TrimSpace(string(blob))+","+path); err != nil {
+			return xerrors.Errorf("failed to add %s: %w", path, err)
+		}
+	}
+	tree, err := gi.run(nil, "write-tree")
+	if err != nil {
+		return xerrors.Errorf("failed to write the tree: %w", err)
+	}
+
+	args := []string{"commit-tree", strings.TrimSpace(string(tree)), "-m", "Add benchmark results of " + shortHash(rep.Head.Hash)}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	commit, err := g.run(nil, args...)
+	if err != nil {
+		return xerrors.Errorf("failed to commit the results: %w", err)
+	}
+	if _, err = g.run(nil, "update-ref", ref, strings.TrimSpace(string(commit)), parent); err != nil {
+		return xerrors.Errorf("failed to update %s: %w", branch, err)
+	}
+
+	if remote == "" {
+		infof("Committed the results to %s", branch)
+		return nil
+	}
+	if _, err = g.run(nil, "push", "--quiet", remote, ref); err != nil {
+		return xerrors.Errorf("failed to push %s: %w", branch, err)
+	}
+	infof("Pushed the results to %s of %s", branch, remote)
+	return nil
+}
+
+// reportVerdict is the verdict of a report read back from JSON.
+func reportVerdict(rep report.Report) (status, string) {
+	var failed, warned int
+	for _, b := range rep.Benchmarks {
+		switch b.Status {
+		case report.StatusFail:
+			failed++
+		case report.StatusWarn:
+			warned++
+		}
+	}
+	return verdictOf(failed, warned, rep.Threshold)
+}
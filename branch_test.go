@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_publishBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote.git")
+	runGit(t, dir, "init", "--quiet", "--bare", remote)
+	repo := filepath.Join(dir, "repo")
+	runGit(t, dir, "clone", "--quiet", remote, repo)
+	runGit(t, repo, "commit", "--quiet", "--allow-empty", "-m", "init")
+	head := runGit(t, repo, "rev-parse", "HEAD")
+
+	g := newGitCLI(repo)
+	first := report.Report{SchemaVersion: 1, Head: report.Commit{Hash: "aaaaaaaa"}, Benchmarks: []report.Benchmark{{Name: "BenchmarkA"}}}
+	require.NoError(t, publishBranch(g, "benchmarks", "origin", first, true))
+	second := first
+	second.Head.Hash = "bbbbbbbb"
+	require.NoError(t, publishBranch(g, "benchmarks", "origin", second, false))
+
+	assert.Equal(t, "index.html\nresults/aaaaaaaa.json\nresults/bbbbbbbb.json",
+		runGit(t, remote, "ls-tree", "-r", "--name-only", "benchmarks"))
+	assert.Equal(t, "2", runGit(t, remote, "rev-list", "--count", "benchmarks"))
+	assert.Equal(t, head, runGit(t, repo, "rev-parse", "HEAD"), "HEAD should not move")
+}
+
+func Test_reportVerdict(t *testing.T) {
+	rep := report.Report{Threshold: 0.2, Benchmarks: []report.Benchmark{{Status: report.StatusOK}, {Status: report.StatusWarn}}}
+	s, message := reportVerdict(rep)
+	assert.Equal(t, statusWarn, s)
+	assert.Equal(t, "PASS: 1 benchmark(s) got worse within the threshold (20.00%)", message)
+
+	rep.Benchmarks[0].Status = report.StatusFail
+	s, message = reportVerdict(rep)
+	assert.Equal(t, statusFail, s)
+	assert.Equal(t, "FAIL: 1 benchmark(s) got worse than the threshold (20.00%)", message)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+)
+
+// buildFlags are the settings of the go command the benchmarks are built with. They are recorded with the
+// results, because a comparison of benchmarks built differently, e.g. with another GOEXPERIMENT, is only
+// meaningful if it is known.
+type buildFlags struct {
+	// goflags is GOFLAGS of the environment followed by -goflags.
+	goflags string
+	// goexperiment is -goexperiment, or GOEXPERIMENT of the environment.
+	goexperiment string
+	gcflags      string
+	ldflags      string
+}
+
+// newBuildFlags combines the flags with GOFLAGS and GOEXPERIMENT of the environment.
+func newBuildFlags(c config, getenv func(string) string) buildFlags {
+	f := buildFlags{
+		goflags:      strings.TrimSpace(getenv("GOFLAGS") + " " + c.goflags),
+		goexperiment: c.goexperiment,
+		gcflags:      c.gcflags,
+		ldflags:      c.ldflags,
+	}
+	if f.goexperiment == "" {
+		f.goexperiment = getenv("GOEXPERIMENT")
+	}
+	return f
+}
+
+// buildFlagsOf returns the flags recorded in the environment of a report.
+func buildFlagsOf(env report.Environment) buildFlags {
+	return buildFlags{goflags: env.GOFLAGS, goexperiment: env.GOEXPERIMENT, gcflags: env.GCFlags, ldflags: env.LDFlags}
+}
+
+// goflagsEnv returns GOFLAGS with -gcflags and -ldflags, so that every go command cob runs, including
+// "go test -c" of the profiles and a -bench-cmd like "make bench" which calls go, builds alike. go splits
+// GOFLAGS at spaces, so a value with spaces is quoted.
+func (f buildFlags) goflagsEnv() string {
+	flags := f.goflags
+	for _, flag := range []struct{ name, value string }{{"-gcflags", f.gcflags}, {"-ldflags", f.ldflags}} {
+		if flag.value == "" {
+			continue
+		}
+		if flags != "" {
+			flags += " "
+		}
+		flags += quoteGoflag(flag.name + "=" + flag.value)
+	}
+	return flags
+}
+
+func quoteGoflag(s string) string {
+	if !strings.ContainsAny(s, " \t\n\"'") {
+		return s
+	}
+	if strings.Contains(s, "'") {
+		return `"` + s + `"`
+	}
+	return "'" + s + "'"
+}
+
+// apply sets GOFLAGS and GOEXPERIMENT of cob, which the go commands it runs inherit.
+func (f buildFlags) apply() error {
+	if err := os.Setenv("GOFLAGS", f.goflagsEnv()); err != nil {
+		return err
+	}
+	return os.Setenv("GOEXPERIMENT", f.goexperiment)
+}
+
+// record adds the flags to the machine, so that they are part of the environment of the report, the history
+// and the cache key.
+func (f buildFlags) record(m *machine) {
+	m.GOFLAGS = f.goflags
+	m.GOEXPERIMENT = f.goexperiment
+	m.GCFlags = f.gcflags
+	m.LDFlags = f.ldflags
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newBuildFlags(t *testing.T) {
+	env := map[string]string{"GOFLAGS": "-mod=mod", "GOEXPERIMENT": "loopvar"}
+	getenv := func(key string) string { return env[key] }
+
+	f := newBuildFlags(config{goflags: "-tags=integration", gcflags: "all=-N -l", ldflags: "-s"}, getenv)
+	assert.Equal(t, buildFlags{goflags: "-mod=mod -tags=integration", goexperiment: "loopvar", gcflags: "all=-N -l", ldflags: "-s"}, f)
+	assert.Equal(t, "-mod=mod -tags=integration '-gcflags=all=-N -l' -ldflags=-s", f.goflagsEnv())
+
+	f = newBuildFlags(config{goexperiment: "rangefunc"}, getenv)
+	assert.Equal(t, "rangefunc", f.goexperiment)
+	assert.Equal(t, "-mod=mod", f.goflagsEnv())
+
+	var m machine
+	f.record(&m)
+	assert.Equal(t, machine{GOFLAGS: "-mod=mod", GOEXPERIMENT: "rangefunc"}, m)
+	assert.Equal(t, f, buildFlagsOf(*m.environment()), "the flags of a report build alike")
+}
+
+func Test_quoteGoflag(t *testing.T) {
+	assert.Equal(t, "-ldflags=-s", quoteGoflag("-ldflags=-s"))
+	assert.Equal(t, "'-ldflags=-s -w'", quoteGoflag("-ldflags=-s -w"))
+	assert.Equal(t, `"-ldflags=-X 'main.v=a b'"`, quoteGoflag("-ldflags=-X 'main.v=a b'"))
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// buildkiteAnnotationContext identifies the annotation so that subsequent runs in the build replace it.
+const buildkiteAnnotationContext = "cob"
+
+var buildkiteStyles = map[status]string{
+	statusOK:   "success",
+	statusWarn: "warning",
+	statusFail: "error",
+}
+
+// annotateBuildkite creates a build annotation with buildkite-agent, styled according to the verdict.
+func annotateBuildkite(agent, body string, s status) error {
+	args := []string{"annotate", "--style", buildkiteStyles[s], "--context", buildkiteAnnotationContext}
+	debugf("exec: %s %s", agent, strings.Join(args, " "))
+	cmd := exec.Command(agent, args...)
+	cmd.Stdin = strings.NewReader(body)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return xerrors.Errorf("failed to run '%s %s': %w: %s", agent, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	infof("Annotated the Buildkite build")
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_annotateBuildkite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// A fake buildkite-agent which records the arguments and stdin
+	agent := filepath.Join(dir, "buildkite-agent")
+	script := "#!/bin/sh\necho \"$@\" > " + filepath.Join(dir, "args") + "\ncat > " + filepath.Join(dir, "stdin") + "\n"
+	require.NoError(t, ioutil.WriteFile(agent, []byte(script), 0755))
+
+	require.NoError(t, annotateBuildkite(agent, "## Benchmark comparison", statusFail))
+
+	args, err := ioutil.ReadFile(filepath.Join(dir, "args"))
+	require.NoError(t, err)
+	assert.Equal(t, "annotate --style error --context cob\n", string(args))
+	stdin, err := ioutil.ReadFile(filepath.Join(dir, "stdin"))
+	require.NoError(t, err)
+	assert.Equal(t, "## Benchmark comparison", string(stdin))
+}
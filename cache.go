@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/tools/benchmark/parse"
+	"golang.org/x/xerrors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// cacheCommand works on the cache of -cache with the options of "cob run", so that the keys are the ones a run
+// with the same options uses.
+var cacheCommand = &cli.Command{
+	Name:  "cache",
+	Usage: "Inspect and fill the cache of results shared with -cache",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "key",
+			Usage:     "Print the key of the results of a commit (default: HEAD) and what it is derived from",
+			ArgsUsage: "[COMMIT]",
+			Flags:     runFlags,
+			Action: func(c *cli.Context) error {
+				_, in, err := cacheKeyOf(c, c.Args().First())
+				if err != nil {
+					return err
+				}
+				return writeCacheKey(os.Stdout, in)
+			},
+		},
+		{
+			Name:      "get",
+			Usage:     "Print the cached results of a commit (default: HEAD) as 'go test -bench' output",
+			ArgsUsage: "[COMMIT]",
+			Flags:     runFlags,
+			Action: func(c *cli.Context) error {
+				cache, in, err := cacheKeyOf(c, c.Args().First())
+				if err != nil {
+					return err
+				}
+				set, err := cache.get(in)
+				if err != nil {
+					return err
+				} else if set == nil {
+					return xerrors.Errorf("the results of %s are not cached", in.Commit)
+				}
+				return writeBenchmarkSet(os.Stdout, set)
+			},
+		},
+		{
+			Name:      "put",
+			Usage:     "Cache the results of a commit from 'go test -bench' output ('-' for stdin), e.g. measured by another runner of the same kind",
+			ArgsUsage: "COMMIT FILE",
+			Flags:     runFlags,
+			Action: func(c *cli.Context) error {
+				if c.Args().Len() != 2 {
+					return cli.ShowSubcommandHelp(c)
+				}
+				cache, in, err := cacheKeyOf(c, c.Args().Get(0))
+				if err != nil {
+					return err
+				}
+				set, _, err := readResults(c.Args().Get(1))
+				if err != nil {
+					return err
+				}
+				if err = cache.put(in, set); err != nil {
+					return err
+				}
+				infof("Cached %d benchmark(s) of %s", len(set), in.Commit)
+				return nil
+			},
+		},
+	},
+}
+
+// cacheKeyOf returns the cache and the key under which "cob run" with the options of c caches the results of the
+// revision, or of HEAD if it is empty. As in a run, -benchmem is added to -bench-args if the memory is compared or
+// shown, and the build flags are part of the key.
+func cacheKeyOf(c *cli.Context, rev string) (resultCache, cacheKeyInputs, error) {
+	err := applyConfigFile(c)
+	if err == nil {
+		err = applyPreset(c)
+	}
+	if err != nil {
+		return resultCache{}, cacheKeyInputs{}, err
+	}
+	cfg := newConfig(c)
+	if cfg.cache == "" {
+		return resultCache{}, cacheKeyInputs{}, xerrors.New("--cache is required")
+	}
+	cache, err := openCache(cfg.cache)
+	if err != nil {
+		return resultCache{}, cacheKeyInputs{}, xerrors.Errorf("failed to open the cache: %w", err)
+	}
+	cols, err := whichColumnsToShow(cfg.columns)
+	if err != nil {
+		return resultCache{}, cacheKeyInputs{}, xerrors.Errorf("invalid columns: %w", err)
+	}
+	if needsBenchmem(whichScoreToCompare(cfg.compare), cols) {
+		cfg.benchArgs, _ = addBenchmem(cfg.benchCmd, cfg.benchArgs)
+	}
+	m, err := benchMachine(cfg)
+	if err != nil {
+		return resultCache{}, cacheKeyInputs{}, err
+	}
+
+	if rev == "" {
+		rev = "HEAD"
+	}
+	r, err := git.PlainOpen(".")
+	if err != nil {
+		return resultCache{}, cacheKeyInputs{}, xerrors.Errorf("unable to open the git repository: %w", err)
+	}
+	hash, err := r.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return resultCache{}, cacheKeyInputs{}, xerrors.Errorf("unable to resolve %s: %w", rev, err)
+	}
+	return cache, newCacheKeyInputs(hash.String(), cfg, m), nil
+}
+
+func writeCacheKey(w io.Writer, in cacheKeyInputs) error {
+	b, err := json.MarshalIndent(in, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("failed to encode the key: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n%s\n", in.key(), b)
+	return err
+}
+
+// writeBenchmarkSet writes the results in the format of "go test -bench", in the order of their names.
+func writeBenchmarkSet(w io.Writer, set parse.Set) error {
+	var names []string
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, b := range set[name] {
+			if _, err := fmt.Fprintln(w, b.String()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cacheStorage is the part of objectStorage a result cache needs, which a directory or a plain HTTP server
+// also provides.
+type cacheStorage interface {
+	putObject(key string, body []byte) error
+	// getObject returns errObjectNotFound if the key doesn't exist.
+	getObject(key string) ([]byte, error)
+}
+
+// resultCache keeps the results of benchmarks under a key derived from everything which affects them, so that
+// a runner can reuse the results of the base commit which another runner already measured.
+type resultCache struct {
+	storage cacheStorage
+	prefix  string
+}
+
+// cacheEntry is the content of a cached object. The inputs of the key are kept for debugging.
+type cacheEntry struct {
+	cacheKeyInputs
+	Benchmarks parse.Set `json:"benchmarks"`
+}
+
+// cacheKeyInputs are the inputs of a cache key. Results on machines with the same tag, OS, architecture and
+// number of CPUs are considered to be interchangeable.
+type cacheKeyInputs struct {
+	Commit    string   `json:"commit"`
+	BenchCmd  string   `json:"benchCmd"`
+	BenchArgs []string `json:"benchArgs"`
+	GoVersion string   `json:"goVersion"`
+	OS        string   `json:"os"`
+	Arch      string   `json:"arch"`
+	Tag       string   `json:"tag,omitempty"`
+	// CPUs is omitted if it is unknown, like the build flags below.
+	CPUs int `json:"cpus,omitempty"`
+	// The build flags are omitted if they are empty, so that the keys of results built without them don't change.
+	GOFLAGS      string `json:"goflags,omitempty"`
+	GOEXPERIMENT string `json:"goexperiment,omitempty"`
+	GCFlags      string `json:"gcflags,omitempty"`
+	LDFlags      string `json:"ldflags,omitempty"`
+}
+
+func (in cacheKeyInputs) key() string {
+	b, _ := json.Marshal(in)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func newCacheKeyInputs(commit string, c config, m machine) cacheKeyInputs {
+	return cacheKeyInputs{
+		Commit:       commit,
+		BenchCmd:     c.benchCmd,
+		BenchArgs:    c.benchArgs,
+		GoVersion:    m.GoVersion,
+		OS:           m.OS,
+		Arch:         m.Arch,
+		Tag:          m.Tag,
+		CPUs:         m.CPUs,
+		GOFLAGS:      m.GOFLAGS,
+		GOEXPERIMENT: m.GOEXPERIMENT,
+		GCFlags:      m.GCFlags,
+		LDFlags:      m.LDFlags,
+	}
+}
+
+// openCache opens a cache at a local directory, s3://, gs://, azblob://, or an http(s):// URL which objects
+// are read from with GET and written to with PUT.
+func openCache(uri string) (resultCache, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 { // "C:\..." is a local path
+		return resultCache{storage: dirStorage{dir: expandHome(uri)}}, nil
+	}
+	switch u.Scheme {
+	case "s3", "gs", "azblob":
+		storage, err := newObjectStorage(u.Scheme, u.Host)
+		if err != nil {
+			return resultCache{}, err
+		}
+		return resultCache{storage: storage, prefix: strings.TrimPrefix(u.Path, "/")}, nil
+	case "http", "https":
+		return resultCache{storage: httpStorage{client: newHTTPClient(), baseURL: strings.TrimSuffix(uri, "/")}}, nil
+	case "file":
+		return resultCache{storage: dirStorage{dir: u.Path}}, nil
+	default:
+		return resultCache{}, xerrors.Errorf("unsupported cache: %s", uri)
+	}
+}
+
+func (c resultCache) object(key string) string {
+	return strings.TrimPrefix(strings.TrimSuffix(c.prefix, "/")+"/"+key+".json", "/")
+}
+
+// get returns nil if the results are not cached.
+func (c resultCache) get(in cacheKeyInputs) (parse.Set, error) {
+	b, err := c.storage.getObject(c.object(in.key()))
+	if xerrors.Is(err, errObjectNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, xerrors.Errorf("failed to read the cache: %w", err)
+	}
+	var entry cacheEntry
+	if err = json.Unmarshal(b, &entry); err != nil {
+		return nil, xerrors.Errorf("failed to decode the cached results: %w", err)
+	}
+	return entry.Benchmarks, nil
+}
+
+func (c resultCache) put(in cacheKeyInputs, set parse.Set) error {
+	b, err := json.Marshal(cacheEntry{cacheKeyInputs: in, Benchmarks: set})
+	if err != nil {
+		return xerrors.Errorf("failed to encode the results: %w", err)
+	}
+	if err = c.storage.putObject(c.object(in.key()), b); err != nil {
+		return xerrors.Errorf("failed to write the cache: %w", err)
+	}
+	return nil
+}
+
+// dirStorage keeps objects as files in a directory, e.g. one restored and saved by actions/cache.
+type dirStorage struct {
+	dir string
+}
+
+func (s dirStorage) putObject(key string, body []byte) error {
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// Through a temporary file of its own, so that a concurrent reader never sees a partial object, and
+	// concurrent writers of the object don't write to the same file.
+	f, err := ioutil.TempFile(filepath.Dir(path), ".cob-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// The cache is shared by the runners, unlike the temporary file.
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func (s dirStorage) getObject(key string) ([]byte, error) {
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil, errObjectNotFound
+	}
+	return b, err
+}
+
+// httpStorage keeps objects on a plain HTTP server, e.g. a remote cache of a build system. Credentials can be
+// given as user info in the URL.
+type httpStorage struct {
+	client  *http.Client
+	baseURL string
+}
+
+func (s httpStorage) do(method, key string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, s.baseURL+"/"+key, bytes.NewReader(body))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create a request: %w", err)
+	}
+	req.Header.Set("User-Agent", "cob")
+	debugf("cache: %s %s", method, key)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errObjectNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, xerrors.Errorf("the cache returned %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return b, nil
+}
+
+func (s httpStorage) putObject(key string, body []byte) error {
+	_, err := s.do(http.MethodPut, key, body)
+	return err
+}
+
+func (s httpStorage) getObject(key string) ([]byte, error) {
+	return s.do(http.MethodGet, key, nil)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/benchmark/parse"
+)
+
+func Test_cacheKeyInputs_key(t *testing.T) {
+	c := config{benchCmd: "go", benchArgs: []string{"test", "-run", "^$", "-bench", ".", "./..."}}
+	m := machine{Hostname: "runner-1", OS: "linux", Arch: "amd64", GoVersion: "go1.13.8"}
+	key := newCacheKeyInputs("aaaaaaaaaa", c, m).key()
+	assert.Len(t, key, 64)
+
+	// The hostname doesn't matter, so that any runner can reuse the results.
+	m2 := m
+	m2.Hostname = "runner-2"
+	assert.Equal(t, key, newCacheKeyInputs("aaaaaaaaaa", c, m2).key())
+
+	m2.GoVersion = "go1.14"
+	assert.NotEqual(t, key, newCacheKeyInputs("aaaaaaaaaa", c, m2).key())
+	m2 = m
+	m2.Tag = "ci-large"
+	assert.NotEqual(t, key, newCacheKeyInputs("aaaaaaaaaa", c, m2).key())
+	m2 = m
+	m2.CPUs = 8
+	assert.NotEqual(t, key, newCacheKeyInputs("aaaaaaaaaa", c, m2).key(), "the number of CPUs is an environment mismatch")
+	m2 = m
+	m2.GOEXPERIMENT = "rangefunc"
+	assert.NotEqual(t, key, newCacheKeyInputs("aaaaaaaaaa", c, m2).key())
+	c2 := c
+	c2.benchArgs = []string{"test", "-bench", "BenchmarkA"}
+	assert.NotEqual(t, key, newCacheKeyInputs("aaaaaaaaaa", c2, m).key())
+	assert.NotEqual(t, key, newCacheKeyInputs("bbbbbbbbbb", c, m).key())
+}
+
+// fakeHTTPCache is a remote cache which keeps objects in memory.
+type fakeHTTPCache struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeHTTPCache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		b, _ := ioutil.ReadAll(r.Body)
+		f.objects[r.URL.Path] = b
+	case http.MethodGet:
+		b, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(b)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func Test_resultCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fake := &fakeHTTPCache{objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	set := parse.Set{"BenchmarkA-8": []*parse.Benchmark{
+		{Name: "BenchmarkA-8", N: 1000, NsPerOp: 100, AllocedBytesPerOp: 16, AllocsPerOp: 1, Measured: parse.NsPerOp | parse.AllocedBytesPerOp | parse.AllocsPerOp},
+	}}
+	in := cacheKeyInputs{Commit: "aaaaaaaaaa", BenchCmd: "go", GoVersion: "go1.13.8"}
+
+	for _, uri := range []string{dir + "/cache", "file://" + dir + "/file", server.URL + "/cob/"} {
+		t.Run(uri, func(t *testing.T) {
+			cache, err := openCache(uri)
+			require.NoError(t, err)
+
+			got, err := cache.get(in)
+			require.NoError(t, err)
+			assert.Nil(t, got)
+
+			require.NoError(t, cache.put(in, set))
+			got, err = cache.get(in)
+			require.NoError(t, err)
+			assert.Equal(t, set, got)
+		})
+	}
+	assert.Contains(t, fake.objects, "/cob/"+in.key()+".json")
+}
+
+func Test_dirStorage_putObject(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := dirStorage{dir: dir}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, s.putObject("a/key.json", []byte(strings.Repeat(strconv.Itoa(i), 4096))))
+		}(i)
+	}
+	wg.Wait()
+
+	b, err := s.getObject("a/key.json")
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat(string(b[0]), 4096), string(b), "one of the writes, not a mix of them")
+	entries, err := ioutil.ReadDir(filepath.Join(dir, "a"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "no temporary files are left")
+	assert.Equal(t, os.FileMode(0644), entries[0].Mode().Perm())
+}
+
+func Test_httpStorage_error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "denied", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	cache, err := openCache(server.URL)
+	require.NoError(t, err)
+	_, err = cache.get(cacheKeyInputs{})
+	require.Error(t, err)
+	assert.True(t, strings.HasSuffix(err.Error(), "the cache returned 403 Forbidden: denied"), err.Error())
+}
+
+func Test_openCache(t *testing.T) {
+	_, err := openCache("ftp://example.com/cache")
+	assert.EqualError(t, err, "unsupported cache: ftp://example.com/cache")
+
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	os.Setenv("AWS_REGION", "us-east-1")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	defer os.Unsetenv("AWS_REGION")
+	cache, err := openCache("s3://my-bucket/cob-cache/")
+	require.NoError(t, err)
+	assert.Equal(t, "cob-cache/"+cacheKeyInputs{}.key()+".json", cache.object(cacheKeyInputs{}.key()))
+}
+
+func Test_writeBenchmarkSet(t *testing.T) {
+	set, err := parse.ParseSet(strings.NewReader("BenchmarkB-8   1000   120 ns/op   16 B/op   1 allocs/op\nBenchmarkA-8   2000   100 ns/op\nBenchmarkA-8   2000   110 ns/op\n"))
+	require.NoError(t, err)
+	var b strings.Builder
+	require.NoError(t, writeBenchmarkSet(&b, set))
+	assert.Equal(t, "BenchmarkA-8 2000 100.00 ns/op\nBenchmarkA-8 2000 110.00 ns/op\nBenchmarkB-8 1000 120.00 ns/op 16 B/op 1 allocs/op\n", b.String())
+
+	// The output can be cached again with "cob cache put".
+	again, err := parse.ParseSet(strings.NewReader(b.String()))
+	require.NoError(t, err)
+	var c strings.Builder
+	require.NoError(t, writeBenchmarkSet(&c, again))
+	assert.Equal(t, b.String(), c.String())
+}
+
+func Test_writeCacheKey(t *testing.T) {
+	in := cacheKeyInputs{Commit: "aaaaaaaaaa", BenchCmd: "go", BenchArgs: []string{"test", "-bench", "."}, GoVersion: "go1.14", OS: "linux", Arch: "amd64"}
+	var b strings.Builder
+	require.NoError(t, writeCacheKey(&b, in))
+	assert.True(t, strings.HasPrefix(b.String(), in.key()+"\n{\n  \"commit\": \"aaaaaaaaaa\",\n"))
+}
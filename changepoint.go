@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+// Defaults of "cob history changes", which are also used by "cob serve".
+const (
+	defaultChangeWindow    = 5
+	defaultChangeZScore    = 3
+	defaultChangeMinChange = 0.05
+)
+
+var historyChangesCommand = &cli.Command{
+	Name:  "changes",
+	Usage: "Find the commits where the results of benchmarks shifted",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "store",
+			Usage: "Store the runs were recorded in with '-store'",
+			Value: defaultStore,
+		},
+		&cli.StringFlag{
+			Name:  "bench",
+			Usage: "Name of the benchmark (default: all benchmarks)",
+		},
+		&cli.StringFlag{
+			Name:  "machine",
+			Usage: "Use only runs on machines with the tag given by '-machine'",
+		},
+		&cli.IntFlag{
+			Name:  "window",
+			Usage: "Number of runs before and after a commit which are compared",
+			Value: defaultChangeWindow,
+		},
+		&cli.Float64Flag{
+			Name:  "z-score",
+			Usage: "Minimum shift of the mean in pooled standard deviations",
+			Value: defaultChangeZScore,
+		},
+		&cli.Float64Flag{
+			Name:  "min-change",
+			Usage: "Minimum relative shift of the mean, which keeps very stable benchmarks from flagging noise",
+			Value: defaultChangeMinChange,
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format (table, json)",
+			Value: "table",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.String("store") == "" {
+			return xerrors.New("-store is required")
+		}
+		if c.Int("window") < 2 {
+			return xerrors.New("-window must be at least 2")
+		}
+		format := c.String("format")
+		if format != "table" && format != "json" {
+			return xerrors.Errorf("unknown output format: %s", format)
+		}
+		st, err := openStore(c.String("store"))
+		if err != nil {
+			return xerrors.Errorf("failed to open the store: %w", err)
+		}
+		runs, err := st.runs()
+		if err != nil {
+			return xerrors.Errorf("failed to read the history: %w", err)
+		}
+		if c.IsSet("machine") {
+			runs = runsOnMachine(runs, c.String("machine"))
+		}
+
+		names := []string{c.String("bench")}
+		if names[0] == "" {
+			names = benchmarkNames(runs)
+		}
+		changes := []changepoint{}
+		for _, name := range names {
+			changes = append(changes, detectChangepoints(name, benchmarkHistory(runs, name, 0),
+				c.Int("window"), c.Float64("z-score"), c.Float64("min-change"))...)
+		}
+
+		if format == "json" {
+			e := json.NewEncoder(os.Stdout)
+			e.SetIndent("", "  ")
+			if err = e.Encode(changes); err != nil {
+				return xerrors.Errorf("failed to encode the changes: %w", err)
+			}
+			return nil
+		}
+		if len(changes) == 0 {
+			infof("No shifts in %d benchmark(s)", len(names))
+			return nil
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetAutoFormatHeaders(false)
+		table.SetHeader([]string{"Name", "Time", "Commit", "Before", "After", "Change", "Z"})
+		for _, ch := range changes {
+			table.Append([]string{
+				ch.Name,
+				ch.Time.UTC().Format(time.RFC3339),
+				shortHash(ch.Commit),
+				strconv.FormatFloat(ch.Before, 'f', 2, 64),
+				strconv.FormatFloat(ch.After, 'f', 2, 64),
+				strconv.FormatFloat(100*ch.Change, 'f', 2, 64) + "%",
+				strconv.FormatFloat(ch.ZScore, 'f', 1, 64),
+			})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+// changepoint is a commit where the results of a benchmark shifted: the first one of the new level.
+type changepoint struct {
+	Name   string    `json:"name"`
+	Time   time.Time `json:"time"`
+	Commit string    `json:"commit"`
+	// Before and After are the means of ns/op in the windows before and after the commit.
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+	// Change is the relative shift of the mean, e.g. 0.2 means 20% slower.
+	Change float64 `json:"change"`
+	// ZScore is the shift in pooled standard deviations of the windows. It is +Inf for windows without noise,
+	// which is encoded as 0 in JSON.
+	ZScore float64 `json:"zScore"`
+}
+
+// MarshalJSON encodes ZScore of noiseless windows as 0, because JSON has no infinity.
+func (c changepoint) MarshalJSON() ([]byte, error) {
+	type alias changepoint
+	if math.IsInf(c.ZScore, 0) {
+		c.ZScore = 0
+	}
+	return json.Marshal(alias(c))
+}
+
+// detectChangepoints compares the mean of ns/op over window results before each point with the one over
+// window results from it. A point is a changepoint if the means differ by at least zScore pooled standard
+// deviations and by minChange relative to the earlier mean. A shift trips a few points around it, so only
+// the point with the largest score within window points is reported.
+func detectChangepoints(name string, points []historyPoint, window int, zScore, minChange float64) []changepoint {
+	var changes []changepoint
+	var best *changepoint
+	bestIndex := 0
+	for i := window; i+window <= len(points); i++ {
+		if best != nil && i >= bestIndex+window {
+			changes = append(changes, *best)
+			best = nil
+		}
+		before, after := nsPerOps(points[i-window:i]), nsPerOps(points[i:i+window])
+		mb, sb := meanStddev(before)
+		ma, sa := meanStddev(after)
+		change := calcRatio(ma, mb)
+		score := math.Inf(1)
+		if s := math.Sqrt((sb*sb + sa*sa) / 2); s > 0 {
+			score = math.Abs(ma-mb) / s
+		}
+		if mb == ma || score < zScore || math.Abs(change) < minChange {
+			continue
+		}
+		if best == nil || score > best.ZScore {
+			best = &changepoint{Name: name, Time: points[i].Time, Commit: points[i].Commit,
+				Before: mb, After: ma, Change: change, ZScore: score}
+			bestIndex = i
+		}
+	}
+	if best != nil {
+		changes = append(changes, *best)
+	}
+	return changes
+}
+
+func nsPerOps(points []historyPoint) []float64 {
+	var values []float64
+	for _, p := range points {
+		values = append(values, p.NsPerOp)
+	}
+	return values
+}
+
+func meanStddev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)))
+}
+
+// benchmarkNames returns the names of the benchmarks recorded in the runs, sorted.
+func benchmarkNames(runs []historyRun) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, run := range runs {
+		for _, b := range run.Report.Benchmarks {
+			if !seen[b.Name] {
+				seen[b.Name] = true
+				names = append(names, b.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
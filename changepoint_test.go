@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func changepointTestPoints(values ...float64) []historyPoint {
+	var points []historyPoint
+	for i, v := range values {
+		points = append(points, historyPoint{
+			Time:    time.Date(2020, 1, 1+i, 0, 0, 0, 0, time.UTC),
+			Commit:  fmt.Sprintf("%010d", i),
+			NsPerOp: v,
+		})
+	}
+	return points
+}
+
+func Test_detectChangepoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []float64
+		commits []string
+	}{
+		{
+			name:    "step",
+			values:  []float64{100, 101, 99, 100, 101, 120, 121, 119, 120, 121},
+			commits: []string{"0000000005"},
+		},
+		{
+			name:    "noiseless step",
+			values:  []float64{100, 100, 100, 100, 100, 90, 90, 90, 90, 90},
+			commits: []string{"0000000005"},
+		},
+		{
+			name:   "noise",
+			values: []float64{100, 110, 95, 105, 100, 98, 112, 96, 104, 101},
+		},
+		{
+			name:   "shift below the minimum change",
+			values: []float64{100, 100, 100, 100, 100, 102, 102, 102, 102, 102},
+		},
+		{
+			name:    "two steps",
+			values:  []float64{100, 101, 99, 100, 101, 120, 121, 119, 120, 121, 150, 151, 149, 150, 151},
+			commits: []string{"0000000005", "0000000010"},
+		},
+		{
+			name:   "too short",
+			values: []float64{100, 100, 120, 120},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commits []string
+			for _, c := range detectChangepoints("BenchmarkA", changepointTestPoints(tt.values...), 5, 3, 0.05) {
+				commits = append(commits, c.Commit)
+			}
+			assert.Equal(t, tt.commits, commits)
+		})
+	}
+}
+
+func Test_detectChangepoints_values(t *testing.T) {
+	changes := detectChangepoints("BenchmarkA", changepointTestPoints(100, 100, 100, 110, 110, 110), 3, 3, 0.05)
+	require.Len(t, changes, 1)
+	c := changes[0]
+	assert.Equal(t, "BenchmarkA", c.Name)
+	assert.Equal(t, time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC), c.Time)
+	assert.Equal(t, 100.0, c.Before)
+	assert.Equal(t, 110.0, c.After)
+	assert.Equal(t, 0.1, c.Change)
+	assert.True(t, math.IsInf(c.ZScore, 1))
+
+	b, err := c.MarshalJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"zScore":0`)
+}
+
+func Test_benchmarkNames(t *testing.T) {
+	assert.Equal(t, []string{"BenchmarkA", "BenchmarkB"}, benchmarkNames(testHistoryRuns()))
+}
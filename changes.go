@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// changedFiles returns the paths of the files which were added, modified or removed between the commits.
+func changedFiles(r *git.Repository, base, head plumbing.Hash) ([]string, error) {
+	baseTree, err := commitTree(r, base)
+	if err != nil {
+		return nil, err
+	}
+	headTree, err := commitTree(r, head)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := baseTree.Diff(headTree)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to diff the commits: %w", err)
+	}
+	seen := map[string]bool{}
+	var files []string
+	for _, c := range changes {
+		for _, name := range []string{c.From.Name, c.To.Name} {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				files = append(files, name)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// goPackage is the part of "go list -json" which is needed to find the imports of a package.
+type goPackage struct {
+	ImportPath   string
+	Dir          string
+	Imports      []string
+	TestImports  []string
+	XTestImports []string
+}
+
+// listPackages returns the packages of the module in the current directory by import path.
+func listPackages() (map[string]goPackage, error) {
+	args := []string{"list", "-e", "-json", "./..."}
+	debugf("exec: go %s", strings.Join(args, " "))
+	out, err := exec.Command("go", args...).Output()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to run 'go %s' command: %w", strings.Join(args, " "), err)
+	}
+	pkgs := map[string]goPackage{}
+	d := json.NewDecoder(strings.NewReader(string(out)))
+	for {
+		var p goPackage
+		if err := d.Decode(&p); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, xerrors.Errorf("invalid output of 'go %s': %w", strings.Join(args, " "), err)
+		}
+		pkgs[p.ImportPath] = p
+	}
+	return pkgs, nil
+}
+
+// packageDirs returns the directories, relative to root, of the package in dir and of the packages of the module
+// which it or its tests import directly.
+func packageDirs(dir, root string, pkgs map[string]goPackage) map[string]bool {
+	rel := func(d string) string {
+		r, err := filepath.Rel(root, d)
+		if err != nil {
+			return ""
+		}
+		return filepath.ToSlash(r)
+	}
+	dirs := map[string]bool{dir: true}
+	for _, p := range pkgs {
+		if rel(p.Dir) != dir {
+			continue
+		}
+		for _, imports := range [][]string{p.Imports, p.TestImports, p.XTestImports} {
+			for _, imp := range imports {
+				if i, ok := pkgs[imp]; ok {
+					dirs[rel(i.Dir)] = true
+				}
+			}
+		}
+	}
+	return dirs
+}
+
+// newChangedFiles returns the changed files in the package of each regressed benchmark and in the packages it
+// imports directly, which are the first places to look for the cause of the regression. The worktree must be at
+// HEAD.
+func newChangedFiles(regressed []report.Benchmark, files []string) []report.ChangedFiles {
+	if len(files) == 0 {
+		return nil
+	}
+	funcs, err := findBenchmarkFuncs(".")
+	if err != nil {
+		warnf("Failed to find the changed files of the benchmarks: %s", err)
+		return nil
+	}
+	pkgs, err := listPackages()
+	if err != nil {
+		warnf("Failed to find the changed files of the benchmarks: %s", err)
+		return nil
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		warnf("Failed to find the changed files of the benchmarks: %s", err)
+		return nil
+	}
+	var changed []report.ChangedFiles
+	for _, b := range regressed {
+		fn, ok := funcs[benchmarkFuncName(b.Name)]
+		if !ok {
+			continue
+		}
+		dirs := packageDirs(path.Dir(fn.Path), root, pkgs)
+		c := report.ChangedFiles{Name: b.Name}
+		for _, f := range files {
+			if dirs[path.Dir(f)] {
+				c.Files = append(c.Files, f)
+			}
+		}
+		if len(c.Files) > 0 {
+			changed = append(changed, c)
+		}
+	}
+	return changed
+}
+
+func showChangedFiles(w io.Writer, changed []report.ChangedFiles) {
+	if len(changed) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nChanged files")
+	fmt.Fprintf(w, "%s\n", strings.Repeat("=", 13))
+	for _, c := range changed {
+		fmt.Fprintf(w, "\n%s\n", c.Name)
+		for _, f := range c.Files {
+			fmt.Fprintf(w, "  %s\n", f)
+		}
+	}
+}
+
+// generateChangedFilesLine renders the changed files of a benchmark as a line for Markdown.
+func generateChangedFilesLine(c report.ChangedFiles) string {
+	var files []string
+	for _, f := range c.Files {
+		files = append(files, "`"+f+"`")
+	}
+	return fmt.Sprintf("%s: %s", c.Name, strings.Join(files, ", "))
+}
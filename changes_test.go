@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_packageDirs(t *testing.T) {
+	pkgs := map[string]goPackage{
+		"example.com/app/pkg": {
+			ImportPath:   "example.com/app/pkg",
+			Dir:          "/src/app/pkg",
+			Imports:      []string{"fmt", "example.com/app/internal/codec"},
+			XTestImports: []string{"example.com/app/pkg", "example.com/app/internal/testutil"},
+		},
+		"example.com/app/internal/codec":    {ImportPath: "example.com/app/internal/codec", Dir: "/src/app/internal/codec"},
+		"example.com/app/internal/testutil": {ImportPath: "example.com/app/internal/testutil", Dir: "/src/app/internal/testutil"},
+		"example.com/app/cmd":               {ImportPath: "example.com/app/cmd", Dir: "/src/app/cmd", Imports: []string{"example.com/app/pkg"}},
+	}
+	assert.Equal(t, map[string]bool{
+		"pkg":               true,
+		"internal/codec":    true,
+		"internal/testutil": true,
+	}, packageDirs("pkg", "/src/app", pkgs))
+}
+
+func Test_showChangedFiles(t *testing.T) {
+	changed := []report.ChangedFiles{{Name: "BenchmarkA-8", Files: []string{"internal/codec/codec.go", "pkg/a.go"}}}
+	w := &bytes.Buffer{}
+	showChangedFiles(w, changed)
+	assert.Equal(t, "\nChanged files\n=============\n\nBenchmarkA-8\n  internal/codec/codec.go\n  pkg/a.go\n", w.String())
+	assert.Equal(t, "BenchmarkA-8: `internal/codec/codec.go`, `pkg/a.go`", generateChangedFilesLine(changed[0]))
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"unicode/utf8"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	checkRunName = "cob benchmarks"
+
+	// The Checks API accepts up to 50 annotations per request.
+	maxAnnotationsPerRequest = 50
+
+	// The Checks API rejects output summaries longer than this.
+	maxCheckRunSummary = 65535
+)
+
+type checkRunAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title"`
+	Message         string `json:"message"`
+}
+
+type checkRunOutput struct {
+	Title       string               `json:"title"`
+	Summary     string               `json:"summary"`
+	Annotations []checkRunAnnotation `json:"annotations,omitempty"`
+}
+
+type checkRun struct {
+	Name       string         `json:"name,omitempty"`
+	HeadSHA    string         `json:"head_sha,omitempty"`
+	Status     string         `json:"status,omitempty"`
+	Conclusion string         `json:"conclusion,omitempty"`
+	Output     checkRunOutput `json:"output"`
+}
+
+// checkRunConclusion returns "failure" if any benchmark got worse than the threshold,
+// "neutral" if any benchmark got worse within the threshold and "success" otherwise.
+func checkRunConclusion(results []result, threshold float64, comparedScore comparedScore) string {
+	conclusion := "success"
+	for _, r := range results {
+		switch benchmarkStatus(r, threshold, comparedScore) {
+		case statusFail:
+			return "failure"
+		case statusWarn:
+			conclusion = "neutral"
+		}
+	}
+	return conclusion
+}
+
+// generateAnnotations creates an annotation on the Benchmark function of each benchmark which got worse.
+func generateAnnotations(results []result, funcs map[string]benchmarkFunc, threshold float64, comparedScore comparedScore) []checkRunAnnotation {
+	var annotations []checkRunAnnotation
+	for _, r := range results {
+		s := benchmarkStatus(r, threshold, comparedScore)
+		if s == statusOK {
+			continue
+		}
+		fn, ok := funcs[benchmarkFuncName(r.Name)]
+		if !ok {
+			debugf("unable to find the function of %s", r.Name)
+			continue
+		}
+
+		level := "warning"
+		if s == statusFail {
+			level = "failure"
+		}
+		annotations = append(annotations, checkRunAnnotation{
+			Path:            fn.Path,
+			StartLine:       fn.Line,
+			EndLine:         fn.Line,
+			AnnotationLevel: level,
+			Title:           fmt.Sprintf("%s got worse", r.Name),
+			Message:         generateDiffRatio(r, comparedScore, columns{nsPerOp: true, allocedBytesPerOp: true, allocsPerOp: true}),
+		})
+	}
+	return annotations
+}
+
+// createCheckRun creates a completed check run. Annotations exceeding the limit of a request are added
+// by updating the check run.
+func (c *githubClient) createCheckRun(owner, repo string, run checkRun) error {
+	annotations := run.Output.Annotations
+	if len(annotations) > maxAnnotationsPerRequest {
+		run.Output.Annotations = annotations[:maxAnnotationsPerRequest]
+	}
+	annotations = annotations[len(run.Output.Annotations):]
+
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.do(http.MethodPost, fmt.Sprintf("/repos/%s/%s/check-runs", owner, repo), run, &created); err != nil {
+		return xerrors.Errorf("failed to create a check run: %w", err)
+	}
+
+	for len(annotations) > 0 {
+		n := len(annotations)
+		if n > maxAnnotationsPerRequest {
+			n = maxAnnotationsPerRequest
+		}
+		update := checkRun{Output: checkRunOutput{
+			Title:       run.Output.Title,
+			Summary:     run.Output.Summary,
+			Annotations: annotations[:n],
+		}}
+		path := fmt.Sprintf("/repos/%s/%s/check-runs/%d", owner, repo, created.ID)
+		if err := c.do(http.MethodPatch, path, update, nil); err != nil {
+			return xerrors.Errorf("failed to add annotations to the check run: %w", err)
+		}
+		annotations = annotations[n:]
+	}
+	return nil
+}
+
+func postGitHubCheckRun(commit, summary string, results []result, threshold float64, comparedScore comparedScore) error {
+	c, err := newGitHubClientFromEnv()
+	if err != nil {
+		return err
+	}
+	owner, repo, err := splitGitHubRepository(os.Getenv("GITHUB_REPOSITORY"))
+	if err != nil {
+		return err
+	}
+
+	funcs, err := findBenchmarkFuncs(".")
+	if err != nil {
+		return err
+	}
+
+	summary = truncateUTF8(summary, maxCheckRunSummary)
+	conclusion := checkRunConclusion(results, threshold, comparedScore)
+	run := checkRun{
+		Name:       checkRunName,
+		HeadSHA:    githubPRHeadSHA(os.Getenv("GITHUB_EVENT_PATH"), commit),
+		Status:     "completed",
+		Conclusion: conclusion,
+		Output: checkRunOutput{
+			Title:       fmt.Sprintf("Benchmarks: %s", conclusion),
+			Summary:     summary,
+			Annotations: generateAnnotations(results, funcs, threshold, comparedScore),
+		},
+	}
+	if err = c.createCheckRun(owner, repo, run); err != nil {
+		return err
+	}
+	infof("Created the check run '%s' on %s", checkRunName, commit)
+	return nil
+}
+
+// truncateUTF8 cuts s to at most n bytes, at the start of a rune so that a multi-byte character, e.g. an emoji
+// of the verdict, isn't cut in half into invalid UTF-8.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_checkRunConclusion(t *testing.T) {
+	compare := comparedScore{nsPerOp: true, allocedBytesPerOp: true}
+	assert.Equal(t, "success", checkRunConclusion([]result{{RatioNsPerOp: -0.1}}, 0.2, compare))
+	assert.Equal(t, "neutral", checkRunConclusion([]result{{RatioNsPerOp: 0.1}}, 0.2, compare))
+	assert.Equal(t, "failure", checkRunConclusion([]result{{RatioNsPerOp: 0.1}, {RatioAllocedBytesPerOp: 0.3}}, 0.2, compare))
+}
+
+func Test_githubClient_createCheckRun(t *testing.T) {
+	var requests []checkRun
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var run checkRun
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&run))
+		requests = append(requests, run)
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		_, _ = w.Write([]byte(`{"id": 42}`))
+	}))
+	defer ts.Close()
+
+	var annotations []checkRunAnnotation
+	for i := 0; i < 120; i++ {
+		annotations = append(annotations, checkRunAnnotation{Path: "a_test.go", StartLine: i, EndLine: i,
+			AnnotationLevel: "failure", Title: fmt.Sprintf("Benchmark%d got worse", i)})
+	}
+
+	c := newGitHubClient(ts.URL, "secret")
+	err := c.createCheckRun("knqyf263", "cob", checkRun{
+		Name:       checkRunName,
+		HeadSHA:    "abc",
+		Status:     "completed",
+		Conclusion: "failure",
+		Output:     checkRunOutput{Title: "Benchmarks: failure", Summary: "summary", Annotations: annotations},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"POST /repos/knqyf263/cob/check-runs",
+		"PATCH /repos/knqyf263/cob/check-runs/42",
+		"PATCH /repos/knqyf263/cob/check-runs/42",
+	}, paths)
+	require.Len(t, requests, 3)
+	assert.Equal(t, "abc", requests[0].HeadSHA)
+	assert.Len(t, requests[0].Output.Annotations, 50)
+	assert.Len(t, requests[1].Output.Annotations, 50)
+	assert.Len(t, requests[2].Output.Annotations, 20)
+	assert.Equal(t, 119, requests[2].Output.Annotations[19].StartLine)
+}
+
+func Test_postGitHubCheckRun(t *testing.T) {
+	var run checkRun
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&run))
+		_, _ = w.Write([]byte(`{"id": 42}`))
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	event := filepath.Join(dir, "event.json")
+	require.NoError(t, ioutil.WriteFile(event, []byte(`{"pull_request": {"number": 1, "head": {"sha": "headsha"}}}`), 0600))
+
+	os.Setenv("GITHUB_TOKEN", "secret")
+	os.Setenv("GITHUB_API_URL", ts.URL)
+	os.Setenv("GITHUB_REPOSITORY", "knqyf263/cob")
+	os.Setenv("GITHUB_EVENT_PATH", event)
+	defer os.Unsetenv("GITHUB_TOKEN")
+	defer os.Unsetenv("GITHUB_API_URL")
+	defer os.Unsetenv("GITHUB_REPOSITORY")
+	defer os.Unsetenv("GITHUB_EVENT_PATH")
+
+	require.NoError(t, postGitHubCheckRun("mergesha", "summary", nil, 0.2, comparedScore{nsPerOp: true}))
+	assert.Equal(t, "headsha", run.HeadSHA, "the check run is on the head of the pull request")
+}
+
+func Test_truncateUTF8(t *testing.T) {
+	assert.Equal(t, "abc", truncateUTF8("abc", 5))
+	assert.Equal(t, "ab", truncateUTF8("abc", 2))
+	assert.Equal(t, "a", truncateUTF8("a✅", 3), "the cut backs off to the start of the rune")
+	assert.Equal(t, "a✅", truncateUTF8("a✅b", 4))
+}
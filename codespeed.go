@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+// codespeedResult is a result accepted by Codespeed's /result/add/json/ endpoint.
+type codespeedResult struct {
+	CommitID    string  `json:"commitid"`
+	Branch      string  `json:"branch"`
+	Project     string  `json:"project"`
+	Executable  string  `json:"executable"`
+	Benchmark   string  `json:"benchmark"`
+	Environment string  `json:"environment"`
+	ResultValue float64 `json:"result_value"`
+}
+
+// generateCodespeedResults maps the report to Codespeed results. Codespeed stores a single value per benchmark,
+// so ns/op is submitted.
+func generateCodespeedResults(rep report.Report, project, executable, environment string) []codespeedResult {
+	branch := rep.Head.Branch
+	if branch == "" {
+		branch = "master"
+	}
+	results := []codespeedResult{}
+	for _, b := range rep.Benchmarks {
+		results = append(results, codespeedResult{
+			CommitID:    rep.Head.Hash,
+			Branch:      branch,
+			Project:     project,
+			Executable:  executable,
+			Benchmark:   b.Name,
+			Environment: environment,
+			ResultValue: b.Head.NsPerOp,
+		})
+	}
+	return results
+}
+
+// codespeedEnvironment returns the environment name, which must be registered in Codespeed beforehand.
+func codespeedEnvironment(environment string) string {
+	if environment != "" {
+		return environment
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
+
+func postCodespeedResults(client *http.Client, codespeedURL string, results []codespeedResult) error {
+	b, err := json.Marshal(results)
+	if err != nil {
+		return xerrors.Errorf("failed to encode the results: %w", err)
+	}
+	form := url.Values{"json": {string(b)}}
+
+	u := strings.TrimSuffix(codespeedURL, "/") + "/result/add/json/"
+	debugf("codespeed: POST %s", u)
+	resp, err := client.PostForm(u, form)
+	if err != nil {
+		return xerrors.Errorf("failed to send the results: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return xerrors.Errorf("Codespeed returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	infof("Submitted %d result(s) to Codespeed", len(results))
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_postCodespeedResults(t *testing.T) {
+	rep := report.Report{
+		Head:       report.Commit{Hash: "599a552"},
+		Benchmarks: []report.Benchmark{{Name: "BenchmarkA", Head: report.Measurement{NsPerOp: 150}}},
+	}
+	results := generateCodespeedResults(rep, "cob", "go1.13", "ci-large")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/result/add/json/", r.URL.Path)
+		var got []codespeedResult
+		require.NoError(t, json.Unmarshal([]byte(r.FormValue("json")), &got))
+		assert.Equal(t, []codespeedResult{{
+			CommitID:    "599a552",
+			Branch:      "master",
+			Project:     "cob",
+			Executable:  "go1.13",
+			Benchmark:   "BenchmarkA",
+			Environment: "ci-large",
+			ResultValue: 150,
+		}}, got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	require.NoError(t, postCodespeedResults(ts.Client(), ts.URL+"/", results))
+}
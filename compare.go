@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/tools/benchmark/parse"
+	"golang.org/x/xerrors"
+)
+
+var compareCommand = &cli.Command{
+	Name:      "compare",
+	Usage:     "Compare two saved results, 'go test -bench' output or JSON reports, without running any benchmark",
+	ArgsUsage: "BASE HEAD",
+	Flags: flagsNamed(runFlags, "config", "preset", "threshold", "significance", "compare", "exclude",
+		"only-degression", "columns", "table-style", "ascii", "summary-line", "format"),
+	Action: compareAction,
+}
+
+// compareAction compares the results with the options of the configuration file and the preset, as "cob run"
+// does, so that both agree on what a regression is.
+func compareAction(c *cli.Context) error {
+	if c.Args().Len() != 2 {
+		return cli.ShowSubcommandHelp(c)
+	}
+	err := applyConfigFile(c)
+	if err == nil {
+		err = applyPreset(c)
+	}
+	if err != nil {
+		return err
+	}
+	return compareResults(os.Stdout, c.Args().Get(0), c.Args().Get(1), newConfig(c))
+}
+
+// flagsNamed returns the flags with the names, so that a command can take some of the options of "cob run" with
+// the same usage and defaults.
+func flagsNamed(flags []cli.Flag, names ...string) []cli.Flag {
+	byName := map[string]cli.Flag{}
+	for _, f := range flags {
+		byName[f.Names()[0]] = f
+	}
+	var named []cli.Flag
+	for _, name := range names {
+		f, ok := byName[name]
+		if !ok {
+			panic("no such flag: " + name)
+		}
+		named = append(named, f)
+	}
+	return named
+}
+
+// readResults reads the results in a file, which is either the output of "go test -bench" or a JSON report
+// written by '-format json', whose results at HEAD are read. "-" reads stdin.
+func readResults(path string) (parse.Set, report.Commit, error) {
+	var b []byte
+	var err error
+	if path == "-" {
+		b, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		b, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, report.Commit{}, xerrors.Errorf("failed to read the results: %w", err)
+	}
+	commit := report.Commit{Ref: path}
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		rep, err := report.Decode(bytes.NewReader(b))
+		if err != nil {
+			return nil, report.Commit{}, xerrors.Errorf("invalid report %s: %w", path, err)
+		}
+		commit.Hash = rep.Head.Hash
+		commit.Branch = rep.Head.Branch
+		return baselineSet(historyRun{Report: rep}), commit, nil
+	}
+	set, err := parse.ParseSet(bytes.NewReader(b))
+	if err != nil {
+		return nil, report.Commit{}, xerrors.Errorf("invalid results %s: %w", path, err)
+	}
+	if len(set) == 0 {
+		return nil, report.Commit{}, xerrors.Errorf("no benchmark results in %s", path)
+	}
+	return set, commit, nil
+}
+
+// compareResults compares the results in two files as "cob run" compares those of two commits.
+func compareResults(w io.Writer, basePath, headPath string, c config) error {
+	if c.format != "table" && c.format != "diff" && c.format != "json" {
+		return xerrors.Errorf("unknown output format: %s", c.format)
+	}
+	if !isTableStyle(c.tableStyle) {
+		return xerrors.Errorf("unknown table style: %s", c.tableStyle)
+	}
+	if c.significance < 0 || c.significance >= 1 {
+		return xerrors.New("--significance must be at least 0 and less than 1")
+	}
+	excludes, err := compileExcludes(c.exclude)
+	if err != nil {
+		return err
+	}
+	cols, err := whichColumnsToShow(c.columns)
+	if err != nil {
+		return xerrors.Errorf("invalid columns: %w", err)
+	}
+	score := whichScoreToCompare(c.compare)
+
+	prevSet, base, err := readResults(basePath)
+	if err != nil {
+		return err
+	}
+	headSet, head, err := readResults(headPath)
+	if err != nil {
+		return err
+	}
+	ratios, rows := compareSets(prevSet, headSet, excludes, nil, c.significance, cols)
+	rep := newReport(ratios, base, head, c.threshold, score, nil)
+	rep.Removed = removedBenchmarks(prevSet, headSet, excludes, nil)
+	rep.Added = addedBenchmarks(prevSet, headSet, excludes, nil)
+
+	worst, _ := verdict(ratios, c.threshold, score)
+	degression := worst == statusFail
+	format, _ := reporterNamed(c.format)
+	rc := reportContext{config: c, report: rep, results: ratios, rows: rows, columns: cols, score: score, degression: degression, w: w}
+	if err = format.reporter.report(rc); err != nil {
+		return err
+	}
+	if degression {
+		return regressionf("%s makes benchmarks worse than %s", headPath, basePath)
+	}
+	return nil
+}
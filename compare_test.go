@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func writeResultFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func Test_readResults(t *testing.T) {
+	dir := writeResultFiles(t, map[string]string{
+		"bench.txt": "goos: linux\nBenchmarkA-8   1000   100 ns/op   16 B/op   1 allocs/op\nBenchmarkA-8   1000   120 ns/op   16 B/op   1 allocs/op\nPASS\n",
+		"report.json": `{"schemaVersion": 1, "head": {"ref": "HEAD", "hash": "aaaaaaaaaa", "branch": "main"},
+			"benchmarks": [{"name": "BenchmarkA-8", "head": {"iterations": 1000, "nsPerOp": 110}}]}`,
+		"empty.txt": "PASS\n",
+	})
+	defer os.RemoveAll(dir)
+
+	set, commit, err := readResults(filepath.Join(dir, "bench.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, report.Commit{Ref: filepath.Join(dir, "bench.txt")}, commit)
+	require.Len(t, set["BenchmarkA-8"], 2)
+	assert.Equal(t, 120.0, set["BenchmarkA-8"][1].NsPerOp)
+
+	set, commit, err = readResults(filepath.Join(dir, "report.json"))
+	require.NoError(t, err)
+	assert.Equal(t, report.Commit{Ref: filepath.Join(dir, "report.json"), Hash: "aaaaaaaaaa", Branch: "main"}, commit)
+	require.Len(t, set["BenchmarkA-8"], 1)
+	assert.Equal(t, 110.0, set["BenchmarkA-8"][0].NsPerOp)
+
+	_, _, err = readResults(filepath.Join(dir, "empty.txt"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no benchmark results in")
+
+	_, _, err = readResults(filepath.Join(dir, "missing.txt"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read the results")
+}
+
+func Test_compareResults(t *testing.T) {
+	dir := writeResultFiles(t, map[string]string{
+		"base.txt": "BenchmarkA-8   1000   100 ns/op   16 B/op   1 allocs/op\nBenchmarkB-8   1000   100 ns/op   16 B/op   1 allocs/op\nBenchmarkOld-8   1000   100 ns/op\n",
+		"head.txt": "BenchmarkA-8   1000   150 ns/op   16 B/op   1 allocs/op\nBenchmarkB-8   1000   100 ns/op   16 B/op   1 allocs/op\nBenchmarkNew-8   1000   100 ns/op\n",
+	})
+	defer os.RemoveAll(dir)
+	base, head := filepath.Join(dir, "base.txt"), filepath.Join(dir, "head.txt")
+	c := config{
+		threshold:  0.2,
+		compare:    []string{"ns/op", "B/op"},
+		columns:    []string{"name", "iter", "ns", "bytes", "ratio", "status"},
+		tableStyle: "ascii",
+		format:     "table",
+		ascii:      true,
+	}
+
+	var w bytes.Buffer
+	err := compareResults(&w, base, head, c)
+	require.Error(t, err)
+	assert.Equal(t, exitRegression, exitCode(err))
+	assert.Contains(t, err.Error(), head+" makes benchmarks worse than "+base)
+	assert.Contains(t, w.String(), "BenchmarkA-8")
+	assert.Contains(t, w.String(), "BenchmarkNew-8 is new, so it has no baseline")
+	assert.Contains(t, w.String(), "BenchmarkOld-8 is removed, so it is not compared")
+
+	c.threshold = 0.6
+	c.format = "json"
+	w.Reset()
+	require.NoError(t, compareResults(&w, base, head, c))
+	rep, err := report.Decode(&w)
+	require.NoError(t, err)
+	assert.False(t, rep.Degression)
+	assert.Equal(t, base, rep.Base.Ref)
+	require.Len(t, rep.Benchmarks, 2)
+	assert.Equal(t, "BenchmarkA-8", rep.Benchmarks[0].Name)
+	assert.InDelta(t, 0.5, rep.Benchmarks[0].Ratio.NsPerOp, 1e-9)
+	assert.Equal(t, []string{"BenchmarkNew-8"}, rep.Added)
+	assert.Equal(t, []string{"BenchmarkOld-8"}, rep.Removed)
+
+	c.format = "csv"
+	err = compareResults(&w, base, head, c)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown output format: csv")
+}
+
+func Test_compareAction(t *testing.T) {
+	dir := writeResultFiles(t, map[string]string{
+		"base.txt":  "BenchmarkA-8   1000   100 ns/op\n",
+		"head.txt":  "BenchmarkA-8   1000   150 ns/op\n",
+		"cob.yaml":  "threshold: 0.6\nbench-args: test -run '^$' -bench . ./...\nformat: json\n",
+		"typo.yaml": "threshold: 0.6\nthresold: 0.1\n",
+	})
+	defer os.RemoveAll(dir)
+	base, head := filepath.Join(dir, "base.txt"), filepath.Join(dir, "head.txt")
+	config := filepath.Join(dir, "cob.yaml")
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{
+			name: "the threshold of the configuration file",
+			args: []string{"-config", config},
+		},
+		{
+			name:     "the threshold of the flag over the configuration file",
+			args:     []string{"-config", config, "-threshold", "0.2"},
+			wantCode: exitRegression,
+			wantErr:  "makes benchmarks worse than",
+		},
+		{
+			name:     "an invalid configuration file",
+			args:     []string{"-config", filepath.Join(dir, "typo.yaml")},
+			wantCode: exitError,
+			wantErr:  "unknown option: thresold",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &cli.App{Flags: runFlags, Commands: []*cli.Command{compareCommand}}
+			args := append(append([]string{"cob", "compare"}, tt.args...), base, head)
+			err := app.Run(args)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.wantCode, exitCode(err))
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func Test_flagsNamed(t *testing.T) {
+	flags := flagsNamed(runFlags, "threshold", "format")
+	require.Len(t, flags, 2)
+	assert.Equal(t, "threshold", flags[0].Names()[0])
+	assert.Equal(t, "format", flags[1].Names()[0])
+	assert.Panics(t, func() { flagsNamed(runFlags, "thresold") })
+}
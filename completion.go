@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+var completionCommand = &cli.Command{
+	Name:      "completion",
+	Usage:     "Print a shell completion script (bash, zsh, fish, powershell)",
+	ArgsUsage: "bash|zsh|fish|powershell",
+	Action: func(c *cli.Context) error {
+		if c.Args().Len() != 1 {
+			return cli.ShowSubcommandHelp(c)
+		}
+		return writeCompletion(os.Stdout, c.App, c.Args().First())
+	},
+}
+
+// completionEntry is a flag, e.g. "--threshold", or a subcommand which can be completed.
+type completionEntry struct {
+	name  string
+	usage string
+	// value is true if the flag takes a value.
+	value bool
+}
+
+// completionNode is the flags and subcommands of a command. path is the names of the command and its parents
+// after the program's name, e.g. "history changes", or "" for the program itself.
+type completionNode struct {
+	path     string
+	flags    []completionEntry
+	commands []completionEntry
+	children []completionChild
+}
+
+// completionChild is a subcommand with its aliases, which all lead to the path of the subcommand.
+type completionChild struct {
+	names []string
+	path  string
+}
+
+// completionNodes walks the flags and subcommands of the app. Hidden commands are left out.
+func completionNodes(app *cli.App) []completionNode {
+	return appendCompletionNodes(nil, "", app.Flags, app.Commands)
+}
+
+func appendCompletionNodes(nodes []completionNode, path string, flags []cli.Flag, commands []*cli.Command) []completionNode {
+	node := completionNode{path: path, flags: completionFlags(flags)}
+	i := len(nodes)
+	nodes = append(nodes, node)
+	for _, c := range commands {
+		if c.Hidden {
+			continue
+		}
+		for _, name := range c.Names() {
+			node.commands = append(node.commands, completionEntry{name: name, usage: c.Usage})
+		}
+		child := completionChild{names: c.Names(), path: strings.TrimSpace(path + " " + c.Name)}
+		node.children = append(node.children, child)
+		nodes = appendCompletionNodes(nodes, child.path, c.Flags, c.Subcommands)
+	}
+	nodes[i] = node
+	return nodes
+}
+
+// completionFlags returns the names of the flags with their dashes, e.g. "--no-fail", "--report-only" and "-h".
+// Every command takes --help, but urfave/cli only adds it to a command when it runs.
+func completionFlags(flags []cli.Flag) []completionEntry {
+	var entries []completionEntry
+	help := false
+	for _, f := range flags {
+		var usage string
+		var value bool
+		if d, ok := f.(cli.DocGenerationFlag); ok {
+			usage, value = d.GetUsage(), d.TakesValue()
+		}
+		for _, name := range f.Names() {
+			if name == "help" {
+				help = true
+			}
+			entries = append(entries, completionEntry{name: dashed(name), usage: usage, value: value})
+		}
+	}
+	if !help {
+		entries = append(entries,
+			completionEntry{name: "--help", usage: "show help"},
+			completionEntry{name: "-h", usage: "show help"})
+	}
+	return entries
+}
+
+func dashed(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+func completionNames(entries []completionEntry) []string {
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.name)
+	}
+	return names
+}
+
+func writeCompletion(w io.Writer, app *cli.App, shell string) error {
+	nodes := completionNodes(app)
+	var script string
+	switch shell {
+	case "bash":
+		script = bashCompletion(app.Name, nodes)
+	case "zsh":
+		script = zshCompletion(app.Name, nodes)
+	case "fish":
+		script = fishCompletion(app.Name, nodes)
+	case "powershell":
+		script = powershellCompletion(app.Name, nodes)
+	default:
+		return xerrors.Errorf("unknown shell: %q: it must be bash, zsh, fish or powershell", shell)
+	}
+	if _, err := io.WriteString(w, script); err != nil {
+		return xerrors.Errorf("failed to write the completion script: %w", err)
+	}
+	return nil
+}
+
+// bashCompletion completes flags if the word starts with "-" and subcommands otherwise. Without any match, e.g.
+// for the value of a flag, bash completes file names.
+func bashCompletion(prog string, nodes []completionNode) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# bash completion for %s, generated by \"%s completion bash\"\n", prog, prog)
+	fmt.Fprintf(&b, "_%s() {\n", prog)
+	b.WriteString("    local cur word cmdpath flags commands\n")
+	b.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    cmdpath=\"\"\n")
+	b.WriteString("    for word in \"${COMP_WORDS[@]:1:COMP_CWORD-1}\"; do\n")
+	b.WriteString("        case \"$cmdpath\" in\n")
+	for _, n := range nodes {
+		if len(n.children) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "        %q)\n", n.path)
+		b.WriteString("            case \"$word\" in\n")
+		for _, c := range n.children {
+			fmt.Fprintf(&b, "            %s) cmdpath=%q ;;\n", strings.Join(c.names, "|"), c.path)
+		}
+		b.WriteString("            esac\n")
+		b.WriteString("            ;;\n")
+	}
+	b.WriteString("        esac\n")
+	b.WriteString("    done\n")
+	b.WriteString("    case \"$cmdpath\" in\n")
+	for _, n := range nodes {
+		fmt.Fprintf(&b, "    %q)\n", n.path)
+		fmt.Fprintf(&b, "        flags=%q\n", strings.Join(completionNames(n.flags), " "))
+		fmt.Fprintf(&b, "        commands=%q\n", strings.Join(completionNames(n.commands), " "))
+		b.WriteString("        ;;\n")
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("    if [[ \"$cur\" == -* ]]; then\n")
+	b.WriteString("        COMPREPLY=($(compgen -W \"$flags\" -- \"$cur\"))\n")
+	b.WriteString("    else\n")
+	b.WriteString("        COMPREPLY=($(compgen -W \"$commands\" -- \"$cur\"))\n")
+	b.WriteString("    fi\n")
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "complete -o default -F _%s %s\n", prog, prog)
+	return b.String()
+}
+
+// zshCompletion works both from a file named "_cob" in $fpath and when it's sourced.
+func zshCompletion(prog string, nodes []completionNode) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#compdef %s\n", prog)
+	fmt.Fprintf(&b, "# zsh completion for %s, generated by \"%s completion zsh\"\n", prog, prog)
+	fmt.Fprintf(&b, "_%s() {\n", prog)
+	// $path is tied to $PATH in zsh.
+	b.WriteString("    local word cmdpath=\"\"\n")
+	b.WriteString("    local -a flags commands\n")
+	b.WriteString("    for word in \"${(@)words[2,CURRENT-1]}\"; do\n")
+	b.WriteString("        case \"$cmdpath\" in\n")
+	for _, n := range nodes {
+		if len(n.children) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "        %q)\n", n.path)
+		b.WriteString("            case \"$word\" in\n")
+		for _, c := range n.children {
+			fmt.Fprintf(&b, "            %s) cmdpath=%q ;;\n", strings.Join(c.names, "|"), c.path)
+		}
+		b.WriteString("            esac\n")
+		b.WriteString("            ;;\n")
+	}
+	b.WriteString("        esac\n")
+	b.WriteString("    done\n")
+	b.WriteString("    case \"$cmdpath\" in\n")
+	for _, n := range nodes {
+		fmt.Fprintf(&b, "    %q)\n", n.path)
+		fmt.Fprintf(&b, "        flags=(%s)\n", zshDescriptions(n.flags))
+		fmt.Fprintf(&b, "        commands=(%s)\n", zshDescriptions(n.commands))
+		b.WriteString("        ;;\n")
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("    if [[ \"$PREFIX\" == -* ]]; then\n")
+	b.WriteString("        _describe -t flags flag flags\n")
+	b.WriteString("    elif (( ${#commands} )); then\n")
+	b.WriteString("        _describe -t commands command commands\n")
+	b.WriteString("    else\n")
+	b.WriteString("        _files\n")
+	b.WriteString("    fi\n")
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "if [ \"$funcstack[1]\" = \"_%s\" ]; then\n", prog)
+	fmt.Fprintf(&b, "    _%s \"$@\"\n", prog)
+	b.WriteString("else\n")
+	fmt.Fprintf(&b, "    compdef _%s %s\n", prog, prog)
+	b.WriteString("fi\n")
+	return b.String()
+}
+
+// zshDescriptions returns the entries as "name:usage" for _describe, which splits them at the first unescaped
+// colon.
+func zshDescriptions(entries []completionEntry) string {
+	var words []string
+	for _, e := range entries {
+		words = append(words, singleQuote(e.name+":"+strings.ReplaceAll(e.usage, ":", `\:`), `'\''`))
+	}
+	return strings.Join(words, " ")
+}
+
+// fishCompletion conditions each completion on the subcommands given so far.
+func fishCompletion(prog string, nodes []completionNode) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# fish completion for %s, generated by \"%s completion fish\"\n", prog, prog)
+	fmt.Fprintf(&b, "function __%s_path\n", prog)
+	b.WriteString("    set -l words (commandline -opc)\n")
+	b.WriteString("    set -e words[1]\n")
+	b.WriteString("    set -l cmdpath ''\n")
+	b.WriteString("    for word in $words\n")
+	b.WriteString("        switch $cmdpath\n")
+	for _, n := range nodes {
+		if len(n.children) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "            case %s\n", fishQuote(n.path))
+		b.WriteString("                switch $word\n")
+		for _, c := range n.children {
+			fmt.Fprintf(&b, "                    case %s\n", strings.Join(c.names, " "))
+			fmt.Fprintf(&b, "                        set cmdpath %s\n", fishQuote(c.path))
+		}
+		b.WriteString("                end\n")
+	}
+	b.WriteString("        end\n")
+	b.WriteString("    end\n")
+	b.WriteString("    echo $cmdpath\n")
+	b.WriteString("end\n\n")
+	fmt.Fprintf(&b, "function __%s_at\n", prog)
+	fmt.Fprintf(&b, "    set -l cmdpath (__%s_path)\n", prog)
+	b.WriteString("    test \"$cmdpath\" = \"$argv[1]\"\n")
+	b.WriteString("end\n")
+	for _, n := range nodes {
+		b.WriteString("\n")
+		cond := fishQuote(fmt.Sprintf("__%s_at %s", prog, fishQuote(n.path)))
+		for _, c := range n.commands {
+			fmt.Fprintf(&b, "complete -c %s -f -n %s -a %s -d %s\n", prog, cond, c.name, fishQuote(c.usage))
+		}
+		for _, f := range n.flags {
+			opt := "-l " + strings.TrimPrefix(f.name, "--")
+			if !strings.HasPrefix(f.name, "--") {
+				opt = "-s " + strings.TrimPrefix(f.name, "-")
+			}
+			if f.value {
+				opt += " -r"
+			}
+			fmt.Fprintf(&b, "complete -c %s -n %s %s -d %s\n", prog, cond, opt, fishQuote(f.usage))
+		}
+	}
+	return b.String()
+}
+
+func fishQuote(s string) string {
+	return singleQuote(strings.ReplaceAll(s, `\`, `\\`), `\'`)
+}
+
+// powershellCompletion registers a native argument completer.
+func powershellCompletion(prog string, nodes []completionNode) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# PowerShell completion for %s, generated by \"%s completion powershell\"\n", prog, prog)
+	fmt.Fprintf(&b, "Register-ArgumentCompleter -Native -CommandName %s -ScriptBlock {\n", prog)
+	b.WriteString("    param($wordToComplete, $commandAst, $cursorPosition)\n")
+	b.WriteString("    $cmdpath = ''\n")
+	b.WriteString("    foreach ($element in $commandAst.CommandElements | Select-Object -Skip 1) {\n")
+	b.WriteString("        if ($element.Extent.EndOffset -ge $cursorPosition) { break }\n")
+	b.WriteString("        $word = $element.ToString()\n")
+	b.WriteString("        switch ($cmdpath) {\n")
+	for _, n := range nodes {
+		if len(n.children) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "            %s {\n", powershellQuote(n.path))
+		for _, c := range n.children {
+			var names []string
+			for _, name := range c.names {
+				names = append(names, powershellQuote(name))
+			}
+			fmt.Fprintf(&b, "                if (@(%s) -contains $word) { $cmdpath = %s }\n", strings.Join(names, ", "), powershellQuote(c.path))
+		}
+		b.WriteString("            }\n")
+	}
+	b.WriteString("        }\n")
+	b.WriteString("    }\n")
+	b.WriteString("    $candidates = switch ($cmdpath) {\n")
+	for _, n := range nodes {
+		fmt.Fprintf(&b, "        %s {\n", powershellQuote(n.path))
+		for _, e := range append(append([]completionEntry{}, n.flags...), n.commands...) {
+			usage := e.usage
+			if usage == "" {
+				// The tooltip of a completion result must not be empty.
+				usage = e.name
+			}
+			fmt.Fprintf(&b, "            @{ Name = %s; Usage = %s }\n", powershellQuote(e.name), powershellQuote(usage))
+		}
+		b.WriteString("        }\n")
+	}
+	b.WriteString("    }\n")
+	b.WriteString("    $flag = $wordToComplete.StartsWith('-')\n")
+	b.WriteString("    $candidates | Where-Object { $_.Name.StartsWith('-') -eq $flag -and $_.Name -like \"$wordToComplete*\" } | ForEach-Object {\n")
+	b.WriteString("        $type = if ($flag) { 'ParameterName' } else { 'ParameterValue' }\n")
+	b.WriteString("        [System.Management.Automation.CompletionResult]::new($_.Name, $_.Name, $type, $_.Usage)\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func powershellQuote(s string) string {
+	return singleQuote(s, "''")
+}
+
+// singleQuote quotes s in single quotes, with quote being how the shell writes a single quote in them.
+func singleQuote(s, quote string) string {
+	return "'" + strings.ReplaceAll(s, "'", quote) + "'"
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func completionTestApp() *cli.App {
+	return &cli.App{
+		Name: "cob",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "no-fail", Aliases: []string{"report-only"}, Usage: "Exit with 0"},
+			&cli.StringFlag{Name: "store", Usage: "Where's the history"},
+		},
+		Commands: []*cli.Command{
+			{
+				Name:    "history",
+				Aliases: []string{"hist"},
+				Usage:   "Show the history: of a benchmark",
+				Subcommands: []*cli.Command{
+					{Name: "changes", Flags: []cli.Flag{&cli.IntFlag{Name: "window", Usage: "Runs"}}},
+				},
+			},
+			{Name: "secret", Hidden: true},
+		},
+	}
+}
+
+func Test_completionNodes(t *testing.T) {
+	nodes := completionNodes(completionTestApp())
+	require.Len(t, nodes, 3)
+
+	assert.Equal(t, "", nodes[0].path)
+	assert.Equal(t, []string{"--no-fail", "--report-only", "--store", "--help", "-h"}, completionNames(nodes[0].flags))
+	assert.Equal(t, []string{"history", "hist"}, completionNames(nodes[0].commands))
+	assert.Equal(t, []completionChild{{names: []string{"history", "hist"}, path: "history"}}, nodes[0].children)
+	assert.False(t, nodes[0].flags[0].value)
+	assert.True(t, nodes[0].flags[2].value)
+
+	assert.Equal(t, "history", nodes[1].path)
+	assert.Equal(t, []completionChild{{names: []string{"changes"}, path: "history changes"}}, nodes[1].children)
+	assert.Equal(t, "history changes", nodes[2].path)
+
+	// Subcommands are walked depth first.
+	nodes = completionNodes(&cli.App{Commands: []*cli.Command{{Name: "a", Subcommands: []*cli.Command{{Name: "b"}}}, {Name: "c"}}})
+	var paths []string
+	for _, n := range nodes {
+		paths = append(paths, n.path)
+	}
+	assert.Equal(t, []string{"", "a", "a b", "c"}, paths)
+}
+
+func Test_writeCompletion(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{
+			shell: "bash",
+			want: []string{
+				`            history|hist) cmdpath="history" ;;`,
+				`            changes) cmdpath="history changes" ;;`,
+				`        flags="--no-fail --report-only --store --help -h"`,
+				`    "history changes")`,
+				"complete -o default -F _cob cob",
+			},
+		},
+		{
+			shell: "zsh",
+			want: []string{
+				"#compdef cob",
+				`        flags=('--no-fail:Exit with 0' '--report-only:Exit with 0' '--store:Where'\''s the history' '--help:show help' '-h:show help')`,
+				`        commands=('history:Show the history\: of a benchmark' 'hist:Show the history\: of a benchmark')`,
+				"    compdef _cob cob",
+			},
+		},
+		{
+			shell: "fish",
+			want: []string{
+				"                    case history hist",
+				"                        set cmdpath 'history changes'",
+				`complete -c cob -f -n '__cob_at \'\'' -a hist -d 'Show the history: of a benchmark'`,
+				`complete -c cob -n '__cob_at \'\'' -l store -r -d 'Where\'s the history'`,
+				`complete -c cob -n '__cob_at \'history changes\'' -l window -r -d 'Runs'`,
+				`complete -c cob -n '__cob_at \'\'' -s h -d 'show help'`,
+			},
+		},
+		{
+			shell: "powershell",
+			want: []string{
+				"Register-ArgumentCompleter -Native -CommandName cob -ScriptBlock {",
+				"                if (@('history', 'hist') -contains $word) { $cmdpath = 'history' }",
+				"            @{ Name = '--store'; Usage = 'Where''s the history' }",
+				"            @{ Name = 'changes'; Usage = 'changes' }",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var b bytes.Buffer
+			require.NoError(t, writeCompletion(&b, completionTestApp(), tt.shell))
+			for _, want := range tt.want {
+				assert.Contains(t, b.String(), want+"\n")
+			}
+			assert.NotContains(t, b.String(), "secret")
+		})
+	}
+
+	err := writeCompletion(ioutil.Discard, completionTestApp(), "tcsh")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown shell: "tcsh"`)
+}
+
+func Test_bashCompletion(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var b bytes.Buffer
+	require.NoError(t, writeCompletion(&b, completionTestApp(), "bash"))
+	script := filepath.Join(dir, "cob.bash")
+	require.NoError(t, ioutil.WriteFile(script, b.Bytes(), 0644))
+
+	tests := []struct {
+		words []string
+		want  string
+	}{
+		{words: []string{"cob", "--re"}, want: "--report-only"},
+		{words: []string{"cob", "hi"}, want: "history hist"},
+		{words: []string{"cob", "hist", ""}, want: "changes"},
+		{words: []string{"cob", "--store", "x", "history", "changes", "--"}, want: "--window --help"},
+		{words: []string{"cob", "history", "changes", ""}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.words, " "), func(t *testing.T) {
+			var words []string
+			for _, w := range tt.words {
+				words = append(words, "'"+w+"'")
+			}
+			cmd := exec.Command("bash", "-c", `source "$1"; COMP_WORDS=(`+strings.Join(words, " ")+`); COMP_CWORD=$((${#COMP_WORDS[@]}-1)); _cob; echo "${COMPREPLY[*]}"`, "bash", script)
+			out, err := cmd.CombinedOutput()
+			require.NoError(t, err, string(out))
+			assert.Equal(t, tt.want, strings.TrimSpace(string(out)))
+		})
+	}
+}
@@ -2,26 +2,225 @@ package main
 
 import (
 	"strings"
+	"time"
 
 	"github.com/urfave/cli/v2"
 )
 
 type config struct {
-	onlyDegression bool
-	threshold      float64
-	base           string
-	compare        []string
-	benchCmd       string
-	benchArgs      []string
+	onlyDegression            bool
+	threshold                 float64
+	significance              float64
+	failFast                  bool
+	confirm                   int
+	strict                    bool
+	base                      string
+	gitExec                   string
+	lfs                       string
+	sameDeps                  bool
+	preserve                  []string
+	postCheckout              string
+	compare                   []string
+	benchCmd                  string
+	benchArgs                 []string
+	exclude                   []string
+	goflags                   string
+	goexperiment              string
+	gcflags                   string
+	ldflags                   string
+	columns                   []string
+	format                    string
+	reporters                 []string
+	tableStyle                string
+	ascii                     bool
+	summaryLine               bool
+	githubPRComment           bool
+	githubCheck               bool
+	githubStatus              bool
+	gitlabMRNote              bool
+	bitbucketPRComment        bool
+	bitbucketStatus           bool
+	giteaURL                  string
+	giteaRepo                 string
+	giteaPR                   int
+	giteaPRComment            bool
+	giteaStatus               bool
+	azurePRThread             bool
+	azureStatus               bool
+	gerritURL                 string
+	gerritChange              string
+	gerritLabel               string
+	gerritReview              bool
+	buildkiteAnnotation       bool
+	webhook                   string
+	slackWebhook              string
+	slackOnlyDegression       bool
+	discordWebhook            string
+	discordOnlyDegression     bool
+	teamsWebhook              string
+	teamsOnlyDegression       bool
+	teamsReportURL            string
+	emailTo                   string
+	emailFrom                 string
+	smtpServer                string
+	emailOnlyDegression       bool
+	pushgateway               string
+	influxDBURL               string
+	influxDBFile              string
+	otlpEndpoint              string
+	datadog                   bool
+	statsd                    string
+	graphite                  string
+	codespeedURL              string
+	codespeedProject          string
+	codespeedExecutable       string
+	codespeedEnvironment      string
+	bencher                   string
+	bencherTestbed            string
+	bencherFile               string
+	githubActionBenchmarkFile string
+	gobenchdataFile           string
+	junitFile                 string
+	reportDir                 string
+	store                     string
+	baselineFromStore         bool
+	baselineArtifact          string
+	baselineURL               string
+	baselineURLHeaders        []string
+	envMismatch               string
+	testTime                  bool
+	testTimeThreshold         float64
+	coverage                  bool
+	coverageThreshold         float64
+	profileDir                string
+	memprofileOnRegression    bool
+	traceOnRegression         bool
+	profileURL                string
+	contention                bool
+	perf                      bool
+	maxRSS                    bool
+	energy                    bool
+	startup                   string
+	startupReady              string
+	startupArgs               []string
+	startupRuns               int
+	startupTimeout            time.Duration
+	inlineDiff                bool
+	escapeDiff                bool
+	cache                     string
+	machine                   string
+	creepThreshold            float64
+	creepWindow               int
+	logLevel                  string
+	logFormat                 string
 }
 
 func newConfig(c *cli.Context) config {
 	return config{
-		onlyDegression: c.Bool("only-degression"),
-		threshold:      c.Float64("threshold"),
-		base:           c.String("base"),
-		compare:        strings.Split(c.String("compare"), ","),
-		benchCmd:       c.String("bench-cmd"),
-		benchArgs:      strings.Fields(c.String("bench-args")),
+		onlyDegression:            c.Bool("only-degression"),
+		threshold:                 c.Float64("threshold"),
+		significance:              c.Float64("significance"),
+		failFast:                  c.Bool("fail-fast"),
+		confirm:                   c.Int("confirm"),
+		strict:                    c.Bool("strict"),
+		base:                      c.String("base"),
+		gitExec:                   c.String("git-exec"),
+		lfs:                       c.String("lfs"),
+		sameDeps:                  c.Bool("same-deps"),
+		preserve:                  c.StringSlice("preserve"),
+		postCheckout:              c.String("post-checkout"),
+		compare:                   strings.Split(c.String("compare"), ","),
+		benchCmd:                  c.String("bench-cmd"),
+		benchArgs:                 strings.Fields(c.String("bench-args")),
+		exclude:                   c.StringSlice("exclude"),
+		goflags:                   c.String("goflags"),
+		goexperiment:              c.String("goexperiment"),
+		gcflags:                   c.String("gcflags"),
+		ldflags:                   c.String("ldflags"),
+		columns:                   strings.Split(c.String("columns"), ","),
+		format:                    c.String("format"),
+		reporters:                 c.StringSlice("reporter"),
+		tableStyle:                c.String("table-style"),
+		ascii:                     c.Bool("ascii"),
+		summaryLine:               c.Bool("summary-line"),
+		githubPRComment:           c.Bool("github-pr-comment"),
+		githubCheck:               c.Bool("github-check"),
+		githubStatus:              c.Bool("github-status"),
+		gitlabMRNote:              c.Bool("gitlab-mr-note"),
+		bitbucketPRComment:        c.Bool("bitbucket-pr-comment"),
+		bitbucketStatus:           c.Bool("bitbucket-status"),
+		giteaURL:                  c.String("gitea-url"),
+		giteaRepo:                 c.String("gitea-repo"),
+		giteaPR:                   c.Int("gitea-pr"),
+		giteaPRComment:            c.Bool("gitea-pr-comment"),
+		giteaStatus:               c.Bool("gitea-status"),
+		azurePRThread:             c.Bool("azure-pr-thread"),
+		azureStatus:               c.Bool("azure-status"),
+		gerritURL:                 c.String("gerrit-url"),
+		gerritChange:              c.String("gerrit-change"),
+		gerritLabel:               c.String("gerrit-label"),
+		gerritReview:              c.Bool("gerrit-review"),
+		buildkiteAnnotation:       c.Bool("buildkite-annotation"),
+		webhook:                   c.String("webhook"),
+		slackWebhook:              c.String("slack-webhook"),
+		slackOnlyDegression:       c.Bool("slack-only-degression"),
+		discordWebhook:            c.String("discord-webhook"),
+		discordOnlyDegression:     c.Bool("discord-only-degression"),
+		teamsWebhook:              c.String("teams-webhook"),
+		teamsOnlyDegression:       c.Bool("teams-only-degression"),
+		teamsReportURL:            c.String("teams-report-url"),
+		emailTo:                   c.String("email-to"),
+		emailFrom:                 c.String("email-from"),
+		smtpServer:                c.String("smtp-server"),
+		emailOnlyDegression:       c.Bool("email-only-degression"),
+		pushgateway:               c.String("pushgateway"),
+		influxDBURL:               c.String("influxdb-url"),
+		influxDBFile:              c.String("influxdb-file"),
+		otlpEndpoint:              c.String("otlp-endpoint"),
+		datadog:                   c.Bool("datadog"),
+		statsd:                    c.String("statsd"),
+		graphite:                  c.String("graphite"),
+		codespeedURL:              c.String("codespeed-url"),
+		codespeedProject:          c.String("codespeed-project"),
+		codespeedExecutable:       c.String("codespeed-executable"),
+		codespeedEnvironment:      c.String("codespeed-environment"),
+		bencher:                   c.String("bencher"),
+		bencherTestbed:            c.String("bencher-testbed"),
+		bencherFile:               c.String("bencher-file"),
+		githubActionBenchmarkFile: c.String("github-action-benchmark-file"),
+		gobenchdataFile:           c.String("gobenchdata-file"),
+		junitFile:                 c.String("junit-file"),
+		reportDir:                 c.String("report-dir"),
+		store:                     c.String("store"),
+		baselineFromStore:         c.Bool("baseline-from-store"),
+		baselineArtifact:          c.String("baseline-artifact"),
+		baselineURL:               c.String("baseline-url"),
+		baselineURLHeaders:        c.StringSlice("baseline-url-header"),
+		envMismatch:               c.String("env-mismatch"),
+		testTime:                  c.Bool("test-time"),
+		testTimeThreshold:         c.Float64("test-time-threshold"),
+		coverage:                  c.Bool("coverage"),
+		coverageThreshold:         c.Float64("coverage-threshold"),
+		profileDir:                c.String("profile-dir"),
+		memprofileOnRegression:    c.Bool("memprofile-on-regression"),
+		traceOnRegression:         c.Bool("trace-on-regression"),
+		profileURL:                c.String("profile-url"),
+		contention:                c.Bool("contention"),
+		perf:                      c.Bool("perf"),
+		maxRSS:                    c.Bool("max-rss"),
+		energy:                    c.Bool("energy"),
+		startup:                   c.String("startup"),
+		startupReady:              c.String("startup-ready"),
+		startupArgs:               c.StringSlice("startup-arg"),
+		startupRuns:               c.Int("startup-runs"),
+		startupTimeout:            c.Duration("startup-timeout"),
+		inlineDiff:                c.Bool("inline-diff"),
+		escapeDiff:                c.Bool("escape-diff"),
+		cache:                     c.String("cache"),
+		machine:                   c.String("machine"),
+		creepThreshold:            c.Float64("creep-threshold"),
+		creepWindow:               c.Int("creep-window"),
+		logLevel:                  c.String("log-level"),
+		logFormat:                 c.String("log-format"),
 	}
 }
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+// defaultConfigFiles are the configuration files read when -config isn't given, in the repository root.
+var defaultConfigFiles = []string{".cob.yaml", ".cob.yml"}
+
+// configSection is the key of the options in a configuration file which is shared with other tools.
+const configSection = "cob"
+
+// yamlNode is a value in the subset of YAML which configuration files are written in: a scalar, a list of
+// scalars or a mapping.
+type yamlNode struct {
+	scalar  string
+	list    []string
+	isList  bool
+	mapping []yamlEntry
+}
+
+type yamlEntry struct {
+	key   string
+	value yamlNode
+	line  int
+}
+
+func (n yamlNode) get(key string) (yamlNode, bool) {
+	for _, e := range n.mapping {
+		if e.key == key {
+			return e.value, true
+		}
+	}
+	return yamlNode{}, false
+}
+
+// yamlLine is a line of a YAML document without its comment and indentation.
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// parseYAML parses a document of mappings with scalars, block or flow lists of scalars and nested mappings as
+// values. Anchors, multi-line strings and other features of YAML aren't supported.
+func parseYAML(r io.Reader) (yamlNode, error) {
+	var lines []yamlLine
+	s := bufio.NewScanner(r)
+	for num := 1; s.Scan(); num++ {
+		text := strings.TrimRight(stripYAMLComment(s.Text()), " \t")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return yamlNode{}, xerrors.Errorf("line %d: tabs can't indent YAML", num)
+		}
+		lines = append(lines, yamlLine{num: num, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	if err := s.Err(); err != nil {
+		return yamlNode{}, err
+	}
+	node, rest, err := parseYAMLMapping(lines, 0)
+	if err != nil {
+		return yamlNode{}, err
+	}
+	if len(rest) > 0 {
+		return yamlNode{}, xerrors.Errorf("line %d: unexpected indentation", rest[0].num)
+	}
+	return node, nil
+}
+
+// parseYAMLMapping parses the entries of a mapping at the indentation, and returns the lines after it.
+func parseYAMLMapping(lines []yamlLine, indent int) (yamlNode, []yamlLine, error) {
+	var node yamlNode
+	for len(lines) > 0 && lines[0].indent == indent {
+		l := lines[0]
+		lines = lines[1:]
+		i := strings.Index(l.text, ":")
+		if i <= 0 || (i+1 < len(l.text) && l.text[i+1] != ' ') {
+			return yamlNode{}, nil, xerrors.Errorf("line %d: expected 'key: value': %s", l.num, l.text)
+		}
+		e := yamlEntry{key: strings.TrimSpace(l.text[:i]), line: l.num}
+		value := strings.TrimSpace(l.text[i+1:])
+		switch {
+		case value != "":
+			v, err := parseYAMLValue(value)
+			if err != nil {
+				return yamlNode{}, nil, xerrors.Errorf("line %d: %w", l.num, err)
+			}
+			e.value = v
+		case len(lines) > 0 && lines[0].indent >= indent && strings.HasPrefix(lines[0].text, "- "):
+			// A block list may be indented as much as its key.
+			e.value.isList = true
+			childIndent := lines[0].indent
+			for len(lines) > 0 && lines[0].indent == childIndent && strings.HasPrefix(lines[0].text, "- ") {
+				item, err := unquoteYAML(strings.TrimSpace(lines[0].text[2:]))
+				if err != nil {
+					return yamlNode{}, nil, xerrors.Errorf("line %d: %w", lines[0].num, err)
+				}
+				e.value.list = append(e.value.list, item)
+				lines = lines[1:]
+			}
+		case len(lines) > 0 && lines[0].indent > indent:
+			v, rest, err := parseYAMLMapping(lines, lines[0].indent)
+			if err != nil {
+				return yamlNode{}, nil, err
+			}
+			e.value, lines = v, rest
+		}
+		node.mapping = append(node.mapping, e)
+	}
+	return node, lines, nil
+}
+
+// parseYAMLValue parses a scalar or a flow list, e.g. "[ns/op, B/op]".
+func parseYAMLValue(value string) (yamlNode, error) {
+	if !strings.HasPrefix(value, "[") {
+		s, err := unquoteYAML(value)
+		return yamlNode{scalar: s}, err
+	}
+	if !strings.HasSuffix(value, "]") {
+		return yamlNode{}, xerrors.Errorf("unterminated list: %s", value)
+	}
+	node := yamlNode{isList: true}
+	inner := strings.TrimSpace(value[1 : len(value)-1])
+	if inner == "" {
+		return node, nil
+	}
+	for _, item := range strings.Split(inner, ",") {
+		s, err := unquoteYAML(strings.TrimSpace(item))
+		if err != nil {
+			return yamlNode{}, err
+		}
+		node.list = append(node.list, s)
+	}
+	return node, nil
+}
+
+func unquoteYAML(s string) (string, error) {
+	switch {
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		return strconv.Unquote(s)
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	case strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'"):
+		return "", xerrors.Errorf("unterminated string: %s", s)
+	}
+	return s, nil
+}
+
+// stripYAMLComment removes a comment, which starts with "#" at the start of the line or after a space, outside
+// of quotes.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// applyConfigFile sets the flags which aren't given on the command line or by an environment variable to the
+// options in the configuration file. The keys are the names of the flags, and may be under a "cob" key, so that
+// the options can be kept in the configuration of other tools.
+func applyConfigFile(c *cli.Context) error {
+	path := c.String("config")
+	if path == "" {
+		for _, f := range defaultConfigFiles {
+			if _, err := os.Stat(f); err == nil {
+				path = f
+				break
+			}
+		}
+		if path == "" {
+			return nil
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return xerrors.Errorf("failed to open the configuration file: %w", err)
+	}
+	defer f.Close()
+	doc, err := parseYAML(f)
+	if err != nil {
+		return xerrors.Errorf("invalid configuration file %s: %w", path, err)
+	}
+	if section, ok := doc.get(configSection); ok && len(section.mapping) > 0 {
+		doc = section
+	}
+	debugf("config: %s", path)
+	if err = applyConfigOptions(c, doc); err != nil {
+		return xerrors.Errorf("invalid configuration file %s: %w", path, err)
+	}
+	return nil
+}
+
+func applyConfigOptions(c *cli.Context, doc yamlNode) error {
+	// Commands with subcommands, e.g. "cob cache", run as an app of their own, so the options of their subcommands
+	// are only the flags of the command.
+	all := c.App.Flags
+	if c.Command != nil {
+		all = append(append([]cli.Flag{}, all...), c.Command.Flags...)
+	}
+	flags := map[string]cli.Flag{}
+	for _, f := range all {
+		for _, name := range f.Names() {
+			flags[name] = f
+		}
+	}
+	for _, e := range doc.mapping {
+		f, ok := flags[e.key]
+		if !ok || e.key == "config" {
+			return xerrors.Errorf("line %d: unknown option: %s", e.line, e.key)
+		}
+		if c.IsSet(e.key) {
+			continue
+		}
+		_, isSlice := f.(*cli.StringSliceFlag)
+		values := []string{e.value.scalar}
+		switch {
+		case len(e.value.mapping) > 0:
+			return xerrors.Errorf("line %d: %s must be a value, not a mapping", e.line, e.key)
+		case e.value.isList && !isSlice:
+			return xerrors.Errorf("line %d: %s must be a single value, not a list", e.line, e.key)
+		case e.value.isList:
+			values = e.value.list
+		}
+		for _, v := range values {
+			if err := setFlag(c, e.key, v); err != nil {
+				return xerrors.Errorf("line %d: invalid %s: %w", e.line, e.key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// setFlag sets the flag in the nearest context which defines it, where c.String and the like look it up, so that
+// a command, e.g. "cob compare", can set the global options it doesn't take itself.
+func setFlag(c *cli.Context, name, value string) error {
+	for _, ctx := range c.Lineage() {
+		if ctx.App == nil {
+			continue
+		}
+		flags := ctx.App.Flags
+		if ctx.Command != nil && ctx.Command.Name != "" {
+			flags = ctx.Command.Flags
+		}
+		for _, f := range flags {
+			for _, n := range f.Names() {
+				if n == name {
+					return ctx.Set(name, value)
+				}
+			}
+		}
+	}
+	return c.Set(name, value)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func Test_parseYAML(t *testing.T) {
+	doc, err := parseYAML(strings.NewReader(`---
+# Options of cob
+threshold: 0.1 # 10%
+bench-args: "test -run '^$' -bench . -benchtime 2s ./..."
+base: 'it''s'
+compare: [ns/op, B/op]
+startup-arg:
+  - -config
+  - testdata/app.yaml
+cob:
+  threshold: 0.3
+  slack-webhook: https://hooks.slack.com/services/T/B/X#not-a-comment
+`))
+	require.NoError(t, err)
+	assert.Equal(t, yamlNode{mapping: []yamlEntry{
+		{key: "threshold", value: yamlNode{scalar: "0.1"}, line: 3},
+		{key: "bench-args", value: yamlNode{scalar: "test -run '^$' -bench . -benchtime 2s ./..."}, line: 4},
+		{key: "base", value: yamlNode{scalar: "it's"}, line: 5},
+		{key: "compare", value: yamlNode{list: []string{"ns/op", "B/op"}, isList: true}, line: 6},
+		{key: "startup-arg", value: yamlNode{list: []string{"-config", "testdata/app.yaml"}, isList: true}, line: 7},
+		{key: "cob", value: yamlNode{mapping: []yamlEntry{
+			{key: "threshold", value: yamlNode{scalar: "0.3"}, line: 11},
+			{key: "slack-webhook", value: yamlNode{scalar: "https://hooks.slack.com/services/T/B/X#not-a-comment"}, line: 12},
+		}}, line: 10},
+	}}, doc)
+
+	for _, invalid := range []string{"threshold", "base: 'unterminated", "threshold: 0.1\n  base: HEAD", "compare: [ns/op"} {
+		_, err = parseYAML(strings.NewReader(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func Test_applyConfigOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		config  string
+		want    config
+		wantErr string
+	}{
+		{
+			name:   "file",
+			config: "threshold: 0.1\nonly-degression: true\nstartup-arg: [-a, -b]\n",
+			want:   config{threshold: 0.1, onlyDegression: true, startupArgs: []string{"-a", "-b"}},
+		},
+		{
+			name:   "flags override the file",
+			args:   []string{"-threshold", "0.3"},
+			config: "threshold: 0.1\n",
+			want:   config{threshold: 0.3, startupArgs: []string{"x"}},
+		},
+		{
+			name:    "unknown option",
+			config:  "thresold: 0.1\n",
+			wantErr: "line 1: unknown option: thresold",
+		},
+		{
+			name:    "list of a single value",
+			config:  "threshold: [0.1, 0.2]\n",
+			wantErr: "line 1: threshold must be a single value, not a list",
+		},
+		{
+			name:    "invalid value",
+			config:  "threshold: high\n",
+			wantErr: "line 1: invalid threshold",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got config
+			app := &cli.App{
+				Flags: []cli.Flag{
+					&cli.Float64Flag{Name: "threshold", Value: 0.2},
+					&cli.BoolFlag{Name: "only-degression"},
+					&cli.StringSliceFlag{Name: "startup-arg", Value: cli.NewStringSlice("x")},
+					&cli.StringFlag{Name: "config"},
+				},
+				Action: func(c *cli.Context) error {
+					doc, err := parseYAML(strings.NewReader(tt.config))
+					require.NoError(t, err)
+					if err = applyConfigOptions(c, doc); err != nil {
+						return err
+					}
+					got = config{threshold: c.Float64("threshold"), onlyDegression: c.Bool("only-degression"), startupArgs: c.StringSlice("startup-arg")}
+					return nil
+				},
+			}
+			err := app.Run(append([]string{"cob"}, tt.args...))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_applyConfigOptions_subcommand(t *testing.T) {
+	// "cob cache" has subcommands, so its subcommands run in an app without the global flags.
+	var got float64
+	flags := []cli.Flag{&cli.Float64Flag{Name: "threshold", Value: 0.2}}
+	app := &cli.App{
+		Flags: flags,
+		Commands: []*cli.Command{{
+			Name: "cache",
+			Subcommands: []*cli.Command{{
+				Name:  "key",
+				Flags: flags,
+				Action: func(c *cli.Context) error {
+					doc, err := parseYAML(strings.NewReader("threshold: 0.1\n"))
+					require.NoError(t, err)
+					if err = applyConfigOptions(c, doc); err != nil {
+						return err
+					}
+					got = c.Float64("threshold")
+					return nil
+				},
+			}},
+		}},
+	}
+	require.NoError(t, app.Run([]string{"cob", "cache", "key"}))
+	assert.Equal(t, 0.1, got)
+}
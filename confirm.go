@@ -0,0 +1,77 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/benchmark/parse"
+)
+
+// confirmRegressions re-runs each benchmark of headSet which got worse than its threshold up to n times with
+// rerun. A regression is confirmed if the benchmark got worse again every time, and otherwise the results of the
+// first re-run which didn't replace those of headSet, so that a noisy run alone doesn't fail. A re-run which fails
+// keeps the regression confirmed.
+func confirmRegressions(check regressionCheck, headSet parse.Set, n int, rerun func(name string) (parse.Set, error)) (
+	set parse.Set, confirmed, unconfirmed []string) {
+	set = parse.Set{}
+	var regressed []string
+	for name, runs := range headSet {
+		set[name] = runs
+		if check.regressed(name, runs) {
+			regressed = append(regressed, name)
+		}
+	}
+	sort.Strings(regressed)
+
+	for _, name := range regressed {
+		ok := true
+		for i := 0; i < n && ok; i++ {
+			infof("Confirm the regression of %s (%d/%d)", name, i+1, n)
+			s, err := rerun(name)
+			if err != nil {
+				warnf("Failed to re-run %s: %s", name, err)
+				break
+			}
+			if runs := s[name]; !check.regressed(name, runs) {
+				ok = false
+				if len(runs) > 0 {
+					set[name] = runs
+				}
+			}
+		}
+		if ok {
+			confirmed = append(confirmed, name)
+		} else {
+			unconfirmed = append(unconfirmed, name)
+		}
+	}
+	return set, confirmed, unconfirmed
+}
+
+// withGoTestFlag returns the arguments of "go test" with the flag set to value, replacing it if it is already set,
+// e.g. "-bench ." or "-test.bench=.".
+func withGoTestFlag(args []string, name, value string) []string {
+	var out []string
+	found := false
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		flag := strings.TrimPrefix(strings.TrimLeft(a, "-"), "test.")
+		switch {
+		case i > 0 && strings.HasPrefix(a, "-") && flag == name:
+			// The value is the next argument.
+			i++
+		case i > 0 && strings.HasPrefix(a, "-") && strings.HasPrefix(flag, name+"="):
+		default:
+			out = append(out, a)
+			continue
+		}
+		if !found {
+			out = append(out, "-"+name, value)
+			found = true
+		}
+	}
+	if !found && len(out) > 0 {
+		out = append(out[:1], append([]string{"-" + name, value}, out[1:]...)...)
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/benchmark/parse"
+	"golang.org/x/xerrors"
+)
+
+func Test_confirmRegressions(t *testing.T) {
+	prevSet := parse.Set{
+		"BenchmarkA-8": {{Name: "BenchmarkA-8", NsPerOp: 100}},
+		"BenchmarkB-8": {{Name: "BenchmarkB-8", NsPerOp: 100}},
+		"BenchmarkC-8": {{Name: "BenchmarkC-8", NsPerOp: 100}},
+		"BenchmarkD-8": {{Name: "BenchmarkD-8", NsPerOp: 100}},
+	}
+	headSet := parse.Set{
+		"BenchmarkA-8": {{Name: "BenchmarkA-8", NsPerOp: 200}},
+		"BenchmarkB-8": {{Name: "BenchmarkB-8", NsPerOp: 200}},
+		"BenchmarkC-8": {{Name: "BenchmarkC-8", NsPerOp: 105}},
+		"BenchmarkD-8": {{Name: "BenchmarkD-8", NsPerOp: 200}},
+	}
+	check := regressionCheck{prevSet: prevSet, threshold: 0.2, score: comparedScore{nsPerOp: true}}
+	reruns := map[string][]float64{
+		"BenchmarkA-8": {200, 200},
+		"BenchmarkB-8": {190, 110},
+	}
+	var ran []string
+	rerun := func(name string) (parse.Set, error) {
+		ran = append(ran, name)
+		if name == "BenchmarkD-8" {
+			return nil, xerrors.New("exit status 1")
+		}
+		ns := reruns[name][0]
+		reruns[name] = reruns[name][1:]
+		return parse.Set{name: {{Name: name, NsPerOp: ns}}}, nil
+	}
+
+	set, confirmed, unconfirmed := confirmRegressions(check, headSet, 2, rerun)
+	assert.Equal(t, []string{"BenchmarkA-8", "BenchmarkA-8", "BenchmarkB-8", "BenchmarkB-8", "BenchmarkD-8"}, ran,
+		"only the regressed benchmarks are re-run")
+	assert.Equal(t, []string{"BenchmarkA-8", "BenchmarkD-8"}, confirmed)
+	assert.Equal(t, []string{"BenchmarkB-8"}, unconfirmed)
+	assert.Equal(t, 110.0, set["BenchmarkB-8"][0].NsPerOp, "the run which didn't regress replaces the results")
+	assert.Equal(t, 200.0, set["BenchmarkA-8"][0].NsPerOp)
+	assert.Equal(t, 200.0, headSet["BenchmarkB-8"][0].NsPerOp, "headSet is not modified")
+}
+
+func Test_withGoTestFlag(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{
+			args: []string{"test", "-run", "^$", "-bench", ".", "./..."},
+			want: []string{"test", "-run", "^$", "-bench", "^BenchmarkA$", "./..."},
+		},
+		{
+			args: []string{"test", "-bench=.", "-test.bench", "Foo", "./..."},
+			want: []string{"test", "-bench", "^BenchmarkA$", "./..."},
+		},
+		{
+			args: []string{"test", "./..."},
+			want: []string{"test", "-bench", "^BenchmarkA$", "./..."},
+		},
+		{
+			args: []string{"test", "-benchmem", "./..."},
+			want: []string{"test", "-bench", "^BenchmarkA$", "-benchmem", "./..."},
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, withGoTestFlag(tt.args, "bench", "^BenchmarkA$"), "%v", tt.args)
+	}
+}
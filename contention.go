@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/xerrors"
+)
+
+// contentionKinds are the profiles collected with -contention.
+var contentionKinds = []profileKind{blockProfile, mutexProfile}
+
+// parallelBenchmarks returns the benchmarks whose functions call b.RunParallel. Their goroutines contend for
+// locks, which ns/op of a benchmark run by a single goroutine doesn't show.
+func parallelBenchmarks(rep report.Report) ([]report.Benchmark, error) {
+	funcs, err := findBenchmarkFuncs(".")
+	if err != nil {
+		return nil, err
+	}
+	var benchmarks []report.Benchmark
+	for _, b := range rep.Benchmarks {
+		if funcs[benchmarkFuncName(b.Name)].Parallel {
+			benchmarks = append(benchmarks, b)
+		}
+	}
+	return benchmarks, nil
+}
+
+// newContention compares the block and mutex profiles of each benchmark at both commits.
+func newContention(benchmarks []report.Benchmark, base, head profileFiles) []report.Contention {
+	var contention []report.Contention
+	for _, b := range benchmarks {
+		c := report.Contention{Name: b.Name}
+		var err error
+		if c.Base, err = contentionEvents(base[b.Name], b.Head.Iterations); err != nil {
+			warnf("Failed to compare the contention of %s: %s", b.Name, err)
+		}
+		if c.Head, err = contentionEvents(head[b.Name], b.Head.Iterations); err != nil {
+			warnf("Failed to compare the contention of %s: %s", b.Name, err)
+		}
+		if c.Base == nil && c.Head == nil {
+			continue
+		}
+		contention = append(contention, c)
+	}
+	return contention
+}
+
+// contentionEvents returns the contentions and the delay per op in the block and mutex profiles of a benchmark,
+// or nil if it wasn't profiled.
+func contentionEvents(files map[string]string, iterations int) (*report.ContentionEvents, error) {
+	if files[blockProfile.name] == "" || files[mutexProfile.name] == "" {
+		return nil, nil
+	}
+	ops := profiledOps(iterations)
+	var e report.ContentionEvents
+	var err error
+	if e.BlockContentions, e.BlockDelay, err = sumContention(files[blockProfile.name]); err != nil {
+		return nil, err
+	}
+	if e.MutexContentions, e.MutexDelay, err = sumContention(files[mutexProfile.name]); err != nil {
+		return nil, err
+	}
+	e.BlockContentions /= ops
+	e.BlockDelay /= ops
+	e.MutexContentions /= ops
+	e.MutexDelay /= ops
+	return &e, nil
+}
+
+// sumContention returns the total contentions and delay in nanoseconds of a block or mutex profile.
+func sumContention(path string) (contentions, delay float64, err error) {
+	p, err := readPprofFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	ci, di := p.valueIndex("contentions"), p.valueIndex("delay")
+	if ci < 0 || di < 0 {
+		return 0, 0, xerrors.Errorf("%s is not a block or mutex profile", path)
+	}
+	for _, s := range p.samples {
+		if ci < len(s.values) && di < len(s.values) {
+			contentions += float64(s.values[ci])
+			delay += float64(s.values[di])
+		}
+	}
+	return contentions, delay, nil
+}
+
+func showContention(w io.Writer, contention []report.Contention, style string) {
+	if len(contention) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nContention")
+	fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 10))
+
+	table := tablewriter.NewWriter(w)
+	table.SetAutoFormatHeaders(false)
+	applyTableStyle(table, style)
+	table.SetHeader([]string{"Name", "Base blocked ns/op", "HEAD blocked ns/op", "Delta", "Base mutex ns/op", "HEAD mutex ns/op", "Delta"})
+	for _, c := range contention {
+		row := []string{c.Name, "-", "-", "-", "-", "-", "-"}
+		if c.Base != nil {
+			row[1] = strconv.FormatFloat(c.Base.BlockDelay, 'f', 2, 64)
+			row[4] = strconv.FormatFloat(c.Base.MutexDelay, 'f', 2, 64)
+		}
+		if c.Head != nil {
+			row[2] = strconv.FormatFloat(c.Head.BlockDelay, 'f', 2, 64)
+			row[5] = strconv.FormatFloat(c.Head.MutexDelay, 'f', 2, 64)
+		}
+		if c.Base != nil && c.Head != nil {
+			row[3] = fmt.Sprintf("%+.2f", c.Head.BlockDelay-c.Base.BlockDelay)
+			row[6] = fmt.Sprintf("%+.2f", c.Head.MutexDelay-c.Base.MutexDelay)
+		}
+		table.Append(row)
+	}
+	table.Render()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testContentionProfile returns a block or mutex profile with a sample of sync.(*Mutex).Lock for each delay.
+func testContentionProfile(delays ...uint64) []byte {
+	var p protoMessage
+	p.bytes(1, (&protoMessage{}).varint(1, 1).varint(2, 2).Bytes())
+	p.bytes(1, (&protoMessage{}).varint(1, 3).varint(2, 4).Bytes())
+	p.bytes(5, (&protoMessage{}).varint(1, 1).varint(2, 5).Bytes())
+	p.bytes(4, (&protoMessage{}).varint(1, 1).bytes(4, (&protoMessage{}).varint(1, 1).Bytes()).Bytes())
+	for _, d := range delays {
+		p.bytes(2, (&protoMessage{}).packed(1, 1).packed(2, 1, d).Bytes())
+	}
+	for _, s := range []string{"", "contentions", "count", "delay", "nanoseconds", "sync.(*Mutex).Lock"} {
+		p.bytes(6, []byte(s))
+	}
+	return p.Bytes()
+}
+
+func Test_newContention(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(name string, b []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, b, 0644))
+		return path
+	}
+	base := profileFiles{"BenchmarkA-8": {
+		"block": write("base.block.pprof", testContentionProfile(100)),
+		"mutex": write("base.mutex.pprof", testContentionProfile()),
+	}}
+	head := profileFiles{
+		"BenchmarkA-8": {
+			"block": write("head.block.pprof", testContentionProfile(1000, 2000)),
+			"mutex": write("head.mutex.pprof", testContentionProfile(300)),
+		},
+		"BenchmarkB-8": {"block": write("b.block.pprof", testMemProfile(t, map[string]int64{"pkg.grow": 1}))},
+	}
+	benchmarks := []report.Benchmark{
+		{Name: "BenchmarkA-8", Head: report.Measurement{Iterations: 9}},
+		{Name: "BenchmarkB-8"},
+		{Name: "BenchmarkC-8"},
+	}
+	got := newContention(benchmarks, base, head)
+	assert.Equal(t, []report.Contention{{
+		Name: "BenchmarkA-8",
+		Base: &report.ContentionEvents{BlockContentions: 0.1, BlockDelay: 10},
+		Head: &report.ContentionEvents{BlockContentions: 0.2, BlockDelay: 300, MutexContentions: 0.1, MutexDelay: 30},
+	}}, got)
+
+	w := &bytes.Buffer{}
+	showContention(w, got, "ascii")
+	assert.Contains(t, w.String(), "Contention\n==========")
+	assert.Contains(t, w.String(), "| BenchmarkA-8 |              10.00 |             300.00 | +290.00 |             0.00 |            30.00 | +30.00 |")
+}
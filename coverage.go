@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/xerrors"
+)
+
+// statementCount is the number of statements of a package, and how many of them the tests ran.
+type statementCount struct {
+	statements int
+	covered    int
+}
+
+func (c statementCount) percent() float64 {
+	if c.statements == 0 {
+		return 0
+	}
+	return 100 * float64(c.covered) / float64(c.statements)
+}
+
+// coverage is the statement coverage of each package at a commit.
+type coverage map[string]statementCount
+
+func (c coverage) total() statementCount {
+	var total statementCount
+	for _, p := range c {
+		total.statements += p.statements
+		total.covered += p.covered
+	}
+	return total
+}
+
+// runCoverage runs the tests with -coverprofile. Like runTestTiming, failing tests don't stop the run, so
+// that the coverage of the other packages is still compared.
+func runCoverage() (coverage, error) {
+	f, err := ioutil.TempFile("", "cob-cover")
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create a coverage profile: %w", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	args := []string{"test", "-count=1", "-coverprofile=" + f.Name(), "./..."}
+	debugf("exec: go %s", strings.Join(args, " "))
+	_, err = exec.Command("go", args...).Output()
+	if _, ok := err.(*exec.ExitError); err != nil && !ok {
+		return nil, xerrors.Errorf("failed to run 'go %s' command: %w", strings.Join(args, " "), err)
+	}
+
+	profile, perr := os.Open(f.Name())
+	if perr != nil {
+		return nil, xerrors.Errorf("failed to open the coverage profile: %w", perr)
+	}
+	defer profile.Close()
+	cov, perr := parseCoverProfile(profile)
+	if perr != nil {
+		return nil, xerrors.Errorf("failed to parse the coverage profile: %w", perr)
+	}
+	if err != nil {
+		if len(cov) == 0 {
+			return nil, xerrors.Errorf("failed to run 'go %s' command: %w", strings.Join(args, " "), err)
+		}
+		warnf("Some tests failed, but the coverage is compared anyway")
+	}
+	return cov, nil
+}
+
+// parseCoverProfile counts the statements of each package in a profile written by -coverprofile. A block
+// which appears more than once, e.g. with -coverpkg, is counted once and covered if any of them ran.
+func parseCoverProfile(r io.Reader) (coverage, error) {
+	type block struct {
+		statements int
+		covered    bool
+	}
+	blocks := map[string]block{}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// e.g. "github.com/knqyf263/cob/main.go:12.34,14.2 1 0"
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, xerrors.Errorf("invalid line: %s", line)
+		}
+		statements, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, xerrors.Errorf("invalid line: %s", line)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, xerrors.Errorf("invalid line: %s", line)
+		}
+		b := blocks[fields[0]]
+		b.statements = statements
+		b.covered = b.covered || count > 0
+		blocks[fields[0]] = b
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	cov := coverage{}
+	for key, b := range blocks {
+		file := key[:strings.LastIndex(key, ":")]
+		c := cov[path.Dir(file)]
+		c.statements += b.statements
+		if b.covered {
+			c.covered += b.statements
+		}
+		cov[path.Dir(file)] = c
+	}
+	return cov, nil
+}
+
+// newCoverage compares the coverage. base is nil if the base commit wasn't tested.
+func newCoverage(base, head coverage) *report.Coverage {
+	rc := &report.Coverage{Head: head.total().percent()}
+	if base != nil {
+		rc.Base = floatPtr(base.total().percent())
+		rc.Change = rc.Head - *rc.Base
+	}
+	var names []string
+	for name := range head {
+		names = append(names, name)
+	}
+	for name := range base {
+		if _, ok := head[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := report.PackageCoverage{Name: name}
+		h, inHead := head[name]
+		if inHead {
+			p.Head = floatPtr(h.percent())
+		}
+		if b, ok := base[name]; ok {
+			p.Base = floatPtr(b.percent())
+			if inHead {
+				p.Change = *p.Head - *p.Base
+			}
+		}
+		rc.Packages = append(rc.Packages, p)
+	}
+	return rc
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func showCoverage(w io.Writer, cov *report.Coverage, style string) {
+	if cov == nil {
+		return
+	}
+	fmt.Fprintln(w, "\nCoverage")
+	fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 8))
+
+	table := tablewriter.NewWriter(w)
+	table.SetAutoFormatHeaders(false)
+	applyTableStyle(table, style)
+	table.SetHeader([]string{"Package", "Base", "HEAD", "Change"})
+	for _, p := range cov.Packages {
+		table.Append(coverageRow(p.Name, p.Base, p.Head, p.Change))
+	}
+	table.SetFooter(coverageRow("total", cov.Base, &cov.Head, cov.Change))
+	table.Render()
+}
+
+func coverageRow(name string, base, head *float64, change float64) []string {
+	row := []string{name, "-", "-", "-"}
+	if base != nil {
+		row[1] = fmt.Sprintf("%.1f%%", *base)
+	}
+	if head != nil {
+		row[2] = fmt.Sprintf("%.1f%%", *head)
+	}
+	if base != nil && head != nil {
+		row[3] = fmt.Sprintf("%+.1fpp", change)
+	}
+	return row
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseCoverProfile(t *testing.T) {
+	profile := `mode: set
+github.com/knqyf263/cob/main.go:10.2,12.3 3 1
+github.com/knqyf263/cob/main.go:14.2,15.3 1 0
+github.com/knqyf263/cob/main.go:14.2,15.3 1 1
+github.com/knqyf263/cob/table.go:7.2,9.3 2 0
+github.com/knqyf263/cob/pkg/report/report.go:92.2,94.3 4 1
+`
+	got, err := parseCoverProfile(strings.NewReader(profile))
+	require.NoError(t, err)
+	assert.Equal(t, coverage{
+		"github.com/knqyf263/cob":            {statements: 6, covered: 4},
+		"github.com/knqyf263/cob/pkg/report": {statements: 4, covered: 4},
+	}, got)
+	assert.Equal(t, statementCount{statements: 10, covered: 8}, got.total())
+
+	_, err = parseCoverProfile(strings.NewReader("mode: set\ngithub.com/knqyf263/cob/main.go:10.2,12.3 x 1\n"))
+	assert.EqualError(t, err, "invalid line: github.com/knqyf263/cob/main.go:10.2,12.3 x 1")
+}
+
+func Test_newCoverage(t *testing.T) {
+	base := coverage{"a": {statements: 10, covered: 5}, "b": {statements: 10, covered: 10}}
+	head := coverage{"a": {statements: 10, covered: 8}, "c": {statements: 10, covered: 2}}
+
+	got := newCoverage(base, head)
+	assert.Equal(t, 50.0, got.Head)
+	require.NotNil(t, got.Base)
+	assert.Equal(t, 75.0, *got.Base)
+	assert.Equal(t, -25.0, got.Change)
+	assert.Equal(t, []report.PackageCoverage{
+		{Name: "a", Base: floatPtr(50), Head: floatPtr(80), Change: 30},
+		{Name: "b", Base: floatPtr(100)},
+		{Name: "c", Head: floatPtr(20)},
+	}, got.Packages)
+
+	got = newCoverage(nil, head)
+	assert.Nil(t, got.Base)
+	assert.Equal(t, 0.0, got.Change)
+}
+
+func Test_showCoverage(t *testing.T) {
+	w := &bytes.Buffer{}
+	showCoverage(w, nil, "ascii")
+	assert.Empty(t, w.String())
+
+	showCoverage(w, newCoverage(
+		coverage{"a": {statements: 10, covered: 5}},
+		coverage{"a": {statements: 10, covered: 8}, "c": {statements: 10, covered: 0}},
+	), "ascii")
+	got := w.String()
+	assert.Contains(t, got, "Coverage\n========")
+	assert.Contains(t, got, "| a       | 50.0% | 80.0% | +30.0pp |")
+	assert.Contains(t, got, "| c       | -     | 0.0%  | -       |")
+	assert.Contains(t, got, "-10.0pp")
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+)
+
+// detectCreep compares ns/op at HEAD with the oldest of the last window-1 recorded runs of each benchmark.
+// Benchmarks which got worse than the threshold at any commit in the window are left to the usual comparison.
+func detectCreep(runs []historyRun, rep report.Report, window int, threshold float64) []report.Creep {
+	var creep []report.Creep
+	for _, b := range rep.Benchmarks {
+		if b.Status == report.StatusFail {
+			continue
+		}
+		points := benchmarkHistory(runs, b.Name, window-1)
+		if len(points) == 0 {
+			continue
+		}
+		tripped := false
+		for _, p := range points {
+			if p.Status == report.StatusFail {
+				tripped = true
+			}
+		}
+		if tripped {
+			continue
+		}
+		ratio := calcRatio(b.Head.NsPerOp, points[0].NsPerOp)
+		if ratio > threshold {
+			creep = append(creep, report.Creep{Name: b.Name, Since: points[0].Commit, Commits: len(points) + 1, Ratio: ratio})
+		}
+	}
+	return creep
+}
+
+func generateCreepLine(c report.Creep) string {
+	return fmt.Sprintf("%s: %+.2f%% ns/op over the last %d commits (since %s)", c.Name, 100*c.Ratio, c.Commits, shortHash(c.Since))
+}
+
+// showCreep prints the benchmarks which crept, if any.
+func showCreep(w io.Writer, creep []report.Creep) {
+	if len(creep) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nCreep")
+	fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 5))
+	for _, c := range creep {
+		fmt.Fprintln(w, generateCreepLine(c))
+	}
+}
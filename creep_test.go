@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_detectCreep(t *testing.T) {
+	// BenchmarkA was 100, 101 and 102 ns/op at the recorded commits.
+	rep := report.Report{Benchmarks: []report.Benchmark{
+		{Name: "BenchmarkA", Head: report.Measurement{NsPerOp: 110}, Status: report.StatusOK},
+		{Name: "BenchmarkB", Head: report.Measurement{NsPerOp: 1}, Status: report.StatusOK},
+		{Name: "BenchmarkC", Head: report.Measurement{NsPerOp: 1}, Status: report.StatusOK},
+	}}
+
+	tests := []struct {
+		name      string
+		window    int
+		threshold float64
+		tripped   bool
+		want      []report.Creep
+	}{
+		{
+			name:      "over the whole history",
+			window:    4,
+			threshold: 0.05,
+			want:      []report.Creep{{Name: "BenchmarkA", Since: "aaaaaaaaaa", Commits: 4, Ratio: 0.1}},
+		},
+		{
+			name:      "over a shorter window",
+			window:    2,
+			threshold: 0.05,
+			want:      []report.Creep{{Name: "BenchmarkA", Since: "cccccccccc", Commits: 2, Ratio: 8.0 / 102}},
+		},
+		{
+			name:      "within the threshold",
+			window:    4,
+			threshold: 0.2,
+		},
+		{
+			name:      "a commit tripped the threshold",
+			window:    4,
+			threshold: 0.05,
+			tripped:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := testHistoryRuns()
+			if tt.tripped {
+				runs[1].Report.Benchmarks[0].Status = report.StatusFail
+			}
+			assert.Equal(t, tt.want, detectCreep(runs, rep, tt.window, tt.threshold))
+		})
+	}
+}
+
+func Test_showCreep(t *testing.T) {
+	w := &bytes.Buffer{}
+	showCreep(w, []report.Creep{{Name: "BenchmarkA", Since: "aaaaaaaaaa", Commits: 4, Ratio: 0.1}})
+	assert.Equal(t, "\nCreep\n=====\n\nBenchmarkA: +10.00% ns/op over the last 4 commits (since aaaaaaa)\n", w.String())
+}
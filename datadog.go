@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+// datadogGauge is the type of a gauge in the v2 series API.
+const datadogGauge = 3
+
+type datadogSeriesRequest struct {
+	Series []datadogSeries `json:"series"`
+}
+
+type datadogSeries struct {
+	Metric string         `json:"metric"`
+	Type   int            `json:"type"`
+	Points []datadogPoint `json:"points"`
+	Tags   []string       `json:"tags"`
+}
+
+type datadogPoint struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+type datadogEvent struct {
+	Title     string   `json:"title"`
+	Text      string   `json:"text"`
+	AlertType string   `json:"alert_type"`
+	Tags      []string `json:"tags"`
+}
+
+func datadogTags(rep report.Report) []string {
+	tags := []string{"commit:" + rep.Head.Hash}
+	if rep.Head.Branch != "" {
+		tags = append(tags, "branch:"+rep.Head.Branch)
+	}
+	return tags
+}
+
+// generateDatadogSeries converts the report into gauges named like "cob.ns_per_op" and tagged by benchmark.
+func generateDatadogSeries(rep report.Report, now time.Time) datadogSeriesRequest {
+	req := datadogSeriesRequest{Series: []datadogSeries{}}
+	for _, m := range benchmarkMetrics(rep) {
+		req.Series = append(req.Series, datadogSeries{
+			Metric: "cob." + m.Name,
+			Type:   datadogGauge,
+			Points: []datadogPoint{{Timestamp: now.Unix(), Value: m.Value}},
+			Tags:   append([]string{"benchmark:" + m.Benchmark}, datadogTags(rep)...),
+		})
+	}
+	return req
+}
+
+// generateDatadogEvent creates an event listing the regressions.
+func generateDatadogEvent(rep report.Report, results []result, comparedScore comparedScore) datadogEvent {
+	var lines []string
+	for _, r := range topRegressions(results, comparedScore, maxNotifiedRegressions) {
+		lines = append(lines, "- "+generateRegressionLine(r, comparedScore))
+	}
+	text := strings.Join(lines, "\n")
+	if u := ciRunURL(); u != "" {
+		text += "\n\n" + u
+	}
+	return datadogEvent{
+		Title:     fmt.Sprintf("cob: %s makes benchmarks worse", shortHash(rep.Head.Hash)),
+		Text:      text,
+		AlertType: "error",
+		Tags:      datadogTags(rep),
+	}
+}
+
+// newDatadogClient returns a client for the Datadog API. The API key is read from DD_API_KEY and the site from
+// DD_SITE, e.g. "datadoghq.eu".
+func newDatadogClient() (restClient, error) {
+	key := os.Getenv("DD_API_KEY")
+	if key == "" {
+		return restClient{}, xerrors.New("DD_API_KEY is not set")
+	}
+	site := os.Getenv("DD_SITE")
+	if site == "" {
+		site = "datadoghq.com"
+	}
+	header := http.Header{}
+	header.Set("DD-API-KEY", key)
+	return newRESTClient("Datadog", "https://api."+site, header), nil
+}
+
+// postDatadog submits the metrics, and an event if benchmarks get worse than the threshold.
+func postDatadog(c restClient, rep report.Report, results []result, comparedScore comparedScore) error {
+	if err := c.do(http.MethodPost, "/api/v2/series", generateDatadogSeries(rep, time.Now()), nil); err != nil {
+		return xerrors.Errorf("failed to submit metrics: %w", err)
+	}
+	if rep.Degression {
+		if err := c.do(http.MethodPost, "/api/v1/events", generateDatadogEvent(rep, results, comparedScore), nil); err != nil {
+			return xerrors.Errorf("failed to post an event: %w", err)
+		}
+	}
+	infof("Submitted metrics to Datadog")
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_generateDatadogSeries(t *testing.T) {
+	rep := report.Report{
+		Head:       report.Commit{Ref: "HEAD", Hash: "599a552", Branch: "main"},
+		Benchmarks: []report.Benchmark{{Name: "BenchmarkA", Head: report.Measurement{NsPerOp: 150}}},
+	}
+	got := generateDatadogSeries(rep, time.Unix(100, 0))
+	require.Len(t, got.Series, 6)
+	assert.Equal(t, datadogSeries{
+		Metric: "cob.ns_per_op",
+		Type:   datadogGauge,
+		Points: []datadogPoint{{Timestamp: 100, Value: 150}},
+		Tags:   []string{"benchmark:BenchmarkA", "commit:599a552", "branch:main"},
+	}, got.Series[0])
+}
+
+func Test_postDatadog(t *testing.T) {
+	tests := []struct {
+		name       string
+		degression bool
+		wantPaths  []string
+	}{
+		{
+			name:      "no degression",
+			wantPaths: []string{"/api/v2/series"},
+		},
+		{
+			name:       "degression",
+			degression: true,
+			wantPaths:  []string{"/api/v2/series", "/api/v1/events"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPaths []string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "secret", r.Header.Get("DD-API-KEY"))
+				gotPaths = append(gotPaths, r.URL.Path)
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer ts.Close()
+
+			header := http.Header{}
+			header.Set("DD-API-KEY", "secret")
+			rep := report.Report{Head: report.Commit{Hash: "599a552"}, Degression: tt.degression}
+			results := []result{{Name: "BenchmarkA", RatioNsPerOp: 0.5}}
+			compare := comparedScore{nsPerOp: true}
+			require.NoError(t, postDatadog(newRESTClient("Datadog", ts.URL, header), rep, results, compare))
+			assert.Equal(t, tt.wantPaths, gotPaths)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/xerrors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// moduleFiles are the files which select the versions of the dependencies.
+var moduleFiles = []string{"go.mod", "go.sum"}
+
+// moduleFilesChanged returns whether go.mod or go.sum differs between the commits.
+func moduleFilesChanged(r *git.Repository, base, head plumbing.Hash) (bool, error) {
+	baseHashes, err := moduleFileHashes(r, base)
+	if err != nil {
+		return false, err
+	}
+	headHashes, err := moduleFileHashes(r, head)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range moduleFiles {
+		if baseHashes[name] != headHashes[name] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// commitTree returns the tree of the commit.
+func commitTree(r *git.Repository, hash plumbing.Hash) (*object.Tree, error) {
+	commit, err := r.CommitObject(hash)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get the commit %s: %w", hash, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get the tree of %s: %w", hash, err)
+	}
+	return tree, nil
+}
+
+// moduleFileHashes returns the blob hashes of the module files at the commit. A missing file has none.
+func moduleFileHashes(r *git.Repository, hash plumbing.Hash) (map[string]plumbing.Hash, error) {
+	tree, err := commitTree(r, hash)
+	if err != nil {
+		return nil, err
+	}
+	hashes := map[string]plumbing.Hash{}
+	for _, name := range moduleFiles {
+		f, err := tree.File(name)
+		if err == object.ErrFileNotFound {
+			continue
+		} else if err != nil {
+			return nil, xerrors.Errorf("failed to get %s at %s: %w", name, hash, err)
+		}
+		hashes[name] = f.Hash
+	}
+	return hashes, nil
+}
+
+// listModules returns the version of each module in the build list, e.g. "v1.2.3" or "v1.2.3 => ../fork" for a
+// replaced module. The worktree must be at the commit to list.
+func listModules() (map[string]string, error) {
+	args := []string{"list", "-m", "all"}
+	if strings.Contains(os.Getenv("GOFLAGS"), "-mod=vendor") {
+		// "all" can't be listed from the vendor directory.
+		args = []string{"list", "-mod=mod", "-m", "all"}
+	}
+	debugf("exec: go %s", strings.Join(args, " "))
+	out, err := exec.Command("go", args...).Output()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to run 'go %s' command: %w", strings.Join(args, " "), err)
+	}
+	return parseModuleList(bytes.NewReader(out)), nil
+}
+
+// parseModuleList parses the output of "go list -m all". The main module, which has no version, is skipped.
+func parseModuleList(r io.Reader) map[string]string {
+	modules := map[string]string{}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		modules[fields[0]] = strings.Join(fields[1:], " ")
+	}
+	return modules
+}
+
+// newDependencyChanges returns the modules which were added, removed or changed their version.
+func newDependencyChanges(base, head map[string]string) []report.DependencyChange {
+	var changes []report.DependencyChange
+	for mod, h := range head {
+		if b := base[mod]; b != h {
+			changes = append(changes, report.DependencyChange{Module: mod, Base: b, Head: h})
+		}
+	}
+	for mod, b := range base {
+		if _, ok := head[mod]; !ok {
+			changes = append(changes, report.DependencyChange{Module: mod, Base: b})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Module < changes[j].Module })
+	return changes
+}
+
+// packageModules returns the modules of the packages which the package and its tests import, directly or not.
+func packageModules(pkg string) (map[string]bool, error) {
+	args := []string{"list", "-deps", "-test", "-f", "{{with .Module}}{{.Path}}{{end}}", pkg}
+	debugf("exec: go %s", strings.Join(args, " "))
+	out, err := exec.Command("go", args...).Output()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to run 'go %s' command: %w", strings.Join(args, " "), err)
+	}
+	modules := map[string]bool{}
+	for _, mod := range strings.Fields(string(out)) {
+		modules[mod] = true
+	}
+	return modules, nil
+}
+
+// attributeDependencyChanges lists the regressed benchmarks whose packages import a changed module, which is a
+// probable cause of their regression. The worktree must be at HEAD.
+func attributeDependencyChanges(changes []report.DependencyChange, regressed []report.Benchmark) {
+	funcs, err := findBenchmarkFuncs(".")
+	if err != nil {
+		warnf("Failed to find the benchmarks which use the changed dependencies: %s", err)
+		return
+	}
+	// modules are the modules which each package imports, by package.
+	modules := map[string]map[string]bool{}
+	for _, b := range regressed {
+		fn, ok := funcs[benchmarkFuncName(b.Name)]
+		if !ok {
+			continue
+		}
+		pkg := "./" + path.Dir(fn.Path)
+		if _, ok := modules[pkg]; !ok {
+			if modules[pkg], err = packageModules(pkg); err != nil {
+				warnf("Failed to find the dependencies of %s: %s", pkg, err)
+			}
+		}
+		for i, c := range changes {
+			if modules[pkg][c.Module] {
+				changes[i].Benchmarks = append(changes[i].Benchmarks, b.Name)
+			}
+		}
+	}
+}
+
+func showDependencyChanges(w io.Writer, changes []report.DependencyChange, style string) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nDependency changes")
+	fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 18))
+
+	table := tablewriter.NewWriter(w)
+	table.SetAutoFormatHeaders(false)
+	applyTableStyle(table, style)
+	table.SetHeader([]string{"Module", "Base", "HEAD", "Used by regressed benchmarks"})
+	for _, c := range changes {
+		table.Append([]string{c.Module, dependencyVersion(c.Base), dependencyVersion(c.Head), strings.Join(c.Benchmarks, ", ")})
+	}
+	table.Render()
+}
+
+// dependencyVersion returns the version of a module, or "-" if it isn't a dependency at the commit.
+func dependencyVersion(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
+
+// generateDependencyLine renders a dependency change as a line for Markdown.
+func generateDependencyLine(c report.DependencyChange) string {
+	line := fmt.Sprintf("`%s`: %s → %s", c.Module, dependencyVersion(c.Base), dependencyVersion(c.Head))
+	if len(c.Benchmarks) > 0 {
+		line += fmt.Sprintf(" (probable cause of the regression of %s)", strings.Join(c.Benchmarks, ", "))
+	}
+	return line
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newDependencyChanges(t *testing.T) {
+	base := parseModuleList(strings.NewReader(`github.com/you/app
+github.com/a/bumped v1.2.0
+github.com/b/removed v0.1.0
+github.com/c/same v1.0.0
+github.com/d/replaced v1.0.0
+`))
+	head := parseModuleList(strings.NewReader(`github.com/you/app
+github.com/a/bumped v1.3.0
+github.com/c/same v1.0.0
+github.com/d/replaced v1.0.0 => ../replaced
+github.com/e/added v0.2.0
+`))
+	assert.Equal(t, []report.DependencyChange{
+		{Module: "github.com/a/bumped", Base: "v1.2.0", Head: "v1.3.0"},
+		{Module: "github.com/b/removed", Base: "v0.1.0"},
+		{Module: "github.com/d/replaced", Base: "v1.0.0", Head: "v1.0.0 => ../replaced"},
+		{Module: "github.com/e/added", Head: "v0.2.0"},
+	}, newDependencyChanges(base, head))
+}
+
+func Test_showDependencyChanges(t *testing.T) {
+	changes := []report.DependencyChange{
+		{Module: "github.com/a/bumped", Base: "v1.2.0", Head: "v1.3.0", Benchmarks: []string{"BenchmarkA-8", "BenchmarkB-8"}},
+		{Module: "github.com/e/added", Head: "v0.2.0"},
+	}
+	w := &bytes.Buffer{}
+	showDependencyChanges(w, changes, "ascii")
+	got := w.String()
+	assert.Contains(t, got, "Dependency changes\n==================")
+	assert.Contains(t, got, "| github.com/a/bumped | v1.2.0 | v1.3.0 | BenchmarkA-8, BenchmarkB-8   |")
+	assert.Contains(t, got, "| github.com/e/added  | -      | v0.2.0 |                              |")
+
+	assert.Equal(t, "`github.com/a/bumped`: v1.2.0 → v1.3.0 (probable cause of the regression of BenchmarkA-8, BenchmarkB-8)", generateDependencyLine(changes[0]))
+	assert.Equal(t, "`github.com/e/added`: - → v0.2.0", generateDependencyLine(changes[1]))
+}
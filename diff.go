@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh/terminal"
+	"golang.org/x/tools/benchmark/parse"
+)
+
+const (
+	colorReset = "\x1b[0m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+)
+
+// colorful reports whether the output to w is colored: escape sequences are only colors on a terminal, and
+// NO_COLOR (https://no-color.org) turns them off there too.
+func colorful(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	return ok && terminal.IsTerminal(int(f.Fd()))
+}
+
+// showDiff prints results in a unified diff style. Lines of the base commit are prefixed with '-'
+// and lines of HEAD are prefixed with '+'.
+func showDiff(w io.Writer, results []result, base string, threshold float64, comparedScore comparedScore, columns columns, onlyDegression, ascii, color bool) bool {
+	paint := func(code, s string) string {
+		if !color {
+			return s
+		}
+		return code + s + colorReset
+	}
+	var degression bool
+	var printed bool
+	for _, result := range results {
+		if isDegression(result, threshold, comparedScore) {
+			degression = true
+		} else if onlyDegression {
+			continue
+		}
+
+		if !printed {
+			fmt.Fprintln(w, paint(colorRed, "--- "+base))
+			fmt.Fprintln(w, paint(colorGreen, "+++ HEAD"))
+			printed = true
+		}
+
+		hunk := paint(colorCyan, "@@ "+result.Name+" @@")
+		if columns.ratio {
+			hunk += " " + generateDiffRatio(result, comparedScore, columns)
+		}
+		if columns.status {
+			hunk += " " + benchmarkStatus(result, threshold, comparedScore).marker(ascii)
+		}
+		fmt.Fprintln(w, hunk)
+		fmt.Fprintln(w, paint(colorRed, "- "+generateDiffLine(result.Prev, columns)))
+		fmt.Fprintln(w, paint(colorGreen, "+ "+generateDiffLine(result.Head, columns)))
+	}
+	return degression
+}
+
+func generateDiffLine(b *parse.Benchmark, columns columns) string {
+	var items []string
+	if columns.iterations {
+		items = append(items, fmt.Sprintf("%d", b.N))
+	}
+	if columns.nsPerOp {
+		items = append(items, fmt.Sprintf("%.2f ns/op", b.NsPerOp))
+	}
+	if columns.allocedBytesPerOp {
+		items = append(items, fmt.Sprintf("%d B/op", b.AllocedBytesPerOp))
+	}
+	if columns.allocsPerOp {
+		items = append(items, fmt.Sprintf("%d allocs/op", b.AllocsPerOp))
+	}
+	if columns.mbPerS {
+		items = append(items, fmt.Sprintf("%.2f MB/s", b.MBPerS))
+	}
+	return strings.Join(items, "\t")
+}
+
+func generateDiffRatio(r result, comparedScore comparedScore, columns columns) string {
+	var items []string
+	if columns.nsPerOp {
+		item := "-"
+		if comparedScore.nsPerOp {
+			item = fmt.Sprintf("%+.2f%%", 100*r.RatioNsPerOp)
+		}
+		items = append(items, "ns/op "+item)
+	}
+	if columns.allocedBytesPerOp {
+		item := "-"
+		if comparedScore.allocedBytesPerOp {
+			item = fmt.Sprintf("%+.2f%%", 100*r.RatioAllocedBytesPerOp)
+		}
+		items = append(items, "B/op "+item)
+	}
+	if columns.allocsPerOp {
+		items = append(items, fmt.Sprintf("allocs/op %+.2f%%", 100*r.RatioAllocsPerOp))
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/benchmark/parse"
+)
+
+func Test_showDiff(t *testing.T) {
+	type args struct {
+		results        []result
+		base           string
+		threshold      float64
+		compare        comparedScore
+		onlyDegression bool
+		color          bool
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     bool
+		wantDiff string
+	}{
+		{
+			name: "happy path",
+			args: args{
+				results: []result{
+					{
+						Name:                   "BenchmarkA",
+						RatioNsPerOp:           0.01,
+						RatioAllocedBytesPerOp: 0.5,
+						Head:                   &parse.Benchmark{Name: "BenchmarkA", N: 1000, NsPerOp: 101, AllocedBytesPerOp: 300},
+						Prev:                   &parse.Benchmark{Name: "BenchmarkA", N: 2000, NsPerOp: 100, AllocedBytesPerOp: 200},
+					},
+				},
+				base:      "HEAD~1",
+				threshold: 0.2,
+				compare: comparedScore{
+					nsPerOp:           true,
+					allocedBytesPerOp: true,
+				},
+				color: true,
+			},
+			want: true,
+			wantDiff: "\x1b[31m--- HEAD~1\x1b[0m\n" +
+				"\x1b[32m+++ HEAD\x1b[0m\n" +
+				"\x1b[36m@@ BenchmarkA @@\x1b[0m ns/op +1.00%, B/op +50.00% FAIL\n" +
+				"\x1b[31m- 2000\t100.00 ns/op\t200 B/op\x1b[0m\n" +
+				"\x1b[32m+ 1000\t101.00 ns/op\t300 B/op\x1b[0m\n",
+		},
+		{
+			name: "no color",
+			args: args{
+				results: []result{
+					{
+						Name:         "BenchmarkA",
+						RatioNsPerOp: 0.5,
+						Head:         &parse.Benchmark{Name: "BenchmarkA", N: 1000, NsPerOp: 150},
+						Prev:         &parse.Benchmark{Name: "BenchmarkA", N: 1000, NsPerOp: 100},
+					},
+				},
+				base:      "HEAD~1",
+				threshold: 0.2,
+				compare: comparedScore{
+					nsPerOp: true,
+				},
+			},
+			want: true,
+			wantDiff: "--- HEAD~1\n" +
+				"+++ HEAD\n" +
+				"@@ BenchmarkA @@ ns/op +50.00%, B/op - FAIL\n" +
+				"- 1000\t100.00 ns/op\t0 B/op\n" +
+				"+ 1000\t150.00 ns/op\t0 B/op\n",
+		},
+		{
+			name: "only degression",
+			args: args{
+				results: []result{
+					{
+						Name:                   "BenchmarkA",
+						RatioNsPerOp:           -0.5,
+						RatioAllocedBytesPerOp: 0,
+						Head:                   &parse.Benchmark{Name: "BenchmarkA", NsPerOp: 50},
+						Prev:                   &parse.Benchmark{Name: "BenchmarkA", NsPerOp: 100},
+					},
+				},
+				base:      "HEAD~1",
+				threshold: 0.2,
+				compare: comparedScore{
+					nsPerOp: true,
+				},
+				onlyDegression: true,
+			},
+			want:     false,
+			wantDiff: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			got := showDiff(w, tt.args.results, tt.args.base, tt.args.threshold, tt.args.compare, defaultColumns, tt.args.onlyDegression, true, tt.args.color)
+			assert.Equal(t, tt.wantDiff, w.String(), tt.name)
+			assert.Equal(t, tt.want, got, tt.name)
+		})
+	}
+}
+
+func Test_colorful(t *testing.T) {
+	assert.False(t, colorful(&bytes.Buffer{}))
+
+	f, err := ioutil.TempFile("", "cob")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+	assert.False(t, colorful(f), "a file is not a terminal")
+
+	os.Setenv("NO_COLOR", "1")
+	defer os.Unsetenv("NO_COLOR")
+	assert.False(t, colorful(os.Stdout))
+}
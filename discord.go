@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+var discordColors = map[status]int{
+	statusOK:   0x2ecc71,
+	statusWarn: 0xf1c40f,
+	statusFail: 0xe74c3c,
+}
+
+type discordMessage struct {
+	Username string         `json:"username"`
+	Embeds   []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	URL         string         `json:"url,omitempty"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields,omitempty"`
+}
+
+type discordField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func generateDiscordMessage(results []result, commit string, threshold float64, comparedScore comparedScore, runURL string) discordMessage {
+	s, message := verdict(results, threshold, comparedScore)
+	embed := discordEmbed{
+		Title:       fmt.Sprintf("%s cob: %s", s.marker(false), shortHash(commit)),
+		Description: message,
+		URL:         runURL,
+		Color:       discordColors[s],
+	}
+
+	regressions := topRegressions(results, comparedScore, maxNotifiedRegressions)
+	if len(regressions) > 0 {
+		var lines []string
+		for _, r := range regressions {
+			lines = append(lines, "• "+generateRegressionLine(r, comparedScore))
+		}
+		embed.Fields = []discordField{{Name: "Worst regressions", Value: strings.Join(lines, "\n")}}
+	}
+	return discordMessage{Username: "cob", Embeds: []discordEmbed{embed}}
+}
+
+func postDiscordMessage(webhookURL string, msg discordMessage) error {
+	c := newRESTClient("Discord", webhookURL, nil)
+	if err := c.do(http.MethodPost, "", msg, nil); err != nil {
+		return xerrors.Errorf("failed to send a Discord message: %w", err)
+	}
+	infof("Sent the result to Discord")
+	return nil
+}
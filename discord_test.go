@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_generateDiscordMessage(t *testing.T) {
+	compare := comparedScore{nsPerOp: true, allocedBytesPerOp: true}
+	results := []result{
+		{Name: "BenchmarkA", RatioNsPerOp: 0.1},
+		{Name: "BenchmarkB", RatioNsPerOp: -0.1},
+	}
+	got := generateDiscordMessage(results, "599a5523729d4d99a331b9d3f71dde9e1e6daef0", 0.2, compare, "https://ci.example.com/1")
+	assert.Equal(t, discordMessage{
+		Username: "cob",
+		Embeds: []discordEmbed{{
+			Title:       "⚠️ cob: 599a552",
+			Description: "PASS: 1 benchmark(s) got worse within the threshold (20.00%)",
+			URL:         "https://ci.example.com/1",
+			Color:       0xf1c40f,
+			Fields:      []discordField{{Name: "Worst regressions", Value: "• BenchmarkA: ns/op +10.00%, B/op +0.00%"}},
+		}},
+	}, got)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+type email struct {
+	From    string
+	To      []string
+	Subject string
+	HTML    string
+}
+
+func generateEmailSubject(results []result, commit string, threshold float64, comparedScore comparedScore) string {
+	_, message := verdict(results, threshold, comparedScore)
+	return fmt.Sprintf("[cob] %s: %s", shortHash(commit), message)
+}
+
+// bytes returns the message in the RFC 5322 format.
+func (e email) bytes(now time.Time) []byte {
+	w := &bytes.Buffer{}
+	fmt.Fprintf(w, "From: %s\r\n", e.From)
+	fmt.Fprintf(w, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(w, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", e.Subject))
+	fmt.Fprintf(w, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprint(w, "MIME-Version: 1.0\r\n")
+	fmt.Fprint(w, "Content-Type: text/html; charset=utf-8\r\n")
+	fmt.Fprint(w, "\r\n")
+	fmt.Fprint(w, strings.Replace(e.HTML, "\n", "\r\n", -1))
+	return w.Bytes()
+}
+
+func splitRecipients(s string) []string {
+	var recipients []string
+	for _, r := range strings.Split(s, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			recipients = append(recipients, r)
+		}
+	}
+	return recipients
+}
+
+// sendEmail sends the email via the SMTP server at addr ("host:port"). PLAIN authentication is used
+// if SMTP_USERNAME is set.
+func sendEmail(addr string, e email) error {
+	if len(e.To) == 0 {
+		return xerrors.New("no recipients are given")
+	}
+	if e.From == "" {
+		return xerrors.New("the sender address is not given")
+	}
+
+	var auth smtp.Auth
+	if user := os.Getenv("SMTP_USERNAME"); user != "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return xerrors.Errorf("invalid SMTP server address: %w", err)
+		}
+		auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+	}
+
+	debugf("smtp: send an email to %s via %s", strings.Join(e.To, ", "), addr)
+	if err := smtp.SendMail(addr, auth, e.From, e.To, e.bytes(time.Now())); err != nil {
+		return xerrors.Errorf("failed to send an email: %w", err)
+	}
+	infof("Sent the result to %s", strings.Join(e.To, ", "))
+	return nil
+}
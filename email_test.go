@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_email_bytes(t *testing.T) {
+	e := email{
+		From:    "cob@example.com",
+		To:      []string{"dev@example.com", "qa@example.com"},
+		Subject: "[cob] 599a552: PASS: no benchmarks got worse",
+		HTML:    "<p>ok</p>\n",
+	}
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := "From: cob@example.com\r\n" +
+		"To: dev@example.com, qa@example.com\r\n" +
+		"Subject: [cob] 599a552: PASS: no benchmarks got worse\r\n" +
+		"Date: Thu, 02 Jan 2020 03:04:05 +0000\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"\r\n" +
+		"<p>ok</p>\r\n"
+	assert.Equal(t, want, string(e.bytes(now)))
+}
+
+func Test_splitRecipients(t *testing.T) {
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, splitRecipients(" a@example.com, ,b@example.com"))
+	assert.Nil(t, splitRecipients(""))
+}
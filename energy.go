@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/xerrors"
+)
+
+// powercapDir is where Linux exposes the RAPL energy counters.
+const powercapDir = "/sys/class/powercap"
+
+// raplPackageRegexp matches the zones of CPU packages, e.g. "intel-rapl:0". Their subzones, e.g.
+// "intel-rapl:0:0" for the cores, are already counted by the package. AMD CPUs use the same names.
+var raplPackageRegexp = regexp.MustCompile(`^intel-rapl:\d+$`)
+
+// raplZone is a RAPL energy counter.
+type raplZone struct {
+	dir string
+	// maxEnergy is the value in microjoules at which the counter wraps around.
+	maxEnergy int64
+}
+
+// raplZones returns the counters of the CPU packages under dir. It fails if there are none or they can't be
+// read, which needs root on most kernels.
+func raplZones(goos, dir string) ([]raplZone, error) {
+	if goos != "linux" {
+		return nil, xerrors.New("--energy is only supported on Linux")
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, xerrors.Errorf("--energy requires RAPL counters in %s: %w", dir, err)
+	}
+	var zones []raplZone
+	for _, e := range entries {
+		if !raplPackageRegexp.MatchString(e.Name()) {
+			continue
+		}
+		z := raplZone{dir: filepath.Join(dir, e.Name())}
+		if z.maxEnergy, err = readMicrojoules(filepath.Join(z.dir, "max_energy_range_uj")); err != nil {
+			return nil, err
+		}
+		if _, err = z.energy(); err != nil {
+			return nil, xerrors.Errorf("%w (reading RAPL counters usually requires root)", err)
+		}
+		zones = append(zones, z)
+	}
+	if len(zones) == 0 {
+		return nil, xerrors.Errorf("--energy requires RAPL counters, but there are none in %s", dir)
+	}
+	return zones, nil
+}
+
+func (z raplZone) energy() (int64, error) {
+	return readMicrojoules(filepath.Join(z.dir, "energy_uj"))
+}
+
+func readMicrojoules(path string) (int64, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to read the energy counter: %w", err)
+	}
+	uj, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, xerrors.Errorf("invalid energy counter %s: %w", path, err)
+	}
+	return uj, nil
+}
+
+// energies reads the counters of all zones.
+func energies(zones []raplZone) ([]int64, error) {
+	var values []int64
+	for _, z := range zones {
+		uj, err := z.energy()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, uj)
+	}
+	return values, nil
+}
+
+// consumedJoules returns the energy consumed by all zones between two readings, with counters which wrapped
+// around in between.
+func consumedJoules(zones []raplZone, before, after []int64) float64 {
+	var uj int64
+	for i, z := range zones {
+		d := after[i] - before[i]
+		if d < 0 {
+			d += z.maxEnergy
+		}
+		uj += d
+	}
+	return float64(uj) / 1e6
+}
+
+// measureEnergy runs each benchmark once more and returns the joules per op the CPU packages consumed while it
+// ran, by benchmark. The test binaries are built beforehand, so that only the benchmark is measured. RAPL counts
+// the whole machine, so other processes add noise. The worktree must be at the commit to measure. Like
+// collectProfiles, benchmarks run as many iterations as they did at HEAD, and a benchmark which can't be measured
+// only prints a warning.
+func measureEnergy(benchmarks []report.Benchmark, zones []raplZone) map[string]float64 {
+	joules := map[string]float64{}
+	funcs, err := findBenchmarkFuncs(".")
+	if err != nil {
+		warnf("Failed to measure the energy: %s", err)
+		return joules
+	}
+	dir, err := ioutil.TempDir("", "cob-energy")
+	if err != nil {
+		warnf("Failed to measure the energy: %s", err)
+		return joules
+	}
+	defer os.RemoveAll(dir)
+
+	// binaries are the test binaries by package directory.
+	binaries := map[string]string{}
+	for _, b := range benchmarks {
+		fn, ok := funcs[benchmarkFuncName(b.Name)]
+		if !ok {
+			warnf("Failed to measure the energy of %s: the benchmark function is not found", b.Name)
+			continue
+		}
+		pkg := path.Dir(fn.Path)
+		bin, ok := binaries[pkg]
+		if !ok {
+			bin = filepath.Join(dir, strconv.Itoa(len(binaries))+".test")
+			args := []string{"test", "-c", "-o", bin, "./" + pkg}
+			debugf("exec: go %s", strings.Join(args, " "))
+			if out, err := exec.Command("go", args...).CombinedOutput(); err != nil {
+				warnf("Failed to build the tests of %s: %s: %s", pkg, err, strings.TrimSpace(string(out)))
+				continue
+			}
+			binaries[pkg] = bin
+		}
+
+		args := []string{"-test.run", "^$", "-test.bench", benchmarkRegexp(b.Name), "-test.count=1"}
+		if b.Head.Iterations > 0 {
+			args = append(args, "-test.benchtime="+strconv.Itoa(b.Head.Iterations)+"x")
+		}
+		cmd := exec.Command(bin, args...)
+		// Tests run in the directory of their package, as with "go test".
+		cmd.Dir = filepath.FromSlash(pkg)
+		debugf("exec: %s %s", bin, strings.Join(args, " "))
+		before, err := energies(zones)
+		if err != nil {
+			warnf("Failed to measure the energy of %s: %s", b.Name, err)
+			continue
+		}
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			warnf("Failed to measure the energy of %s: %s: %s", b.Name, err, strings.TrimSpace(string(out)))
+			continue
+		}
+		after, err := energies(zones)
+		if err != nil {
+			warnf("Failed to measure the energy of %s: %s", b.Name, err)
+			continue
+		}
+		joules[b.Name] = consumedJoules(zones, before, after) / profiledOps(b.Head.Iterations)
+	}
+	return joules
+}
+
+// newEnergy pairs the joules per op of each benchmark at both commits.
+func newEnergy(benchmarks []report.Benchmark, base, head map[string]float64) []report.Energy {
+	var energy []report.Energy
+	for _, b := range benchmarks {
+		base, inBase := base[b.Name]
+		head, inHead := head[b.Name]
+		if !inBase && !inHead {
+			continue
+		}
+		energy = append(energy, report.Energy{Name: b.Name, Base: base, Head: head, Ratio: calcRatio(head, base)})
+	}
+	return energy
+}
+
+func showEnergy(w io.Writer, energy []report.Energy, style string) {
+	if len(energy) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nEnergy")
+	fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 6))
+
+	table := tablewriter.NewWriter(w)
+	table.SetAutoFormatHeaders(false)
+	applyTableStyle(table, style)
+	table.SetHeader([]string{"Name", "Base µJ/op", "HEAD µJ/op", "Delta"})
+	for _, e := range energy {
+		row := []string{e.Name, "-", "-", "-"}
+		if e.Base > 0 {
+			row[1] = strconv.FormatFloat(e.Base*1e6, 'f', 3, 64)
+		}
+		if e.Head > 0 {
+			row[2] = strconv.FormatFloat(e.Head*1e6, 'f', 3, 64)
+		}
+		if e.Base > 0 && e.Head > 0 {
+			row[3] = fmt.Sprintf("%+.2f%%", 100*e.Ratio)
+		}
+		table.Append(row)
+	}
+	table.Render()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_raplZones(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = raplZones("darwin", dir)
+	assert.EqualError(t, err, "--energy is only supported on Linux")
+	_, err = raplZones("linux", dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "there are none in")
+
+	zone := func(name, energy, max string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name, "energy_uj"), []byte(energy), 0644))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name, "max_energy_range_uj"), []byte(max), 0644))
+	}
+	zone("intel-rapl:0", "1000\n", "262143328850\n")
+	zone("intel-rapl:0:0", "500\n", "262143328850\n")
+	zone("intel-rapl:1", "999000\n", "1000000\n")
+	zones, err := raplZones("linux", dir)
+	require.NoError(t, err)
+	require.Len(t, zones, 2)
+	assert.Equal(t, int64(1000000), zones[1].maxEnergy)
+
+	before, err := energies(zones)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1000, 999000}, before)
+	// The counter of the second package wrapped around.
+	assert.Equal(t, 0.5+0.003, consumedJoules(zones, before, []int64{501000, 2000}))
+}
+
+func Test_showEnergy(t *testing.T) {
+	benchmarks := []report.Benchmark{{Name: "BenchmarkA-8"}, {Name: "BenchmarkB-8"}, {Name: "BenchmarkC-8"}}
+	energy := newEnergy(benchmarks,
+		map[string]float64{"BenchmarkA-8": 2e-6},
+		map[string]float64{"BenchmarkA-8": 3e-6, "BenchmarkC-8": 1e-7},
+	)
+	require.Len(t, energy, 2)
+	assert.InDelta(t, 0.5, energy[0].Ratio, 1e-9)
+
+	w := &bytes.Buffer{}
+	showEnergy(w, energy, "ascii")
+	got := w.String()
+	assert.Contains(t, got, "Energy\n======")
+	assert.Contains(t, got, "| BenchmarkA-8 |      2.000 |      3.000 | +50.00% |")
+	assert.Contains(t, got, "| BenchmarkC-8 | -          |      0.100 | -       |")
+}
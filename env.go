@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// cobEnvVar returns the environment variable of a flag, e.g. COB_BENCH_ARGS for "bench-args".
+func cobEnvVar(flag string) string {
+	return "COB_" + strings.ToUpper(strings.Replace(flag, "-", "_", -1))
+}
+
+// pluginEnvVar returns the environment variable Drone and Woodpecker set for a plugin setting,
+// e.g. PLUGIN_BENCH_ARGS for "bench_args" or "bench-args".
+func pluginEnvVar(flag string) string {
+	return "PLUGIN_" + strings.ToUpper(strings.Replace(flag, "-", "_", -1))
+}
+
+// actionInputEnvVar returns the environment variable GitHub Actions sets for an input of the same name as the
+// flag, e.g. INPUT_BENCH-ARGS. Unlike Drone, hyphens are kept.
+func actionInputEnvVar(flag string) string {
+	return "INPUT_" + strings.ToUpper(flag)
+}
+
+// addEnvVars lets the environment variables returned by envVar set the flags. The flags of "cob run" are the
+// global flags as well, so a variable which a flag already has is not added again.
+func addEnvVars(flags []cli.Flag, envVar func(flag string) string) {
+	for _, f := range flags {
+		switch f := f.(type) {
+		case *cli.StringFlag:
+			f.EnvVars = appendEnvVar(f.EnvVars, envVar(f.Name))
+		case *cli.BoolFlag:
+			f.EnvVars = appendEnvVar(f.EnvVars, envVar(f.Name))
+		case *cli.Float64Flag:
+			f.EnvVars = appendEnvVar(f.EnvVars, envVar(f.Name))
+		case *cli.IntFlag:
+			f.EnvVars = appendEnvVar(f.EnvVars, envVar(f.Name))
+		case *cli.DurationFlag:
+			f.EnvVars = appendEnvVar(f.EnvVars, envVar(f.Name))
+		case *cli.StringSliceFlag:
+			f.EnvVars = appendEnvVar(f.EnvVars, envVar(f.Name))
+		case *cli.TimestampFlag:
+			f.EnvVars = appendEnvVar(f.EnvVars, envVar(f.Name))
+		}
+	}
+}
+
+// addCommandEnvVars lets the environment variables returned by envVar set the flags of the commands and their
+// subcommands.
+func addCommandEnvVars(commands []*cli.Command, envVar func(flag string) string) {
+	for _, c := range commands {
+		addEnvVars(c.Flags, envVar)
+		addCommandEnvVars(c.Subcommands, envVar)
+	}
+}
+
+// appendEnvVar appends the variable to vars unless they already have it.
+func appendEnvVar(vars []string, v string) []string {
+	for _, existing := range vars {
+		if existing == v {
+			return vars
+		}
+	}
+	return append(vars, v)
+}
+
+// unsetEmptyActionInputs removes the variables of inputs which are not given. GitHub Actions sets them to an
+// empty string, which would otherwise override the default values of the flags.
+func unsetEmptyActionInputs(flags []cli.Flag) {
+	for _, f := range flags {
+		for _, name := range f.Names() {
+			key := actionInputEnvVar(name)
+			if v, ok := os.LookupEnv(key); ok && v == "" {
+				os.Unsetenv(key)
+			}
+		}
+	}
+}
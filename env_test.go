@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func Test_addEnvVars(t *testing.T) {
+	tests := []struct {
+		name   string
+		envVar func(string) string
+		env    map[string]string
+	}{
+		{
+			name:   "COB_* variables",
+			envVar: cobEnvVar,
+			env: map[string]string{
+				"COB_THRESHOLD":       "0.5",
+				"COB_BENCH_ARGS":      "test -bench Foo .",
+				"COB_ONLY_DEGRESSION": "true",
+			},
+		},
+		{
+			name:   "Drone plugin settings",
+			envVar: pluginEnvVar,
+			env: map[string]string{
+				"PLUGIN_THRESHOLD":       "0.5",
+				"PLUGIN_BENCH_ARGS":      "test -bench Foo .",
+				"PLUGIN_ONLY_DEGRESSION": "true",
+			},
+		},
+		{
+			name:   "GitHub Actions inputs",
+			envVar: actionInputEnvVar,
+			env: map[string]string{
+				"INPUT_THRESHOLD":       "0.5",
+				"INPUT_BENCH-ARGS":      "test -bench Foo .",
+				"INPUT_ONLY-DEGRESSION": "true",
+				"INPUT_BASE":            "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+			}
+			defer func() {
+				for k := range tt.env {
+					os.Unsetenv(k)
+				}
+			}()
+
+			var got config
+			app := &cli.App{
+				Flags: []cli.Flag{
+					&cli.Float64Flag{Name: "threshold", Value: 0.2},
+					&cli.StringFlag{Name: "base", Value: "HEAD~1"},
+					&cli.StringFlag{Name: "bench-args"},
+					&cli.BoolFlag{Name: "only-degression"},
+				},
+				Action: func(c *cli.Context) error {
+					got = config{
+						threshold:      c.Float64("threshold"),
+						base:           c.String("base"),
+						benchArgs:      []string{c.String("bench-args")},
+						onlyDegression: c.Bool("only-degression"),
+					}
+					return nil
+				},
+			}
+			addEnvVars(app.Flags, tt.envVar)
+			unsetEmptyActionInputs(app.Flags)
+			require.NoError(t, app.Run([]string{"cob"}))
+			assert.Equal(t, config{threshold: 0.5, base: "HEAD~1", benchArgs: []string{"test -bench Foo ."}, onlyDegression: true}, got)
+		})
+	}
+}
+
+func Test_addCommandEnvVars(t *testing.T) {
+	os.Setenv("COB_STARTUP_ARG", "-a,-b")
+	os.Setenv("COB_STARTUP_TIMEOUT", "5s")
+	defer os.Unsetenv("COB_STARTUP_ARG")
+	defer os.Unsetenv("COB_STARTUP_TIMEOUT")
+
+	var got config
+	app := &cli.App{
+		Commands: []*cli.Command{{
+			Name: "history",
+			Subcommands: []*cli.Command{{
+				Name: "show",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{Name: "startup-arg"},
+					&cli.DurationFlag{Name: "startup-timeout", Value: 30 * time.Second},
+				},
+				Action: func(c *cli.Context) error {
+					got = config{startupArgs: c.StringSlice("startup-arg"), startupTimeout: c.Duration("startup-timeout")}
+					return nil
+				},
+			}},
+		}},
+	}
+	addCommandEnvVars(app.Commands, cobEnvVar)
+	require.NoError(t, app.Run([]string{"cob", "history", "show"}))
+	assert.Equal(t, config{startupArgs: []string{"-a", "-b"}, startupTimeout: 5 * time.Second}, got)
+}
+
+func Test_addEnvVars_shared(t *testing.T) {
+	// The flags of "cob run" are the global flags as well.
+	flag := &cli.StringFlag{Name: "bench-args"}
+	flags := []cli.Flag{flag}
+	commands := []*cli.Command{{Name: "run", Flags: flags}}
+	addEnvVars(flags, cobEnvVar)
+	addCommandEnvVars(commands, cobEnvVar)
+	addEnvVars(flags, pluginEnvVar)
+	assert.Equal(t, []string{"COB_BENCH_ARGS", "PLUGIN_BENCH_ARGS"}, flag.EnvVars)
+}
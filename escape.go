@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/olekukonko/tablewriter"
+)
+
+var (
+	// escapesInRegexp matches the explanation of an escape with -m=2, which names the function, e.g.
+	// "make([]byte, n) escapes to heap in grow:".
+	escapesInRegexp = regexp.MustCompile(`^(.+) escapes to heap in (\S+):$`)
+	escapesRegexp   = regexp.MustCompile(`^(.+) escapes to heap$`)
+	movedRegexp     = regexp.MustCompile(`^moved to heap: (\S+)$`)
+)
+
+// heapValue is a value which the compiler allocates on the heap.
+type heapValue struct {
+	function string
+	value    string
+}
+
+// heapValues returns the positions of the values allocated on the heap by value, in the order the compiler
+// reported them. Positions change with any edit above them, so values are compared by function and expression.
+func heapValues(diags []compilerDiagnostic) map[heapValue][]string {
+	// functions are the functions of the escapes by position.
+	functions := map[string]string{}
+	for _, d := range diags {
+		if m := escapesInRegexp.FindStringSubmatch(d.message); m != nil {
+			functions[d.pos] = m[2]
+		}
+	}
+	values := map[heapValue][]string{}
+	for _, d := range diags {
+		var value string
+		if m := movedRegexp.FindStringSubmatch(d.message); m != nil {
+			value = m[1]
+		} else if m := escapesRegexp.FindStringSubmatch(d.message); m != nil {
+			value = m[1]
+		} else {
+			continue
+		}
+		v := heapValue{function: functions[d.pos], value: value}
+		values[v] = append(values[v], d.pos)
+	}
+	return values
+}
+
+// newEscapes returns the values which escape to the heap at HEAD, but didn't at the base commit. Each new heap
+// allocation adds to B/op and allocs/op of the benchmarks which run it. A value which escapes at more places than
+// before is reported at the places after those of the base commit.
+func newEscapes(pkgs []string, base, head map[string][]compilerDiagnostic) []report.Escape {
+	var escapes []report.Escape
+	for _, pkg := range pkgs {
+		if _, ok := base[pkg]; !ok {
+			continue
+		}
+		baseValues, headValues := heapValues(base[pkg]), heapValues(head[pkg])
+		for v, positions := range headValues {
+			if n := len(baseValues[v]); n < len(positions) {
+				positions = positions[n:]
+			} else {
+				continue
+			}
+			for _, pos := range positions {
+				escapes = append(escapes, report.Escape{Package: pkg, Function: v.function, Value: v.value, Position: pos})
+			}
+		}
+	}
+	sort.Slice(escapes, func(i, j int) bool {
+		if escapes[i].Package != escapes[j].Package {
+			return escapes[i].Package < escapes[j].Package
+		}
+		if escapes[i].Position != escapes[j].Position {
+			return escapes[i].Position < escapes[j].Position
+		}
+		return escapes[i].Value < escapes[j].Value
+	})
+	return escapes
+}
+
+func showEscapes(w io.Writer, escapes []report.Escape, style string) {
+	if len(escapes) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nNew heap allocations")
+	fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 20))
+
+	table := tablewriter.NewWriter(w)
+	table.SetAutoFormatHeaders(false)
+	applyTableStyle(table, style)
+	table.SetHeader([]string{"Value", "Function", "Position"})
+	for _, e := range escapes {
+		fn := e.Function
+		if fn == "" {
+			fn = "-"
+		}
+		table.Append([]string{e.Value, fn, e.Position})
+	}
+	table.Render()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newEscapes(t *testing.T) {
+	base := map[string][]compilerDiagnostic{"./pkg": parseDiagnostics(strings.NewReader(`# example.com/pkg
+pkg/a.go:7:33: make([]byte, n) escapes to heap in grow:
+pkg/a.go:7:33:   flow: ~r0 ← &{storage for make([]byte, n)}:
+pkg/a.go:7:33: make([]byte, n) escapes to heap
+pkg/a.go:9:13: b does not escape
+`))}
+	head := map[string][]compilerDiagnostic{"./pkg": parseDiagnostics(strings.NewReader(`# example.com/pkg
+pkg/a.go:8:33: make([]byte, n) escapes to heap in grow:
+pkg/a.go:8:33: make([]byte, n) escapes to heap
+pkg/a.go:10:2: x escapes to heap in keep:
+pkg/a.go:10:2: moved to heap: x
+pkg/a.go:12:7: n escapes to heap
+pkg/a.go:14:33: make([]byte, n) escapes to heap in grow:
+pkg/a.go:14:33: make([]byte, n) escapes to heap
+`))}
+	assert.Equal(t, []report.Escape{
+		{Package: "./pkg", Function: "keep", Value: "x", Position: "pkg/a.go:10:2"},
+		{Package: "./pkg", Value: "n", Position: "pkg/a.go:12:7"},
+		{Package: "./pkg", Function: "grow", Value: "make([]byte, n)", Position: "pkg/a.go:14:33"},
+	}, newEscapes([]string{"./pkg"}, base, head))
+	assert.Nil(t, newEscapes([]string{"./pkg"}, nil, head))
+}
+
+func Test_showEscapes(t *testing.T) {
+	w := &bytes.Buffer{}
+	showEscapes(w, []report.Escape{
+		{Package: "./pkg", Function: "keep", Value: "x", Position: "pkg/a.go:10:2"},
+		{Package: "./pkg", Value: "n", Position: "pkg/a.go:12:7"},
+	}, "ascii")
+	got := w.String()
+	assert.Contains(t, got, "New heap allocations\n====================")
+	assert.Contains(t, got, "| x     | keep     | pkg/a.go:10:2 |")
+	assert.Contains(t, got, "| n     | -        | pkg/a.go:12:7 |")
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"regexp"
+
+	"golang.org/x/xerrors"
+)
+
+// compileExcludes compiles the regular expressions of -exclude.
+func compileExcludes(patterns []string) ([]*regexp.Regexp, error) {
+	var excludes []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid --exclude: %w", err)
+		}
+		excludes = append(excludes, re)
+	}
+	return excludes, nil
+}
+
+// isExcluded returns whether the name of the benchmark, e.g. "BenchmarkFoo/bar-8", matches any of excludes.
+func isExcluded(name string, excludes []*regexp.Regexp) bool {
+	for _, re := range excludes {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_isExcluded(t *testing.T) {
+	excludes, err := compileExcludes([]string{"^BenchmarkNoisy", "/network-"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "BenchmarkNoisy-8", want: true},
+		{name: "BenchmarkNoisyChannel/small-8", want: true},
+		{name: "BenchmarkFetch/network-8", want: true},
+		{name: "BenchmarkFetch/disk-8", want: false},
+		{name: "BenchmarkNotNoisy-8", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isExcluded(tt.name, excludes))
+		})
+	}
+	assert.False(t, isExcluded("BenchmarkNoisy-8", nil))
+
+	_, err = compileExcludes([]string{"Benchmark("})
+	assert.Error(t, err)
+}
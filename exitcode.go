@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/xerrors"
+)
+
+// The exit codes tell a run which found a regression from one which couldn't compare, e.g. because git, the
+// benchmarks or a reporter failed, so that CI can retry the latter or alert someone else than the author.
+const (
+	exitRegression = 1
+	exitError      = 2
+)
+
+// regressionError is the error of a commit which makes the benchmarks, the tests or the coverage worse.
+type regressionError struct {
+	msg string
+}
+
+func (e *regressionError) Error() string {
+	return e.msg
+}
+
+func regressionf(format string, a ...interface{}) error {
+	return &regressionError{msg: fmt.Sprintf(format, a...)}
+}
+
+// exitCode returns the exit code for the error returned by the app.
+func exitCode(err error) int {
+	var r *regressionError
+	if xerrors.As(err, &r) {
+		return exitRegression
+	}
+	return exitError
+}
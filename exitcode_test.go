@@ -0,0 +1,15 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
+)
+
+func Test_exitCode(t *testing.T) {
+	assert.Equal(t, exitRegression, exitCode(regressionf("This commit makes tests slower by %.2f%%", 12.5)))
+	assert.Equal(t, exitRegression, exitCode(xerrors.Errorf("wrapped: %w", regressionf("This commit makes benchmarks worse"))))
+	assert.Equal(t, exitError, exitCode(xerrors.New("failed to run a benchmark")))
+	assert.Equal(t, "This commit makes tests slower by 12.50%", regressionf("This commit makes tests slower by %.2f%%", 12.5).Error())
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+var historyExportCommand = &cli.Command{
+	Name:  "export",
+	Usage: "Export all the recorded results for analysis in other tools",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "store",
+			Usage: "Store the runs were recorded in with '-store'",
+			Value: defaultStore,
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format (csv, parquet)",
+			Value: "csv",
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "File to write to ('-' for stdout)",
+			Value: "-",
+		},
+		&cli.StringFlag{
+			Name:  "machine",
+			Usage: "Export only runs on machines with the tag given by '-machine'",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.String("store") == "" {
+			return xerrors.New("-store is required")
+		}
+		format := c.String("format")
+		if format != "csv" && format != "parquet" {
+			return xerrors.Errorf("unknown output format: %s", format)
+		}
+		st, err := openStore(c.String("store"))
+		if err != nil {
+			return xerrors.Errorf("failed to open the store: %w", err)
+		}
+		runs, err := st.runs()
+		if err != nil {
+			return xerrors.Errorf("failed to read the history: %w", err)
+		}
+		if c.IsSet("machine") {
+			runs = runsOnMachine(runs, c.String("machine"))
+		}
+
+		w := io.Writer(os.Stdout)
+		if output := c.String("output"); output != "-" {
+			f, err := os.Create(output)
+			if err != nil {
+				return xerrors.Errorf("failed to create %s: %w", output, err)
+			}
+			defer f.Close()
+			w = f
+		}
+		columns := exportColumns(runs)
+		if format == "parquet" {
+			return writeParquet(w, columns)
+		}
+		return writeExportCSV(w, columns)
+	},
+}
+
+// exportColumn is a column of the exported dataset, which has a row per benchmark of each run.
+type exportColumn struct {
+	name  string
+	kind  parquetKind
+	value func(run historyRun, b report.Benchmark) interface{}
+}
+
+var exportSchema = []exportColumn{
+	{"time", parquetTimestamp, func(run historyRun, _ report.Benchmark) interface{} { return run.Time.UTC() }},
+	{"commit", parquetString, func(run historyRun, _ report.Benchmark) interface{} { return run.Report.Head.Hash }},
+	{"branch", parquetString, func(run historyRun, _ report.Benchmark) interface{} { return run.Report.Head.Branch }},
+	{"base_commit", parquetString, func(run historyRun, _ report.Benchmark) interface{} { return run.Report.Base.Hash }},
+	{"hostname", parquetString, func(run historyRun, _ report.Benchmark) interface{} { return run.Machine.Hostname }},
+	{"os", parquetString, func(run historyRun, _ report.Benchmark) interface{} { return run.Machine.OS }},
+	{"arch", parquetString, func(run historyRun, _ report.Benchmark) interface{} { return run.Machine.Arch }},
+	{"cpus", parquetInt64, func(run historyRun, _ report.Benchmark) interface{} { return int64(run.Machine.CPUs) }},
+	{"go_version", parquetString, func(run historyRun, _ report.Benchmark) interface{} { return run.Machine.GoVersion }},
+	{"machine_tag", parquetString, func(run historyRun, _ report.Benchmark) interface{} { return run.Machine.Tag }},
+	{"rollup", parquetInt64, func(run historyRun, _ report.Benchmark) interface{} { return int64(run.Rollup) }},
+	{"benchmark", parquetString, func(_ historyRun, b report.Benchmark) interface{} { return b.Name }},
+	{"status", parquetString, func(_ historyRun, b report.Benchmark) interface{} { return b.Status }},
+	{"iterations", parquetInt64, func(_ historyRun, b report.Benchmark) interface{} { return int64(b.Head.Iterations) }},
+	{"ns_per_op", parquetDouble, func(_ historyRun, b report.Benchmark) interface{} { return b.Head.NsPerOp }},
+	{"alloced_bytes_per_op", parquetInt64, func(_ historyRun, b report.Benchmark) interface{} { return int64(b.Head.AllocedBytesPerOp) }},
+	{"allocs_per_op", parquetInt64, func(_ historyRun, b report.Benchmark) interface{} { return int64(b.Head.AllocsPerOp) }},
+	{"mb_per_s", parquetDouble, func(_ historyRun, b report.Benchmark) interface{} { return b.Head.MBPerS }},
+	{"base_ns_per_op", parquetDouble, func(_ historyRun, b report.Benchmark) interface{} { return b.Base.NsPerOp }},
+	{"base_alloced_bytes_per_op", parquetInt64, func(_ historyRun, b report.Benchmark) interface{} { return int64(b.Base.AllocedBytesPerOp) }},
+	{"base_allocs_per_op", parquetInt64, func(_ historyRun, b report.Benchmark) interface{} { return int64(b.Base.AllocsPerOp) }},
+	{"ratio_ns_per_op", parquetDouble, func(_ historyRun, b report.Benchmark) interface{} { return b.Ratio.NsPerOp }},
+	{"ratio_alloced_bytes_per_op", parquetDouble, func(_ historyRun, b report.Benchmark) interface{} { return b.Ratio.AllocedBytesPerOp }},
+	{"ratio_allocs_per_op", parquetDouble, func(_ historyRun, b report.Benchmark) interface{} { return b.Ratio.AllocsPerOp }},
+}
+
+// exportColumns flattens the runs into columns. Base values are 0 for runs without a comparison, e.g. rollups
+// and imported results.
+func exportColumns(runs []historyRun) []parquetColumn {
+	columns := make([]parquetColumn, len(exportSchema))
+	for i, col := range exportSchema {
+		columns[i] = parquetColumn{name: col.name, kind: col.kind, values: []interface{}{}}
+	}
+	for _, run := range runs {
+		for _, b := range run.Report.Benchmarks {
+			for i, col := range exportSchema {
+				columns[i].values = append(columns[i].values, col.value(run, b))
+			}
+		}
+	}
+	return columns
+}
+
+func writeExportCSV(w io.Writer, columns []parquetColumn) error {
+	cw := csv.NewWriter(w)
+	var header []string
+	for _, c := range columns {
+		header = append(header, c.name)
+	}
+	_ = cw.Write(header)
+	if len(columns) > 0 {
+		for i := range columns[0].values {
+			var record []string
+			for _, c := range columns {
+				record = append(record, formatExportValue(c.values[i]))
+			}
+			_ = cw.Write(record)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return xerrors.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+func formatExportValue(v interface{}) string {
+	switch v := v.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	}
+	return ""
+}
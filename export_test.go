@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_exportColumns(t *testing.T) {
+	runs := testHistoryRuns()
+	runs[0].Machine.Tag = "ci-large"
+	runs[0].Rollup = 3
+	columns := exportColumns(runs)
+	require.Len(t, columns, len(exportSchema))
+
+	row := map[string]interface{}{}
+	for _, c := range columns {
+		require.Len(t, c.values, 6)
+		row[c.name] = c.values[0]
+	}
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), row["time"])
+	assert.Equal(t, "aaaaaaaaaa", row["commit"])
+	assert.Equal(t, "ci-1", row["hostname"])
+	assert.Equal(t, "ci-large", row["machine_tag"])
+	assert.Equal(t, int64(3), row["rollup"])
+	assert.Equal(t, "BenchmarkA", row["benchmark"])
+	assert.Equal(t, 100.0, row["ns_per_op"])
+	assert.Equal(t, int64(16), row["alloced_bytes_per_op"])
+
+	// Every value must be encodable in Parquet.
+	require.NoError(t, writeParquet(&bytes.Buffer{}, columns))
+}
+
+func Test_writeExportCSV(t *testing.T) {
+	w := &bytes.Buffer{}
+	require.NoError(t, writeExportCSV(w, exportColumns(testHistoryRuns()[:1])))
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "time,commit,branch,base_commit,hostname,"))
+	assert.Equal(t, "2020-01-02T03:04:05Z,aaaaaaaaaa,main,,ci-1,,,0,,,0,BenchmarkA,ok,0,100,16,1,0,0,0,0,0,0,0", lines[1])
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"regexp"
+
+	"github.com/knqyf263/cob/pkg/cob"
+	"golang.org/x/tools/benchmark/parse"
+)
+
+// regressionCheck tells whether a benchmark at HEAD got worse than its threshold, like it would fail at the end.
+type regressionCheck struct {
+	prevSet     parse.Set
+	annotations map[string]benchmarkAnnotation
+	excludes    []*regexp.Regexp
+	threshold   float64
+	score       comparedScore
+	alpha       float64
+}
+
+// regressed returns whether the runs of the benchmark at HEAD got worse than its threshold. Excluded and skipped
+// benchmarks and those without a baseline never regress.
+func (r regressionCheck) regressed(name string, headRuns []*parse.Benchmark) bool {
+	prevRuns, ok := r.prevSet[name]
+	if !ok || len(prevRuns) == 0 || len(headRuns) == 0 {
+		return false
+	}
+	annotation := annotationOf(r.annotations, name)
+	if isExcluded(name, r.excludes) || annotation.skip {
+		return false
+	}
+	return isDegression(cob.NewResult(name, prevRuns, headRuns, annotation.threshold, r.alpha), r.threshold, r.score)
+}
+
+// stopAtRegression returns the stop function of runBenchmarkUntil for -fail-fast. A benchmark is compared as soon as
+// it ran as many times as at the base commit, e.g. with -count, and the run stops if it got worse than its
+// threshold. Benchmarks in ignored, e.g. whose regression wasn't confirmed, don't stop the run.
+func stopAtRegression(check regressionCheck, ignored map[string]bool) func(parse.Set, string) bool {
+	return func(headSet parse.Set, name string) bool {
+		if ignored[name] || len(headSet[name]) < len(check.prevSet[name]) || !check.regressed(name, headSet[name]) {
+			return false
+		}
+		infof("%s got worse than the threshold", name)
+		return true
+	}
+}
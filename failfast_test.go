@@ -0,0 +1,38 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/benchmark/parse"
+)
+
+func Test_stopAtRegression(t *testing.T) {
+	prevSet := parse.Set{
+		"BenchmarkA-8": {{Name: "BenchmarkA-8", NsPerOp: 100}, {Name: "BenchmarkA-8", NsPerOp: 100}},
+		"BenchmarkB-8": {{Name: "BenchmarkB-8", NsPerOp: 100}},
+		"BenchmarkC-8": {{Name: "BenchmarkC-8", NsPerOp: 100}},
+	}
+	annotations := map[string]benchmarkAnnotation{"BenchmarkC": {threshold: floatPtr(1)}}
+	excludes := []*regexp.Regexp{regexp.MustCompile("^BenchmarkB")}
+	check := regressionCheck{prevSet: prevSet, annotations: annotations, excludes: excludes, threshold: 0.2,
+		score: comparedScore{nsPerOp: true}}
+	stop := stopAtRegression(check, map[string]bool{"BenchmarkIgnored-8": true})
+
+	headSet := parse.Set{"BenchmarkA-8": {{Name: "BenchmarkA-8", NsPerOp: 200}}}
+	assert.False(t, stop(headSet, "BenchmarkA-8"), "not all runs yet")
+	headSet["BenchmarkA-8"] = append(headSet["BenchmarkA-8"], &parse.Benchmark{Name: "BenchmarkA-8", NsPerOp: 200})
+	assert.True(t, stop(headSet, "BenchmarkA-8"))
+
+	headSet["BenchmarkB-8"] = []*parse.Benchmark{{Name: "BenchmarkB-8", NsPerOp: 200}}
+	assert.False(t, stop(headSet, "BenchmarkB-8"), "excluded")
+	headSet["BenchmarkC-8"] = []*parse.Benchmark{{Name: "BenchmarkC-8", NsPerOp: 150}}
+	assert.False(t, stop(headSet, "BenchmarkC-8"), "within the threshold of the annotation")
+	headSet["BenchmarkNew-8"] = []*parse.Benchmark{{Name: "BenchmarkNew-8", NsPerOp: 150}}
+	assert.False(t, stop(headSet, "BenchmarkNew-8"), "no baseline")
+
+	check.prevSet["BenchmarkIgnored-8"] = []*parse.Benchmark{{Name: "BenchmarkIgnored-8", NsPerOp: 100}}
+	headSet["BenchmarkIgnored-8"] = []*parse.Benchmark{{Name: "BenchmarkIgnored-8", NsPerOp: 200}}
+	assert.False(t, stop(headSet, "BenchmarkIgnored-8"), "ignored")
+}
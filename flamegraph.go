@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+// Dimensions of a flamegraph in pixels.
+const (
+	flamegraphWidth       = 1200
+	flamegraphFrameHeight = 16
+	flamegraphTitleHeight = 32
+	// Frames narrower than this are not drawn, and frames narrower than flamegraphMinLabelWidth have no label.
+	flamegraphMinWidth      = 0.5
+	flamegraphMinLabelWidth = 35
+	flamegraphCharWidth     = 7
+)
+
+// flameNode is a frame of a flamegraph: the total value of the samples whose stacks go through it.
+type flameNode struct {
+	name     string
+	value    int64
+	children map[string]*flameNode
+}
+
+func (n *flameNode) child(name string) *flameNode {
+	c, ok := n.children[name]
+	if !ok {
+		c = &flameNode{name: name, children: map[string]*flameNode{}}
+		n.children[name] = c
+	}
+	return c
+}
+
+func (n *flameNode) depth() int {
+	d := 0
+	for _, c := range n.children {
+		if cd := c.depth(); cd > d {
+			d = cd
+		}
+	}
+	return d + 1
+}
+
+// sortedChildren orders the frames by name, like the original flamegraph.pl, so that the graphs of both
+// commits are laid out in the same way.
+func (n *flameNode) sortedChildren() []*flameNode {
+	var children []*flameNode
+	for _, c := range n.children {
+		children = append(children, c)
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].name < children[j].name })
+	return children
+}
+
+// newFlameTree merges the stacks of the samples, weighted by the value of sampleType.
+func newFlameTree(p pprofProfile, sampleType string) (*flameNode, error) {
+	i := p.valueIndex(sampleType)
+	if i < 0 {
+		return nil, xerrors.Errorf("no %s samples in the profile", sampleType)
+	}
+	root := &flameNode{name: "all", children: map[string]*flameNode{}}
+	for _, s := range p.samples {
+		if i >= len(s.values) || s.values[i] == 0 {
+			continue
+		}
+		v := s.values[i]
+		root.value += v
+		n := root
+		for j := len(s.stack) - 1; j >= 0; j-- {
+			n = n.child(s.stack[j])
+			n.value += v
+		}
+	}
+	return root, nil
+}
+
+// writeFlamegraph renders the profile as an SVG flamegraph: callers are below their callees, and the width of
+// a frame is its share of the samples.
+func writeFlamegraph(profilePath, svgPath, sampleType, title string) error {
+	p, err := readPprofFile(profilePath)
+	if err != nil {
+		return err
+	}
+	root, err := newFlameTree(p, sampleType)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(svgPath, renderFlamegraph(root, title), 0644); err != nil {
+		return xerrors.Errorf("failed to write the flamegraph: %w", err)
+	}
+	return nil
+}
+
+func renderFlamegraph(root *flameNode, title string) []byte {
+	height := flamegraphTitleHeight + root.depth()*flamegraphFrameHeight + 8
+	w := &bytes.Buffer{}
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Verdana, sans-serif" font-size="12">
+<rect width="100%%" height="100%%" fill="#f8f8f8"/>
+<text x="%d" y="20" text-anchor="middle" font-size="16">%s</text>
+`, flamegraphWidth, height, flamegraphWidth, height, flamegraphWidth/2, html.EscapeString(title))
+	if root.value > 0 {
+		scale := float64(flamegraphWidth-20) / float64(root.value)
+		renderFlameNode(w, root, root.value, 10, float64(height-8-flamegraphFrameHeight), scale)
+	}
+	fmt.Fprintln(w, "</svg>")
+	return w.Bytes()
+}
+
+func renderFlameNode(w *bytes.Buffer, n *flameNode, total int64, x, y, scale float64) {
+	width := float64(n.value) * scale
+	if width < flamegraphMinWidth {
+		return
+	}
+	name := html.EscapeString(n.name)
+	fmt.Fprintf(w, `<g><title>%s (%.2f%%)</title><rect x="%.1f" y="%.1f" width="%.1f" height="%d" fill="%s" rx="2"/>`,
+		name, 100*float64(n.value)/float64(total), x, y, width, flamegraphFrameHeight-1, flameColor(n.name))
+	if width >= flamegraphMinLabelWidth {
+		label := n.name
+		if max := int(width-6) / flamegraphCharWidth; len(label) > max {
+			label = label[:max-2] + ".."
+		}
+		fmt.Fprintf(w, `<text x="%.1f" y="%.1f">%s</text>`, x+3, y+float64(flamegraphFrameHeight)-4, html.EscapeString(label))
+	}
+	fmt.Fprintln(w, "</g>")
+	for _, c := range n.sortedChildren() {
+		renderFlameNode(w, c, total, x, y-flamegraphFrameHeight, scale)
+		x += float64(c.value) * scale
+	}
+}
+
+// flameColor picks a warm color derived from the name, so that a function has the same color in both graphs.
+func flameColor(name string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	v := h.Sum32()
+	return fmt.Sprintf("rgb(%d,%d,%d)", 205+v%50, 60+(v>>8)%170, (v>>16)%55)
+}
+
+// flamegraphLink is where the flamegraphs of a profile are published, for PR comments.
+type flamegraphLink struct {
+	name string
+	kind string
+	base string
+	head string
+}
+
+// flamegraphLinks returns the URLs of the flamegraphs under baseURL, which is where dir is published. There are no
+// links without baseURL, because the files only exist on the runner.
+func flamegraphLinks(profiles []report.Profile, dir, baseURL string) []flamegraphLink {
+	if baseURL == "" {
+		return nil
+	}
+	url := func(path string) string {
+		if path == "" {
+			return ""
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSuffix(baseURL, "/") + "/" + filepath.ToSlash(rel)
+	}
+	var links []flamegraphLink
+	for _, p := range profiles {
+		l := flamegraphLink{name: p.Name, kind: p.Kind, base: url(p.BaseFlamegraph), head: url(p.HeadFlamegraph)}
+		if l.base != "" || l.head != "" {
+			links = append(links, l)
+		}
+	}
+	return links
+}
+
+// relativeFlamegraphs returns the profiles with the paths of their flamegraphs relative to dir, so that the
+// links of a report written to dir still work when the directory is moved, e.g. as a CI artifact.
+func relativeFlamegraphs(profiles []report.Profile, dir string) []report.Profile {
+	rel := func(path string) string {
+		if path == "" {
+			return ""
+		}
+		r, err := filepath.Rel(dir, path)
+		if err != nil {
+			return path
+		}
+		return filepath.ToSlash(r)
+	}
+	var relative []report.Profile
+	for _, p := range profiles {
+		p.BaseFlamegraph = rel(p.BaseFlamegraph)
+		p.HeadFlamegraph = rel(p.HeadFlamegraph)
+		relative = append(relative, p)
+	}
+	return relative
+}
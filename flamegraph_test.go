@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_writeFlamegraph(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	profile := filepath.Join(dir, "BenchmarkA.mem.pprof")
+	require.NoError(t, ioutil.WriteFile(profile, testMemProfile(t, map[string]int64{"pkg.grow": 3072, "pkg.<lambda>": 1024}), 0644))
+
+	svg := filepath.Join(dir, "BenchmarkA.mem.svg")
+	require.NoError(t, writeFlamegraph(profile, svg, "alloc_space", "BenchmarkA-8 (mem, HEAD)"))
+	b, err := ioutil.ReadFile(svg)
+	require.NoError(t, err)
+	got := string(b)
+	assert.Contains(t, got, `<text x="600" y="20" text-anchor="middle" font-size="16">BenchmarkA-8 (mem, HEAD)</text>`)
+	assert.Contains(t, got, `<title>all (100.00%)</title><rect x="10.0" y="64.0" width="1180.0"`)
+	assert.Contains(t, got, `<title>main (100.00%)</title><rect x="10.0" y="48.0" width="1180.0"`)
+	// Frames are ordered by name: pkg.&lt;lambda&gt; before pkg.grow.
+	assert.Contains(t, got, `<title>pkg.&lt;lambda&gt; (25.00%)</title><rect x="10.0" y="32.0" width="295.0"`)
+	assert.Contains(t, got, `<title>pkg.grow (75.00%)</title><rect x="305.0" y="32.0" width="885.0"`)
+
+	assert.Error(t, writeFlamegraph(profile, svg, "cpu", "BenchmarkA-8 (cpu, HEAD)"))
+}
+
+func Test_flamegraphLinks(t *testing.T) {
+	profiles := []report.Profile{
+		{Name: "BenchmarkA-8", Kind: "cpu", BaseFlamegraph: "out/profiles/base/BenchmarkA.cpu.svg", HeadFlamegraph: "out/profiles/head/BenchmarkA.cpu.svg"},
+		{Name: "BenchmarkB-8", Kind: "cpu", HeadFlamegraph: "out/profiles/head/BenchmarkB.cpu.svg"},
+		{Name: "BenchmarkC-8", Kind: "cpu", Head: "out/profiles/head/BenchmarkC.cpu.pprof"},
+	}
+	assert.Nil(t, flamegraphLinks(profiles, "out/profiles", ""))
+	assert.Equal(t, []flamegraphLink{
+		{name: "BenchmarkA-8", kind: "cpu", base: "https://example.com/run/1/base/BenchmarkA.cpu.svg", head: "https://example.com/run/1/head/BenchmarkA.cpu.svg"},
+		{name: "BenchmarkB-8", kind: "cpu", head: "https://example.com/run/1/head/BenchmarkB.cpu.svg"},
+	}, flamegraphLinks(profiles, "out/profiles", "https://example.com/run/1/"))
+
+	rel := relativeFlamegraphs(profiles, "out")
+	assert.Equal(t, "profiles/base/BenchmarkA.cpu.svg", rel[0].BaseFlamegraph)
+	assert.Equal(t, "profiles/head/BenchmarkB.cpu.svg", rel[1].HeadFlamegraph)
+	assert.Equal(t, "", rel[2].HeadFlamegraph)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	gcpStorageScope = "https://www.googleapis.com/auth/devstorage.read_write"
+	gcpTokenURL     = "https://oauth2.googleapis.com/token"
+)
+
+// gcpCredentialsFile is a service account key or the user credentials written by
+// "gcloud auth application-default login".
+type gcpCredentialsFile struct {
+	Type string `json:"type"`
+
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// newGCPTokenSource looks up Application Default Credentials in the same order as the Google Cloud client
+// libraries: the file of GOOGLE_APPLICATION_CREDENTIALS, the gcloud user credentials and the metadata server.
+func newGCPTokenSource(client *http.Client, scope string) (*tokenSource, error) {
+	ts := &tokenSource{now: time.Now}
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		if p := gcloudCredentialsPath(); p != "" {
+			if _, err := os.Stat(p); err == nil {
+				path = p
+			}
+		}
+	}
+	if path == "" {
+		ts.fetch = func() (oauthToken, error) { return gcpMetadataToken(client) }
+		return ts, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read the credentials: %w", err)
+	}
+	var f gcpCredentialsFile
+	if err = json.Unmarshal(b, &f); err != nil {
+		return nil, xerrors.Errorf("failed to decode %s: %w", path, err)
+	}
+	switch f.Type {
+	case "service_account":
+		key, err := parseRSAPrivateKey(f.PrivateKey)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid private key in %s: %w", path, err)
+		}
+		if f.TokenURI == "" {
+			f.TokenURI = gcpTokenURL
+		}
+		ts.fetch = func() (oauthToken, error) {
+			assertion, err := signJWT(key, map[string]interface{}{
+				"iss":   f.ClientEmail,
+				"scope": scope,
+				"aud":   f.TokenURI,
+				"iat":   ts.now().Unix(),
+				"exp":   ts.now().Add(time.Hour).Unix(),
+			})
+			if err != nil {
+				return oauthToken{}, err
+			}
+			return postTokenRequest(client, f.TokenURI, url.Values{
+				"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+				"assertion":  {assertion},
+			})
+		}
+	case "authorized_user":
+		ts.fetch = func() (oauthToken, error) {
+			return postTokenRequest(client, gcpTokenURL, url.Values{
+				"grant_type":    {"refresh_token"},
+				"client_id":     {f.ClientID},
+				"client_secret": {f.ClientSecret},
+				"refresh_token": {f.RefreshToken},
+			})
+		}
+	default:
+		return nil, xerrors.Errorf("unsupported credentials type %q in %s", f.Type, path)
+	}
+	return ts, nil
+}
+
+func gcloudCredentialsPath() string {
+	dir := os.Getenv("CLOUDSDK_CONFIG")
+	if dir == "" {
+		if runtime.GOOS == "windows" {
+			dir = filepath.Join(os.Getenv("APPDATA"), "gcloud")
+		} else if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, ".config", "gcloud")
+		} else {
+			return ""
+		}
+	}
+	return filepath.Join(dir, "application_default_credentials.json")
+}
+
+// gcpMetadataToken gets a token of the attached service account from the metadata server of GCE, GKE or Cloud Run.
+func gcpMetadataToken(client *http.Client) (oauthToken, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+	req, err := http.NewRequest(http.MethodGet, "http://"+host+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return oauthToken{}, xerrors.Errorf("failed to create a request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := client.Do(req)
+	if err != nil {
+		return oauthToken{}, xerrors.New("no Google Cloud credentials found: set GOOGLE_APPLICATION_CREDENTIALS")
+	}
+	return decodeToken(resp)
+}
+
+func parseRSAPrivateKey(s string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, xerrors.New("no PEM block")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, xerrors.New("not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// signJWT returns the claims as a JWT signed with RS256.
+func signJWT(key *rsa.PrivateKey, claims map[string]interface{}) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", xerrors.Errorf("failed to encode the claims: %w", err)
+	}
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + enc.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", xerrors.Errorf("failed to sign the JWT: %w", err)
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
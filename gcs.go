@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// gcsStorage is a bucket of Google Cloud Storage, accessed with the JSON API.
+type gcsStorage struct {
+	client  *http.Client
+	baseURL string
+	bucket  string
+	// tokens is nil when talking to an emulator.
+	tokens *tokenSource
+}
+
+// newGCSStorage authenticates with Application Default Credentials. STORAGE_EMULATOR_HOST points it at an
+// emulator such as fake-gcs-server, without authentication.
+func newGCSStorage(client *http.Client, bucket string) (*gcsStorage, error) {
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+		return &gcsStorage{client: client, baseURL: strings.TrimSuffix(host, "/"), bucket: bucket}, nil
+	}
+	tokens, err := newGCPTokenSource(client, gcpStorageScope)
+	if err != nil {
+		return nil, err
+	}
+	return &gcsStorage{client: client, baseURL: "https://storage.googleapis.com", bucket: bucket, tokens: tokens}, nil
+}
+
+func (s *gcsStorage) do(method, u string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create a request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.tokens != nil {
+		token, err := s.tokens.accessToken()
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	debugf("gcs: %s %s", method, req.URL.Path)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to send a request to Cloud Storage: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, errObjectNotFound
+	}
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		resp.Body.Close()
+		return nil, errObjectChanged
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		return nil, xerrors.Errorf("Cloud Storage returned %s for %s %s: %s", resp.Status, method, req.URL.Path, strings.TrimSpace(string(b)))
+	}
+	return resp, nil
+}
+
+func (s *gcsStorage) objectsURL() string {
+	return s.baseURL + "/storage/v1/b/" + url.PathEscape(s.bucket) + "/o"
+}
+
+func (s *gcsStorage) putObject(key string, body []byte) error {
+	return s.upload(url.Values{"uploadType": {"media"}, "name": {key}}, body)
+}
+
+// putObjectIf uses generations as versions. Generation 0 matches only an object which doesn't exist.
+func (s *gcsStorage) putObjectIf(key string, body []byte, version string) error {
+	if version == "" {
+		version = "0"
+	}
+	return s.upload(url.Values{"uploadType": {"media"}, "name": {key}, "ifGenerationMatch": {version}}, body)
+}
+
+func (s *gcsStorage) upload(query url.Values, body []byte) error {
+	resp, err := s.do(http.MethodPost, s.baseURL+"/upload/storage/v1/b/"+url.PathEscape(s.bucket)+"/o?"+query.Encode(), body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *gcsStorage) getObject(key string) ([]byte, error) {
+	b, _, err := s.getObjectVersion(key)
+	return b, err
+}
+
+func (s *gcsStorage) getObjectVersion(key string) ([]byte, string, error) {
+	resp, err := s.do(http.MethodGet, s.objectsURL()+"/"+url.PathEscape(key)+"?alt=media", nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	return b, resp.Header.Get("X-Goog-Generation"), err
+}
+
+func (s *gcsStorage) listObjects(prefix string) ([]string, error) {
+	var keys []string
+	var token string
+	for {
+		query := url.Values{"prefix": {prefix}, "fields": {"items(name),nextPageToken"}}
+		if token != "" {
+			query.Set("pageToken", token)
+		}
+		resp, err := s.do(http.MethodGet, s.objectsURL()+"?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Items []struct {
+				Name string `json:"name"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode the object list: %w", err)
+		}
+		for _, item := range result.Items {
+			keys = append(keys, item.Name)
+		}
+		if result.NextPageToken == "" {
+			return keys, nil
+		}
+		token = result.NextPageToken
+	}
+}
+
+func (s *gcsStorage) deleteObject(key string) error {
+	resp, err := s.do(http.MethodDelete, s.objectsURL()+"/"+url.PathEscape(key), nil)
+	if xerrors.Is(err, errObjectNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_gcpTokenSource_serviceAccount(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.PostForm.Get("grant_type"))
+
+		parts := strings.Split(r.PostForm.Get("assertion"), ".")
+		require.Len(t, parts, 3)
+		sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+		sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, sum[:], sig))
+
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+		var claims map[string]interface{}
+		require.NoError(t, json.Unmarshal(payload, &claims))
+		assert.Equal(t, "cob@project.iam.gserviceaccount.com", claims["iss"])
+		assert.Equal(t, gcpStorageScope, claims["scope"])
+
+		_, _ = w.Write([]byte(`{"access_token":"token","expires_in":3600,"token_type":"Bearer"}`))
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	creds, err := json.Marshal(gcpCredentialsFile{
+		Type:        "service_account",
+		ClientEmail: "cob@project.iam.gserviceaccount.com",
+		PrivateKey:  string(pemKey),
+		TokenURI:    ts.URL,
+	})
+	require.NoError(t, err)
+	path := filepath.Join(dir, "key.json")
+	require.NoError(t, ioutil.WriteFile(path, creds, 0600))
+	os.Setenv("GOOGLE_APPLICATION_CREDENTIALS", path)
+	defer os.Unsetenv("GOOGLE_APPLICATION_CREDENTIALS")
+
+	tokens, err := newGCPTokenSource(ts.Client(), gcpStorageScope)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		token, err := tokens.accessToken()
+		require.NoError(t, err)
+		assert.Equal(t, "token", token)
+	}
+	assert.Equal(t, 1, requests, "the token should be cached")
+
+	tokens.now = func() time.Time { return time.Now().Add(time.Hour) }
+	_, err = tokens.accessToken()
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests, "an expired token should be refreshed")
+}
+
+// fakeGCS serves the JSON API of Cloud Storage from memory.
+type fakeGCS struct {
+	mu          sync.Mutex
+	objects     map[string][]byte
+	generations map[string]int64
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/bucket/o":
+		name := r.URL.Query().Get("name")
+		if match := r.URL.Query().Get("ifGenerationMatch"); match != "" && match != strconv.FormatInt(f.generations[name], 10) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		f.objects[name] = b
+		f.generations[name]++
+	case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/b/bucket/o":
+		var names []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				names = append(names, k)
+			}
+		}
+		sort.Strings(names)
+		var items []map[string]string
+		for _, name := range names {
+			items = append(items, map[string]string{"name": name})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/storage/v1/b/bucket/o/"):
+		name := strings.TrimPrefix(r.URL.Path, "/storage/v1/b/bucket/o/")
+		b, ok := f.objects[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("X-Goog-Generation", strconv.FormatInt(f.generations[name], 10))
+		_, _ = w.Write(b)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func Test_objectStore_gcs(t *testing.T) {
+	fake := &fakeGCS{objects: map[string][]byte{}, generations: map[string]int64{}}
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	tokens := &tokenSource{
+		fetch: func() (oauthToken, error) {
+			return oauthToken{AccessToken: "token", Expiry: time.Now().Add(time.Hour)}, nil
+		},
+		now: time.Now,
+	}
+	s := objectStore{storage: &gcsStorage{client: ts.Client(), baseURL: ts.URL, bucket: "bucket", tokens: tokens}, prefix: "cob"}
+
+	run := historyRun{
+		Time:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Report: report.Report{SchemaVersion: 1, Head: report.Commit{Hash: "aaaaaaaa"}, Benchmarks: []report.Benchmark{{Name: "BenchmarkA"}}},
+	}
+	require.NoError(t, s.put(run))
+	assert.Contains(t, fake.objects, "cob/commits/aaaaaaaa.json")
+
+	runs, err := s.runs()
+	require.NoError(t, err)
+	assert.Equal(t, []historyRun{run}, runs)
+
+	latest, err := s.latest("aaaaaaaa", "")
+	require.NoError(t, err)
+	assert.Equal(t, &run, latest)
+	latest, err = s.latest("bbbbbbbb", "")
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+}
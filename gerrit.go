@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"golang.org/x/xerrors"
+)
+
+type gerritClient struct {
+	restClient
+}
+
+type gerritReviewInput struct {
+	Message string         `json:"message"`
+	Labels  map[string]int `json:"labels,omitempty"`
+}
+
+func newGerritClient(baseURL, user, password string) *gerritClient {
+	header := http.Header{}
+	header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user+":"+password)))
+	return &gerritClient{restClient: newRESTClient("Gerrit", baseURL, header)}
+}
+
+// setReview posts a review on the revision. Authenticated endpoints of Gerrit are prefixed with "/a".
+func (c *gerritClient) setReview(change, revision string, review gerritReviewInput) error {
+	path := fmt.Sprintf("/a/changes/%s/revisions/%s/review", url.PathEscape(change), url.PathEscape(revision))
+	if err := c.do(http.MethodPost, path, review, nil); err != nil {
+		return xerrors.Errorf("failed to set a review: %w", err)
+	}
+	return nil
+}
+
+// gerritVote returns -1 if any benchmark got worse than the threshold, 0 if any benchmark got worse
+// within the threshold and +1 otherwise.
+func gerritVote(results []result, threshold float64, comparedScore comparedScore) int {
+	vote := 1
+	for _, r := range results {
+		switch benchmarkStatus(r, threshold, comparedScore) {
+		case statusFail:
+			return -1
+		case statusWarn:
+			vote = 0
+		}
+	}
+	return vote
+}
+
+func postGerritReview(c config, commit, message string, results []result, comparedScore comparedScore) error {
+	if c.gerritURL == "" {
+		return xerrors.New("--gerrit-url is not set")
+	}
+	user, password := os.Getenv("GERRIT_USERNAME"), os.Getenv("GERRIT_PASSWORD")
+	if user == "" || password == "" {
+		return xerrors.New("GERRIT_USERNAME and GERRIT_PASSWORD must be set")
+	}
+	change := c.gerritChange
+	if change == "" {
+		// Set by the Gerrit Trigger plugin of Jenkins
+		change = os.Getenv("GERRIT_CHANGE_NUMBER")
+	}
+	if change == "" {
+		return xerrors.New("unable to detect the change: use --gerrit-change")
+	}
+
+	review := gerritReviewInput{Message: message}
+	if c.gerritLabel != "" {
+		review.Labels = map[string]int{c.gerritLabel: gerritVote(results, c.threshold, comparedScore)}
+	}
+	if err := newGerritClient(c.gerritURL, user, password).setReview(change, commit, review); err != nil {
+		return err
+	}
+	infof("Posted the review to the change %s", change)
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_gerritVote(t *testing.T) {
+	compare := comparedScore{nsPerOp: true, allocedBytesPerOp: true}
+	assert.Equal(t, 1, gerritVote([]result{{RatioNsPerOp: -0.1}}, 0.2, compare))
+	assert.Equal(t, 0, gerritVote([]result{{RatioNsPerOp: 0.1}}, 0.2, compare))
+	assert.Equal(t, -1, gerritVote([]result{{RatioNsPerOp: 0.1}, {RatioAllocedBytesPerOp: 0.3}}, 0.2, compare))
+}
+
+func Test_gerritClient_setReview(t *testing.T) {
+	var gotPath string
+	var got gerritReviewInput
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "cob", user)
+		assert.Equal(t, "secret", password)
+		gotPath = r.URL.Path
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(")]}'\n{}"))
+	}))
+	defer ts.Close()
+
+	c := newGerritClient(ts.URL, "cob", "secret")
+	review := gerritReviewInput{Message: "result", Labels: map[string]int{"Verified": -1}}
+	require.NoError(t, c.setReview("1234", "abc", review))
+	assert.Equal(t, "/a/changes/1234/revisions/abc/review", gotPath)
+	assert.Equal(t, review, got)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// gitCLI runs git in a repository, for what go-git can't do well, e.g. pushing with the user's credentials.
+type gitCLI struct {
+	dir string
+	env []string
+}
+
+func newGitCLI(dir string) gitCLI {
+	g := gitCLI{dir: dir, env: os.Environ()}
+	// Creating a commit fails on CI runners without an identity.
+	if out, err := g.run(nil, "config", "user.email"); err != nil || len(bytes.TrimSpace(out)) == 0 {
+		g.env = append(g.env, "GIT_AUTHOR_NAME=cob", "GIT_AUTHOR_EMAIL=cob@localhost",
+			"GIT_COMMITTER_NAME=cob", "GIT_COMMITTER_EMAIL=cob@localhost")
+	}
+	return g
+}
+
+// withEnv returns a copy which runs git with additional environment variables, e.g. GIT_INDEX_FILE.
+func (g gitCLI) withEnv(env ...string) gitCLI {
+	g.env = append(append([]string{}, g.env...), env...)
+	return g
+}
+
+func (g gitCLI) run(stdin []byte, args ...string) ([]byte, error) {
+	debugf("exec: git %s", strings.Join(args, " "))
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.dir
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Env = g.env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, xerrors.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func (g gitCLI) hasRemote(name string) bool {
+	_, err := g.run(nil, "remote", "get-url", name)
+	return err == nil
+}
+
+// gitExecPath returns the git command which checks out the commits for --git-exec, or "" to check them out with
+// go-git.
+func gitExecPath(mode string, lookPath func(string) (string, error)) (string, error) {
+	switch mode {
+	case "false":
+		return "", nil
+	case "auto", "true":
+		path, err := lookPath("git")
+		if err == nil {
+			return path, nil
+		}
+		if mode == "true" {
+			return "", xerrors.Errorf("--git-exec needs git in PATH: %w", err)
+		}
+		return "", nil
+	default:
+		return "", xerrors.Errorf("invalid --git-exec: %s (auto, true or false)", mode)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_gitExecPath(t *testing.T) {
+	found := func(string) (string, error) { return "/usr/bin/git", nil }
+	notFound := func(file string) (string, error) { return "", &exec.Error{Name: file, Err: exec.ErrNotFound} }
+	tests := []struct {
+		mode     string
+		lookPath func(string) (string, error)
+		want     string
+		wantErr  string
+	}{
+		{mode: "auto", lookPath: found, want: "/usr/bin/git"},
+		{mode: "auto", lookPath: notFound, want: ""},
+		{mode: "true", lookPath: found, want: "/usr/bin/git"},
+		{mode: "true", lookPath: notFound, wantErr: "--git-exec needs git in PATH"},
+		{mode: "false", lookPath: found, want: ""},
+		{mode: "yes", lookPath: found, wantErr: "invalid --git-exec: yes (auto, true or false)"},
+	}
+	for _, tt := range tests {
+		got, err := gitExecPath(tt.mode, tt.lookPath)
+		if tt.wantErr != "" {
+			assert.Contains(t, err.Error(), tt.wantErr, tt.mode)
+			continue
+		}
+		assert.NoError(t, err, tt.mode)
+		assert.Equal(t, tt.want, got, tt.mode)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+type giteaClient struct {
+	restClient
+}
+
+func newGiteaClient(baseURL, token string) *giteaClient {
+	header := http.Header{}
+	header.Set("Authorization", "token "+token)
+	return &giteaClient{restClient: newRESTClient("Gitea", strings.TrimSuffix(baseURL, "/")+"/api/v1", header)}
+}
+
+// detectGiteaRepository returns the repository and the pull request. Values which are not given explicitly are
+// detected from variables of Gitea/Forgejo Actions (GITHUB_*) or Woodpecker CI (CI_*).
+func detectGiteaRepository(repository string, number int) (githubRepository, error) {
+	if repository == "" {
+		repository = os.Getenv("GITHUB_REPOSITORY")
+	}
+	if repository == "" {
+		repository = os.Getenv("CI_REPO")
+	}
+	owner, repo, err := splitGitHubRepository(repository)
+	if err != nil {
+		return githubRepository{}, xerrors.Errorf("unable to detect the repository: %w", err)
+	}
+
+	if number == 0 {
+		number = detectGitHubPRNumber(os.Getenv("GITHUB_EVENT_PATH"), os.Getenv("GITHUB_REF"))
+	}
+	if number == 0 {
+		number, _ = strconv.Atoi(os.Getenv("CI_COMMIT_PULL_REQUEST"))
+	}
+	return githubRepository{owner: owner, repo: repo, number: number}, nil
+}
+
+// findComment returns the ID of the comment containing the marker, or 0 if there is no such comment.
+func (c *giteaClient) findComment(r githubRepository, marker string) (int64, error) {
+	// Gitea caps the page size with MAX_RESPONSE_ITEMS, 50 by default
+	const limit = 50
+	for page := 1; ; page++ {
+		var comments []githubComment
+		path := fmt.Sprintf("/repos/%s/%s/issues/%d/comments?limit=%d&page=%d", r.owner, r.repo, r.number, limit, page)
+		if err := c.do(http.MethodGet, path, nil, &comments); err != nil {
+			return 0, xerrors.Errorf("failed to list comments: %w", err)
+		}
+		for _, comment := range comments {
+			if strings.Contains(comment.Body, marker) {
+				return comment.ID, nil
+			}
+		}
+		if len(comments) < limit {
+			return 0, nil
+		}
+	}
+}
+
+// upsertComment updates the previous comment posted by cob, or creates a new one if there is none.
+func (c *giteaClient) upsertComment(r githubRepository, body string) error {
+	body = commentMarker + "\n" + body
+	id, err := c.findComment(r, commentMarker)
+	if err != nil {
+		return err
+	}
+
+	in := map[string]string{"body": body}
+	if id == 0 {
+		path := fmt.Sprintf("/repos/%s/%s/issues/%d/comments", r.owner, r.repo, r.number)
+		if err = c.do(http.MethodPost, path, in, nil); err != nil {
+			return xerrors.Errorf("failed to create a comment: %w", err)
+		}
+		return nil
+	}
+	debugf("gitea: update the comment %d", id)
+	path := fmt.Sprintf("/repos/%s/%s/issues/comments/%d", r.owner, r.repo, id)
+	if err = c.do(http.MethodPatch, path, in, nil); err != nil {
+		return xerrors.Errorf("failed to update the comment: %w", err)
+	}
+	return nil
+}
+
+func (c *giteaClient) createCommitStatus(r githubRepository, commit string, status commitStatus) error {
+	path := fmt.Sprintf("/repos/%s/%s/statuses/%s", r.owner, r.repo, commit)
+	if err := c.do(http.MethodPost, path, status, nil); err != nil {
+		return xerrors.Errorf("failed to create a commit status: %w", err)
+	}
+	return nil
+}
+
+func newGiteaClientFromConfig(c config) (*giteaClient, githubRepository, error) {
+	if c.giteaURL == "" {
+		return nil, githubRepository{}, xerrors.New("--gitea-url is not set")
+	}
+	token := os.Getenv("GITEA_TOKEN")
+	if token == "" {
+		return nil, githubRepository{}, xerrors.New("GITEA_TOKEN is not set")
+	}
+	r, err := detectGiteaRepository(c.giteaRepo, c.giteaPR)
+	if err != nil {
+		return nil, githubRepository{}, err
+	}
+	return newGiteaClient(c.giteaURL, token), r, nil
+}
+
+func postGiteaPRComment(c config, body string) error {
+	client, r, err := newGiteaClientFromConfig(c)
+	if err != nil {
+		return err
+	}
+	if r.number == 0 {
+		return xerrors.New("unable to detect the pull request: use --gitea-pr")
+	}
+	if err = client.upsertComment(r, body); err != nil {
+		return err
+	}
+	infof("Posted the result to %s/%s#%d", r.owner, r.repo, r.number)
+	return nil
+}
+
+func postGiteaCommitStatus(c config, commit string, results []result, comparedScore comparedScore) error {
+	client, r, err := newGiteaClientFromConfig(c)
+	if err != nil {
+		return err
+	}
+
+	state := "success"
+	for _, res := range results {
+		if isDegression(res, c.threshold, comparedScore) {
+			state = "failure"
+			break
+		}
+	}
+	status := commitStatus{
+		State:       state,
+		Description: generateStatusDescription(results, c.threshold, comparedScore),
+		Context:     commitStatusContext,
+	}
+	if err = client.createCommitStatus(r, commit, status); err != nil {
+		return err
+	}
+	infof("Set the commit status '%s' to %s", commitStatusContext, state)
+	return nil
+}
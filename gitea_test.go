@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_giteaClient(t *testing.T) {
+	var paths []string
+	var status commitStatus
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodGet:
+			assert.Equal(t, "50", r.URL.Query().Get("limit"))
+			_, _ = w.Write([]byte(`[{"id": 9, "body": "` + commentMarker + ` old result"}]`))
+		case r.URL.Path == "/api/v1/repos/owner/repo/statuses/abc":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&status))
+		}
+	}))
+	defer ts.Close()
+
+	c := newGiteaClient(ts.URL+"/", "secret")
+	r := githubRepository{owner: "owner", repo: "repo", number: 4}
+	require.NoError(t, c.upsertComment(r, "new result"))
+	require.NoError(t, c.createCommitStatus(r, "abc", commitStatus{State: "failure", Context: commitStatusContext}))
+
+	assert.Equal(t, []string{
+		"GET /api/v1/repos/owner/repo/issues/4/comments",
+		"PATCH /api/v1/repos/owner/repo/issues/comments/9",
+		"POST /api/v1/repos/owner/repo/statuses/abc",
+	}, paths)
+	assert.Equal(t, commitStatus{State: "failure", Context: commitStatusContext}, status)
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+const defaultGitHubAPIURL = "https://api.github.com"
+
+var githubPRRefRegexp = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+type githubClient struct {
+	restClient
+}
+
+// githubRepository is the repository and the pull request which the current commit belongs to.
+type githubRepository struct {
+	owner  string
+	repo   string
+	number int
+}
+
+func newGitHubClient(baseURL, token string) *githubClient {
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github.v3+json")
+	header.Set("Authorization", "token "+token)
+	return &githubClient{restClient: newRESTClient("GitHub", baseURL, header)}
+}
+
+func newGitHubClientFromEnv() (*githubClient, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return nil, xerrors.New("GITHUB_TOKEN is not set")
+	}
+	baseURL := os.Getenv("GITHUB_API_URL")
+	if baseURL == "" {
+		baseURL = defaultGitHubAPIURL
+	}
+	return newGitHubClient(baseURL, token), nil
+}
+
+// detectGitHubRepository detects the repository and the pull request from environment variables of GitHub Actions.
+// If the pull request cannot be detected from them, it is looked up by the commit hash.
+func (c *githubClient) detectGitHubRepository(commit string) (githubRepository, error) {
+	owner, repo, err := splitGitHubRepository(os.Getenv("GITHUB_REPOSITORY"))
+	if err != nil {
+		return githubRepository{}, err
+	}
+
+	number := detectGitHubPRNumber(os.Getenv("GITHUB_EVENT_PATH"), os.Getenv("GITHUB_REF"))
+	if number == 0 {
+		number, err = c.findPullRequest(owner, repo, commit)
+		if err != nil {
+			return githubRepository{}, err
+		}
+	}
+	return githubRepository{owner: owner, repo: repo, number: number}, nil
+}
+
+// githubPRHeadSHA returns the head commit of the pull request of a pull_request event, or commit otherwise. The
+// workflow of a pull request checks out the merge commit of refs/pull/N/merge, and a check run or a commit status
+// of that commit isn't shown on the pull request.
+func githubPRHeadSHA(eventPath, commit string) string {
+	if eventPath == "" {
+		return commit
+	}
+	b, err := ioutil.ReadFile(eventPath)
+	if err != nil {
+		return commit
+	}
+	var event struct {
+		PullRequest struct {
+			Head struct {
+				SHA string `json:"sha"`
+			} `json:"head"`
+		} `json:"pull_request"`
+	}
+	if err = json.Unmarshal(b, &event); err != nil || event.PullRequest.Head.SHA == "" {
+		return commit
+	}
+	if event.PullRequest.Head.SHA != commit {
+		debugf("Use the head commit %s of the pull request instead of %s", event.PullRequest.Head.SHA, commit)
+	}
+	return event.PullRequest.Head.SHA
+}
+
+func splitGitHubRepository(s string) (string, string, error) {
+	ss := strings.Split(s, "/")
+	if len(ss) != 2 || ss[0] == "" || ss[1] == "" {
+		return "", "", xerrors.Errorf("invalid GITHUB_REPOSITORY: '%s'", s)
+	}
+	return ss[0], ss[1], nil
+}
+
+func detectGitHubPRNumber(eventPath, ref string) int {
+	if eventPath != "" {
+		if b, err := ioutil.ReadFile(eventPath); err == nil {
+			var event struct {
+				Number      int `json:"number"`
+				PullRequest struct {
+					Number int `json:"number"`
+				} `json:"pull_request"`
+			}
+			if err = json.Unmarshal(b, &event); err == nil {
+				if event.PullRequest.Number != 0 {
+					return event.PullRequest.Number
+				}
+				if event.Number != 0 {
+					return event.Number
+				}
+			}
+		}
+	}
+
+	if m := githubPRRefRegexp.FindStringSubmatch(ref); m != nil {
+		number, _ := strconv.Atoi(m[1])
+		return number
+	}
+	return 0
+}
+
+func (c *githubClient) findPullRequest(owner, repo, commit string) (int, error) {
+	var pulls []struct {
+		Number int    `json:"number"`
+		State  string `json:"state"`
+	}
+	path := fmt.Sprintf("/repos/%s/%s/commits/%s/pulls", owner, repo, commit)
+	if err := c.do(http.MethodGet, path, nil, &pulls); err != nil {
+		return 0, xerrors.Errorf("failed to list pull requests associated with %s: %w", commit, err)
+	}
+	for _, p := range pulls {
+		if p.State == "open" {
+			return p.Number, nil
+		}
+	}
+	return 0, xerrors.Errorf("no open pull request is associated with %s", commit)
+}
+
+type githubComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+func (c *githubClient) createComment(r githubRepository, body string) error {
+	path := fmt.Sprintf("/repos/%s/%s/issues/%d/comments", r.owner, r.repo, r.number)
+	in := map[string]string{"body": body}
+	if err := c.do(http.MethodPost, path, in, nil); err != nil {
+		return xerrors.Errorf("failed to create a comment: %w", err)
+	}
+	return nil
+}
+
+func (c *githubClient) updateComment(r githubRepository, id int64, body string) error {
+	path := fmt.Sprintf("/repos/%s/%s/issues/comments/%d", r.owner, r.repo, id)
+	in := map[string]string{"body": body}
+	if err := c.do(http.MethodPatch, path, in, nil); err != nil {
+		return xerrors.Errorf("failed to update the comment: %w", err)
+	}
+	return nil
+}
+
+// findComment returns the ID of the comment containing the marker, or 0 if there is no such comment.
+func (c *githubClient) findComment(r githubRepository, marker string) (int64, error) {
+	const perPage = 100
+	for page := 1; ; page++ {
+		var comments []githubComment
+		path := fmt.Sprintf("/repos/%s/%s/issues/%d/comments?per_page=%d&page=%d", r.owner, r.repo, r.number, perPage, page)
+		if err := c.do(http.MethodGet, path, nil, &comments); err != nil {
+			return 0, xerrors.Errorf("failed to list comments: %w", err)
+		}
+		for _, comment := range comments {
+			if strings.Contains(comment.Body, marker) {
+				return comment.ID, nil
+			}
+		}
+		if len(comments) < perPage {
+			return 0, nil
+		}
+	}
+}
+
+// upsertComment updates the previous comment posted by cob, or creates a new one if there is none.
+func (c *githubClient) upsertComment(r githubRepository, body string) error {
+	body = commentMarker + "\n" + body
+	id, err := c.findComment(r, commentMarker)
+	if err != nil {
+		return err
+	}
+	if id == 0 {
+		return c.createComment(r, body)
+	}
+	debugf("github: update the comment %d", id)
+	return c.updateComment(r, id, body)
+}
+
+func postGitHubPRComment(commit, body string) error {
+	c, err := newGitHubClientFromEnv()
+	if err != nil {
+		return err
+	}
+	r, err := c.detectGitHubRepository(commit)
+	if err != nil {
+		return xerrors.Errorf("unable to detect the pull request: %w", err)
+	}
+	if err = c.upsertComment(r, body); err != nil {
+		return err
+	}
+	infof("Posted the result to %s/%s#%d", r.owner, r.repo, r.number)
+	return nil
+}
+
+const (
+	commitStatusContext = "cob/benchmarks"
+
+	// GitHub rejects descriptions longer than this.
+	maxCommitStatusDescription = 140
+)
+
+type commitStatus struct {
+	State       string `json:"state"`
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description"`
+	Context     string `json:"context"`
+}
+
+func (c *githubClient) createCommitStatus(owner, repo, commit string, status commitStatus) error {
+	path := fmt.Sprintf("/repos/%s/%s/statuses/%s", owner, repo, commit)
+	if err := c.do(http.MethodPost, path, status, nil); err != nil {
+		return xerrors.Errorf("failed to create a commit status: %w", err)
+	}
+	return nil
+}
+
+// generateStatusDescription describes the worst benchmark, e.g. "worst: +18.4% (BenchmarkFoo)".
+func generateStatusDescription(results []result, threshold float64, comparedScore comparedScore) string {
+	if len(results) == 0 {
+		return "no benchmarks to compare"
+	}
+	worst := results[0]
+	for _, r := range results[1:] {
+		if worstRatio(r, comparedScore) > worstRatio(worst, comparedScore) {
+			worst = r
+		}
+	}
+	description := fmt.Sprintf("worst: %+.1f%% (%s), threshold: %.1f%%",
+		100*worstRatio(worst, comparedScore), worst.Name, 100*threshold)
+	return truncateUTF8(description, maxCommitStatusDescription)
+}
+
+// githubActionsRunURL returns the URL of the current workflow run, or an empty string outside GitHub Actions.
+func githubActionsRunURL() string {
+	server, repo, runID := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID")
+	if server == "" || repo == "" || runID == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/actions/runs/%s", strings.TrimSuffix(server, "/"), repo, runID)
+}
+
+func postGitHubCommitStatus(commit string, results []result, threshold float64, comparedScore comparedScore) error {
+	c, err := newGitHubClientFromEnv()
+	if err != nil {
+		return err
+	}
+	owner, repo, err := splitGitHubRepository(os.Getenv("GITHUB_REPOSITORY"))
+	if err != nil {
+		return err
+	}
+
+	state := "success"
+	for _, r := range results {
+		if isDegression(r, threshold, comparedScore) {
+			state = "failure"
+			break
+		}
+	}
+	status := commitStatus{
+		State:       state,
+		TargetURL:   githubActionsRunURL(),
+		Description: generateStatusDescription(results, threshold, comparedScore),
+		Context:     commitStatusContext,
+	}
+	if err = c.createCommitStatus(owner, repo, githubPRHeadSHA(os.Getenv("GITHUB_EVENT_PATH"), commit), status); err != nil {
+		return err
+	}
+	infof("Set the commit status '%s' to %s", commitStatusContext, state)
+	return nil
+}
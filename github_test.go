@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_detectGitHubPRNumber(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	prEvent := filepath.Join(dir, "pull_request.json")
+	require.NoError(t, ioutil.WriteFile(prEvent, []byte(`{"number": 12, "pull_request": {"number": 12}}`), 0600))
+	pushEvent := filepath.Join(dir, "push.json")
+	require.NoError(t, ioutil.WriteFile(pushEvent, []byte(`{"ref": "refs/heads/master"}`), 0600))
+
+	tests := []struct {
+		name      string
+		eventPath string
+		ref       string
+		want      int
+	}{
+		{
+			name:      "pull_request event",
+			eventPath: prEvent,
+			ref:       "refs/pull/12/merge",
+			want:      12,
+		},
+		{
+			name: "ref",
+			ref:  "refs/pull/34/merge",
+			want: 34,
+		},
+		{
+			name:      "push event",
+			eventPath: pushEvent,
+			ref:       "refs/heads/master",
+			want:      0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectGitHubPRNumber(tt.eventPath, tt.ref)
+			assert.Equal(t, tt.want, got, tt.name)
+		})
+	}
+}
+
+func Test_githubClient(t *testing.T) {
+	var gotBody map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/repos/knqyf263/cob/commits/abc/pulls":
+			_, _ = w.Write([]byte(`[{"number": 1, "state": "closed"}, {"number": 2, "state": "open"}]`))
+		case "/repos/knqyf263/cob/issues/2/comments":
+			assert.Equal(t, http.MethodPost, r.Method)
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	c := newGitHubClient(ts.URL, "secret")
+
+	number, err := c.findPullRequest("knqyf263", "cob", "abc")
+	require.NoError(t, err)
+	assert.Equal(t, 2, number)
+
+	err = c.createComment(githubRepository{owner: "knqyf263", repo: "cob", number: number}, "result")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"body": "result"}, gotBody)
+
+	_, err = c.findPullRequest("knqyf263", "cob", "unknown")
+	assert.Error(t, err)
+}
+
+func Test_githubClient_upsertComment(t *testing.T) {
+	tests := []struct {
+		name       string
+		comments   string
+		wantMethod string
+		wantPath   string
+	}{
+		{
+			name:       "no previous comment",
+			comments:   `[{"id": 1, "body": "LGTM"}]`,
+			wantMethod: http.MethodPost,
+			wantPath:   "/repos/knqyf263/cob/issues/2/comments",
+		},
+		{
+			name:       "previous comment",
+			comments:   `[{"id": 1, "body": "LGTM"}, {"id": 3, "body": "` + commentMarker + ` old result"}]`,
+			wantMethod: http.MethodPatch,
+			wantPath:   "/repos/knqyf263/cob/issues/comments/3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMethod, gotPath string
+			var gotBody map[string]string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					assert.Equal(t, "/repos/knqyf263/cob/issues/2/comments", r.URL.Path)
+					_, _ = w.Write([]byte(tt.comments))
+					return
+				}
+				gotMethod, gotPath = r.Method, r.URL.Path
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+			}))
+			defer ts.Close()
+
+			c := newGitHubClient(ts.URL, "secret")
+			err := c.upsertComment(githubRepository{owner: "knqyf263", repo: "cob", number: 2}, "new result")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMethod, gotMethod, tt.name)
+			assert.Equal(t, tt.wantPath, gotPath, tt.name)
+			assert.Equal(t, commentMarker+"\nnew result", gotBody["body"], tt.name)
+		})
+	}
+}
+
+func Test_githubPRHeadSHA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	prEvent := filepath.Join(dir, "pull_request.json")
+	require.NoError(t, ioutil.WriteFile(prEvent, []byte(`{"number": 12, "pull_request": {"number": 12, "head": {"sha": "headsha"}}}`), 0600))
+	pushEvent := filepath.Join(dir, "push.json")
+	require.NoError(t, ioutil.WriteFile(pushEvent, []byte(`{"after": "pushed"}`), 0600))
+
+	assert.Equal(t, "headsha", githubPRHeadSHA(prEvent, "mergesha"), "the head of the pull request, not the merge commit")
+	assert.Equal(t, "mergesha", githubPRHeadSHA(pushEvent, "mergesha"))
+	assert.Equal(t, "mergesha", githubPRHeadSHA(filepath.Join(dir, "missing.json"), "mergesha"))
+	assert.Equal(t, "mergesha", githubPRHeadSHA("", "mergesha"))
+}
+
+func Test_postGitHubCommitStatus(t *testing.T) {
+	var path string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	event := filepath.Join(dir, "event.json")
+	require.NoError(t, ioutil.WriteFile(event, []byte(`{"pull_request": {"number": 1, "head": {"sha": "headsha"}}}`), 0600))
+
+	os.Setenv("GITHUB_TOKEN", "secret")
+	os.Setenv("GITHUB_API_URL", ts.URL)
+	os.Setenv("GITHUB_REPOSITORY", "knqyf263/cob")
+	os.Setenv("GITHUB_EVENT_PATH", event)
+	defer os.Unsetenv("GITHUB_TOKEN")
+	defer os.Unsetenv("GITHUB_API_URL")
+	defer os.Unsetenv("GITHUB_REPOSITORY")
+	defer os.Unsetenv("GITHUB_EVENT_PATH")
+
+	require.NoError(t, postGitHubCommitStatus("mergesha", nil, 0.2, comparedScore{nsPerOp: true}))
+	assert.Equal(t, "/repos/knqyf263/cob/statuses/headsha", path, "the status is on the head of the pull request")
+}
+
+func Test_generateStatusDescription(t *testing.T) {
+	compare := comparedScore{nsPerOp: true, allocedBytesPerOp: true}
+	results := []result{
+		{Name: "BenchmarkA", RatioNsPerOp: 0.05},
+		{Name: "BenchmarkB", RatioNsPerOp: -0.1, RatioAllocedBytesPerOp: 0.184},
+	}
+	assert.Equal(t, "worst: +18.4% (BenchmarkB), threshold: 20.0%", generateStatusDescription(results, 0.2, compare))
+	assert.Equal(t, "no benchmarks to compare", generateStatusDescription(nil, 0.2, compare))
+
+	long := []result{{Name: "BenchmarkA" + strings.Repeat("ä", 70), RatioNsPerOp: 0.5}}
+	description := generateStatusDescription(long, 0.2, compare)
+	assert.True(t, len(description) <= maxCommitStatusDescription)
+	assert.True(t, utf8.ValidString(description), "the description is cut at a rune boundary")
+}
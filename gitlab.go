@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+type gitlabClient struct {
+	restClient
+}
+
+// gitlabMergeRequest is the merge request which the current pipeline runs for.
+type gitlabMergeRequest struct {
+	projectID string
+	iid       string
+}
+
+type gitlabNote struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+func newGitLabClient(baseURL, token string) *gitlabClient {
+	header := http.Header{}
+	header.Set("PRIVATE-TOKEN", token)
+	return &gitlabClient{restClient: newRESTClient("GitLab", baseURL, header)}
+}
+
+// newGitLabClientFromEnv creates a client from predefined variables of GitLab CI.
+// CI_JOB_TOKEN cannot create notes, so GITLAB_TOKEN is required.
+func newGitLabClientFromEnv() (*gitlabClient, gitlabMergeRequest, error) {
+	token := os.Getenv("GITLAB_TOKEN")
+	if token == "" {
+		return nil, gitlabMergeRequest{}, xerrors.New("GITLAB_TOKEN is not set")
+	}
+	baseURL := os.Getenv("CI_API_V4_URL")
+	if baseURL == "" {
+		return nil, gitlabMergeRequest{}, xerrors.New("CI_API_V4_URL is not set")
+	}
+	mr := gitlabMergeRequest{
+		projectID: os.Getenv("CI_PROJECT_ID"),
+		iid:       os.Getenv("CI_MERGE_REQUEST_IID"),
+	}
+	if mr.projectID == "" || mr.iid == "" {
+		return nil, gitlabMergeRequest{}, xerrors.New("CI_PROJECT_ID and CI_MERGE_REQUEST_IID must be set: use pipelines for merge requests")
+	}
+	return newGitLabClient(baseURL, token), mr, nil
+}
+
+func (c *gitlabClient) notesPath(mr gitlabMergeRequest) string {
+	return fmt.Sprintf("/projects/%s/merge_requests/%s/notes", url.PathEscape(mr.projectID), url.PathEscape(mr.iid))
+}
+
+// findNote returns the ID of the note containing the marker, or 0 if there is no such note.
+func (c *gitlabClient) findNote(mr gitlabMergeRequest, marker string) (int64, error) {
+	const perPage = 100
+	for page := 1; ; page++ {
+		var notes []gitlabNote
+		path := fmt.Sprintf("%s?per_page=%d&page=%d", c.notesPath(mr), perPage, page)
+		if err := c.do(http.MethodGet, path, nil, &notes); err != nil {
+			return 0, xerrors.Errorf("failed to list notes: %w", err)
+		}
+		for _, note := range notes {
+			if strings.Contains(note.Body, marker) {
+				return note.ID, nil
+			}
+		}
+		if len(notes) < perPage {
+			return 0, nil
+		}
+	}
+}
+
+// upsertNote updates the previous note posted by cob, or creates a new one if there is none.
+func (c *gitlabClient) upsertNote(mr gitlabMergeRequest, body string) error {
+	body = commentMarker + "\n" + body
+	id, err := c.findNote(mr, commentMarker)
+	if err != nil {
+		return err
+	}
+
+	in := map[string]string{"body": body}
+	if id == 0 {
+		if err = c.do(http.MethodPost, c.notesPath(mr), in, nil); err != nil {
+			return xerrors.Errorf("failed to create a note: %w", err)
+		}
+		return nil
+	}
+	debugf("gitlab: update the note %d", id)
+	if err = c.do(http.MethodPut, fmt.Sprintf("%s/%d", c.notesPath(mr), id), in, nil); err != nil {
+		return xerrors.Errorf("failed to update the note: %w", err)
+	}
+	return nil
+}
+
+func postGitLabMRNote(body string) error {
+	c, mr, err := newGitLabClientFromEnv()
+	if err != nil {
+		return err
+	}
+	if err = c.upsertNote(mr, body); err != nil {
+		return err
+	}
+	infof("Posted the result to !%s of the project %s", mr.iid, mr.projectID)
+	return nil
+}
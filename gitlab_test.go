@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_gitlabClient_upsertNote(t *testing.T) {
+	tests := []struct {
+		name       string
+		notes      string
+		wantMethod string
+		wantPath   string
+	}{
+		{
+			name:       "no previous note",
+			notes:      `[{"id": 1, "body": "LGTM"}]`,
+			wantMethod: http.MethodPost,
+			wantPath:   "/projects/12/merge_requests/3/notes",
+		},
+		{
+			name:       "previous note",
+			notes:      `[{"id": 1, "body": "LGTM"}, {"id": 5, "body": "` + commentMarker + ` old result"}]`,
+			wantMethod: http.MethodPut,
+			wantPath:   "/projects/12/merge_requests/3/notes/5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMethod, gotPath string
+			var gotBody map[string]string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+				if r.Method == http.MethodGet {
+					assert.Equal(t, "/projects/12/merge_requests/3/notes", r.URL.Path)
+					_, _ = w.Write([]byte(tt.notes))
+					return
+				}
+				gotMethod, gotPath = r.Method, r.URL.Path
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+			}))
+			defer ts.Close()
+
+			c := newGitLabClient(ts.URL, "secret")
+			err := c.upsertNote(gitlabMergeRequest{projectID: "12", iid: "3"}, "new result")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMethod, gotMethod, tt.name)
+			assert.Equal(t, tt.wantPath, gotPath, tt.name)
+			assert.Equal(t, commentMarker+"\nnew result", gotBody["body"], tt.name)
+		})
+	}
+}
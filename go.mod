@@ -3,9 +3,10 @@ module github.com/knqyf263/cob
 go 1.13
 
 require (
-	github.com/olekukonko/tablewriter v0.0.4
+	github.com/olekukonko/tablewriter v0.0.5
 	github.com/stretchr/testify v1.3.0
 	github.com/urfave/cli/v2 v2.1.1
+	golang.org/x/crypto v0.0.0-20191011191535-87dc89f01550
 	golang.org/x/tools v0.0.0-20200110213125-a7a6caa82ab2
 	golang.org/x/xerrors v0.0.0-20191011141410-1b5146add898
 	gopkg.in/src-d/go-git.v4 v4.13.1
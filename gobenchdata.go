@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+// The types below are the result format of gobenchdata (https://github.com/bobheadxi/gobenchdata).
+type gobenchdataRun struct {
+	Version string             `json:"Version"`
+	Date    int64              `json:"Date"`
+	Tags    []string           `json:"Tags"`
+	Suites  []gobenchdataSuite `json:"Suites"`
+}
+
+type gobenchdataSuite struct {
+	Goos       string                 `json:"Goos"`
+	Goarch     string                 `json:"Goarch"`
+	Pkg        string                 `json:"Pkg"`
+	Benchmarks []gobenchdataBenchmark `json:"Benchmarks"`
+}
+
+type gobenchdataBenchmark struct {
+	Name    string             `json:"Name"`
+	Runs    int                `json:"Runs"`
+	NsPerOp float64            `json:"NsPerOp"`
+	Mem     gobenchdataMem     `json:"Mem"`
+	Custom  map[string]float64 `json:"Custom,omitempty"`
+}
+
+type gobenchdataMem struct {
+	BytesPerOp  uint64  `json:"BytesPerOp"`
+	AllocsPerOp uint64  `json:"AllocsPerOp"`
+	MBPerSec    float64 `json:"MBPerSec"`
+}
+
+// newGobenchdataRun converts the HEAD results. As with gobenchdata, the version is the commit hash.
+func newGobenchdataRun(rep report.Report, goos, goarch string, now time.Time) gobenchdataRun {
+	run := gobenchdataRun{
+		Version: rep.Head.Hash,
+		Date:    now.Unix(),
+		Tags:    []string{},
+	}
+	if rep.Head.Branch != "" {
+		run.Tags = append(run.Tags, rep.Head.Branch)
+	}
+	suite := gobenchdataSuite{Goos: goos, Goarch: goarch, Benchmarks: []gobenchdataBenchmark{}}
+	for _, b := range rep.Benchmarks {
+		suite.Benchmarks = append(suite.Benchmarks, gobenchdataBenchmark{
+			Name:    b.Name,
+			Runs:    b.Head.Iterations,
+			NsPerOp: b.Head.NsPerOp,
+			Mem: gobenchdataMem{
+				BytesPerOp:  b.Head.AllocedBytesPerOp,
+				AllocsPerOp: b.Head.AllocsPerOp,
+				MBPerSec:    b.Head.MBPerS,
+			},
+		})
+	}
+	run.Suites = []gobenchdataSuite{suite}
+	return run
+}
+
+func readGobenchdataRuns(path string) ([]gobenchdataRun, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, xerrors.Errorf("failed to read the gobenchdata results: %w", err)
+	}
+	var runs []gobenchdataRun
+	if err = json.Unmarshal(b, &runs); err != nil {
+		return nil, xerrors.Errorf("failed to decode the gobenchdata results: %w", err)
+	}
+	return runs, nil
+}
+
+// mergeGobenchdataRun adds the run to the existing runs, newest first, as "gobenchdata merge" does.
+// A previous run of the same version is replaced.
+func mergeGobenchdataRun(runs []gobenchdataRun, run gobenchdataRun) []gobenchdataRun {
+	merged := []gobenchdataRun{run}
+	for _, r := range runs {
+		if r.Version != run.Version {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_gobenchdata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// A file written by gobenchdata
+	path := filepath.Join(dir, "benchmarks.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`[{"Version":"aaa","Date":1,"Tags":["ref=main"],"Suites":[{"Goos":"linux","Goarch":"amd64","Pkg":"example.com/foo","Benchmarks":[{"Name":"BenchmarkA","Runs":10,"NsPerOp":100,"Mem":{"BytesPerOp":16,"AllocsPerOp":1,"MBPerSec":0}}]}]}]`), 0644))
+	runs, err := readGobenchdataRuns(path)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, 100.0, runs[0].Suites[0].Benchmarks[0].NsPerOp)
+
+	rep := report.Report{
+		Head:       report.Commit{Hash: "bbb", Branch: "main"},
+		Benchmarks: []report.Benchmark{{Name: "BenchmarkA", Head: report.Measurement{Iterations: 20, NsPerOp: 150, AllocedBytesPerOp: 16, AllocsPerOp: 1}}},
+	}
+	run := newGobenchdataRun(rep, "linux", "amd64", time.Unix(2, 0))
+	assert.Equal(t, gobenchdataRun{
+		Version: "bbb",
+		Date:    2,
+		Tags:    []string{"main"},
+		Suites: []gobenchdataSuite{{
+			Goos:   "linux",
+			Goarch: "amd64",
+			Benchmarks: []gobenchdataBenchmark{{
+				Name: "BenchmarkA", Runs: 20, NsPerOp: 150, Mem: gobenchdataMem{BytesPerOp: 16, AllocsPerOp: 1},
+			}},
+		}},
+	}, run)
+
+	merged := mergeGobenchdataRun(runs, run)
+	assert.Equal(t, []string{"bbb", "aaa"}, []string{merged[0].Version, merged[1].Version})
+	// Re-running the same commit replaces its run.
+	assert.Len(t, mergeGobenchdataRun(merged, run), 2)
+
+	runs, err = readGobenchdataRuns(filepath.Join(dir, "missing.json"))
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+}
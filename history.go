@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+// historyRun is a run recorded in the history.
+type historyRun struct {
+	Time    time.Time     `json:"time"`
+	Machine machine       `json:"machine"`
+	Report  report.Report `json:"report"`
+	// Rollup is the number of runs of the day aggregated into this one by "cob history prune", or 0 for a single run.
+	Rollup int `json:"rollup,omitempty"`
+}
+
+// defaultStore is the history file of -store, shared by the repositories benchmarked on the machine.
+const defaultStore = "~/.cob/history.jsonl"
+
+// fileStore records runs in a local JSON Lines file, one run per line, so that appending a run never rewrites
+// the previous ones.
+type fileStore struct {
+	path string
+}
+
+// expandHome expands a leading "~/" because the path may come from a file or an environment variable rather
+// than a shell.
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+func newFileStore(path string) fileStore {
+	return fileStore{path: expandHome(path)}
+}
+
+func (s fileStore) put(run historyRun) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return xerrors.Errorf("failed to create the history directory: %w", err)
+	}
+	b, err := json.Marshal(run)
+	if err != nil {
+		return xerrors.Errorf("failed to encode the run: %w", err)
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return xerrors.Errorf("failed to open the history: %w", err)
+	}
+	defer f.Close()
+	if _, err = f.Write(append(b, '\n')); err != nil {
+		return xerrors.Errorf("failed to write the history: %w", err)
+	}
+	return nil
+}
+
+// runs returns the recorded runs, oldest first.
+func (s fileStore) runs() ([]historyRun, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, xerrors.Errorf("failed to open the history: %w", err)
+	}
+	defer f.Close()
+	return readRuns(f)
+}
+
+// readRuns reads runs in JSON Lines, one run per line.
+func readRuns(r io.Reader) ([]historyRun, error) {
+	var runs []historyRun
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 64*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		var run historyRun
+		if err := json.Unmarshal(sc.Bytes(), &run); err != nil {
+			return nil, xerrors.Errorf("failed to decode line %d of the history: %w", line, err)
+		}
+		runs = append(runs, run)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, xerrors.Errorf("failed to read the history: %w", err)
+	}
+	return runs, nil
+}
+
+func (s fileStore) latest(commit, tag string) (*historyRun, error) {
+	runs, err := s.runs()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(runs) - 1; i >= 0; i-- {
+		if runs[i].Report.Head.Hash == commit && runs[i].Machine.Tag == tag && runs[i].Rollup == 0 {
+			return &runs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// runsOnMachine returns the runs on machines with the tag.
+func runsOnMachine(runs []historyRun, tag string) []historyRun {
+	var filtered []historyRun
+	for _, run := range runs {
+		if run.Machine.Tag == tag {
+			filtered = append(filtered, run)
+		}
+	}
+	return filtered
+}
+
+// Timeouts of the lock of a history file.
+const (
+	fileStoreLockTimeout = time.Minute
+	// fileStoreStaleLock is the age of a lock which a crashed process must have left.
+	fileStoreStaleLock = 10 * time.Minute
+)
+
+// lock creates a lock file next to the history, so that runs recorded by processes sharing the file, e.g. CI
+// jobs on a network file system, are not lost while it is rewritten. A lock file works on any file system and
+// OS, unlike flock(2).
+func (s fileStore) lock() (func(), error) {
+	path := s.path + ".lock"
+	deadline := time.Now().Add(fileStoreLockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, xerrors.Errorf("failed to lock the history: %w", err)
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > fileStoreStaleLock {
+			warnf("Removing a stale lock of the history: %s", path)
+			_ = os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, xerrors.Errorf("timed out waiting for the lock of the history: %s", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// update reads and rewrites the history while holding the lock.
+func (s fileStore) update(fn func(runs []historyRun) []historyRun) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return xerrors.Errorf("failed to create the history directory: %w", err)
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return updateRuns(s, fn)
+}
+
+// rewrite replaces the history file, through a temporary file so that a failure doesn't lose the history.
+func (s fileStore) rewrite(runs []historyRun) error {
+	tmp := s.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return xerrors.Errorf("failed to create the history: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	for _, run := range runs {
+		b, err := json.Marshal(run)
+		if err != nil {
+			f.Close()
+			return xerrors.Errorf("failed to encode the run: %w", err)
+		}
+		_, _ = w.Write(append(b, '\n'))
+	}
+	if err = w.Flush(); err != nil {
+		f.Close()
+		return xerrors.Errorf("failed to write the history: %w", err)
+	}
+	if err = f.Close(); err != nil {
+		return xerrors.Errorf("failed to write the history: %w", err)
+	}
+	if err = os.Rename(tmp, s.path); err != nil {
+		return xerrors.Errorf("failed to replace the history: %w", err)
+	}
+	return nil
+}
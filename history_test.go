@@ -0,0 +1,112 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_fileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := newFileStore(filepath.Join(dir, ".cob", "history.jsonl"))
+	runs, err := s.runs()
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	first := historyRun{
+		Time:    time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Machine: machine{Hostname: "ci-1", OS: "linux", Arch: "amd64", CPUs: 8, GoVersion: "go1.13.5"},
+		Report:  report.Report{SchemaVersion: 1, Head: report.Commit{Ref: "HEAD", Hash: "aaa"}, Benchmarks: []report.Benchmark{{Name: "BenchmarkA"}}},
+	}
+	second := first
+	second.Report.Head.Hash = "bbb"
+	require.NoError(t, s.put(first))
+	require.NoError(t, s.put(second))
+
+	runs, err = s.runs()
+	require.NoError(t, err)
+	assert.Equal(t, []historyRun{first, second}, runs)
+
+	latest, err := s.latest("aaa", "")
+	require.NoError(t, err)
+	assert.Equal(t, &first, latest)
+	latest, err = s.latest("ccc", "")
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+	latest, err = s.latest("aaa", "ci-large")
+	require.NoError(t, err)
+	assert.Nil(t, latest, "runs on differently tagged machines are not baselines")
+}
+
+func Test_runsOnMachine(t *testing.T) {
+	runs := []historyRun{
+		{Machine: machine{Hostname: "a"}},
+		{Machine: machine{Hostname: "b", Tag: "ci-large"}},
+		{Machine: machine{Hostname: "c", Tag: "ci-large"}},
+	}
+	assert.Equal(t, runs[1:], runsOnMachine(runs, "ci-large"))
+	assert.Equal(t, runs[:1], runsOnMachine(runs, ""))
+}
+
+func Test_expandHome(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".cob", "history.jsonl"), expandHome("~/.cob/history.jsonl"))
+	assert.Equal(t, "history.jsonl", expandHome("history.jsonl"))
+}
+
+func Test_fileStore_concurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	s := newFileStore(filepath.Join(dir, "history.jsonl"))
+
+	// Runs recorded while the history is rewritten are not lost.
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, s.put(historyRun{Time: time.Unix(int64(i), 0).UTC()}))
+		}(i)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, s.update(func(runs []historyRun) []historyRun {
+				// Widen the window between reading and rewriting the history.
+				time.Sleep(time.Millisecond)
+				return append([]historyRun{}, runs...)
+			}))
+		}()
+	}
+	wg.Wait()
+	runs, err := s.runs()
+	require.NoError(t, err)
+	assert.Len(t, runs, 20)
+	_, err = os.Stat(s.path + ".lock")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_fileStore_staleLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	s := newFileStore(filepath.Join(dir, "history.jsonl"))
+
+	require.NoError(t, ioutil.WriteFile(s.path+".lock", nil, 0644))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(s.path+".lock", old, old))
+	require.NoError(t, s.put(historyRun{}))
+	runs, err := s.runs()
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+
+	"golang.org/x/xerrors"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// postCheckoutHook returns a hook which runs the shell command for -post-checkout after each checkout, with the
+// commit in COB_COMMIT. Its output goes to stderr to keep stdout for the results.
+func postCheckoutHook(command string) func(plumbing.Hash) error {
+	return func(commit plumbing.Hash) error {
+		infof("Run the post-checkout hook at %s: %s", commit, command)
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Env = append(os.Environ(), "COB_COMMIT="+commit.String())
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return xerrors.Errorf("%s: %w", command, err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+func Test_postCheckoutHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	out := filepath.Join(dir, "commit.txt")
+	commit := plumbing.NewHash("2c335e6d1b4e0f5e5a7c3a0f0d7f1c9b8a6e4d21")
+	require.NoError(t, postCheckoutHook("echo $COB_COMMIT > "+out)(commit))
+	b, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, commit.String()+"\n", string(b))
+
+	err = postCheckoutHook("exit 3")(commit)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit 3: exit status 3")
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(ratio float64) string { return fmt.Sprintf("%+.2f%%", 100*ratio) },
+	"sub":     func(a, b float64) float64 { return a - b },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>cob: HEAD vs {{.Report.Base.Ref}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; }
+td.number { text-align: right; }
+tr.warn { background: #fff8e1; }
+tr.fail { background: #ffebee; }
+</style>
+</head>
+<body>
+<h1>Benchmark comparison: HEAD vs {{.Report.Base.Ref}}</h1>
+<p>{{.Verdict}}</p>
+{{- if .Report.Creep}}
+<p>Gradually got worse:</p>
+<ul>
+{{- range .Report.Creep}}
+<li>{{.Name}}: {{percent .Ratio}} ns/op over the last {{.Commits}} commits (since <code>{{printf "%.7s" .Since}}</code>)</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if or .Report.Added .Report.Removed}}
+<p>New and removed benchmarks:</p>
+<ul>
+{{- range .Report.Added}}
+<li>{{.}} is new, so it has no baseline</li>
+{{- end}}
+{{- range .Report.Removed}}
+<li>{{.}} is removed, so it is not compared</li>
+{{- end}}
+</ul>
+{{- end}}
+<p>Base: <code>{{.Report.Base.Hash}}</code><br>HEAD: <code>{{.Report.Head.Hash}}</code></p>
+<table>
+<tr><th>Name</th><th>Base ns/op</th><th>HEAD ns/op</th><th>ns/op</th><th>Base B/op</th><th>HEAD B/op</th><th>B/op</th><th>allocs/op</th><th>Status</th></tr>
+{{- range .Report.Benchmarks}}
+<tr class="{{.Status}}"><td>{{.Name}}</td><td class="number">{{printf "%.2f" .Base.NsPerOp}}</td><td class="number">{{printf "%.2f" .Head.NsPerOp}}</td><td class="number">{{percent .Ratio.NsPerOp}}</td><td class="number">{{.Base.AllocedBytesPerOp}}</td><td class="number">{{.Head.AllocedBytesPerOp}}</td><td class="number">{{percent .Ratio.AllocedBytesPerOp}}</td><td class="number">{{percent .Ratio.AllocsPerOp}}</td><td>{{.Status}}</td></tr>
+{{- end}}
+</table>
+{{- range .Report.Profiles}}
+{{- if .Functions}}
+<h2>CPU time: {{.Name}}</h2>
+<table>
+<tr><th>Function</th><th>Base ns/op</th><th>HEAD ns/op</th><th>Delta</th></tr>
+{{- range .Functions}}
+<tr><td>{{.Function}}</td><td class="number">{{printf "%.2f" .Base}}</td><td class="number">{{printf "%.2f" .Head}}</td><td class="number">{{printf "%+.2f" (sub .Head .Base)}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Sites}}
+<h2>Allocation sites: {{.Name}}</h2>
+<table>
+<tr><th>Function</th><th>Base B/op</th><th>HEAD B/op</th><th>Delta</th></tr>
+{{- range .Sites}}
+<tr><td>{{.Function}}</td><td class="number">{{printf "%.0f" .Base}}</td><td class="number">{{printf "%.0f" .Head}}</td><td class="number">{{printf "%+.0f" (sub .Head .Base)}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+{{- if .Report.Profiles}}
+<h2>Flamegraphs</h2>
+<ul>
+{{- range .Report.Profiles}}
+{{- if or .BaseFlamegraph .HeadFlamegraph}}
+<li>{{.Name}} ({{.Kind}}):{{if .BaseFlamegraph}} <a href="{{.BaseFlamegraph}}">base</a>{{end}}{{if .HeadFlamegraph}} <a href="{{.HeadFlamegraph}}">HEAD</a>{{end}}</li>
+{{- end}}
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// generateHTML renders the report as a standalone HTML page, e.g. for emails and CI artifacts.
+func generateHTML(rep report.Report, verdict string) (string, error) {
+	w := &bytes.Buffer{}
+	data := struct {
+		Report  report.Report
+		Verdict string
+	}{rep, verdict}
+	if err := htmlTemplate.Execute(w, data); err != nil {
+		return "", xerrors.Errorf("failed to render the HTML report: %w", err)
+	}
+	return w.String(), nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_generateHTML(t *testing.T) {
+	rep := report.Report{
+		Base: report.Commit{Ref: "HEAD~1", Hash: "aaa"},
+		Head: report.Commit{Ref: "HEAD", Hash: "bbb"},
+		Benchmarks: []report.Benchmark{{
+			Name:   "BenchmarkA<script>",
+			Base:   report.Measurement{NsPerOp: 100, AllocedBytesPerOp: 10},
+			Head:   report.Measurement{NsPerOp: 150, AllocedBytesPerOp: 10},
+			Ratio:  report.Ratio{NsPerOp: 0.5},
+			Status: report.StatusFail,
+		}},
+		Profiles: []report.Profile{{
+			Name:           "BenchmarkA-8",
+			Kind:           "cpu",
+			HeadFlamegraph: "profiles/head/BenchmarkA.cpu.svg",
+			Functions:      []report.CPUFunction{{Function: "pkg.hash", Base: 100, Head: 250}},
+		}},
+		Added: []string{"BenchmarkNew-8"},
+	}
+	got, err := generateHTML(rep, "FAIL: 1 benchmark(s) got worse than the threshold (20.00%)")
+	require.NoError(t, err)
+	assert.Contains(t, got, "<h1>Benchmark comparison: HEAD vs HEAD~1</h1>")
+	assert.Contains(t, got, "<p>FAIL: 1 benchmark(s) got worse than the threshold (20.00%)</p>")
+	assert.Contains(t, got, `<tr class="fail"><td>BenchmarkA&lt;script&gt;</td><td class="number">100.00</td><td class="number">150.00</td><td class="number">&#43;50.00%</td>`)
+	assert.Contains(t, got, `<tr><td>pkg.hash</td><td class="number">100.00</td><td class="number">250.00</td><td class="number">&#43;150.00</td></tr>`)
+	assert.Contains(t, got, "<li>BenchmarkNew-8 is new, so it has no baseline</li>")
+	assert.Contains(t, got, `<li>BenchmarkA-8 (cpu): <a href="profiles/head/BenchmarkA.cpu.svg">HEAD</a></li>`)
+}
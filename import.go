@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/cob"
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/tools/benchmark/parse"
+	"golang.org/x/xerrors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+var historyImportCommand = &cli.Command{
+	Name:      "import",
+	Usage:     "Record results collected by other means: 'go test -bench' output or a gobenchdata file",
+	ArgsUsage: "FILE",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "store",
+			Usage: "Store to record the results in",
+			Value: defaultStore,
+		},
+		&cli.StringFlag{
+			Name:  "commit",
+			Usage: "Commit the 'go test -bench' output was measured at",
+		},
+		&cli.TimestampFlag{
+			Name:   "time",
+			Usage:  "Time the 'go test -bench' output was measured at, e.g. 2020-01-02T03:04:05Z (default: now)",
+			Layout: time.RFC3339,
+		},
+		&cli.StringFlag{
+			Name:  "machine",
+			Usage: "Tag of the machine the results were measured on, as '-machine'",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.String("store") == "" || c.Args().Len() != 1 {
+			return xerrors.New("usage: cob history import -store STORE [-commit COMMIT] FILE")
+		}
+		b, err := ioutil.ReadFile(c.Args().First())
+		if err != nil {
+			return xerrors.Errorf("failed to read the results: %w", err)
+		}
+
+		var runs []historyRun
+		if bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+			if runs, err = importGobenchdata(b, c.String("machine")); err != nil {
+				return err
+			}
+		} else {
+			if c.String("commit") == "" {
+				return xerrors.New("-commit is required to import 'go test -bench' output")
+			}
+			t := time.Now()
+			if ts := c.Timestamp("time"); ts != nil {
+				t = *ts
+			}
+			run, err := importBenchmarkOutput(b, resolveCommit(c.String("commit")), t, c.String("machine"))
+			if err != nil {
+				return err
+			}
+			runs = append(runs, run)
+		}
+
+		st, err := openStore(c.String("store"))
+		if err != nil {
+			return xerrors.Errorf("failed to open the store: %w", err)
+		}
+		for _, run := range runs {
+			if err = st.put(run); err != nil {
+				return xerrors.Errorf("failed to record the run: %w", err)
+			}
+		}
+		infof("Imported %d run(s)", len(runs))
+		return nil
+	},
+}
+
+// resolveCommit expands the revision to the full hash if it is run in the repository.
+func resolveCommit(rev string) string {
+	r, err := git.PlainOpen(".")
+	if err != nil {
+		return rev
+	}
+	hash, err := r.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		debugf("git: failed to resolve %s: %s", rev, err)
+		return rev
+	}
+	return hash.String()
+}
+
+// importBenchmarkOutput converts the output of 'go test -bench', or a file in the same format as benchstat
+// reads, into a run. The first result of each benchmark is used, as in a comparison.
+func importBenchmarkOutput(b []byte, commit string, t time.Time, tag string) (historyRun, error) {
+	set, err := parse.ParseSet(bytes.NewReader(b))
+	if err != nil {
+		return historyRun{}, xerrors.Errorf("failed to parse the benchmark results: %w", err)
+	}
+	if len(set) == 0 {
+		return historyRun{}, xerrors.New("no benchmark results found")
+	}
+
+	run := historyRun{
+		Time:    t.UTC(),
+		Machine: machine{Tag: tag},
+		Report: report.Report{
+			SchemaVersion: report.SchemaVersion,
+			Head:          report.Commit{Hash: commit},
+			Benchmarks:    []report.Benchmark{},
+		},
+	}
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		line := sc.Text()
+		if v := strings.TrimPrefix(line, "goos: "); v != line {
+			run.Machine.OS = strings.TrimSpace(v)
+		} else if v := strings.TrimPrefix(line, "goarch: "); v != line {
+			run.Machine.Arch = strings.TrimSpace(v)
+		}
+	}
+
+	var names []string
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		run.Report.Benchmarks = append(run.Report.Benchmarks, report.Benchmark{
+			Name:   name,
+			Head:   cob.NewMeasurement(set[name][0]),
+			Status: report.StatusOK,
+		})
+	}
+	return run, nil
+}
+
+// importGobenchdata converts the runs of a gobenchdata file. As with gobenchdata, the version is the commit.
+func importGobenchdata(b []byte, tag string) ([]historyRun, error) {
+	var runs []gobenchdataRun
+	if err := json.Unmarshal(b, &runs); err != nil {
+		return nil, xerrors.Errorf("failed to decode the gobenchdata results: %w", err)
+	}
+	var imported []historyRun
+	for _, r := range runs {
+		run := historyRun{
+			Time:    time.Unix(r.Date, 0).UTC(),
+			Machine: machine{Tag: tag},
+			Report: report.Report{
+				SchemaVersion: report.SchemaVersion,
+				Head:          report.Commit{Hash: resolveCommit(r.Version)},
+				Benchmarks:    []report.Benchmark{},
+			},
+		}
+		for _, suite := range r.Suites {
+			run.Machine.OS, run.Machine.Arch = suite.Goos, suite.Goarch
+			for _, bench := range suite.Benchmarks {
+				run.Report.Benchmarks = append(run.Report.Benchmarks, report.Benchmark{
+					Name: bench.Name,
+					Head: report.Measurement{
+						Iterations:        bench.Runs,
+						NsPerOp:           bench.NsPerOp,
+						AllocedBytesPerOp: bench.Mem.BytesPerOp,
+						AllocsPerOp:       bench.Mem.AllocsPerOp,
+						MBPerS:            bench.Mem.MBPerSec,
+					},
+					Status: report.StatusOK,
+				})
+			}
+		}
+		imported = append(imported, run)
+	}
+	// gobenchdata keeps the newest run first.
+	sort.SliceStable(imported, func(i, j int) bool { return imported[i].Time.Before(imported[j].Time) })
+	return imported, nil
+}
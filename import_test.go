@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_importBenchmarkOutput(t *testing.T) {
+	out := `goos: linux
+goarch: amd64
+pkg: github.com/knqyf263/cob
+BenchmarkB-8   	 2000000	       600 ns/op	      16 B/op	       1 allocs/op
+BenchmarkA-8   	 1000000	      1200 ns/op
+BenchmarkA-8   	 1000000	      1300 ns/op
+PASS
+`
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	run, err := importBenchmarkOutput([]byte(out), "abc", now, "ci-large")
+	require.NoError(t, err)
+	assert.Equal(t, historyRun{
+		Time:    now,
+		Machine: machine{OS: "linux", Arch: "amd64", Tag: "ci-large"},
+		Report: report.Report{
+			SchemaVersion: report.SchemaVersion,
+			Head:          report.Commit{Hash: "abc"},
+			Benchmarks: []report.Benchmark{
+				{Name: "BenchmarkA-8", Head: report.Measurement{Iterations: 1000000, NsPerOp: 1200}, Status: report.StatusOK},
+				{Name: "BenchmarkB-8", Head: report.Measurement{Iterations: 2000000, NsPerOp: 600, AllocedBytesPerOp: 16, AllocsPerOp: 1}, Status: report.StatusOK},
+			},
+		},
+	}, run)
+
+	_, err = importBenchmarkOutput([]byte("PASS\n"), "abc", now, "")
+	assert.Error(t, err)
+}
+
+func Test_importGobenchdata(t *testing.T) {
+	data := `[
+  {"Version": "bbb", "Date": 1577934245, "Tags": [], "Suites": [{"Goos": "linux", "Goarch": "arm64", "Pkg": "p",
+    "Benchmarks": [{"Name": "BenchmarkA", "Runs": 10, "NsPerOp": 2, "Mem": {"BytesPerOp": 3, "AllocsPerOp": 4, "MBPerSec": 0}}]}]},
+  {"Version": "aaa", "Date": 1577847845, "Tags": [], "Suites": []}
+]`
+	runs, err := importGobenchdata([]byte(data), "")
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "aaa", runs[0].Report.Head.Hash, "runs should be oldest first")
+	assert.Equal(t, historyRun{
+		Time:    time.Unix(1577934245, 0).UTC(),
+		Machine: machine{OS: "linux", Arch: "arm64"},
+		Report: report.Report{
+			SchemaVersion: report.SchemaVersion,
+			Head:          report.Commit{Hash: "bbb"},
+			Benchmarks: []report.Benchmark{
+				{Name: "BenchmarkA", Head: report.Measurement{Iterations: 10, NsPerOp: 2, AllocedBytesPerOp: 3, AllocsPerOp: 4}, Status: report.StatusOK},
+			},
+		},
+	}, runs[1])
+}
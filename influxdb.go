@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+// influxMeasurement is the measurement name of the written points.
+const influxMeasurement = "cob"
+
+var (
+	influxTagEscaper    = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `)
+	influxStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+)
+
+// generateInfluxLines renders the report in the InfluxDB line protocol, one point per benchmark.
+func generateInfluxLines(rep report.Report, now time.Time) string {
+	var benchmarks []string
+	fields := map[string][]string{}
+	for _, m := range benchmarkMetrics(rep) {
+		if _, ok := fields[m.Benchmark]; !ok {
+			benchmarks = append(benchmarks, m.Benchmark)
+		}
+		fields[m.Benchmark] = append(fields[m.Benchmark], fmt.Sprintf("%s=%g", m.Name, m.Value))
+	}
+
+	w := &bytes.Buffer{}
+	for _, b := range benchmarks {
+		tags := influxMeasurement + ",benchmark=" + influxTagEscaper.Replace(b)
+		if rep.Head.Branch != "" {
+			tags += ",branch=" + influxTagEscaper.Replace(rep.Head.Branch)
+		}
+		fmt.Fprintf(w, "%s commit=\"%s\",%s %d\n", tags, influxStringEscaper.Replace(rep.Head.Hash),
+			strings.Join(fields[b], ","), now.UnixNano())
+	}
+	return w.String()
+}
+
+// writeInfluxLines writes the points to the InfluxDB write endpoint, e.g.
+// "http://localhost:8086/api/v2/write?org=myorg&bucket=benchmarks". INFLUX_TOKEN is sent as the token if it is set.
+func writeInfluxLines(client *http.Client, writeURL, lines string) error {
+	req, err := http.NewRequest(http.MethodPost, writeURL, strings.NewReader(lines))
+	if err != nil {
+		return xerrors.Errorf("failed to create a request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("User-Agent", "cob")
+	if token := os.Getenv("INFLUX_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+
+	debugf("influxdb: POST %s", writeURL)
+	resp, err := client.Do(req)
+	if err != nil {
+		return xerrors.Errorf("failed to write points: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return xerrors.Errorf("InfluxDB returned %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	infof("Wrote points to InfluxDB")
+	return nil
+}
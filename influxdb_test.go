@@ -0,0 +1,46 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_generateInfluxLines(t *testing.T) {
+	rep := report.Report{
+		Head: report.Commit{Ref: "HEAD", Hash: "599a552", Branch: "feature x"},
+		Benchmarks: []report.Benchmark{
+			{Name: "BenchmarkA/n=1", Head: report.Measurement{NsPerOp: 150, AllocedBytesPerOp: 16, AllocsPerOp: 1}, Ratio: report.Ratio{NsPerOp: 0.5}},
+			{Name: "BenchmarkB", Head: report.Measurement{NsPerOp: 10}},
+		},
+	}
+	now := time.Unix(1577934245, 0)
+	want := `cob,benchmark=BenchmarkA/n\=1,branch=feature\ x commit="599a552",ns_per_op=150,alloced_bytes_per_op=16,allocs_per_op=1,ratio_ns_per_op=0.5,ratio_alloced_bytes_per_op=0,ratio_allocs_per_op=0 1577934245000000000
+cob,benchmark=BenchmarkB,branch=feature\ x commit="599a552",ns_per_op=10,alloced_bytes_per_op=0,allocs_per_op=0,ratio_ns_per_op=0,ratio_alloced_bytes_per_op=0,ratio_allocs_per_op=0 1577934245000000000
+`
+	assert.Equal(t, want, generateInfluxLines(rep, now))
+}
+
+func Test_writeInfluxLines(t *testing.T) {
+	os.Setenv("INFLUX_TOKEN", "token")
+	defer os.Unsetenv("INFLUX_TOKEN")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Token token", r.Header.Get("Authorization"))
+		assert.Equal(t, "benchmarks", r.URL.Query().Get("bucket"))
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "cob,benchmark=BenchmarkA ns_per_op=1 0\n", string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	require.NoError(t, writeInfluxLines(ts.Client(), ts.URL+"/api/v2/write?bucket=benchmarks", "cob,benchmark=BenchmarkA ns_per_op=1 0\n"))
+}
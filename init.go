@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+// maxInitPackages is the number of packages listed in a generated workflow before falling back to "./...".
+const maxInitPackages = 5
+
+var initCommand = &cli.Command{
+	Name:      "init",
+	Usage:     "Write a CI configuration running cob (github, gitlab)",
+	ArgsUsage: "github|gitlab",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "Path of the configuration (default: .github/workflows/cob.yml or .gitlab-ci.yml)",
+		},
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "Overwrite an existing file",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		return initCI(".", c.Args().First(), c.String("output"), c.Bool("force"))
+	},
+}
+
+var initTemplates = map[string]struct {
+	path     string
+	template *template.Template
+}{
+	"github": {
+		path: filepath.Join(".github", "workflows", "cob.yml"),
+		template: template.Must(template.New("github").Parse(`name: cob
+on: [pull_request]
+jobs:
+  benchmark:
+    runs-on: ubuntu-latest
+    permissions:
+      contents: read
+      pull-requests: write
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          fetch-depth: 2
+      - uses: actions/setup-go@v5
+        with:
+          go-version: "{{.GoVersion}}"
+          cache: true
+      - name: Install cob
+        run: curl -sfL https://raw.githubusercontent.com/knqyf263/cob/master/install.sh | sudo sh -s -- -b /usr/local/bin
+      - name: Run benchmarks
+        run: cob -bench-args "{{.BenchArgs}}" -github-pr-comment -report-dir cob-reports
+        env:
+          GITHUB_TOKEN: ${{"{{"}} secrets.GITHUB_TOKEN {{"}}"}}
+      - uses: actions/upload-artifact@v4
+        if: always()
+        with:
+          name: cob-reports
+          path: cob-reports
+`)),
+	},
+	"gitlab": {
+		path: ".gitlab-ci.yml",
+		template: template.Must(template.New("gitlab").Parse(`cob:
+  image: golang:{{.GoVersion}}
+  rules:
+    - if: $CI_PIPELINE_SOURCE == "merge_request_event"
+  variables:
+    GIT_DEPTH: "10"
+    GOPATH: $CI_PROJECT_DIR/.go
+  cache:
+    key: go-mod
+    paths:
+      - .go/pkg/mod
+  before_script:
+    - curl -sfL https://raw.githubusercontent.com/knqyf263/cob/master/install.sh | sh -s -- -b /usr/local/bin
+  script:
+    # GITLAB_TOKEN needs the api scope to post notes.
+    - cob -bench-args "{{.BenchArgs}}" -gitlab-mr-note -report-dir cob-reports
+  artifacts:
+    when: always
+    paths:
+      - cob-reports
+    reports:
+      junit: cob-reports/junit/cob.xml
+`)),
+	},
+}
+
+// goVersion returns the version in the go directive of go.mod, or "1.x" if it is not found.
+func goVersion(root string) string {
+	f, err := os.Open(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "1.x"
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 2 && fields[0] == "go" {
+			return fields[1]
+		}
+	}
+	return "1.x"
+}
+
+// benchmarkPackages returns the packages which have Benchmark functions, e.g. "./pkg/foo", or "./..." if there
+// are none or too many of them to list.
+func benchmarkPackages(root string) (string, error) {
+	funcs, err := findBenchmarkFuncs(root)
+	if err != nil {
+		return "", err
+	}
+	dirs := map[string]struct{}{}
+	for _, f := range funcs {
+		dirs["./"+path.Dir(f.Path)] = struct{}{}
+	}
+	if len(dirs) == 0 || len(dirs) > maxInitPackages {
+		return "./...", nil
+	}
+	var pkgs []string
+	for d := range dirs {
+		pkgs = append(pkgs, strings.TrimSuffix(d, "/."))
+	}
+	sort.Strings(pkgs)
+	return strings.Join(pkgs, " "), nil
+}
+
+func generateCIConfig(root, ci string) (string, error) {
+	t, ok := initTemplates[ci]
+	if !ok {
+		return "", xerrors.Errorf("unknown CI: %q (github, gitlab)", ci)
+	}
+	pkgs, err := benchmarkPackages(root)
+	if err != nil {
+		return "", xerrors.Errorf("failed to find benchmarks: %w", err)
+	}
+	data := struct {
+		GoVersion string
+		BenchArgs string
+	}{
+		GoVersion: goVersion(root),
+		BenchArgs: "test -run '^$' -bench . -benchmem " + pkgs,
+	}
+	w := &bytes.Buffer{}
+	if err = t.template.Execute(w, data); err != nil {
+		return "", xerrors.Errorf("failed to render the configuration: %w", err)
+	}
+	return w.String(), nil
+}
+
+func initCI(root, ci, output string, force bool) error {
+	config, err := generateCIConfig(root, ci)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		output = filepath.Join(root, initTemplates[ci].path)
+	}
+	if _, err = os.Stat(output); err == nil && !force {
+		return xerrors.Errorf("%s already exists: use -force to overwrite it or -output to write another file", output)
+	}
+	if err = os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return xerrors.Errorf("failed to create the directory: %w", err)
+	}
+	if err = ioutil.WriteFile(output, []byte(config), 0644); err != nil {
+		return xerrors.Errorf("failed to write %s: %w", output, err)
+	}
+	infof("Wrote %s", output)
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_initCI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/foo\n\ngo 1.14\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg", "bar"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pkg", "bar", "bar_test.go"),
+		[]byte("package bar\n\nimport \"testing\"\n\nfunc BenchmarkBar(b *testing.B) {}\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "foo_test.go"),
+		[]byte("package foo\n\nimport \"testing\"\n\nfunc BenchmarkFoo(b *testing.B) {}\n"), 0644))
+
+	require.NoError(t, initCI(dir, "github", "", false))
+	b, err := ioutil.ReadFile(filepath.Join(dir, ".github", "workflows", "cob.yml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `go-version: "1.14"`)
+	assert.Contains(t, string(b), `cob -bench-args "test -run '^$' -bench . -benchmem . ./pkg/bar" -github-pr-comment`)
+	assert.Contains(t, string(b), "GITHUB_TOKEN: ${{ secrets.GITHUB_TOKEN }}")
+
+	err = initCI(dir, "github", "", false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+	require.NoError(t, initCI(dir, "github", "", true))
+
+	require.NoError(t, initCI(dir, "gitlab", "", false))
+	b, err = ioutil.ReadFile(filepath.Join(dir, ".gitlab-ci.yml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "image: golang:1.14")
+	assert.Contains(t, string(b), "junit: cob-reports/junit/cob.xml")
+
+	err = initCI(dir, "jenkins", "", false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown CI: "jenkins"`)
+}
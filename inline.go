@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/xerrors"
+)
+
+// compilerDiagnostic is a line of the optimization decisions which the compiler prints with -gcflags=-m=2.
+type compilerDiagnostic struct {
+	// pos is the position in the source, e.g. "pkg/a.go:12:6".
+	pos     string
+	message string
+}
+
+// diagnosticRegexp matches a decision of the compiler, e.g. "pkg/a.go:12:6: can inline f with cost 3 as: ...".
+var diagnosticRegexp = regexp.MustCompile(`^(\S+\.go:\d+:\d+): (.*)$`)
+
+// packagesOfBenchmarks returns the packages of the benchmarks, e.g. "./pkg".
+func packagesOfBenchmarks(benchmarks []report.Benchmark) ([]string, error) {
+	funcs, err := findBenchmarkFuncs(".")
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var pkgs []string
+	for _, b := range benchmarks {
+		fn, ok := funcs[benchmarkFuncName(b.Name)]
+		if !ok {
+			continue
+		}
+		pkg := "./" + path.Dir(fn.Path)
+		if !seen[pkg] {
+			seen[pkg] = true
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	sort.Strings(pkgs)
+	return pkgs, nil
+}
+
+// compileDiagnostics compiles the tests of the package with -gcflags=-m=2 and returns the decisions of the
+// compiler. The test binary is thrown away. The build cache replays the decisions of a package which didn't change.
+func compileDiagnostics(pkg string) ([]compilerDiagnostic, error) {
+	dir, err := ioutil.TempDir("", "cob-gcflags")
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create a directory for the test binary: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	args := []string{"test", "-c", "-o", filepath.Join(dir, "pkg.test"), "-gcflags=-m=2", pkg}
+	debugf("exec: go %s", strings.Join(args, " "))
+	out, err := exec.Command("go", args...).CombinedOutput()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to compile %s: %s: %s", pkg, err, lastLines(out, 5))
+	}
+	return parseDiagnostics(bytes.NewReader(out)), nil
+}
+
+func parseDiagnostics(r io.Reader) []compilerDiagnostic {
+	var diags []compilerDiagnostic
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for s.Scan() {
+		if m := diagnosticRegexp.FindStringSubmatch(s.Text()); m != nil {
+			diags = append(diags, compilerDiagnostic{pos: m[1], message: m[2]})
+		}
+	}
+	return diags
+}
+
+// lastLines returns the last n lines of the output, e.g. the compile error after the decisions of the compiler.
+func lastLines(out []byte, n int) string {
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inlineDecision is whether the compiler can inline a function.
+type inlineDecision struct {
+	pos       string
+	inlinable bool
+	// reason is why the function can't be inlined, e.g. "function too complex: cost 92 exceeds budget 80".
+	reason string
+}
+
+var (
+	canInlineRegexp    = regexp.MustCompile(`^can inline (\S+)`)
+	cannotInlineRegexp = regexp.MustCompile(`^cannot inline (\S+): (.*)$`)
+)
+
+// inlineDecisions returns the decision of the compiler for each function of the package by name.
+func inlineDecisions(diags []compilerDiagnostic) map[string]inlineDecision {
+	decisions := map[string]inlineDecision{}
+	for _, d := range diags {
+		if m := cannotInlineRegexp.FindStringSubmatch(d.message); m != nil {
+			decisions[m[1]] = inlineDecision{pos: d.pos, reason: m[2]}
+		} else if m := canInlineRegexp.FindStringSubmatch(d.message); m != nil {
+			decisions[m[1]] = inlineDecision{pos: d.pos, inlinable: true}
+		}
+	}
+	return decisions
+}
+
+// collectDiagnostics compiles each package and returns the decisions of the compiler by package. The worktree
+// must be at the commit to compile. A package which can't be compiled only prints a warning.
+func collectDiagnostics(pkgs []string) map[string][]compilerDiagnostic {
+	diags := map[string][]compilerDiagnostic{}
+	for _, pkg := range pkgs {
+		d, err := compileDiagnostics(pkg)
+		if err != nil {
+			warnf("Failed to compare the decisions of the compiler: %s", err)
+			continue
+		}
+		diags[pkg] = d
+	}
+	return diags
+}
+
+// newInlineChanges returns the functions which the compiler inlined at the base commit, but can't inline at HEAD.
+// A lost inlining is a common cause of an increase of ns/op which the diff doesn't show.
+func newInlineChanges(pkgs []string, base, head map[string][]compilerDiagnostic) []report.InlineChange {
+	var changes []report.InlineChange
+	for _, pkg := range pkgs {
+		if _, ok := base[pkg]; !ok {
+			continue
+		}
+		baseDecisions, headDecisions := inlineDecisions(base[pkg]), inlineDecisions(head[pkg])
+		var names []string
+		for name := range headDecisions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			b, ok := baseDecisions[name]
+			h := headDecisions[name]
+			if !ok || !b.inlinable || h.inlinable {
+				continue
+			}
+			changes = append(changes, report.InlineChange{Package: pkg, Function: name, Position: h.pos, Reason: h.reason})
+		}
+	}
+	return changes
+}
+
+func showInlineChanges(w io.Writer, changes []report.InlineChange, style string) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nNo longer inlined")
+	fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 17))
+
+	table := tablewriter.NewWriter(w)
+	table.SetAutoFormatHeaders(false)
+	applyTableStyle(table, style)
+	table.SetHeader([]string{"Function", "Position", "Reason"})
+	for _, c := range changes {
+		table.Append([]string{c.Function, c.Position, c.Reason})
+	}
+	table.Render()
+}
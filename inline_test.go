@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newInlineChanges(t *testing.T) {
+	base := map[string][]compilerDiagnostic{"./pkg": parseDiagnostics(strings.NewReader(`# example.com/pkg [example.com/pkg.test]
+pkg/a.go:7:6: can inline grow with cost 3 as: func() []byte { return make([]byte, 10) }
+pkg/a.go:9:6: can inline (*T).spin with cost 24 as: method(*T) func(int) int { s := 0; for loop; return s }
+pkg/a.go:15:6: cannot inline walk: recursive
+pkg/a.go:21:14: inlining call to grow
+`))}
+	head := map[string][]compilerDiagnostic{"./pkg": parseDiagnostics(strings.NewReader(`# example.com/pkg [example.com/pkg.test]
+pkg/a.go:7:6: can inline grow with cost 3 as: func() []byte { return make([]byte, 10) }
+pkg/a.go:10:6: cannot inline (*T).spin: function too complex: cost 92 exceeds budget 80
+pkg/a.go:16:6: cannot inline walk: recursive
+pkg/b.go:3:6: cannot inline added: marked go:noinline
+`))}
+	assert.Equal(t, []report.InlineChange{{
+		Package:  "./pkg",
+		Function: "(*T).spin",
+		Position: "pkg/a.go:10:6",
+		Reason:   "function too complex: cost 92 exceeds budget 80",
+	}}, newInlineChanges([]string{"./pkg"}, base, head))
+	assert.Nil(t, newInlineChanges([]string{"./pkg"}, nil, head))
+}
+
+func Test_showInlineChanges(t *testing.T) {
+	w := &bytes.Buffer{}
+	showInlineChanges(w, []report.InlineChange{{Package: "./pkg", Function: "spin", Position: "pkg/a.go:10:6", Reason: "marked go:noinline"}}, "ascii")
+	got := w.String()
+	assert.Contains(t, got, "No longer inlined\n=================")
+	assert.Contains(t, got, "| spin     | pkg/a.go:10:6 | marked go:noinline |")
+}
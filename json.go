@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/knqyf263/cob/pkg/cob"
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+func newReport(results []result, base, head report.Commit, threshold float64, comparedScore comparedScore, timer *phaseTimer) report.Report {
+	rep := cob.NewReport(results, base, head, threshold, comparedScore.cobScore())
+	if timer != nil {
+		for _, p := range timer.phases {
+			rep.Timings = append(rep.Timings, report.Timing{Name: p.Name, Seconds: p.Duration.Seconds()})
+		}
+	}
+	return rep
+}
+
+// writeJSONFile writes v as indented JSON, which keeps files committed to a repository diffable.
+func writeJSONFile(path string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err = ioutil.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return xerrors.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string `xml:"name,attr"`
+	ClassName string `xml:"classname,attr"`
+	// Time is ns/op in seconds, which the Jenkins Performance plugin charts as the response time.
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+}
+
+// generateJUnit converts the report into a JUnit XML report with one test case per benchmark. Benchmarks which
+// got worse than the threshold are failures.
+func generateJUnit(rep report.Report) junitTestSuites {
+	suite := junitTestSuite{Name: "cob"}
+	var total float64
+	for _, b := range rep.Benchmarks {
+		tc := junitTestCase{
+			Name:      b.Name,
+			ClassName: "cob",
+			Time:      junitTime(b.Head.NsPerOp),
+			SystemOut: fmt.Sprintf("%.2f ns/op (%+.2f%%), %d B/op (%+.2f%%), %d allocs/op (%+.2f%%)",
+				b.Head.NsPerOp, 100*b.Ratio.NsPerOp, b.Head.AllocedBytesPerOp, 100*b.Ratio.AllocedBytesPerOp,
+				b.Head.AllocsPerOp, 100*b.Ratio.AllocsPerOp),
+		}
+		if b.Status == report.StatusFail {
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("got worse than the threshold (%.2f%%)", 100*rep.Threshold),
+				Type:    "regression",
+			}
+			suite.Failures++
+		}
+		suite.Tests++
+		total += b.Head.NsPerOp
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Time = junitTime(total)
+	return junitTestSuites{Suites: []junitTestSuite{suite}}
+}
+
+// junitTime formats nanoseconds as seconds without an exponent, which some JUnit parsers don't accept.
+func junitTime(ns float64) string {
+	return fmt.Sprintf("%.9f", ns/1e9)
+}
+
+func writeJUnitFile(path string, rep report.Report) error {
+	b, err := xml.MarshalIndent(generateJUnit(rep), "", "  ")
+	if err != nil {
+		return xerrors.Errorf("failed to encode the JUnit report: %w", err)
+	}
+	b = append([]byte(xml.Header), append(b, '\n')...)
+	if err = ioutil.WriteFile(path, b, 0644); err != nil {
+		return xerrors.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
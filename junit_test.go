@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_generateJUnit(t *testing.T) {
+	rep := report.Report{
+		Threshold: 0.2,
+		Benchmarks: []report.Benchmark{
+			{Name: "BenchmarkA", Head: report.Measurement{NsPerOp: 1500}, Ratio: report.Ratio{NsPerOp: 0.5}, Status: report.StatusFail},
+			{Name: "BenchmarkB", Head: report.Measurement{NsPerOp: 500}, Status: report.StatusOK},
+		},
+	}
+	b, err := xml.MarshalIndent(generateJUnit(rep), "", "  ")
+	require.NoError(t, err)
+	want := `<testsuites>
+  <testsuite name="cob" tests="2" failures="1" time="0.000002000">
+    <testcase name="BenchmarkA" classname="cob" time="0.000001500">
+      <failure message="got worse than the threshold (20.00%)" type="regression"></failure>
+      <system-out>1500.00 ns/op (+50.00%), 0 B/op (+0.00%), 0 allocs/op (+0.00%)</system-out>
+    </testcase>
+    <testcase name="BenchmarkB" classname="cob" time="0.000000500">
+      <system-out>500.00 ns/op (+0.00%), 0 B/op (+0.00%), 0 allocs/op (+0.00%)</system-out>
+    </testcase>
+  </testsuite>
+</testsuites>`
+	assert.Equal(t, want, string(b))
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// usesLFS returns whether the .gitattributes in the repository root track any files with Git LFS.
+func usesLFS(dir string) bool {
+	f, err := os.Open(filepath.Join(dir, ".gitattributes"))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, attr := range strings.Fields(line) {
+			if attr == "filter=lfs" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lfsInstalled returns whether Git LFS is installed for the git command.
+func lfsInstalled(gitPath string) bool {
+	return exec.Command(gitPath, "lfs", "version").Run() == nil
+}
+
+// useLFS returns whether the Git LFS files are checked out at each commit for --lfs. auto checks them out if the
+// repository uses Git LFS and warns if it can't, because the benchmarks would read the pointer files.
+func useLFS(mode, gitPath string, usesLFS bool, installed func(gitPath string) bool) (bool, error) {
+	switch mode {
+	case "false":
+		return false, nil
+	case "true":
+		if gitPath == "" {
+			return false, xerrors.New("--lfs needs git: install it, or don't disable -git-exec")
+		}
+		if !installed(gitPath) {
+			return false, xerrors.New("--lfs needs Git LFS: 'git lfs version' failed")
+		}
+		return true, nil
+	case "auto":
+		if !usesLFS {
+			return false, nil
+		}
+		switch {
+		case gitPath == "":
+			warnf("The repository uses Git LFS, which needs -git-exec: the benchmarks may read the pointer files instead of the files")
+		case !installed(gitPath):
+			warnf("The repository uses Git LFS, which isn't installed: the benchmarks may read the pointer files instead of the files")
+		default:
+			return true, nil
+		}
+		return false, nil
+	default:
+		return false, xerrors.Errorf("invalid --lfs: %s (auto, true or false)", mode)
+	}
+}
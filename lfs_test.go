@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_usesLFS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.False(t, usesLFS(dir), "no .gitattributes")
+	attributes := filepath.Join(dir, ".gitattributes")
+	require.NoError(t, ioutil.WriteFile(attributes, []byte("# *.bin filter=lfs\n*.go text eol=lf\n"), 0644))
+	assert.False(t, usesLFS(dir))
+	require.NoError(t, ioutil.WriteFile(attributes, []byte("testdata/*.bin filter=lfs diff=lfs merge=lfs -text\n"), 0644))
+	assert.True(t, usesLFS(dir))
+}
+
+func Test_useLFS(t *testing.T) {
+	installed := func(string) bool { return true }
+	notInstalled := func(string) bool { return false }
+	tests := []struct {
+		mode      string
+		gitPath   string
+		usesLFS   bool
+		installed func(string) bool
+		want      bool
+		wantErr   string
+	}{
+		{mode: "auto", gitPath: "/usr/bin/git", usesLFS: true, installed: installed, want: true},
+		{mode: "auto", gitPath: "/usr/bin/git", usesLFS: false, installed: installed, want: false},
+		{mode: "auto", gitPath: "/usr/bin/git", usesLFS: true, installed: notInstalled, want: false},
+		{mode: "auto", gitPath: "", usesLFS: true, installed: installed, want: false},
+		{mode: "true", gitPath: "/usr/bin/git", usesLFS: false, installed: installed, want: true},
+		{mode: "true", gitPath: "/usr/bin/git", installed: notInstalled, wantErr: "--lfs needs Git LFS"},
+		{mode: "true", gitPath: "", installed: installed, wantErr: "--lfs needs git"},
+		{mode: "false", gitPath: "/usr/bin/git", usesLFS: true, installed: installed, want: false},
+		{mode: "yes", installed: installed, wantErr: "invalid --lfs: yes"},
+	}
+	for _, tt := range tests {
+		got, err := useLFS(tt.mode, tt.gitPath, tt.usesLFS, tt.installed)
+		if tt.wantErr != "" {
+			require.Error(t, err, tt.mode)
+			assert.Contains(t, err.Error(), tt.wantErr, tt.mode)
+			continue
+		}
+		assert.NoError(t, err, tt.mode)
+		assert.Equal(t, tt.want, got, "%+v", tt)
+	}
+}
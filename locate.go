@@ -0,0 +1,117 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+var procsSuffixRegexp = regexp.MustCompile(`-\d+$`)
+
+// benchmarkFunc is the location of a Benchmark function.
+type benchmarkFunc struct {
+	Name string
+	// Path is relative to the root directory and slash-separated.
+	Path string
+	Line int
+	// Parallel is whether the function calls b.RunParallel.
+	Parallel bool
+	// Annotation is the configuration in the magic comments above the function. AnnotationErr is why they
+	// couldn't be parsed, in which case the function has no annotation.
+	Annotation    benchmarkAnnotation
+	AnnotationErr error
+}
+
+// withoutProcsSuffix returns the name of the benchmark without the GOMAXPROCS suffix, e.g.
+// "BenchmarkFoo/bar-8" => "BenchmarkFoo/bar".
+func withoutProcsSuffix(name string) string {
+	return procsSuffixRegexp.ReplaceAllString(name, "")
+}
+
+// benchmarkFuncName returns the name of the function which defines the benchmark,
+// e.g. "BenchmarkFoo/bar-8" => "BenchmarkFoo".
+func benchmarkFuncName(name string) string {
+	name = withoutProcsSuffix(name)
+	if i := strings.Index(name, "/"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// callsRunParallel returns whether the function calls a RunParallel method, e.g. b.RunParallel.
+func callsRunParallel(fn *ast.FuncDecl) bool {
+	found := false
+	ast.Inspect(fn, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "RunParallel" {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// findBenchmarkFuncs walks test files under root and returns Benchmark functions by name.
+// If functions with the same name exist in several packages, the first one found is used.
+func findBenchmarkFuncs(root string) (map[string]benchmarkFunc, error) {
+	funcs := map[string]benchmarkFunc{}
+	fset := token.NewFileSet()
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			switch info.Name() {
+			case ".git", "vendor", "testdata", "node_modules":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			debugf("unable to parse %s: %s", path, err)
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, "Benchmark") {
+				continue
+			}
+			if _, ok = funcs[fn.Name.Name]; ok {
+				continue
+			}
+			annotation, annotationErr := parseAnnotation(fn.Doc)
+			if annotationErr != nil {
+				annotationErr = xerrors.Errorf("%s:%d: %w", filepath.ToSlash(rel), fset.Position(fn.Doc.Pos()).Line, annotationErr)
+			}
+			funcs[fn.Name.Name] = benchmarkFunc{
+				Name:          fn.Name.Name,
+				Path:          filepath.ToSlash(rel),
+				Line:          fset.Position(fn.Pos()).Line,
+				Parallel:      callsRunParallel(fn),
+				Annotation:    annotation,
+				AnnotationErr: annotationErr,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to find benchmark functions: %w", err)
+	}
+	return funcs, nil
+}
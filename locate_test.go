@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_benchmarkFuncName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "BenchmarkFoo", want: "BenchmarkFoo"},
+		{name: "BenchmarkFoo-16", want: "BenchmarkFoo"},
+		{name: "BenchmarkFoo/bar-8", want: "BenchmarkFoo"},
+		{name: "BenchmarkFoo/size-1024", want: "BenchmarkFoo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, benchmarkFuncName(tt.name))
+		})
+	}
+}
+
+func Test_findBenchmarkFuncs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "foo"), 0700))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "vendor", "bar"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "foo", "foo_test.go"), []byte(`package foo
+
+import "testing"
+
+func TestFoo(t *testing.T) {}
+
+func BenchmarkFoo(b *testing.B) {}
+
+func BenchmarkFooParallel(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+		}
+	})
+}
+`), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "vendor", "bar", "bar_test.go"), []byte(`package bar
+
+import "testing"
+
+func BenchmarkBar(b *testing.B) {}
+`), 0600))
+
+	got, err := findBenchmarkFuncs(dir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]benchmarkFunc{
+		"BenchmarkFoo":         {Name: "BenchmarkFoo", Path: "foo/foo_test.go", Line: 7},
+		"BenchmarkFooParallel": {Name: "BenchmarkFooParallel", Path: "foo/foo_test.go", Line: 9, Parallel: true},
+	}, got)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+func (l logLevel) String() string {
+	switch l {
+	case levelDebug:
+		return "debug"
+	case levelWarn:
+		return "warn"
+	case levelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+func parseLogLevel(s string) (logLevel, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return levelDebug, nil
+	case "info":
+		return levelInfo, nil
+	case "warn":
+		return levelWarn, nil
+	}
+	return levelInfo, xerrors.Errorf("unknown log level: %s", s)
+}
+
+// logger writes leveled messages as text or as JSON lines.
+type logger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level logLevel
+	json  bool
+	now   func() time.Time
+}
+
+var defaultLogger = &logger{w: os.Stderr, level: levelInfo, now: time.Now}
+
+func setupLogger(level, format string) error {
+	l, err := parseLogLevel(level)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "text", "json":
+	default:
+		return xerrors.Errorf("unknown log format: %s", format)
+	}
+
+	defaultLogger.mu.Lock()
+	defer defaultLogger.mu.Unlock()
+	defaultLogger.level = l
+	defaultLogger.json = format == "json"
+	return nil
+}
+
+func (l *logger) logf(level logLevel, format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if level < l.level {
+		return
+	}
+
+	msg := fmt.Sprintf(format, v...)
+	now := l.now()
+	if l.json {
+		b, _ := json.Marshal(struct {
+			Time  string `json:"time"`
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}{now.Format(time.RFC3339), level.String(), msg})
+		fmt.Fprintln(l.w, string(b))
+		return
+	}
+	fmt.Fprintf(l.w, "%s %s %s\n", now.Format("2006/01/02 15:04:05"), strings.ToUpper(level.String()), msg)
+}
+
+func debugf(format string, v ...interface{}) {
+	defaultLogger.logf(levelDebug, format, v...)
+}
+
+func infof(format string, v ...interface{}) {
+	defaultLogger.logf(levelInfo, format, v...)
+}
+
+func warnf(format string, v ...interface{}) {
+	defaultLogger.logf(levelWarn, format, v...)
+}
+
+func fatal(err error) {
+	defaultLogger.logf(levelError, "%s", err)
+	os.Exit(exitCode(err))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_logger(t *testing.T) {
+	now := func() time.Time {
+		return time.Date(2020, 1, 12, 17, 32, 30, 0, time.UTC)
+	}
+	tests := []struct {
+		name  string
+		level logLevel
+		json  bool
+		want  string
+	}{
+		{
+			name:  "text",
+			level: levelInfo,
+			want: "2020/01/12 17:32:30 INFO info message\n" +
+				"2020/01/12 17:32:30 WARN warn message\n",
+		},
+		{
+			name:  "debug",
+			level: levelDebug,
+			want: "2020/01/12 17:32:30 DEBUG debug message\n" +
+				"2020/01/12 17:32:30 INFO info message\n" +
+				"2020/01/12 17:32:30 WARN warn message\n",
+		},
+		{
+			name:  "json",
+			level: levelWarn,
+			json:  true,
+			want:  `{"time":"2020-01-12T17:32:30Z","level":"warn","msg":"warn message"}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			l := &logger{w: w, level: tt.level, json: tt.json, now: now}
+			l.logf(levelDebug, "debug %s", "message")
+			l.logf(levelInfo, "info %s", "message")
+			l.logf(levelWarn, "warn %s", "message")
+			assert.Equal(t, tt.want, w.String(), tt.name)
+		})
+	}
+}
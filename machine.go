@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+// machine describes where benchmarks ran, so that results from different runners can be told apart.
+type machine struct {
+	Hostname  string `json:"hostname"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	CPUs      int    `json:"cpus"`
+	GoVersion string `json:"goVersion,omitempty"`
+	// Tag is given by the user with -machine, e.g. "ci-large-8core". Only runs with the same tag are compared.
+	Tag string `json:"tag,omitempty"`
+	// GOFLAGS, GOEXPERIMENT, GCFlags and LDFlags are the buildFlags the benchmarks were built with.
+	GOFLAGS      string `json:"goflags,omitempty"`
+	GOEXPERIMENT string `json:"goexperiment,omitempty"`
+	GCFlags      string `json:"gcflags,omitempty"`
+	LDFlags      string `json:"ldflags,omitempty"`
+}
+
+// benchMachine sets the build flags of c in the environment of the benchmarks, and returns the machine they run
+// on, tagged with -machine.
+func benchMachine(c config) (machine, error) {
+	build := newBuildFlags(c, os.Getenv)
+	if err := build.apply(); err != nil {
+		return machine{}, xerrors.Errorf("failed to set the build flags: %w", err)
+	}
+	m := currentMachine()
+	build.record(&m)
+	m.Tag = c.machine
+	return m, nil
+}
+
+// currentMachine returns the machine cob runs on. The Go version is the one of the go command, which builds
+// the benchmarks, rather than the one cob was built with.
+func currentMachine() machine {
+	hostname, _ := os.Hostname()
+	m := machine{
+		Hostname: hostname,
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		CPUs:     runtime.NumCPU(),
+	}
+	if out, err := exec.Command("go", "env", "GOVERSION").Output(); err == nil {
+		m.GoVersion = strings.TrimSpace(string(out))
+	}
+	return m
+}
+
+func (m machine) environment() *report.Environment {
+	return &report.Environment{
+		Hostname:     m.Hostname,
+		OS:           m.OS,
+		Arch:         m.Arch,
+		CPUs:         m.CPUs,
+		GoVersion:    m.GoVersion,
+		Tag:          m.Tag,
+		GOFLAGS:      m.GOFLAGS,
+		GOEXPERIMENT: m.GOEXPERIMENT,
+		GCFlags:      m.GCFlags,
+		LDFlags:      m.LDFlags,
+	}
+}
+
+func machineOf(env report.Environment) machine {
+	return machine{
+		Hostname:     env.Hostname,
+		OS:           env.OS,
+		Arch:         env.Arch,
+		CPUs:         env.CPUs,
+		GoVersion:    env.GoVersion,
+		Tag:          env.Tag,
+		GOFLAGS:      env.GOFLAGS,
+		GOEXPERIMENT: env.GOEXPERIMENT,
+		GCFlags:      env.GCFlags,
+		LDFlags:      env.LDFlags,
+	}
+}
+
+// environmentMismatches lists how the machine the baseline was measured on differs from the current one. The
+// hostname is not compared, because runners of the same kind have different names. A field the baseline
+// didn't record, e.g. the Go version of an old run, is not compared either.
+func environmentMismatches(baseline, current machine) []string {
+	var mismatches []string
+	compare := func(name, b, c string) {
+		if b != "" && b != c {
+			mismatches = append(mismatches, fmt.Sprintf("%s: %s (baseline) vs %s (HEAD)", name, b, c))
+		}
+	}
+	compare("OS", baseline.OS, current.OS)
+	compare("architecture", baseline.Arch, current.Arch)
+	if baseline.CPUs != 0 {
+		compare("CPUs", strconv.Itoa(baseline.CPUs), strconv.Itoa(current.CPUs))
+	}
+	compare("Go version", baseline.GoVersion, current.GoVersion)
+	if baseline.Tag != current.Tag {
+		compare("machine tag", orNone(baseline.Tag), orNone(current.Tag))
+	}
+	// Unlike the fields above, a build flag the baseline didn't record is compared as well, because it most
+	// likely wasn't set.
+	for _, f := range []struct{ name, baseline, current string }{
+		{"GOFLAGS", baseline.GOFLAGS, current.GOFLAGS},
+		{"GOEXPERIMENT", baseline.GOEXPERIMENT, current.GOEXPERIMENT},
+		{"-gcflags", baseline.GCFlags, current.GCFlags},
+		{"-ldflags", baseline.LDFlags, current.LDFlags},
+	} {
+		if f.baseline != f.current {
+			compare(f.name, orNone(f.baseline), orNone(f.current))
+		}
+	}
+	return mismatches
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
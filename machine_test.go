@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_environmentMismatches(t *testing.T) {
+	current := machine{Hostname: "runner-2", OS: "linux", Arch: "amd64", CPUs: 8, GoVersion: "go1.14", Tag: "ci-large"}
+	tests := []struct {
+		name     string
+		baseline machine
+		want     []string
+	}{
+		{
+			name:     "another runner of the same kind",
+			baseline: machine{Hostname: "runner-1", OS: "linux", Arch: "amd64", CPUs: 8, GoVersion: "go1.14", Tag: "ci-large"},
+		},
+		{
+			name:     "unrecorded fields",
+			baseline: machine{OS: "linux", Arch: "amd64", Tag: "ci-large"},
+		},
+		{
+			name:     "another machine and toolchain",
+			baseline: machine{OS: "darwin", Arch: "arm64", CPUs: 4, GoVersion: "go1.13.8"},
+			want: []string{
+				"OS: darwin (baseline) vs linux (HEAD)",
+				"architecture: arm64 (baseline) vs amd64 (HEAD)",
+				"CPUs: 4 (baseline) vs 8 (HEAD)",
+				"Go version: go1.13.8 (baseline) vs go1.14 (HEAD)",
+				"machine tag: none (baseline) vs ci-large (HEAD)",
+			},
+		},
+		{
+			name:     "another GOEXPERIMENT",
+			baseline: machine{OS: "linux", Arch: "amd64", Tag: "ci-large", GOEXPERIMENT: "rangefunc"},
+			want:     []string{"GOEXPERIMENT: rangefunc (baseline) vs none (HEAD)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, environmentMismatches(tt.baseline, current))
+		})
+	}
+
+	m := machineOf(*current.environment())
+	assert.Equal(t, current, m)
+	current.GCFlags = "all=-N -l"
+	assert.Equal(t, current, machineOf(*current.environment()))
+}
@@ -1,16 +1,21 @@
 package main
 
 import (
-	"bytes"
 	"fmt"
 	"io"
-	"log"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 
 	"gopkg.in/src-d/go-git.v4/plumbing"
 
+	"github.com/knqyf263/cob/pkg/cob"
+	"github.com/knqyf263/cob/pkg/report"
+
 	"golang.org/x/xerrors"
 
 	"github.com/olekukonko/tablewriter"
@@ -19,64 +24,665 @@ import (
 	"gopkg.in/src-d/go-git.v4"
 )
 
-type result struct {
-	Name                   string
-	RatioNsPerOp           float64
-	RatioAllocedBytesPerOp float64
-}
+// result is the comparison of a benchmark. Its Threshold is that of a //cob:threshold annotation.
+type result = cob.Result
 
 type comparedScore struct {
 	nsPerOp           bool
 	allocedBytesPerOp bool
 }
 
+func (s comparedScore) cobScore() cob.Score {
+	return cob.Score{NsPerOp: s.nsPerOp, AllocedBytesPerOp: s.allocedBytesPerOp}
+}
+
+type columns struct {
+	name              bool
+	iterations        bool
+	nsPerOp           bool
+	allocedBytesPerOp bool
+	allocsPerOp       bool
+	mbPerS            bool
+	ratio             bool
+	status            bool
+}
+
+var runCommand = &cli.Command{
+	Name:   "run",
+	Usage:  "Benchmark the base commit and HEAD and compare them, as cob without a command does",
+	Flags:  runFlags,
+	Action: runAction,
+}
+
+// runFlags are the options of "cob run", which are also the global options so that "cob" without a command
+// keeps working as before.
+var runFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:  "only-degression",
+		Usage: "Show only benchmarks with worse score",
+	},
+	&cli.Float64Flag{
+		Name:  "threshold",
+		Usage: "The program fails if the benchmark gets worse than the threshold",
+		Value: 0.2,
+	},
+	&cli.Float64Flag{
+		Name:  "significance",
+		Usage: "Only fail on changes which are significant at the level, e.g. 0.05, in the Mann-Whitney U test of the runs with -count (0 to disable)",
+	},
+	&cli.StringFlag{
+		Name:  "preset",
+		Usage: "Defaults for the other options: quick (-benchtime=100ms -count=1) or thorough (-count=10 -significance 0.05 -confirm 1)",
+	},
+	&cli.BoolFlag{
+		Name:  "fail-fast",
+		Usage: "Stop benchmarking HEAD at the first benchmark which got worse than the threshold, and only compare the benchmarks until then",
+	},
+	&cli.IntFlag{
+		Name:  "confirm",
+		Usage: "Re-run the benchmarks which got worse than the threshold up to N times, and fail only on those which got worse every time (0 to disable)",
+	},
+	&cli.BoolFlag{
+		Name:    "no-fail",
+		Aliases: []string{"report-only"},
+		Usage:   "Compare and report as usual, but exit with 0 even if benchmarks got worse or cob failed",
+	},
+	&cli.BoolFlag{
+		Name:  "strict",
+		Usage: "Fail if benchmarks of the base commit are missing at HEAD, e.g. because they were removed or renamed",
+	},
+	&cli.StringFlag{
+		Name:  "base",
+		Usage: "Specify a base commit compared with HEAD",
+		Value: "HEAD~1",
+	},
+	&cli.StringFlag{
+		Name:  "git-exec",
+		Usage: "Check out the commits with the git command instead of go-git, which is much faster in large repositories and applies .gitattributes (auto, true, false). auto uses git if it is in PATH",
+		Value: "auto",
+	},
+	&cli.StringFlag{
+		Name:  "lfs",
+		Usage: "Check out the Git LFS files of each commit with 'git lfs pull' instead of their pointer files (auto, true, false). auto does it if .gitattributes tracks files with Git LFS",
+		Value: "auto",
+	},
+	&cli.BoolFlag{
+		Name:  "same-deps",
+		Usage: "Benchmark the base commit with go.mod and go.sum of HEAD, to compare only the changes of the code",
+	},
+	&cli.StringSliceFlag{
+		Name:  "preserve",
+		Usage: "Keep the path, e.g. generated code which isn't committed, as it is at HEAD across checkouts. Glob patterns are allowed (repeatable)",
+	},
+	&cli.StringFlag{
+		Name:  "post-checkout",
+		Usage: "Run the shell command after each checkout, e.g. 'go generate ./...'. COB_COMMIT is the commit checked out",
+	},
+	&cli.StringFlag{
+		Name:  "compare",
+		Usage: "Which score to compare",
+		Value: "ns/op,B/op",
+	},
+	&cli.StringFlag{
+		Name:  "bench-cmd",
+		Usage: "Specify a command to measure benchmarks",
+		Value: "go",
+	},
+	&cli.StringFlag{
+		Name:  "bench-args",
+		Usage: "Specify arguments passed to -cmd",
+		Value: "test -run '^$' -bench . -benchmem ./...",
+	},
+	&cli.StringSliceFlag{
+		Name:  "exclude",
+		Usage: "Exclude the benchmarks matching the regular expression from the comparison (repeatable)",
+	},
+	&cli.StringFlag{
+		Name:  "goflags",
+		Usage: "Flags added to GOFLAGS for the go commands which build the benchmarks, e.g. -tags=integration",
+	},
+	&cli.StringFlag{
+		Name:  "goexperiment",
+		Usage: "GOEXPERIMENT for the go commands which build the benchmarks, e.g. rangefunc",
+	},
+	&cli.StringFlag{
+		Name:  "gcflags",
+		Usage: "-gcflags for the go commands which build the benchmarks, e.g. all=-d=checkptr",
+	},
+	&cli.StringFlag{
+		Name:  "ldflags",
+		Usage: "-ldflags for the go commands which build the benchmarks",
+	},
+	&cli.StringFlag{
+		Name:  "columns",
+		Usage: "Which columns to show (name, iter, ns, bytes, allocs, mbs, ratio, status)",
+		Value: "name,iter,ns,bytes,ratio,status",
+	},
+	&cli.StringFlag{
+		Name:  "table-style",
+		Usage: "Table style (ascii, unicode, compact, markdown)",
+		Value: "ascii",
+	},
+	&cli.BoolFlag{
+		Name:  "summary-line",
+		Usage: "Print a single-line summary at the end",
+	},
+	&cli.BoolFlag{
+		Name:  "ascii",
+		Usage: "Use ASCII status markers instead of emoji",
+	},
+	&cli.BoolFlag{
+		Name:  "github-pr-comment",
+		Usage: "Post the result as a comment on the pull request (GITHUB_TOKEN is required)",
+	},
+	&cli.BoolFlag{
+		Name:  "github-check",
+		Usage: "Create a check run with annotations on the benchmark functions (GITHUB_TOKEN is required)",
+	},
+	&cli.BoolFlag{
+		Name:  "github-status",
+		Usage: "Set a commit status with the worst ratio (GITHUB_TOKEN is required)",
+	},
+	&cli.BoolFlag{
+		Name:  "gitlab-mr-note",
+		Usage: "Post the result as a note on the merge request (GITLAB_TOKEN is required)",
+	},
+	&cli.BoolFlag{
+		Name:  "bitbucket-pr-comment",
+		Usage: "Post the result as a comment on the Bitbucket pull request",
+	},
+	&cli.BoolFlag{
+		Name:  "bitbucket-status",
+		Usage: "Set a Bitbucket build status with the worst ratio",
+	},
+	&cli.StringFlag{
+		Name:  "gitea-url",
+		Usage: "Base URL of the Gitea/Forgejo instance, e.g. https://gitea.example.com",
+	},
+	&cli.StringFlag{
+		Name:  "gitea-repo",
+		Usage: "Gitea repository as owner/repo (default: detected from CI variables)",
+	},
+	&cli.IntFlag{
+		Name:  "gitea-pr",
+		Usage: "Gitea pull request number (default: detected from CI variables)",
+	},
+	&cli.BoolFlag{
+		Name:  "gitea-pr-comment",
+		Usage: "Post the result as a comment on the Gitea pull request (GITEA_TOKEN is required)",
+	},
+	&cli.BoolFlag{
+		Name:  "gitea-status",
+		Usage: "Set a Gitea commit status with the worst ratio (GITEA_TOKEN is required)",
+	},
+	&cli.BoolFlag{
+		Name:  "azure-pr-thread",
+		Usage: "Post the result as a thread on the Azure DevOps pull request",
+	},
+	&cli.BoolFlag{
+		Name:  "azure-status",
+		Usage: "Set an Azure DevOps commit status with the worst ratio",
+	},
+	&cli.StringFlag{
+		Name:  "gerrit-url",
+		Usage: "Base URL of the Gerrit server, e.g. https://gerrit.example.com",
+	},
+	&cli.StringFlag{
+		Name:  "gerrit-change",
+		Usage: "Gerrit change to review (default: $GERRIT_CHANGE_NUMBER)",
+	},
+	&cli.StringFlag{
+		Name:  "gerrit-label",
+		Usage: "Gerrit label to vote -1/0/+1 on, e.g. Verified (default: no vote)",
+	},
+	&cli.BoolFlag{
+		Name:  "gerrit-review",
+		Usage: "Post the result as a Gerrit review message (GERRIT_USERNAME and GERRIT_PASSWORD are required)",
+	},
+	&cli.BoolFlag{
+		Name:  "buildkite-annotation",
+		Usage: "Annotate the Buildkite build with the comparison",
+	},
+	&cli.StringFlag{
+		Name:  "webhook",
+		Usage: "POST the JSON result to the URL (signed with $COB_WEBHOOK_SECRET if set)",
+	},
+	&cli.StringFlag{
+		Name:  "slack-webhook",
+		Usage: "Send a summary to the Slack incoming webhook URL",
+	},
+	&cli.BoolFlag{
+		Name:  "slack-only-degression",
+		Usage: "Send the Slack summary only when benchmarks get worse than the threshold",
+	},
+	&cli.StringFlag{
+		Name:  "discord-webhook",
+		Usage: "Send a summary to the Discord webhook URL",
+	},
+	&cli.BoolFlag{
+		Name:  "discord-only-degression",
+		Usage: "Send the Discord summary only when benchmarks get worse than the threshold",
+	},
+	&cli.StringFlag{
+		Name:  "teams-webhook",
+		Usage: "Send a summary to the Microsoft Teams incoming webhook URL",
+	},
+	&cli.BoolFlag{
+		Name:  "teams-only-degression",
+		Usage: "Send the Teams summary only when benchmarks get worse than the threshold",
+	},
+	&cli.StringFlag{
+		Name:  "teams-report-url",
+		Usage: "URL of the full report linked from the Teams card (default: the CI run)",
+	},
+	&cli.StringFlag{
+		Name:  "email-to",
+		Usage: "Send the HTML report to the comma-separated email addresses",
+	},
+	&cli.StringFlag{
+		Name:  "email-from",
+		Usage: "Sender address of the email",
+	},
+	&cli.StringFlag{
+		Name:  "smtp-server",
+		Usage: "SMTP server used to send the email",
+		Value: "localhost:25",
+	},
+	&cli.BoolFlag{
+		Name:  "email-only-degression",
+		Usage: "Send the email only when benchmarks get worse than the threshold",
+	},
+	&cli.StringFlag{
+		Name:  "pushgateway",
+		Usage: "Push metrics to the Prometheus Pushgateway URL",
+	},
+	&cli.StringFlag{
+		Name:  "influxdb-url",
+		Usage: "Write points in the InfluxDB line protocol to the write endpoint URL",
+	},
+	&cli.StringFlag{
+		Name:  "influxdb-file",
+		Usage: "Write points in the InfluxDB line protocol to the file",
+	},
+	&cli.StringFlag{
+		Name:  "otlp-endpoint",
+		Usage: "Export metrics to the OpenTelemetry collector endpoint over OTLP/HTTP",
+	},
+	&cli.BoolFlag{
+		Name:  "datadog",
+		Usage: "Submit metrics, and an event on degression, to Datadog",
+	},
+	&cli.StringFlag{
+		Name:  "statsd",
+		Usage: "Send gauges to the StatsD server (host:port) over UDP",
+	},
+	&cli.StringFlag{
+		Name:  "graphite",
+		Usage: "Send metrics to the Graphite server (host:port) over UDP",
+	},
+	&cli.StringFlag{
+		Name:  "codespeed-url",
+		Usage: "Submit results to the Codespeed instance",
+	},
+	&cli.StringFlag{
+		Name:  "codespeed-project",
+		Usage: "Codespeed project of the results",
+	},
+	&cli.StringFlag{
+		Name:  "codespeed-executable",
+		Usage: "Codespeed executable of the results",
+		Value: "go",
+	},
+	&cli.StringFlag{
+		Name:  "codespeed-environment",
+		Usage: "Codespeed environment of the results (default: the hostname)",
+	},
+	&cli.StringFlag{
+		Name:  "bencher",
+		Usage: "Submit results to the Bencher project",
+	},
+	&cli.StringFlag{
+		Name:  "bencher-testbed",
+		Usage: "Bencher testbed of the results",
+		Value: "localhost",
+	},
+	&cli.StringFlag{
+		Name:  "bencher-file",
+		Usage: "Write results in the Bencher Metric Format to the file",
+	},
+	&cli.StringFlag{
+		Name:  "github-action-benchmark-file",
+		Usage: "Write results for github-action-benchmark's customSmallerIsBetter tool to the file",
+	},
+	&cli.StringFlag{
+		Name:  "gobenchdata-file",
+		Usage: "Add results to the gobenchdata JSON file",
+	},
+	&cli.StringFlag{
+		Name:  "junit-file",
+		Usage: "Write results as a JUnit XML report to the file",
+	},
+	&cli.StringFlag{
+		Name:  "report-dir",
+		Usage: "Write HTML, JSON and JUnit XML reports to the directory (default: /tmp/cob on CircleCI)",
+	},
+	&cli.StringFlag{
+		Name:  "store",
+		Usage: "Record the run in the store: a history file, git-notes, s3://, gs://, azblob:// or <scheme>:// of a cob-store-<scheme> plugin (empty not to record it)",
+		Value: defaultStore,
+	},
+	&cli.BoolFlag{
+		Name:  "baseline-from-store",
+		Usage: "Use the stored result of the base commit instead of running its benchmark, if there is one",
+	},
+	&cli.StringFlag{
+		Name:  "baseline-artifact",
+		Usage: "Compare HEAD with the JSON report in the latest GitHub Actions artifact uploaded on the branch, given as name@branch (GITHUB_TOKEN is required)",
+	},
+	&cli.StringFlag{
+		Name:  "baseline-url",
+		Usage: "Compare HEAD with the JSON report at the URL (with $COB_BASELINE_TOKEN as a bearer token if set)",
+	},
+	&cli.StringSliceFlag{
+		Name:  "baseline-url-header",
+		Usage: "Header sent with the request to -baseline-url, as 'Name: value' (repeatable)",
+	},
+	&cli.StringFlag{
+		Name:  "env-mismatch",
+		Usage: "What to do when a stored or downloaded baseline was measured in another environment (informational, fail)",
+		Value: "informational",
+	},
+	&cli.BoolFlag{
+		Name:  "test-time",
+		Usage: "Time the tests ('go test ./...') on both commits and report the change",
+	},
+	&cli.Float64Flag{
+		Name:  "test-time-threshold",
+		Usage: "The program fails if the tests get slower than the threshold with -test-time (0 to only report)",
+	},
+	&cli.BoolFlag{
+		Name:  "coverage",
+		Usage: "Measure the statement coverage of the tests on both commits and report the change",
+	},
+	&cli.Float64Flag{
+		Name:  "coverage-threshold",
+		Usage: "The program fails if the coverage drops by more than the percentage points with -coverage (0 to only report)",
+	},
+	&cli.StringFlag{
+		Name:  "profile-dir",
+		Usage: "Profile benchmarks which got worse than the threshold on both commits with -cpuprofile and write the profiles to the directory",
+	},
+	&cli.BoolFlag{
+		Name:  "memprofile-on-regression",
+		Usage: "Also collect -memprofile with -profile-dir, and show the allocation sites which changed the most",
+	},
+	&cli.BoolFlag{
+		Name:  "trace-on-regression",
+		Usage: "Also capture execution traces with -profile-dir, and show the change of the scheduler latency and the garbage collections",
+	},
+	&cli.StringFlag{
+		Name:  "profile-url",
+		Usage: "URL where the -profile-dir is published, e.g. by the CI. PR comments link to the flamegraphs under it",
+	},
+	&cli.BoolFlag{
+		Name:  "contention",
+		Usage: "Also collect -blockprofile and -mutexprofile of parallel benchmarks with -profile-dir, and show the change of their contention",
+	},
+	&cli.BoolFlag{
+		Name:  "perf",
+		Usage: "Count instructions, cache misses and branch mispredictions per op of each benchmark on both commits with 'perf stat' (Linux only)",
+	},
+	&cli.BoolFlag{
+		Name:  "max-rss",
+		Usage: "Measure the peak RSS of the process of each benchmark on both commits and report the change",
+	},
+	&cli.BoolFlag{
+		Name:  "energy",
+		Usage: "Measure the energy per op of each benchmark on both commits with the RAPL counters of the CPU (Linux only, usually requires root)",
+	},
+	&cli.StringFlag{
+		Name:  "startup",
+		Usage: "Build the main package, e.g. ./cmd/server, on both commits and compare how long the binary takes to start",
+	},
+	&cli.StringFlag{
+		Name:  "startup-ready",
+		Usage: "When the binary of -startup is ready: exit, port:ADDR (it accepts connections) or log:REGEXP (it prints a matching line)",
+		Value: "exit",
+	},
+	&cli.StringSliceFlag{
+		Name:  "startup-arg",
+		Usage: "Argument of the binary of -startup (repeatable)",
+	},
+	&cli.IntFlag{
+		Name:  "startup-runs",
+		Usage: "Number of times the binary of each commit is started with -startup. The median is compared",
+		Value: 5,
+	},
+	&cli.DurationFlag{
+		Name:  "startup-timeout",
+		Usage: "How long the binary of -startup may take to be ready",
+		Value: 30 * time.Second,
+	},
+	&cli.BoolFlag{
+		Name:  "inline-diff",
+		Usage: "Compile the packages of the benchmarks on both commits with -gcflags=-m=2 and show the functions which are no longer inlined",
+	},
+	&cli.BoolFlag{
+		Name:  "escape-diff",
+		Usage: "Compile the packages of the benchmarks on both commits with -gcflags=-m=2 and show the values which newly escape to the heap",
+	},
+	&cli.StringFlag{
+		Name:  "cache",
+		Usage: "Share results between runners through the cache: a directory, s3://, gs://, azblob:// or an http(s):// URL",
+	},
+	&cli.StringFlag{
+		Name:  "machine",
+		Usage: "Tag of the machine recorded with the run, e.g. ci-large-8core. Baselines and creep only use runs with the same tag",
+	},
+	&cli.Float64Flag{
+		Name:  "creep-threshold",
+		Usage: "Warn about benchmarks which got worse than the threshold over the last commits in the store (0 to disable)",
+	},
+	&cli.IntFlag{
+		Name:  "creep-window",
+		Usage: "Number of commits, including HEAD, to look for creep over",
+		Value: 10,
+	},
+	&cli.StringFlag{
+		Name:  "log-level",
+		Usage: "Log level (debug, info, warn)",
+		Value: "info",
+	},
+	&cli.StringFlag{
+		Name:  "log-format",
+		Usage: "Log format (text, json)",
+		Value: "text",
+	},
+	&cli.StringFlag{
+		Name:  "config",
+		Usage: "YAML file with the options, e.g. 'threshold: 0.1' (default: .cob.yaml if it exists). Flags and environment variables override it",
+	},
+	&cli.StringFlag{
+		Name:  "format",
+		Usage: "Output format (table, diff, json)",
+		Value: "table",
+	},
+	&cli.StringSliceFlag{
+		Name:  "reporter",
+		Usage: "Enable a reporter by name, e.g. slack, or write the output of one to a file, e.g. json=report.json. Other names run cob-reporter-<name> in PATH with the JSON report on stdin (repeatable)",
+	},
+}
+
+func runAction(c *cli.Context) error {
+	err := applyConfigFile(c)
+	if err == nil {
+		err = applyPreset(c)
+	}
+	if err == nil {
+		err = run(newConfig(c))
+	}
+	if err != nil && c.Bool("no-fail") {
+		// Everything is reported as usual, but the run never blocks.
+		warnf("%s (ignored with --no-fail)", err)
+		return nil
+	}
+	return err
+}
+
 func main() {
 	app := &cli.App{
-		Name:  "cob",
-		Usage: "Continuous Benchmark for Go project",
-		Action: func(c *cli.Context) error {
-			return run(newConfig(c))
-		},
-		Flags: []cli.Flag{
-			&cli.BoolFlag{
-				Name:  "only-degression",
-				Usage: "Show only benchmarks with worse score",
-			},
-			&cli.Float64Flag{
-				Name:  "threshold",
-				Usage: "The program fails if the benchmark gets worse than the threshold",
-				Value: 0.2,
-			},
-			&cli.StringFlag{
-				Name:  "base",
-				Usage: "Specify a base commit compared with HEAD",
-				Value: "HEAD~1",
-			},
-			&cli.StringFlag{
-				Name:  "compare",
-				Usage: "Which score to compare",
-				Value: "ns/op,B/op",
-			},
-			&cli.StringFlag{
-				Name:  "bench-cmd",
-				Usage: "Specify a command to measure benchmarks",
-				Value: "go",
-			},
-			&cli.StringFlag{
-				Name:  "bench-args",
-				Usage: "Specify arguments passed to -cmd",
-				Value: "test -run '^$' -bench . -benchmem ./...",
-			},
+		Name:   "cob",
+		Usage:  "Continuous Benchmark for Go project",
+		Action: runAction,
+		Commands: []*cli.Command{
+			runCommand,
+			compareCommand,
+			initCommand,
+			publishCommand,
+			historyCommand,
+			reportCommand,
+			serveCommand,
+			tuiCommand,
+			cacheCommand,
+			completionCommand,
+			selfUpdateCommand,
+			versionCommand,
 		},
+		Flags: runFlags,
 	}
 
+	// COB_* come first, so that they take precedence over the variables CI systems set for their settings.
+	addEnvVars(app.Flags, cobEnvVar)
+	addCommandEnvVars(app.Commands, cobEnvVar)
+	addEnvVars(app.Flags, pluginEnvVar)
+	addEnvVars(app.Flags, actionInputEnvVar)
+	unsetEmptyActionInputs(app.Flags)
+	useActionToken()
+
 	err := app.Run(os.Args)
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 }
 
 func run(c config) error {
+	if err := setupLogger(c.logLevel, c.logFormat); err != nil {
+		return xerrors.Errorf("invalid logging options: %w", err)
+	}
+
+	if c.format != "table" && c.format != "diff" && c.format != "json" {
+		return xerrors.Errorf("unknown output format: %s", c.format)
+	}
+
+	active, err := activeReporters(c, exec.LookPath)
+	if err != nil {
+		return err
+	}
+
+	if !isTableStyle(c.tableStyle) {
+		return xerrors.Errorf("unknown table style: %s", c.tableStyle)
+	}
+
+	if c.baselineFromStore && c.store == "" {
+		return xerrors.New("--baseline-from-store requires --store")
+	}
+
+	if c.baselineArtifact != "" && c.baselineURL != "" {
+		return xerrors.New("--baseline-artifact can't be used with --baseline-url")
+	}
+
+	if (c.baselineArtifact != "" || c.baselineURL != "") && c.baselineFromStore {
+		return xerrors.New("--baseline-artifact and --baseline-url can't be used with --baseline-from-store")
+	}
+
+	if c.envMismatch != "informational" && c.envMismatch != "fail" {
+		return xerrors.Errorf("unknown --env-mismatch: %s", c.envMismatch)
+	}
+
+	for _, h := range c.baselineURLHeaders {
+		if _, _, err := parseHeader(h); err != nil {
+			return xerrors.Errorf("invalid --baseline-url-header: %w", err)
+		}
+	}
+
+	if c.significance < 0 || c.significance >= 1 {
+		return xerrors.New("--significance must be at least 0 and less than 1")
+	}
+
+	if c.testTimeThreshold > 0 && !c.testTime {
+		return xerrors.New("--test-time-threshold requires --test-time")
+	}
+
+	if c.coverageThreshold > 0 && !c.coverage {
+		return xerrors.New("--coverage-threshold requires --coverage")
+	}
+
+	if c.memprofileOnRegression && c.profileDir == "" {
+		return xerrors.New("--memprofile-on-regression requires --profile-dir")
+	}
+	if c.maxRSS {
+		if err := checkMaxRSS(runtime.GOOS); err != nil {
+			return err
+		}
+	}
+	var zones []raplZone
+	if c.energy {
+		var err error
+		if zones, err = raplZones(runtime.GOOS, powercapDir); err != nil {
+			return err
+		}
+	}
+	var ready readiness
+	if c.startup != "" {
+		var err error
+		if ready, err = parseReadiness(c.startupReady); err != nil {
+			return err
+		}
+		if c.startupRuns < 1 {
+			return xerrors.New("--startup-runs must be at least 1")
+		}
+	}
+	if c.traceOnRegression && c.profileDir == "" {
+		return xerrors.New("--trace-on-regression requires --profile-dir")
+	}
+	if c.contention && c.profileDir == "" {
+		return xerrors.New("--contention requires --profile-dir")
+	}
+	if c.profileURL != "" && c.profileDir == "" {
+		return xerrors.New("--profile-url requires --profile-dir")
+	}
+	if c.perf {
+		if err := checkPerf(runtime.GOOS); err != nil {
+			return err
+		}
+	}
+
+	if c.creepThreshold > 0 && c.store == "" {
+		return xerrors.New("--creep-threshold requires --store")
+	}
+	if c.creepThreshold > 0 && c.creepWindow < 2 {
+		// A window of HEAD alone has nothing to creep from.
+		return xerrors.New("--creep-window must be at least 2")
+	}
+
+	excludes, err := compileExcludes(c.exclude)
+	if err != nil {
+		return err
+	}
+
+	cols, err := whichColumnsToShow(c.columns)
+	if err != nil {
+		return xerrors.Errorf("invalid columns: %w", err)
+	}
+	memory := needsBenchmem(whichScoreToCompare(c.compare), cols)
+	if memory {
+		var added bool
+		if c.benchArgs, added = addBenchmem(c.benchCmd, c.benchArgs); added {
+			infof("Add -benchmem to -bench-args, because the memory is compared or shown")
+		}
+	}
+
+	debugf("git: open the repository in the current directory")
 	r, err := git.PlainOpen(".")
 	if err != nil {
 		return xerrors.Errorf("unable to open the git repository: %w", err)
@@ -87,169 +693,775 @@ func run(c config) error {
 		return xerrors.Errorf("unable to get the reference where HEAD is pointing to: %w", err)
 	}
 
-	prev, err := r.ResolveRevision(plumbing.Revision(c.base))
+	debugf("git: HEAD is %s", head.Hash())
+
+	gitPath, err := gitExecPath(c.gitExec, exec.LookPath)
 	if err != nil {
-		return xerrors.Errorf("unable to resolves revision to corresponding hash: %w", err)
+		return err
 	}
-
-	w, err := r.Worktree()
+	lfs, err := useLFS(c.lfs, gitPath, usesLFS("."), lfsInstalled)
 	if err != nil {
-		return xerrors.Errorf("unable to get a worktree based on the given fs: %w", err)
+		return err
+	}
+	// The dependencies are compared once the base commit is resolved.
+	deps := &moduleSync{}
+	// GOFLAGS with -mod=vendor is set for each commit once the build flags are set.
+	var vendor *vendorFlags
+	hook := postCheckoutHook(c.postCheckout)
+	opts := cob.WorktreeOptions{Git: gitPath, LFS: lfs, Preserve: c.preserve}
+	opts.PostCheckout = func(commit plumbing.Hash) error {
+		if err := deps.afterCheckout(commit); err != nil {
+			return err
+		}
+		if vendor != nil {
+			if _, err := vendor.apply(); err != nil {
+				return err
+			}
+		}
+		if c.postCheckout != "" {
+			return hook(commit)
+		}
+		return nil
+	}
+	w, err := cob.NewWorktree(r, opts)
+	if xerrors.Is(err, cob.ErrDirty) {
+		return xerrors.New("the repository is dirty: commit all changes before running 'cob', or keep generated files with -preserve")
+	} else if err != nil {
+		return err
+	}
+	defer w.Close()
+	if w.UsesGit() {
+		debugf("git: check out the commits with %s", gitPath)
+	}
+	if lfs {
+		debugf("git: check out the Git LFS files of each commit")
 	}
 
-	s, err := w.Status()
-	if err != nil {
-		return xerrors.Errorf("unable to get the working tree status: %w", err)
+	// The base commit doesn't need to be in the repository when the baseline is downloaded, e.g. in a shallow
+	// clone, so it is resolved only if it is benchmarked or looked up.
+	base := report.Commit{Ref: c.base}
+	var prev *plumbing.Hash
+	if c.baselineArtifact == "" && c.baselineURL == "" {
+		h, err := w.Resolve(c.base)
+		if err != nil {
+			return err
+		}
+		prev = &h
+		base.Hash = prev.String()
+
+		debugf("git: %s resolves to %s", c.base, prev)
+
+		if deps, err = newModuleSync(r, ".", *prev, head.Hash(), c.sameDeps); err != nil {
+			warnf("Failed to compare the dependencies: %s", err)
+			deps = &moduleSync{}
+		}
+		if deps.files != nil {
+			infof("Benchmark %s with go.mod and go.sum of HEAD", c.base)
+		}
 	}
 
-	if !s.IsClean() {
-		return xerrors.New("the repository is dirty: commit all changes before running 'cob'")
+	var st store
+	if c.store != "" {
+		if st, err = openStore(c.store); err != nil {
+			return xerrors.Errorf("failed to open the store: %w", err)
+		}
 	}
 
-	err = w.Reset(&git.ResetOptions{Commit: *prev, Mode: git.HardReset})
+	var cache resultCache
+	if c.cache != "" {
+		if cache, err = openCache(c.cache); err != nil {
+			return xerrors.Errorf("failed to open the cache: %w", err)
+		}
+	}
+
+	m, err := benchMachine(c)
 	if err != nil {
-		return xerrors.Errorf("failed to reset the worktree to a previous commit: %w", err)
+		return err
+	}
+	vendor = &vendorFlags{goflags: os.Getenv("GOFLAGS")}
+	if vendored, err := vendor.apply(); err != nil {
+		return err
+	} else if vendored {
+		infof("Build with -mod=vendor, because the dependencies are vendored")
 	}
 
-	defer func() {
-		_ = w.Reset(&git.ResetOptions{Commit: head.Hash(), Mode: git.HardReset})
-	}()
+	startedAt := time.Now()
+	timer := newPhaseTimer()
+	var prevSet parse.Set
+	// baselineMachine is the machine a baseline which wasn't measured here was measured on, if it is known.
+	var baselineMachine *machine
+	if c.baselineArtifact != "" {
+		timer.start("fetch base")
+		rep, err := fetchArtifactBaseline(c.baselineArtifact)
+		if err != nil {
+			return xerrors.Errorf("failed to fetch the baseline artifact: %w", err)
+		}
+		base = report.Commit{Ref: c.baselineArtifact, Hash: rep.Head.Hash, Branch: rep.Head.Branch}
+		infof("Use the result of %s in the artifact %s as the baseline", shortHash(base.Hash), c.baselineArtifact)
+		prevSet = baselineSet(historyRun{Report: rep})
+		if rep.Environment != nil {
+			bm := machineOf(*rep.Environment)
+			baselineMachine = &bm
+		}
+	} else if c.baselineURL != "" {
+		timer.start("fetch base")
+		rep, err := fetchURLBaseline(newHTTPClient(), c.baselineURL, c.baselineURLHeaders)
+		if err != nil {
+			return xerrors.Errorf("failed to fetch the baseline: %w", err)
+		}
+		base = report.Commit{Ref: displayURL(c.baselineURL), Hash: rep.Head.Hash, Branch: rep.Head.Branch}
+		infof("Use the result of %s at %s as the baseline", shortHash(base.Hash), base.Ref)
+		prevSet = baselineSet(historyRun{Report: rep})
+		if rep.Environment != nil {
+			bm := machineOf(*rep.Environment)
+			baselineMachine = &bm
+		}
+	}
 
-	log.Printf("Run Benchmark: %s %s", prev, c.base)
-	prevSet, err := runBenchmark(c.benchCmd, c.benchArgs)
-	if err != nil {
-		return xerrors.Errorf("failed to run a benchmark: %w", err)
+	if c.baselineFromStore {
+		timer.start("fetch base")
+		run, err := st.latest(prev.String(), c.machine)
+		if err != nil {
+			return xerrors.Errorf("failed to look up the baseline in the store: %w", err)
+		}
+		if run != nil {
+			infof("Use the stored result of %s as the baseline", prev)
+			prevSet = baselineSet(*run)
+			baselineMachine = &run.Machine
+		} else {
+			infof("No stored result of %s, running the benchmark", prev)
+		}
 	}
 
-	err = w.Reset(&git.ResetOptions{Commit: head.Hash(), Mode: git.HardReset})
-	if err != nil {
-		return xerrors.Errorf("failed to reset the worktree to HEAD: %w", err)
+	// Results of the cache are keyed by the environment, so only the other baselines can be from elsewhere.
+	var mismatches []string
+	if baselineMachine != nil {
+		mismatches = environmentMismatches(*baselineMachine, m)
+	}
+	if len(mismatches) > 0 {
+		if c.envMismatch == "fail" {
+			return xerrors.Errorf("the baseline was measured in another environment: %s", strings.Join(mismatches, ", "))
+		}
+		warnf("The baseline was measured in another environment, so the comparison is informational: %s",
+			strings.Join(mismatches, ", "))
 	}
 
-	log.Printf("Run Benchmark: %s %s", head.Hash(), "HEAD")
-	headSet, err := runBenchmark(c.benchCmd, c.benchArgs)
-	if err != nil {
-		return xerrors.Errorf("failed to run a benchmark: %w", err)
+	// With -same-deps, the results of the base commit depend on the dependencies of HEAD.
+	baseCacheable := c.cache != "" && deps.files == nil
+	if prevSet == nil && baseCacheable {
+		timer.start("fetch cache")
+		if prevSet, err = cache.get(newCacheKeyInputs(prev.String(), c, m)); err != nil {
+			warnf("Failed to look up the baseline in the cache: %s", err)
+		} else if prevSet != nil {
+			infof("Use the cached result of %s as the baseline", prev)
+		}
 	}
 
-	var ratios []result
-	var rows [][]string
-	for benchName, headBenchmarks := range headSet {
-		prevBenchmarks, ok := prevSet[benchName]
-		if !ok {
-			continue
+	var baseTests, headTests *testTiming
+	var baseCoverage, headCoverage coverage
+	if prevSet == nil {
+		timer.start("checkout base")
+		debugf("git: reset --hard %s", prev)
+		if err = w.Checkout(*prev); err != nil {
+			return err
 		}
-		if len(headBenchmarks) == 0 || len(prevBenchmarks) == 0 {
-			continue
+
+		defer func() {
+			debugf("git: reset --hard %s", head.Hash())
+			if err := w.Restore(); err != nil {
+				warnf("failed to reset the worktree to HEAD: %s", err)
+			}
+		}()
+
+		timer.start("bench base")
+		infof("Run Benchmark: %s %s", prev, c.base)
+		prevSet, err = runBenchmark(c.benchCmd, c.benchArgs)
+		if err != nil {
+			return xerrors.Errorf("failed to run a benchmark: %w", err)
+		}
+		if baseCacheable {
+			if err = cache.put(newCacheKeyInputs(prev.String(), c, m), prevSet); err != nil {
+				warnf("Failed to cache the result of %s: %s", prev, err)
+			}
 		}
-		prevBench := prevBenchmarks[0]
-		headBench := headBenchmarks[0]
 
-		var ratioNsPerOp float64
-		if prevBench.NsPerOp != 0 {
-			ratioNsPerOp = (headBench.NsPerOp - prevBench.NsPerOp) / prevBench.NsPerOp
+		if c.testTime {
+			timer.start("test base")
+			infof("Run Tests: %s %s", prev, c.base)
+			if baseTests, err = runTestTiming(); err != nil {
+				return xerrors.Errorf("failed to time the tests: %w", err)
+			}
 		}
 
-		var ratioAllocedBytesPerOp float64
-		if prevBench.AllocedBytesPerOp != 0 {
-			ratioAllocedBytesPerOp = (float64(headBench.AllocedBytesPerOp) - float64(prevBench.AllocedBytesPerOp)) / float64(prevBench.AllocedBytesPerOp)
+		if c.coverage {
+			timer.start("coverage base")
+			infof("Measure Coverage: %s %s", prev, c.base)
+			if baseCoverage, err = runCoverage(); err != nil {
+				return xerrors.Errorf("failed to measure the coverage: %w", err)
+			}
+		}
+
+		timer.start("checkout head")
+		debugf("git: reset --hard %s", head.Hash())
+		if err = w.Restore(); err != nil {
+			return err
 		}
+	}
 
-		rows = append(rows, generateRow("HEAD", headBench))
-		rows = append(rows, generateRow("HEAD@{1}", prevBench))
+	annotations, err := benchmarkAnnotations(".")
+	if err != nil {
+		warnf("Failed to read the annotations of the benchmarks: %s", err)
+	}
 
-		ratios = append(ratios, result{
-			Name:                   benchName,
-			RatioNsPerOp:           ratioNsPerOp,
-			RatioAllocedBytesPerOp: ratioAllocedBytesPerOp,
+	timer.start("bench head")
+	infof("Run Benchmark: %s %s", head.Hash(), "HEAD")
+	check := regressionCheck{prevSet: prevSet, annotations: annotations, excludes: excludes, threshold: c.threshold,
+		score: whichScoreToCompare(c.compare), alpha: c.significance}
+	confirm := c.confirm
+	if confirm > 0 && !isGoTest(c.benchCmd, c.benchArgs) {
+		warnf("Regressions are not confirmed: re-running a benchmark needs 'go test' in -bench-cmd and -bench-args")
+		confirm = 0
+	}
+	ignored := map[string]bool{}
+	var stop func(parse.Set, string) bool
+	if c.failFast {
+		stop = stopAtRegression(check, ignored)
+	}
+	var headSet parse.Set
+	var stopped bool
+	for {
+		headSet, stopped, err = runBenchmarkUntil(c.benchCmd, c.benchArgs, stop)
+		if err != nil {
+			return xerrors.Errorf("failed to run a benchmark: %w", err)
+		}
+		if confirm == 0 {
+			break
+		}
+		var confirmed, unconfirmed []string
+		headSet, confirmed, unconfirmed = confirmRegressions(check, headSet, confirm, func(name string) (parse.Set, error) {
+			return runBenchmark(c.benchCmd, withGoTestFlag(c.benchArgs, "bench", benchmarkRegexp(name)))
 		})
+		if len(unconfirmed) > 0 {
+			infof("%d regression(s) were not confirmed: %s", len(unconfirmed), strings.Join(unconfirmed, ", "))
+		}
+		// A run stopped at a regression which wasn't confirmed runs again past it.
+		if !stopped || len(confirmed) > 0 || len(unconfirmed) == 0 {
+			break
+		}
+		for _, name := range unconfirmed {
+			ignored[name] = true
+		}
+		infof("Run Benchmark again: %s %s", head.Hash(), "HEAD")
+	}
+	if stopped {
+		infof("Stop benchmarking at the first benchmark which got worse than the threshold")
+	}
+	// HEAD is the base commit of later runs once it is merged. The results of a stopped run are incomplete.
+	if c.cache != "" && !stopped {
+		if err = cache.put(newCacheKeyInputs(head.Hash().String(), c, m), headSet); err != nil {
+			warnf("Failed to cache the result of %s: %s", head.Hash(), err)
+		}
 	}
 
-	if !c.onlyDegression {
-		showResult(os.Stdout, rows)
+	if c.testTime && !stopped {
+		if baseTests == nil {
+			infof("The base commit is not checked out, so only the tests of HEAD are timed")
+		}
+		timer.start("test head")
+		infof("Run Tests: %s %s", head.Hash(), "HEAD")
+		if headTests, err = runTestTiming(); err != nil {
+			return xerrors.Errorf("failed to time the tests: %w", err)
+		}
 	}
 
-	degression := showRatio(os.Stdout, ratios, c.threshold, whichScoreToCompare(c.compare), c.onlyDegression)
-	if degression {
-		return xerrors.New("This commit makes benchmarks worse")
+	if c.coverage && !stopped {
+		if baseCoverage == nil {
+			infof("The base commit is not checked out, so only the coverage of HEAD is measured")
+		}
+		timer.start("coverage head")
+		infof("Measure Coverage: %s %s", head.Hash(), "HEAD")
+		if headCoverage, err = runCoverage(); err != nil {
+			return xerrors.Errorf("failed to measure the coverage: %w", err)
+		}
+	}
+
+	timer.start("analysis")
+	if names := withoutMemoryStats(headSet); memory && len(names) > 0 {
+		warnf("%d benchmark(s) reported no B/op, so their memory is compared as 0: run them with -benchmem or b.ReportAllocs(): %s",
+			len(names), strings.Join(names, ", "))
+	}
+	ratios, rows := compareSets(prevSet, headSet, excludes, annotations, c.significance, cols)
+
+	if c.significance > 0 {
+		var untested []string
+		for _, r := range ratios {
+			if r.PValue == nil {
+				untested = append(untested, r.Name)
+			}
+		}
+		if len(untested) > 0 {
+			warnf("%d benchmark(s) ran less than twice, so their significance is not tested: add e.g. -count=10 to -bench-args: %s",
+				len(untested), strings.Join(untested, ", "))
+		}
+	}
+
+	score := whichScoreToCompare(c.compare)
+	timer.stop()
+	infof("Phase timing: %s", timer)
+
+	rep := newReport(ratios, base,
+		report.Commit{Ref: "HEAD", Hash: head.Hash().String(), Branch: detectBranch(head)}, c.threshold, score, timer)
+	rep.Environment = m.environment()
+	built := currentBuildInfo()
+	rep.Cob = &built
+	rep.Partial = stopped
+	// The benchmarks after the one a stopped run stopped at are missing because they didn't run.
+	if !stopped {
+		rep.Removed = removedBenchmarks(prevSet, headSet, excludes, annotations)
+	}
+	rep.Added = addedBenchmarks(prevSet, headSet, excludes, annotations)
+	if c.strict && len(rep.Removed) > 0 {
+		warnf("%d benchmark(s) of %s are missing at HEAD: %s", len(rep.Removed), base.Ref, strings.Join(rep.Removed, ", "))
+	}
+	if headTests != nil {
+		rep.TestTime = newTestTime(baseTests, headTests)
+	}
+	if headCoverage != nil {
+		rep.Coverage = newCoverage(baseCoverage, headCoverage)
+	}
+	if len(mismatches) > 0 {
+		rep.EnvironmentMismatches = mismatches
+		rep.Degression = false
 	}
 
+	if c.creepThreshold > 0 {
+		runs, err := st.runs()
+		if err != nil {
+			return xerrors.Errorf("failed to read the history: %w", err)
+		}
+		rep.Creep = detectCreep(runsOnMachine(runs, c.machine), rep, c.creepWindow, c.creepThreshold)
+	}
+
+	// atBase runs fn with the worktree at the base commit, and resets it to HEAD afterwards.
+	atBase := func(fn func()) error {
+		debugf("git: reset --hard %s", prev)
+		if err := w.Checkout(*prev); err != nil {
+			return err
+		}
+		fn()
+		debugf("git: reset --hard %s", head.Hash())
+		if err := w.Restore(); err != nil {
+			return err
+		}
+		return nil
+	}
+	if prev != nil && deps.changed() {
+		infof("Compare the dependencies of %s and HEAD", prev)
+		var baseModules map[string]string
+		var listErr error
+		if err = atBase(func() { baseModules, listErr = listModules() }); err != nil {
+			return err
+		}
+		headModules, err := listModules()
+		if listErr != nil {
+			err = listErr
+		}
+		if err != nil {
+			warnf("Failed to compare the dependencies: %s", err)
+		} else {
+			rep.Dependencies = newDependencyChanges(baseModules, headModules)
+			attributeDependencyChanges(rep.Dependencies, regressedBenchmarks(rep))
+		}
+	}
+	if regressed := regressedBenchmarks(rep); prev != nil && len(regressed) > 0 {
+		files, err := changedFiles(r, *prev, head.Hash())
+		if err != nil {
+			warnf("Failed to find the changed files: %s", err)
+		}
+		rep.ChangedFiles = newChangedFiles(regressed, files)
+	}
+	if regressed := regressedBenchmarks(rep); c.profileDir != "" && len(regressed) > 0 {
+		kinds := []profileKind{cpuProfile}
+		if c.memprofileOnRegression {
+			kinds = append(kinds, memProfile)
+		}
+		var baseProfiles profileFiles
+		if prev != nil {
+			infof("Profile %d benchmark(s) which got worse at %s", len(regressed), prev)
+			if err = atBase(func() {
+				baseProfiles = collectProfiles(regressed, filepath.Join(c.profileDir, "base"), kinds)
+			}); err != nil {
+				return err
+			}
+		}
+		infof("Profile %d benchmark(s) which got worse at HEAD", len(regressed))
+		headProfiles := collectProfiles(regressed, filepath.Join(c.profileDir, "head"), kinds)
+		rep.Profiles = newProfiles(regressed, kinds, baseProfiles, headProfiles)
+		if c.traceOnRegression {
+			var baseTraces profileFiles
+			var baseGC map[string]gcStats
+			if prev != nil {
+				infof("Trace %d benchmark(s) which got worse at %s", len(regressed), prev)
+				if err = atBase(func() {
+					baseTraces, baseGC = collectTraces(regressed, filepath.Join(c.profileDir, "base"))
+				}); err != nil {
+					return err
+				}
+			}
+			infof("Trace %d benchmark(s) which got worse at HEAD", len(regressed))
+			headTraces, headGC := collectTraces(regressed, filepath.Join(c.profileDir, "head"))
+			rep.Profiles = append(rep.Profiles, newProfiles(regressed, []profileKind{traceProfile}, baseTraces, headTraces)...)
+			rep.Traces = newTraceSummaries(regressed, baseTraces, headTraces, baseGC, headGC)
+		}
+		infof("Wrote the profiles to %s", c.profileDir)
+	}
+	if c.contention {
+		parallel, err := parallelBenchmarks(rep)
+		if err != nil {
+			warnf("Failed to compare the contention: %s", err)
+		}
+		if len(parallel) > 0 {
+			var baseProfiles profileFiles
+			if prev != nil {
+				infof("Profile the contention of %d parallel benchmark(s) at %s", len(parallel), prev)
+				if err = atBase(func() {
+					baseProfiles = collectProfiles(parallel, filepath.Join(c.profileDir, "base"), contentionKinds)
+				}); err != nil {
+					return err
+				}
+			}
+			infof("Profile the contention of %d parallel benchmark(s) at HEAD", len(parallel))
+			headProfiles := collectProfiles(parallel, filepath.Join(c.profileDir, "head"), contentionKinds)
+			rep.Contention = newContention(parallel, baseProfiles, headProfiles)
+			rep.Profiles = append(rep.Profiles, newProfiles(parallel, contentionKinds, baseProfiles, headProfiles)...)
+		}
+	}
+	if c.perf && len(rep.Benchmarks) > 0 {
+		var baseEvents map[string]*report.Events
+		if prev != nil {
+			infof("Count the hardware events of %d benchmark(s) at %s", len(rep.Benchmarks), prev)
+			if err = atBase(func() { baseEvents = countEvents(rep.Benchmarks) }); err != nil {
+				return err
+			}
+		}
+		infof("Count the hardware events of %d benchmark(s) at HEAD", len(rep.Benchmarks))
+		rep.HardwareCounters = newHardwareCounters(rep.Benchmarks, baseEvents, countEvents(rep.Benchmarks))
+	}
+	if c.maxRSS && len(rep.Benchmarks) > 0 {
+		var baseRSS map[string]int64
+		if prev != nil {
+			infof("Measure the peak RSS of %d benchmark(s) at %s", len(rep.Benchmarks), prev)
+			if err = atBase(func() { baseRSS = measureMaxRSS(rep.Benchmarks) }); err != nil {
+				return err
+			}
+		}
+		infof("Measure the peak RSS of %d benchmark(s) at HEAD", len(rep.Benchmarks))
+		rep.MaxRSS = newMaxRSS(rep.Benchmarks, baseRSS, measureMaxRSS(rep.Benchmarks))
+	}
+	if c.energy && len(rep.Benchmarks) > 0 {
+		var baseEnergy map[string]float64
+		if prev != nil {
+			infof("Measure the energy of %d benchmark(s) at %s", len(rep.Benchmarks), prev)
+			if err = atBase(func() { baseEnergy = measureEnergy(rep.Benchmarks, zones) }); err != nil {
+				return err
+			}
+		}
+		infof("Measure the energy of %d benchmark(s) at HEAD", len(rep.Benchmarks))
+		rep.Energy = newEnergy(rep.Benchmarks, baseEnergy, measureEnergy(rep.Benchmarks, zones))
+	}
+	if c.startup != "" {
+		dir, err := ioutil.TempDir("", "cob-startup")
+		if err != nil {
+			return xerrors.Errorf("failed to create a directory for the binaries: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		var baseBin string
+		if prev != nil {
+			var buildErr error
+			if err = atBase(func() {
+				buildErr = buildStartupBinary(c.startup, filepath.Join(dir, "base"))
+			}); err != nil {
+				return err
+			}
+			if buildErr != nil {
+				return buildErr
+			}
+			baseBin = filepath.Join(dir, "base")
+		}
+		headBin := filepath.Join(dir, "head")
+		if err = buildStartupBinary(c.startup, headBin); err != nil {
+			return err
+		}
+		infof("Start %s %d time(s) on each commit", c.startup, c.startupRuns)
+		medians, err := measureStartup([]string{baseBin, headBin}, c.startupArgs, ready, c.startupRuns, c.startupTimeout)
+		if err != nil {
+			return xerrors.Errorf("failed to measure the startup time: %w", err)
+		}
+		rep.Startup = newStartup(c.startup, c.startupReady, medians[0], medians[1])
+	}
+	if (c.inlineDiff || c.escapeDiff) && len(rep.Benchmarks) > 0 {
+		pkgs, err := packagesOfBenchmarks(rep.Benchmarks)
+		if err != nil {
+			warnf("Failed to compare the decisions of the compiler: %s", err)
+		}
+		var baseDiags map[string][]compilerDiagnostic
+		if prev != nil {
+			infof("Compile %d package(s) with -gcflags=-m=2 at %s", len(pkgs), prev)
+			if err = atBase(func() { baseDiags = collectDiagnostics(pkgs) }); err != nil {
+				return err
+			}
+		}
+		infof("Compile %d package(s) with -gcflags=-m=2 at HEAD", len(pkgs))
+		headDiags := collectDiagnostics(pkgs)
+		if c.inlineDiff {
+			rep.Inlining = newInlineChanges(pkgs, baseDiags, headDiags)
+		}
+		if c.escapeDiff {
+			rep.Escapes = newEscapes(pkgs, baseDiags, headDiags)
+		}
+	}
+	flamegraphs := flamegraphLinks(rep.Profiles, c.profileDir, c.profileURL)
+
+	worst, _ := verdict(ratios, c.threshold, score)
+	degression := worst == statusFail
+	if degression && len(mismatches) > 0 {
+		infof("Benchmarks got worse, but the comparison is informational because the environments differ")
+		degression = false
+	}
+	rc := reportContext{
+		config:      c,
+		report:      rep,
+		results:     ratios,
+		rows:        rows,
+		columns:     cols,
+		score:       score,
+		head:        head.Hash().String(),
+		flamegraphs: flamegraphs,
+		degression:  degression,
+		startedAt:   startedAt,
+	}
+	// The run is recorded first, so that a reporter which fails doesn't lose it.
+	var failures []string
+	if c.store != "" && stopped {
+		infof("The run stopped at the first benchmark which got worse, so it is not recorded in the store")
+	} else if c.store != "" {
+		run := historyRun{Time: time.Now().UTC(), Machine: m, Report: rep}
+		if err = st.put(run); err != nil {
+			failures = append(failures, fmt.Sprintf("failed to record the run: %s", err))
+		}
+	}
+	if err = runReporters(active, rc); err != nil {
+		failures = append(failures, err.Error())
+	}
+
+	// A regression decides the exit code even if reporting it failed, which is only logged then.
+	if err = regression(c, rep, degression); err != nil {
+		for _, f := range failures {
+			warnf("%s", f)
+		}
+		return err
+	}
+	if len(failures) > 0 {
+		return xerrors.New(strings.Join(failures, "; "))
+	}
 	return nil
 }
 
-func runBenchmark(cmd string, args []string) (parse.Set, error) {
-	out, err := exec.Command(cmd, args...).Output()
-	if err != nil {
-		return nil, xerrors.Errorf("failed to run '%s %s' command: %w", cmd, strings.Join(args, " "), err)
+// regression returns the regressionError of the first check the run failed, or nil.
+func regression(c config, rep report.Report, degression bool) error {
+	if degression {
+		return regressionf("This commit makes benchmarks worse")
 	}
 
-	b := bytes.NewBuffer(out)
-	s, err := parse.ParseSet(b)
-	if err != nil {
-		return nil, xerrors.Errorf("failed to parse a result of benchmarks: %w", err)
+	if c.strict && len(rep.Removed) > 0 {
+		return regressionf("This commit removes %d benchmark(s)", len(rep.Removed))
 	}
-	return s, nil
+
+	if tt := rep.TestTime; c.testTimeThreshold > 0 && tt != nil && tt.Base > 0 && tt.Ratio > c.testTimeThreshold {
+		return regressionf("This commit makes tests slower by %.2f%%", 100*tt.Ratio)
+	}
+
+	if cov := rep.Coverage; c.coverageThreshold > 0 && cov != nil && cov.Base != nil && -cov.Change > c.coverageThreshold {
+		return regressionf("This commit drops the coverage by %.1f percentage points", -cov.Change)
+	}
+
+	return nil
+}
+
+func runBenchmark(cmd string, args []string) (parse.Set, error) {
+	s, _, err := runBenchmarkUntil(cmd, args, nil)
+	return s, err
 }
 
-func generateRow(ref string, b *parse.Benchmark) []string {
-	return []string{b.Name, ref, fmt.Sprintf(" %.2f ns/op", b.NsPerOp),
-		fmt.Sprintf(" %d B/op", b.AllocedBytesPerOp)}
+// runBenchmarkUntil runs the benchmarks and parses their results as they are printed. If stop returns true
+// for the benchmark whose result was just parsed, the command is killed, and the results until then are
+// returned with stopped true.
+func runBenchmarkUntil(cmd string, args []string, stop func(s parse.Set, name string) bool) (set parse.Set, stopped bool, err error) {
+	debugf("exec: %s %s", cmd, strings.Join(args, " "))
+	return cob.RunBenchmarks("", cmd, args, stop)
 }
 
-func showResult(w io.Writer, rows [][]string) {
+func calcRatio(head, prev float64) float64 {
+	return cob.Ratio(head, prev)
+}
+
+func generateRow(ref string, b *parse.Benchmark, columns columns) []string {
+	var row []string
+	if columns.name {
+		row = append(row, b.Name)
+	}
+	row = append(row, ref)
+	if columns.iterations {
+		row = append(row, fmt.Sprintf(" %d", b.N))
+	}
+	if columns.nsPerOp {
+		row = append(row, fmt.Sprintf(" %.2f ns/op", b.NsPerOp))
+	}
+	if columns.allocedBytesPerOp {
+		row = append(row, fmt.Sprintf(" %d B/op", b.AllocedBytesPerOp))
+	}
+	if columns.allocsPerOp {
+		row = append(row, fmt.Sprintf(" %d allocs/op", b.AllocsPerOp))
+	}
+	if columns.mbPerS {
+		row = append(row, fmt.Sprintf(" %.2f MB/s", b.MBPerS))
+	}
+	return row
+}
+
+func showResult(w io.Writer, rows [][]string, columns columns, style string) {
 	fmt.Fprintln(w, "\nResult")
 	fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 6))
 
+	newResultTable(w, rows, columns, style).Render()
+}
+
+func newResultTable(w io.Writer, rows [][]string, columns columns, style string) *tablewriter.Table {
 	table := tablewriter.NewWriter(w)
 	table.SetAutoFormatHeaders(false)
 	table.SetAlignment(tablewriter.ALIGN_CENTER)
-	headers := []string{"Name", "Commit", "NsPerOp", "AllocedBytesPerOp"}
+	var headers []string
+	if columns.name {
+		headers = append(headers, "Name")
+	}
+	headers = append(headers, "Commit")
+	if columns.iterations {
+		headers = append(headers, "Iterations")
+	}
+	if columns.nsPerOp {
+		headers = append(headers, "NsPerOp")
+	}
+	if columns.allocedBytesPerOp {
+		headers = append(headers, "AllocedBytesPerOp")
+	}
+	if columns.allocsPerOp {
+		headers = append(headers, "AllocsPerOp")
+	}
+	if columns.mbPerS {
+		headers = append(headers, "MBPerS")
+	}
 	table.SetHeader(headers)
-	table.SetAutoMergeCells(true)
+	// Only the name of a benchmark is shared by its rows: equal iterations or results at both commits are
+	// values of their own.
+	if columns.name {
+		table.SetAutoMergeCellsByColumnIndex([]int{0})
+	}
 	table.SetRowLine(true)
+	applyTableStyle(table, style)
 	table.AppendBulk(rows)
-	table.Render()
+	return table
 }
 
-func showRatio(w io.Writer, results []result, threshold float64, comparedScore comparedScore, onlyDegression bool) bool {
+func showRatio(w io.Writer, results []result, threshold float64, comparedScore comparedScore, columns columns, onlyDegression, ascii, color bool, style string) bool {
+	table, degression := newRatioTable(w, results, threshold, comparedScore, columns, onlyDegression, ascii, color, style)
+	if table.NumLines() > 0 {
+		fmt.Fprintln(w, "\nComparison")
+		fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 10))
+
+		table.Render()
+		fmt.Fprintln(w)
+	}
+	return degression
+}
+
+func newRatioTable(w io.Writer, results []result, threshold float64, comparedScore comparedScore, columns columns, onlyDegression, ascii, color bool, style string) (*tablewriter.Table, bool) {
 	table := tablewriter.NewWriter(w)
 	table.SetAutoFormatHeaders(false)
 	table.SetAlignment(tablewriter.ALIGN_CENTER)
 	table.SetRowLine(true)
-	headers := []string{"Name", "NsPerOp", "AllocedBytesPerOp"}
+	var headers []string
+	if columns.name {
+		headers = append(headers, "Name")
+	}
+	if columns.nsPerOp {
+		headers = append(headers, "NsPerOp")
+	}
+	if columns.allocedBytesPerOp {
+		headers = append(headers, "AllocedBytesPerOp")
+	}
+	if columns.allocsPerOp {
+		headers = append(headers, "AllocsPerOp")
+	}
+	if columns.status {
+		headers = append(headers, "Status")
+	}
 	table.SetHeader(headers)
+	applyTableStyle(table, style)
 
 	var degression bool
 	for _, result := range results {
-		if comparedScore.nsPerOp && threshold < result.RatioNsPerOp {
-			degression = true
-		} else if comparedScore.allocedBytesPerOp && threshold < result.RatioAllocedBytesPerOp {
+		if isDegression(result, threshold, comparedScore) {
 			degression = true
-		} else {
-			if onlyDegression {
-				continue
+		} else if onlyDegression {
+			continue
+		}
+		if !columns.ratio {
+			continue
+		}
+
+		var row []string
+		var colors []tablewriter.Colors
+		if columns.name {
+			row = append(row, result.Name)
+			colors = append(colors, tablewriter.Colors{})
+		}
+		if columns.nsPerOp {
+			if comparedScore.nsPerOp {
+				row = append(row, generateRatioItem(result.RatioNsPerOp))
+				colors = append(colors, generateColor(result.RatioNsPerOp))
+			} else {
+				row = append(row, "-")
+				colors = append(colors, tablewriter.Colors{})
+			}
+		}
+		if columns.allocedBytesPerOp {
+			if comparedScore.allocedBytesPerOp {
+				row = append(row, generateRatioItem(result.RatioAllocedBytesPerOp))
+				colors = append(colors, generateColor(result.RatioAllocedBytesPerOp))
+			} else {
+				row = append(row, "-")
+				colors = append(colors, tablewriter.Colors{})
 			}
 		}
-		row := []string{result.Name, generateRatioItem(result.RatioNsPerOp), generateRatioItem(result.RatioAllocedBytesPerOp)}
-		colors := []tablewriter.Colors{{}, generateColor(result.RatioNsPerOp), generateColor(result.RatioAllocedBytesPerOp)}
-		if !comparedScore.nsPerOp {
-			row[1] = "-"
-			colors[1] = tablewriter.Colors{}
+		if columns.allocsPerOp {
+			row = append(row, generateRatioItem(result.RatioAllocsPerOp))
+			colors = append(colors, generateColor(result.RatioAllocsPerOp))
+		}
+		if columns.status {
+			row = append(row, benchmarkStatus(result, threshold, comparedScore).marker(ascii))
+			colors = append(colors, tablewriter.Colors{})
 		}
-		if !comparedScore.allocedBytesPerOp {
-			row[2] = "-"
-			colors[2] = tablewriter.Colors{}
+		if style == "markdown" || !color {
+			table.Append(row)
+			continue
 		}
 		table.Rich(row, colors)
 	}
-	if table.NumLines() > 0 {
-		fmt.Fprintln(w, "\nComparison")
-		fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 10))
+	return table, degression
+}
 
-		table.Render()
-		fmt.Fprintln(w)
-	}
-	return degression
+func isDegression(r result, threshold float64, comparedScore comparedScore) bool {
+	return cob.IsRegression(r, threshold, comparedScore.cobScore())
 }
 
 func generateRatioItem(ratio float64) string {
@@ -269,15 +1481,40 @@ func generateColor(ratio float64) tablewriter.Colors {
 	return tablewriter.Colors{tablewriter.Bold, tablewriter.FgBlueColor}
 }
 
+func whichColumnsToShow(c []string) (columns, error) {
+	var columns columns
+	for _, cc := range c {
+		switch cc {
+		case "name":
+			columns.name = true
+		case "iter":
+			columns.iterations = true
+		case "ns":
+			columns.nsPerOp = true
+		case "bytes":
+			columns.allocedBytesPerOp = true
+		case "allocs":
+			columns.allocsPerOp = true
+		case "mbs":
+			columns.mbPerS = true
+		case "ratio":
+			columns.ratio = true
+		case "status":
+			columns.status = true
+		default:
+			return columns, xerrors.Errorf("unknown column: %s", cc)
+		}
+	}
+	return columns, nil
+}
+
 func whichScoreToCompare(c []string) comparedScore {
 	var comparedScore comparedScore
 	for _, cc := range c {
 		switch cc {
 		case "ns/op":
-			fmt.Println("cpu")
 			comparedScore.nsPerOp = true
 		case "B/op":
-			fmt.Println("memory")
 			comparedScore.allocedBytesPerOp = true
 		}
 	}
@@ -22,10 +22,10 @@ func Test_showResult(t *testing.T) {
 			name: "happy path",
 			args: args{
 				rows: [][]string{
-					{"BenchmarkA", "HEAD", "123 ns/op", "234 B/op"},
-					{"BenchmarkA", "HEAD{@1}", "133 ns/op", "255 B/op"},
-					{"BenchmarkB", "HEAD", "5 ns/op", "7 B/op"},
-					{"BenchmarkB", "HEAD{@1}", "9 ns/op", "8 B/op"},
+					{"BenchmarkA", "HEAD", "1000", "123 ns/op", "234 B/op"},
+					{"BenchmarkA", "HEAD{@1}", "1000", "133 ns/op", "255 B/op"},
+					{"BenchmarkB", "HEAD", "300000", "5 ns/op", "7 B/op"},
+					{"BenchmarkB", "HEAD{@1}", "200000", "9 ns/op", "8 B/op"},
 				},
 				benchmem: true,
 			},
@@ -33,24 +33,24 @@ func Test_showResult(t *testing.T) {
 Result
 ======
 
-+------------+----------+-----------+-------------------+
-|    Name    |  Commit  |  NsPerOp  | AllocedBytesPerOp |
-+------------+----------+-----------+-------------------+
-| BenchmarkA |   HEAD   | 123 ns/op |     234 B/op      |
-+            +----------+-----------+-------------------+
-|            | HEAD{@1} | 133 ns/op |     255 B/op      |
-+------------+----------+-----------+-------------------+
-| BenchmarkB |   HEAD   |  5 ns/op  |      7 B/op       |
-+            +----------+-----------+-------------------+
-|            | HEAD{@1} |  9 ns/op  |      8 B/op       |
-+------------+----------+-----------+-------------------+
++------------+----------+------------+-----------+-------------------+
+|    Name    |  Commit  | Iterations |  NsPerOp  | AllocedBytesPerOp |
++------------+----------+------------+-----------+-------------------+
+| BenchmarkA |   HEAD   |    1000    | 123 ns/op |     234 B/op      |
++            +----------+------------+-----------+-------------------+
+|            | HEAD{@1} |    1000    | 133 ns/op |     255 B/op      |
++------------+----------+------------+-----------+-------------------+
+| BenchmarkB |   HEAD   |   300000   |  5 ns/op  |      7 B/op       |
++            +----------+------------+-----------+-------------------+
+|            | HEAD{@1} |   200000   |  9 ns/op  |      8 B/op       |
++------------+----------+------------+-----------+-------------------+
 `,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			w := &bytes.Buffer{}
-			showResult(w, tt.args.rows)
+			showResult(w, tt.args.rows, defaultColumns, "ascii")
 			gotTable := w.String()
 			assert.Equal(t, tt.wantTable, gotTable, tt.name)
 		})
@@ -92,11 +92,11 @@ func Test_showRatio(t *testing.T) {
 Comparison
 ==========
 
-+------------+---------+-------------------+
-|    Name    | NsPerOp | AllocedBytesPerOp |
-+------------+---------+-------------------+
-| BenchmarkA |  %s  |      %s       |
-+------------+---------+-------------------+
++------------+---------+-------------------+--------+
+|    Name    | NsPerOp | AllocedBytesPerOp | Status |
++------------+---------+-------------------+--------+
+| BenchmarkA |  %s  |      %s       |  FAIL  |
++------------+---------+-------------------+--------+
 
 `, "\x1b[1;91m1.00%\x1b[0m", "\x1b[1;91m50.00%\x1b[0m"),
 		},
@@ -127,13 +127,13 @@ Comparison
 Comparison
 ==========
 
-+------------+---------+-------------------+
-|    Name    | NsPerOp | AllocedBytesPerOp |
-+------------+---------+-------------------+
-| BenchmarkA | %s  |      %s       |
-+------------+---------+-------------------+
-| BenchmarkB |  %s  |      %s       |
-+------------+---------+-------------------+
++------------+---------+-------------------+--------+
+|    Name    | NsPerOp | AllocedBytesPerOp | Status |
++------------+---------+-------------------+--------+
+| BenchmarkA | %s  |      %s       |  WARN  |
++------------+---------+-------------------+--------+
+| BenchmarkB |  %s  |      %s       |  WARN  |
++------------+---------+-------------------+--------+
 
 `, "\x1b[1;91m12.35%\x1b[0m", "\x1b[1;34m90.00%\x1b[0m", "\x1b[1;34m3.00%\x1b[0m", "\x1b[1;91m50.00%\x1b[0m"),
 		},
@@ -164,11 +164,11 @@ Comparison
 Comparison
 ==========
 
-+------------+---------+-------------------+
-|    Name    | NsPerOp | AllocedBytesPerOp |
-+------------+---------+-------------------+
-| BenchmarkB |  %s  |      %s       |
-+------------+---------+-------------------+
++------------+---------+-------------------+--------+
+|    Name    | NsPerOp | AllocedBytesPerOp | Status |
++------------+---------+-------------------+--------+
+| BenchmarkB |  %s  |      %s       |  FAIL  |
++------------+---------+-------------------+--------+
 
 `, "\x1b[1;34m3.00%\x1b[0m", "\x1b[1;91m50.00%\x1b[0m"),
 		},
@@ -201,7 +201,7 @@ Comparison
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			w := &bytes.Buffer{}
-			got := showRatio(w, tt.args.results, tt.args.threshold, tt.args.compare, tt.args.onlyDegression)
+			got := showRatio(w, tt.args.results, tt.args.threshold, tt.args.compare, defaultColumns, tt.args.onlyDegression, true, true, "ascii")
 			gotTable := w.String()
 			assert.Equal(t, tt.wantTable, gotTable, tt.name)
 			assert.Equal(t, tt.want, got, tt.name)
@@ -275,3 +275,52 @@ func Test_generateRatioItem(t *testing.T) {
 		})
 	}
 }
+
+var defaultColumns = columns{
+	name:              true,
+	iterations:        true,
+	nsPerOp:           true,
+	allocedBytesPerOp: true,
+	ratio:             true,
+	status:            true,
+}
+
+func Test_whichColumnsToShow(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []string
+		want    columns
+		wantErr string
+	}{
+		{
+			name:    "default",
+			columns: []string{"name", "iter", "ns", "bytes", "ratio", "status"},
+			want:    defaultColumns,
+		},
+		{
+			name:    "allocs and throughput",
+			columns: []string{"name", "allocs", "mbs"},
+			want: columns{
+				name:        true,
+				allocsPerOp: true,
+				mbPerS:      true,
+			},
+		},
+		{
+			name:    "unknown column",
+			columns: []string{"name", "foo"},
+			wantErr: "unknown column: foo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := whichColumnsToShow(tt.columns)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr, tt.name)
+				return
+			}
+			assert.NoError(t, err, tt.name)
+			assert.Equal(t, tt.want, got, tt.name)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/olekukonko/tablewriter"
+)
+
+// commentMarker identifies comments posted by cob so that they can be updated in place.
+const commentMarker = "<!-- cob:benchmark-comparison -->"
+
+// generateMarkdown renders the verdict, the comparison and the result as Markdown, e.g. for PR comments.
+func generateMarkdown(rows [][]string, results []result, base string, threshold float64, comparedScore comparedScore, columns columns, creep []report.Creep, added, removed []string, profiles []report.Profile, dependencies []report.DependencyChange, changedFiles []report.ChangedFiles, flamegraphs []flamegraphLink) string {
+	w := &bytes.Buffer{}
+	fmt.Fprintf(w, "## Benchmark comparison: HEAD vs %s\n\n", base)
+	showVerdict(w, results, threshold, comparedScore, false)
+
+	table, _ := newRatioTable(w, results, threshold, comparedScore, columns, false, false, false, "markdown")
+	if table.NumLines() > 0 {
+		fmt.Fprint(w, "\n### Comparison\n\n")
+		table.Render()
+	}
+
+	if len(creep) > 0 {
+		fmt.Fprint(w, "\n### Creep\n\n")
+		for _, c := range creep {
+			fmt.Fprintf(w, "- %s\n", generateCreepLine(c))
+		}
+	}
+
+	if len(added) > 0 || len(removed) > 0 {
+		fmt.Fprint(w, "\n### New and removed benchmarks\n\n")
+		for _, line := range generateAddedAndRemovedLines(added, removed) {
+			fmt.Fprintf(w, "- %s\n", line)
+		}
+	}
+
+	if len(changedFiles) > 0 {
+		fmt.Fprint(w, "\n### Changed files\n\n")
+		for _, c := range changedFiles {
+			fmt.Fprintf(w, "- %s\n", generateChangedFilesLine(c))
+		}
+	}
+
+	if len(dependencies) > 0 {
+		fmt.Fprint(w, "\n### Dependency changes\n\n")
+		for _, c := range dependencies {
+			fmt.Fprintf(w, "- %s\n", generateDependencyLine(c))
+		}
+	}
+
+	for _, p := range profiles {
+		title, header, rows := profileFunctionTable(p)
+		if len(rows) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n### %s: %s\n\n", title, p.Name)
+		table := tablewriter.NewWriter(w)
+		table.SetAutoFormatHeaders(false)
+		applyTableStyle(table, "markdown")
+		table.SetHeader(header)
+		table.AppendBulk(rows)
+		table.Render()
+	}
+
+	if len(flamegraphs) > 0 {
+		fmt.Fprint(w, "\n### Flamegraphs\n\n")
+		for _, l := range flamegraphs {
+			fmt.Fprintf(w, "- %s (%s): %s\n", l.name, l.kind, markdownFlamegraphLinks(l))
+		}
+	}
+
+	if len(rows) > 0 {
+		fmt.Fprint(w, "\n<details>\n<summary>Result</summary>\n\n")
+		newResultTable(w, rows, columns, "markdown").Render()
+		fmt.Fprintln(w, "\n</details>")
+	}
+	return w.String()
+}
+
+func markdownFlamegraphLinks(l flamegraphLink) string {
+	var links []string
+	if l.base != "" {
+		links = append(links, fmt.Sprintf("[base](%s)", l.base))
+	}
+	if l.head != "" {
+		links = append(links, fmt.Sprintf("[HEAD](%s)", l.head))
+	}
+	return strings.Join(links, " / ")
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// metric is a single value exported to metrics backends.
+type metric struct {
+	// Name is the name without any prefix, e.g. "ns_per_op".
+	Name      string
+	Benchmark string
+	Value     float64
+}
+
+// benchmarkMetrics flattens the HEAD measurements and the ratios of the report.
+func benchmarkMetrics(rep report.Report) []metric {
+	var metrics []metric
+	for _, b := range rep.Benchmarks {
+		metrics = append(metrics,
+			metric{Name: "ns_per_op", Benchmark: b.Name, Value: b.Head.NsPerOp},
+			metric{Name: "alloced_bytes_per_op", Benchmark: b.Name, Value: float64(b.Head.AllocedBytesPerOp)},
+			metric{Name: "allocs_per_op", Benchmark: b.Name, Value: float64(b.Head.AllocsPerOp)},
+			metric{Name: "ratio_ns_per_op", Benchmark: b.Name, Value: b.Ratio.NsPerOp},
+			metric{Name: "ratio_alloced_bytes_per_op", Benchmark: b.Name, Value: b.Ratio.AllocedBytesPerOp},
+			metric{Name: "ratio_allocs_per_op", Benchmark: b.Name, Value: b.Ratio.AllocsPerOp},
+		)
+	}
+	return metrics
+}
+
+// detectBranch returns the branch HEAD points to. CI systems usually check out a detached HEAD,
+// so the branch is taken from their environment variables in that case.
+func detectBranch(head *plumbing.Reference) string {
+	if head.Name().IsBranch() {
+		return head.Name().Short()
+	}
+	for _, key := range []string{
+		"GITHUB_HEAD_REF",        // GitHub Actions (pull requests)
+		"GITHUB_REF_NAME",        // GitHub Actions
+		"CI_COMMIT_REF_NAME",     // GitLab CI
+		"BITBUCKET_BRANCH",       // Bitbucket Pipelines
+		"BUILD_SOURCEBRANCHNAME", // Azure Pipelines
+		"CIRCLE_BRANCH",          // CircleCI
+		"BUILDKITE_BRANCH",       // Buildkite
+		"TRAVIS_BRANCH",          // Travis CI
+		"BRANCH_NAME",            // Jenkins
+	} {
+		if b := os.Getenv(key); b != "" {
+			return b
+		}
+	}
+	return ""
+}
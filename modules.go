@@ -0,0 +1,119 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/xerrors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// moduleSync prepares the dependencies of each commit checked out when go.mod or go.sum differs between the
+// compared commits, so that neither side is built with the module state left by the other.
+type moduleSync struct {
+	// dir is the root of the worktree, or "" for the current directory.
+	dir  string
+	head plumbing.Hash
+	// files are the module files at HEAD which replace those of the other commits for -same-deps. nil keeps the
+	// module files of each commit.
+	files map[string][]byte
+	// download runs "go mod download" after each checkout, or "go mod vendor" if the vendor directory isn't
+	// committed but generated, so that it matches the module files of each commit.
+	download bool
+	// generatedVendor is whether the vendor directory at HEAD is generated instead of committed.
+	generatedVendor bool
+}
+
+// newModuleSync returns how the dependencies are prepared after checking out base or HEAD in the worktree at dir.
+func newModuleSync(r *git.Repository, dir string, base, head plumbing.Hash, sameDeps bool) (*moduleSync, error) {
+	s := &moduleSync{dir: dir, head: head}
+	changed, err := moduleFilesChanged(r, base, head)
+	if err != nil || !changed {
+		return s, err
+	}
+	s.download = true
+	if vendored(s.dir) {
+		tree, err := commitTree(r, head)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tree.File(vendorManifest)
+		s.generatedVendor = err == object.ErrFileNotFound
+	}
+	if !sameDeps {
+		return s, nil
+	}
+
+	tree, err := commitTree(r, head)
+	if err != nil {
+		return nil, err
+	}
+	s.files = map[string][]byte{}
+	for _, name := range moduleFiles {
+		f, err := tree.File(name)
+		if err == object.ErrFileNotFound {
+			// The file is removed at the other commits as well.
+			s.files[name] = nil
+			continue
+		} else if err != nil {
+			return nil, xerrors.Errorf("failed to get %s at HEAD: %w", name, err)
+		}
+		content, err := f.Contents()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read %s at HEAD: %w", name, err)
+		}
+		s.files[name] = []byte(content)
+	}
+	return s, nil
+}
+
+// changed returns whether the dependencies of the commits differ, taking -same-deps into account.
+func (s *moduleSync) changed() bool {
+	return s.download && s.files == nil
+}
+
+// afterCheckout is called after each checkout of commit in the current directory.
+func (s *moduleSync) afterCheckout(commit plumbing.Hash) error {
+	if s.files != nil && commit != s.head {
+		infof("Use go.mod and go.sum of HEAD at %s", commit)
+		for name, content := range s.files {
+			path := filepath.Join(s.dir, name)
+			if content == nil {
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				continue
+			}
+			if err := ioutil.WriteFile(path, content, 0644); err != nil {
+				return xerrors.Errorf("failed to write %s of HEAD: %w", name, err)
+			}
+		}
+	}
+	// A commit without go.mod has no modules to download.
+	if _, err := os.Stat(filepath.Join(s.dir, "go.mod")); !s.download || err != nil {
+		return nil
+	}
+	args := []string{"mod", "download"}
+	switch {
+	case s.generatedVendor:
+		infof("Refresh the vendor directory at %s", commit)
+		args = []string{"mod", "vendor"}
+	case vendored(s.dir):
+		// The committed vendor directory has the modules.
+		return nil
+	}
+	debugf("exec: go %s", strings.Join(args, " "))
+	cmd := exec.Command("go", args...)
+	cmd.Dir = s.dir
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return xerrors.Errorf("failed to run 'go %s' at %s: %w", strings.Join(args, " "), commit, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+func Test_moduleSync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	runGit(t, dir, "init", "--quiet")
+	write("go.mod", "module example.com/a\n\ngo 1.13\n")
+	write("go.sum", "example.com/b v1.0.0 h1:base=\n")
+	write("a.go", "package a\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "--quiet", "-m", "base")
+	write("a.go", "package a // changed\n")
+	runGit(t, dir, "commit", "--quiet", "-am", "code")
+	code := plumbing.NewHash(runGit(t, dir, "rev-parse", "HEAD"))
+	write("go.mod", "module example.com/a\n\ngo 1.14\n")
+	runGit(t, dir, "rm", "--quiet", "go.sum")
+	runGit(t, dir, "commit", "--quiet", "-am", "deps")
+	head := plumbing.NewHash(runGit(t, dir, "rev-parse", "HEAD"))
+	base := plumbing.NewHash(runGit(t, dir, "rev-parse", "HEAD~2"))
+
+	r, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+
+	s, err := newModuleSync(r, dir, code, head, false)
+	require.NoError(t, err)
+	assert.True(t, s.changed())
+	assert.True(t, s.download)
+	assert.Nil(t, s.files)
+	assert.False(t, s.generatedVendor)
+
+	// The vendor directory is generated by "go mod vendor" instead of committed.
+	write(".gitignore", "/vendor/\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "vendor"), 0755))
+	write("vendor/modules.txt", "# example.com/b v1.0.0\n")
+	s, err = newModuleSync(r, dir, code, head, false)
+	require.NoError(t, err)
+	assert.True(t, s.generatedVendor)
+	require.NoError(t, os.Remove(filepath.Join(dir, ".gitignore")))
+
+	s, err = newModuleSync(r, dir, base, code, false)
+	require.NoError(t, err)
+	assert.False(t, s.changed(), "only the code changed")
+	assert.False(t, s.download)
+
+	s, err = newModuleSync(r, dir, base, head, true)
+	require.NoError(t, err)
+	assert.False(t, s.changed(), "the base commit is built with the dependencies of HEAD")
+	assert.Equal(t, map[string][]byte{"go.mod": []byte("module example.com/a\n\ngo 1.14\n"), "go.sum": nil}, s.files)
+
+	runGit(t, dir, "checkout", "--quiet", base.String())
+	s.download = false
+	require.NoError(t, s.afterCheckout(base))
+	b, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Equal(t, "module example.com/a\n\ngo 1.14\n", string(b))
+	_, err = os.Stat(filepath.Join(dir, "go.sum"))
+	assert.True(t, os.IsNotExist(err), "go.sum is removed as at HEAD")
+}
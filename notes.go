@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	gitNotesRef = "refs/notes/cob"
+	// gitNotesPushAttempts is how many times a rejected push is retried after fetching the notes again,
+	// because jobs of other commits may push at the same time.
+	gitNotesPushAttempts = 3
+)
+
+// gitNotesStore attaches the latest run of each commit as a git note, so that the history stays in the
+// repository. A note has a JSON line for each machine tag. The notes are fetched from and pushed to the
+// remote, if there is one.
+type gitNotesStore struct {
+	git    gitCLI
+	remote string
+}
+
+// newGitNotesStore fetches the notes of origin. Without origin, the notes are only kept locally.
+func newGitNotesStore(dir string) (gitNotesStore, error) {
+	s := gitNotesStore{git: newGitCLI(dir), remote: "origin"}
+	if !s.git.hasRemote(s.remote) {
+		debugf("git: no remote %s, keeping notes locally", s.remote)
+		s.remote = ""
+		return s, nil
+	}
+	s.fetch()
+	return s, nil
+}
+
+// fetch replaces the local notes with those of the remote. The remote may not have any notes yet.
+func (s gitNotesStore) fetch() {
+	if s.remote == "" {
+		return
+	}
+	if _, err := s.git.run(nil, "fetch", "--quiet", s.remote, "+"+gitNotesRef+":"+gitNotesRef); err != nil {
+		debugf("git: failed to fetch notes: %s", err)
+	}
+}
+
+func (s gitNotesStore) put(run historyRun) error {
+	for attempt := 1; ; attempt++ {
+		runs, err := s.note(run.Report.Head.Hash)
+		if err != nil {
+			return err
+		}
+		var others []historyRun
+		for _, r := range runs {
+			if r.Machine.Tag != run.Machine.Tag {
+				others = append(others, r)
+			}
+		}
+		if err = s.addNote(run.Report.Head.Hash, append(others, run)); err != nil {
+			return err
+		}
+		if s.remote == "" {
+			return nil
+		}
+		_, err = s.git.run(nil, "push", "--quiet", s.remote, gitNotesRef)
+		if err == nil {
+			infof("Pushed the result to %s of %s", gitNotesRef, s.remote)
+			return nil
+		}
+		if attempt == gitNotesPushAttempts {
+			return xerrors.Errorf("failed to push notes: %w", err)
+		}
+		debugf("git: retrying a rejected push: %s", err)
+		s.fetch()
+	}
+}
+
+// notedCommits returns the commits which have a note.
+func (s gitNotesStore) notedCommits() ([]string, error) {
+	out, err := s.git.run(nil, "notes", "--ref", gitNotesRef, "list")
+	if err != nil {
+		return nil, xerrors.Errorf("failed to list notes: %w", err)
+	}
+	var commits []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			commits = append(commits, fields[1])
+		}
+	}
+	return commits, nil
+}
+
+func (s gitNotesStore) runs() ([]historyRun, error) {
+	commits, err := s.notedCommits()
+	if err != nil {
+		return nil, err
+	}
+	var runs []historyRun
+	for _, commit := range commits {
+		note, err := s.note(commit)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, note...)
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Time.Before(runs[j].Time) })
+	return runs, nil
+}
+
+func (s gitNotesStore) latest(commit, tag string) (*historyRun, error) {
+	runs, err := s.note(commit)
+	if err != nil {
+		return nil, err
+	}
+	for _, run := range runs {
+		if run.Machine.Tag == tag && run.Rollup == 0 {
+			return &run, nil
+		}
+	}
+	return nil, nil
+}
+
+// rewrite removes the notes of commits without a run, and replaces the notes which changed, e.g. with rollups.
+func (s gitNotesStore) rewrite(runs []historyRun) error {
+	commits, err := s.notedCommits()
+	if err != nil {
+		return err
+	}
+	byCommit := map[string][]historyRun{}
+	for _, run := range runs {
+		byCommit[run.Report.Head.Hash] = append(byCommit[run.Report.Head.Hash], run)
+	}
+	for _, commit := range commits {
+		if _, ok := byCommit[commit]; ok {
+			continue
+		}
+		if _, err = s.git.run(nil, "notes", "--ref", gitNotesRef, "remove", commit); err != nil {
+			return xerrors.Errorf("failed to remove a note: %w", err)
+		}
+	}
+	for commit, want := range byCommit {
+		current, err := s.note(commit)
+		if err != nil {
+			return err
+		}
+		if reflect.DeepEqual(current, want) {
+			continue
+		}
+		if err = s.addNote(commit, want); err != nil {
+			return err
+		}
+	}
+	if s.remote == "" {
+		return nil
+	}
+	if _, err = s.git.run(nil, "push", "--quiet", s.remote, gitNotesRef); err != nil {
+		return xerrors.Errorf("failed to push notes: %w", err)
+	}
+	return nil
+}
+
+// update retries when the push is rejected because notes were pushed by another run meanwhile.
+func (s gitNotesStore) update(fn func(runs []historyRun) []historyRun) error {
+	for attempt := 1; ; attempt++ {
+		err := updateRuns(s, fn)
+		if err == nil || attempt == gitNotesPushAttempts {
+			return err
+		}
+		debugf("git: retrying the update of notes: %s", err)
+		s.fetch()
+	}
+}
+
+func (s gitNotesStore) addNote(commit string, runs []historyRun) error {
+	b := &bytes.Buffer{}
+	for _, run := range runs {
+		line, err := json.Marshal(run)
+		if err != nil {
+			return xerrors.Errorf("failed to encode the run: %w", err)
+		}
+		b.Write(append(line, '\n'))
+	}
+	if _, err := s.git.run(b.Bytes(), "notes", "--ref", gitNotesRef, "add", "--force", "--file", "-", commit); err != nil {
+		return xerrors.Errorf("failed to add a note: %w", err)
+	}
+	return nil
+}
+
+// note returns the runs in the note of the commit, or nil if it has no note.
+func (s gitNotesStore) note(commit string) ([]historyRun, error) {
+	if out, err := s.git.run(nil, "notes", "--ref", gitNotesRef, "list", commit); err != nil || len(bytes.TrimSpace(out)) == 0 {
+		// "git notes list" fails if the commit has no note.
+		return nil, nil
+	}
+	out, err := s.git.run(nil, "notes", "--ref", gitNotesRef, "show", commit)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read the note: %w", err)
+	}
+	var runs []historyRun
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var run historyRun
+		if err = json.Unmarshal(line, &run); err != nil {
+			return nil, xerrors.Errorf("failed to decode the note of %s: %w", commit, err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+func Test_gitNotesStore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote.git")
+	runGit(t, dir, "init", "--quiet", "--bare", remote)
+	first := filepath.Join(dir, "first")
+	runGit(t, dir, "clone", "--quiet", remote, first)
+	runGit(t, first, "commit", "--quiet", "--allow-empty", "-m", "init")
+	runGit(t, first, "push", "--quiet", "origin", "HEAD")
+	hash := runGit(t, first, "rev-parse", "HEAD")
+
+	s, err := newGitNotesStore(first)
+	require.NoError(t, err)
+	latest, err := s.latest(hash, "")
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+
+	run := historyRun{
+		Time:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Report: report.Report{SchemaVersion: 1, Head: report.Commit{Hash: hash}, Benchmarks: []report.Benchmark{{Name: "BenchmarkA"}}},
+	}
+	require.NoError(t, s.put(run))
+
+	// Another clone sees the pushed note.
+	second := filepath.Join(dir, "second")
+	runGit(t, dir, "clone", "--quiet", remote, second)
+	s, err = newGitNotesStore(second)
+	require.NoError(t, err)
+	latest, err = s.latest(hash, "")
+	require.NoError(t, err)
+	assert.Equal(t, &run, latest)
+
+	runs, err := s.runs()
+	require.NoError(t, err)
+	assert.Equal(t, []historyRun{run}, runs)
+
+	// Runs on another machine are kept in the same note.
+	tagged := run
+	tagged.Machine.Tag = "ci-large"
+	tagged.Time = run.Time.Add(time.Hour)
+	require.NoError(t, s.put(tagged))
+	latest, err = s.latest(hash, "ci-large")
+	require.NoError(t, err)
+	assert.Equal(t, &tagged, latest)
+	latest, err = s.latest(hash, "")
+	require.NoError(t, err)
+	assert.Equal(t, &run, latest)
+	runs, err = s.runs()
+	require.NoError(t, err)
+	assert.Equal(t, []historyRun{run, tagged}, runs)
+
+	rollup := run
+	rollup.Rollup = 2
+	require.NoError(t, s.rewrite([]historyRun{rollup}))
+	runs, err = s.runs()
+	require.NoError(t, err)
+	assert.Equal(t, []historyRun{rollup}, runs)
+	latest, err = s.latest(hash, "")
+	require.NoError(t, err)
+	assert.Nil(t, latest, "a rollup is not a baseline")
+
+	require.NoError(t, s.rewrite(nil))
+	runs, err = s.runs()
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// maxNotifiedRegressions is the number of regressions listed in chat notifications.
+const maxNotifiedRegressions = 5
+
+// topRegressions returns up to n benchmarks which got worse, the worst first.
+func topRegressions(results []result, comparedScore comparedScore, n int) []result {
+	var regressions []result
+	for _, r := range results {
+		if worstRatio(r, comparedScore) > 0 {
+			regressions = append(regressions, r)
+		}
+	}
+	sort.SliceStable(regressions, func(i, j int) bool {
+		return worstRatio(regressions[i], comparedScore) > worstRatio(regressions[j], comparedScore)
+	})
+	if len(regressions) > n {
+		regressions = regressions[:n]
+	}
+	return regressions
+}
+
+func generateRegressionLine(r result, comparedScore comparedScore) string {
+	return fmt.Sprintf("%s: %s", r.Name, generateDiffRatio(r, comparedScore, columns{nsPerOp: true, allocedBytesPerOp: true}))
+}
+
+// ciRunURL returns the URL of the current CI run, or an empty string if it is unknown.
+func ciRunURL() string {
+	if u := githubActionsRunURL(); u != "" {
+		return u
+	}
+	if u := bitbucketPipelinesURL(); u != "" {
+		return u
+	}
+	if u := azureBuildURL(); u != "" {
+		return u
+	}
+	for _, key := range []string{
+		"CI_JOB_URL",           // GitLab CI
+		"BUILDKITE_BUILD_URL",  // Buildkite
+		"CIRCLE_BUILD_URL",     // CircleCI
+		"TRAVIS_BUILD_WEB_URL", // Travis CI
+		"BUILD_URL",            // Jenkins
+	} {
+		if u := os.Getenv(key); u != "" {
+			return u
+		}
+	}
+	return ""
+}
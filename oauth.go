@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+type oauthToken struct {
+	AccessToken string
+	Expiry      time.Time
+}
+
+// tokenSource caches OAuth2 access tokens until they expire.
+type tokenSource struct {
+	fetch func() (oauthToken, error)
+	token oauthToken
+	now   func() time.Time
+}
+
+// accessToken returns the cached token, or gets a new one if it expires within a minute.
+func (ts *tokenSource) accessToken() (string, error) {
+	if ts.token.AccessToken == "" || ts.now().Add(time.Minute).After(ts.token.Expiry) {
+		token, err := ts.fetch()
+		if err != nil {
+			return "", xerrors.Errorf("failed to get an access token: %w", err)
+		}
+		ts.token = token
+	}
+	return ts.token.AccessToken, nil
+}
+
+func postTokenRequest(client *http.Client, tokenURL string, form url.Values) (oauthToken, error) {
+	resp, err := client.PostForm(tokenURL, form)
+	if err != nil {
+		return oauthToken{}, xerrors.Errorf("failed to send a token request: %w", err)
+	}
+	return decodeToken(resp)
+}
+
+// expiresIn is the lifetime of a token in seconds. Some Azure endpoints return it as a string.
+type expiresIn int
+
+func (e *expiresIn) UnmarshalJSON(b []byte) error {
+	n, err := strconv.Atoi(strings.Trim(string(b), `"`))
+	if err != nil {
+		return xerrors.Errorf("invalid expires_in: %s", b)
+	}
+	*e = expiresIn(n)
+	return nil
+}
+
+func decodeToken(resp *http.Response) (oauthToken, error) {
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return oauthToken{}, xerrors.Errorf("%s returned %s: %s", resp.Request.URL.Host, resp.Status, strings.TrimSpace(string(b)))
+	}
+	var token struct {
+		AccessToken string    `json:"access_token"`
+		ExpiresIn   expiresIn `json:"expires_in"`
+	}
+	if err := json.Unmarshal(b, &token); err != nil {
+		return oauthToken{}, xerrors.Errorf("failed to decode the token: %w", err)
+	}
+	return oauthToken{AccessToken: token.AccessToken, Expiry: time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)}, nil
+}
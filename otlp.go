@@ -0,0 +1,141 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+// The types below are the subset of the OTLP/HTTP JSON encoding of ExportMetricsServiceRequest used by cob.
+// See https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/metrics/v1/metrics.proto
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name  string    `json:"name"`
+	Unit  string    `json:"unit,omitempty"`
+	Gauge otlpGauge `json:"gauge"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpDataPoint struct {
+	Attributes []otlpAttribute `json:"attributes"`
+	// TimeUnixNano is a fixed64, which is encoded as a string in JSON.
+	TimeUnixNano string  `json:"timeUnixNano"`
+	AsDouble     float64 `json:"asDouble"`
+}
+
+type otlpAttribute struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+var otlpUnits = map[string]string{
+	"ns_per_op":            "ns",
+	"alloced_bytes_per_op": "By",
+	"allocs_per_op":        "{allocation}",
+}
+
+func otlpString(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpAnyValue{StringValue: value}}
+}
+
+// generateOTLPMetrics converts the report into gauges named like "cob.ns_per_op".
+func generateOTLPMetrics(rep report.Report, now time.Time) otlpMetricsRequest {
+	var metrics []otlpMetric
+	index := map[string]int{}
+	for _, m := range benchmarkMetrics(rep) {
+		i, ok := index[m.Name]
+		if !ok {
+			i = len(metrics)
+			index[m.Name] = i
+			unit := otlpUnits[m.Name]
+			if unit == "" {
+				unit = "1"
+			}
+			metrics = append(metrics, otlpMetric{Name: "cob." + m.Name, Unit: unit})
+		}
+
+		attrs := []otlpAttribute{otlpString("benchmark", m.Benchmark), otlpString("commit", rep.Head.Hash)}
+		if rep.Head.Branch != "" {
+			attrs = append(attrs, otlpString("branch", rep.Head.Branch))
+		}
+		metrics[i].Gauge.DataPoints = append(metrics[i].Gauge.DataPoints, otlpDataPoint{
+			Attributes:   attrs,
+			TimeUnixNano: strconv.FormatInt(now.UnixNano(), 10),
+			AsDouble:     m.Value,
+		})
+	}
+
+	return otlpMetricsRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     otlpResource{Attributes: []otlpAttribute{otlpString("service.name", "cob")}},
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScope{Name: "cob"}, Metrics: metrics}},
+	}}}
+}
+
+// parseOTLPHeaders parses OTEL_EXPORTER_OTLP_HEADERS, e.g. "api-key=secret,tenant=a".
+func parseOTLPHeaders(s string) (http.Header, error) {
+	header := http.Header{}
+	for _, kv := range strings.Split(s, ",") {
+		if strings.TrimSpace(kv) == "" {
+			continue
+		}
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			return nil, xerrors.Errorf("invalid header: %s", kv)
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(kv[i+1:]))
+		if err != nil {
+			return nil, xerrors.Errorf("invalid header value: %w", err)
+		}
+		header.Set(strings.TrimSpace(kv[:i]), value)
+	}
+	return header, nil
+}
+
+// exportOTLPMetrics sends the metrics to the OTLP/HTTP endpoint. As with OpenTelemetry SDKs, "/v1/metrics" is
+// appended to the endpoint and OTEL_EXPORTER_OTLP_HEADERS are sent with the request.
+func exportOTLPMetrics(endpoint string, req otlpMetricsRequest) error {
+	header, err := parseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		return xerrors.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS: %w", err)
+	}
+	c := newRESTClient("OTLP", endpoint, header)
+	if err = c.do(http.MethodPost, "/v1/metrics", req, nil); err != nil {
+		return err
+	}
+	infof("Exported metrics to %s", endpoint)
+	return nil
+}
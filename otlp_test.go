@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_generateOTLPMetrics(t *testing.T) {
+	rep := report.Report{
+		Head: report.Commit{Ref: "HEAD", Hash: "599a552", Branch: "main"},
+		Benchmarks: []report.Benchmark{
+			{Name: "BenchmarkA", Head: report.Measurement{NsPerOp: 150}},
+		},
+	}
+	got := generateOTLPMetrics(rep, time.Unix(1, 0))
+	metrics := got.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	require.Len(t, metrics, 6)
+	assert.Equal(t, otlpMetric{
+		Name: "cob.ns_per_op",
+		Unit: "ns",
+		Gauge: otlpGauge{DataPoints: []otlpDataPoint{{
+			Attributes:   []otlpAttribute{otlpString("benchmark", "BenchmarkA"), otlpString("commit", "599a552"), otlpString("branch", "main")},
+			TimeUnixNano: "1000000000",
+			AsDouble:     150,
+		}}},
+	}, metrics[0])
+	assert.Equal(t, "1", metrics[3].Unit)
+}
+
+func Test_parseOTLPHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    http.Header
+		wantErr string
+	}{
+		{
+			name: "empty",
+			want: http.Header{},
+		},
+		{
+			name: "headers",
+			in:   "api-key=secret, Authorization=Bearer%20xxx",
+			want: http.Header{"Api-Key": {"secret"}, "Authorization": {"Bearer xxx"}},
+		},
+		{
+			name:    "invalid",
+			in:      "api-key",
+			wantErr: "invalid header: api-key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseOTLPHeaders(tt.in)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_exportOTLPMetrics(t *testing.T) {
+	os.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=secret")
+	defer os.Unsetenv("OTEL_EXPORTER_OTLP_HEADERS")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/metrics", r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("api-key"))
+		var req otlpMetricsRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "cob", req.ResourceMetrics[0].ScopeMetrics[0].Scope.Name)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	require.NoError(t, exportOTLPMetrics(ts.URL, generateOTLPMetrics(report.Report{}, time.Unix(0, 0))))
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// A minimal Parquet writer, which is enough for "cob history export": flat, required columns in a single row
+// group, with one uncompressed data page per column in the PLAIN encoding. The metadata is encoded with the
+// Thrift compact protocol as specified in https://github.com/apache/parquet-format.
+
+const parquetMagic = "PAR1"
+
+type parquetKind int
+
+const (
+	parquetString parquetKind = iota
+	parquetInt64
+	parquetDouble
+	// parquetTimestamp is stored as milliseconds since the Unix epoch in UTC.
+	parquetTimestamp
+)
+
+// Values of enums in parquet.thrift.
+const (
+	parquetTypeInt64     = 2
+	parquetTypeDouble    = 5
+	parquetTypeByteArray = 6
+
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMillis = 9
+
+	parquetRepetitionRequired = 0
+	parquetEncodingPlain      = 0
+	parquetEncodingRLE        = 3
+	parquetCodecUncompressed  = 0
+	parquetPageData           = 0
+)
+
+// parquetColumn is a column with a value per row. Values are string, int64, float64 or time.Time by kind.
+type parquetColumn struct {
+	name   string
+	kind   parquetKind
+	values []interface{}
+}
+
+func (c parquetColumn) physicalType() int32 {
+	switch c.kind {
+	case parquetString:
+		return parquetTypeByteArray
+	case parquetDouble:
+		return parquetTypeDouble
+	default:
+		return parquetTypeInt64
+	}
+}
+
+// encodeValues encodes the values in the PLAIN encoding.
+func (c parquetColumn) encodeValues() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	b := make([]byte, 8)
+	for i, v := range c.values {
+		ok := true
+		switch c.kind {
+		case parquetString:
+			var s string
+			if s, ok = v.(string); ok {
+				binary.LittleEndian.PutUint32(b, uint32(len(s)))
+				buf.Write(b[:4])
+				buf.WriteString(s)
+			}
+		case parquetInt64:
+			var n int64
+			if n, ok = v.(int64); ok {
+				binary.LittleEndian.PutUint64(b, uint64(n))
+				buf.Write(b)
+			}
+		case parquetDouble:
+			var f float64
+			if f, ok = v.(float64); ok {
+				binary.LittleEndian.PutUint64(b, math.Float64bits(f))
+				buf.Write(b)
+			}
+		case parquetTimestamp:
+			var t time.Time
+			if t, ok = v.(time.Time); ok {
+				binary.LittleEndian.PutUint64(b, uint64(t.UnixNano()/int64(time.Millisecond)))
+				buf.Write(b)
+			}
+		}
+		if !ok {
+			return nil, xerrors.Errorf("invalid value of column %s at row %d: %v", c.name, i, v)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// writeParquet writes the columns, which must have the same number of rows, as a Parquet file.
+func writeParquet(w io.Writer, columns []parquetColumn) error {
+	rows := 0
+	if len(columns) > 0 {
+		rows = len(columns[0].values)
+	}
+	file := &bytes.Buffer{}
+	file.WriteString(parquetMagic)
+
+	type chunk struct {
+		offset, size int64
+	}
+	var chunks []chunk
+	if rows > 0 {
+		for _, c := range columns {
+			if len(c.values) != rows {
+				return xerrors.Errorf("column %s has %d rows, not %d", c.name, len(c.values), rows)
+			}
+			data, err := c.encodeValues()
+			if err != nil {
+				return err
+			}
+			// Required columns have no repetition or definition levels, so the page is only the values.
+			header := &thriftWriter{}
+			header.beginStruct()
+			header.i32(1, parquetPageData)
+			header.i32(2, int32(len(data)))
+			header.i32(3, int32(len(data)))
+			header.beginField(5, thriftStruct)
+			header.i32(1, int32(rows))
+			header.i32(2, parquetEncodingPlain)
+			header.i32(3, parquetEncodingRLE)
+			header.i32(4, parquetEncodingRLE)
+			header.endStruct()
+			header.endStruct()
+
+			offset := int64(file.Len())
+			file.Write(header.Bytes())
+			file.Write(data)
+			chunks = append(chunks, chunk{offset: offset, size: int64(file.Len()) - offset})
+		}
+	}
+
+	meta := &thriftWriter{}
+	meta.beginStruct()
+	meta.i32(1, 1)
+	meta.beginList(2, thriftStruct, len(columns)+1)
+	meta.beginStruct()
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(columns)))
+	meta.endStruct()
+	for _, c := range columns {
+		meta.beginStruct()
+		meta.i32(1, c.physicalType())
+		meta.i32(3, parquetRepetitionRequired)
+		meta.binary(4, c.name)
+		switch c.kind {
+		case parquetString:
+			meta.i32(6, parquetConvertedUTF8)
+		case parquetTimestamp:
+			meta.i32(6, parquetConvertedTimestampMillis)
+		}
+		meta.endStruct()
+	}
+	meta.i64(3, int64(rows))
+	var total int64
+	for _, ch := range chunks {
+		total += ch.size
+	}
+	// Empty files have no row groups.
+	if rows == 0 {
+		meta.beginList(4, thriftStruct, 0)
+	} else {
+		meta.beginList(4, thriftStruct, 1)
+		meta.beginStruct()
+		meta.beginList(1, thriftStruct, len(chunks))
+		for i, c := range columns {
+			meta.beginStruct()
+			meta.i64(2, chunks[i].offset)
+			meta.beginField(3, thriftStruct)
+			meta.i32(1, c.physicalType())
+			meta.beginList(2, thriftI32, 2)
+			meta.listI32(parquetEncodingPlain)
+			meta.listI32(parquetEncodingRLE)
+			meta.beginList(3, thriftBinary, 1)
+			meta.listBinary(c.name)
+			meta.i32(4, parquetCodecUncompressed)
+			meta.i64(5, int64(rows))
+			meta.i64(6, chunks[i].size)
+			meta.i64(7, chunks[i].size)
+			meta.i64(9, chunks[i].offset)
+			meta.endStruct()
+			meta.endStruct()
+		}
+		meta.i64(2, total)
+		meta.i64(3, int64(rows))
+		meta.endStruct()
+	}
+	meta.binary(6, "cob")
+	meta.endStruct()
+
+	file.Write(meta.Bytes())
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, uint32(len(meta.Bytes())))
+	file.Write(b)
+	file.WriteString(parquetMagic)
+
+	if _, err := w.Write(file.Bytes()); err != nil {
+		return xerrors.Errorf("failed to write Parquet: %w", err)
+	}
+	return nil
+}
+
+// Types of the Thrift compact protocol.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structs with the Thrift compact protocol. Field ids are delta encoded against the
+// previous field of the same struct, so the last id is kept for each open struct.
+type thriftWriter struct {
+	bytes.Buffer
+	last []int16
+}
+
+func (t *thriftWriter) uvarint(n uint64) {
+	b := make([]byte, binary.MaxVarintLen64)
+	t.Write(b[:binary.PutUvarint(b, n)])
+}
+
+func (t *thriftWriter) varint(n int64) {
+	t.uvarint(uint64((n << 1) ^ (n >> 63)))
+}
+
+func (t *thriftWriter) beginField(id int16, typ byte) {
+	last := &t.last[len(t.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.WriteByte(typ)
+		t.varint(int64(id))
+	}
+	*last = id
+	if typ == thriftStruct {
+		t.last = append(t.last, 0)
+	}
+}
+
+// beginStruct begins a struct which is not a field: the file metadata, a page header or an element of a list.
+func (t *thriftWriter) beginStruct() {
+	t.last = append(t.last, 0)
+}
+
+func (t *thriftWriter) endStruct() {
+	t.WriteByte(0)
+	t.last = t.last[:len(t.last)-1]
+}
+
+func (t *thriftWriter) i32(id int16, n int32) {
+	t.beginField(id, thriftI32)
+	t.varint(int64(n))
+}
+
+func (t *thriftWriter) i64(id int16, n int64) {
+	t.beginField(id, thriftI64)
+	t.varint(n)
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.beginField(id, thriftBinary)
+	t.listBinary(s)
+}
+
+// beginList begins a list field, whose elements are written with the list* methods or beginStruct.
+func (t *thriftWriter) beginList(id int16, elem byte, n int) {
+	t.beginField(id, thriftList)
+	if n < 15 {
+		t.WriteByte(byte(n)<<4 | elem)
+	} else {
+		t.WriteByte(0xf0 | elem)
+		t.uvarint(uint64(n))
+	}
+}
+
+func (t *thriftWriter) listI32(n int32) {
+	t.varint(int64(n))
+}
+
+func (t *thriftWriter) listBinary(s string) {
+	t.uvarint(uint64(len(s)))
+	t.WriteString(s)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// thriftReader decodes structs of the Thrift compact protocol into maps of field ids to values, which is
+// enough to check the metadata written by thriftWriter.
+type thriftReader struct {
+	*bytes.Reader
+}
+
+func (r thriftReader) varint(t *testing.T) int64 {
+	n, err := binary.ReadUvarint(r)
+	require.NoError(t, err)
+	return int64(n>>1) ^ -int64(n&1)
+}
+
+func (r thriftReader) value(t *testing.T, typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint(t)
+	case thriftBinary:
+		n, err := binary.ReadUvarint(r)
+		require.NoError(t, err)
+		b := make([]byte, n)
+		_, err = r.Read(b)
+		require.NoError(t, err)
+		return string(b)
+	case thriftList:
+		h, err := r.ReadByte()
+		require.NoError(t, err)
+		n := int(h >> 4)
+		if n == 15 {
+			u, err := binary.ReadUvarint(r)
+			require.NoError(t, err)
+			n = int(u)
+		}
+		list := []interface{}{}
+		for i := 0; i < n; i++ {
+			list = append(list, r.value(t, h&0x0f))
+		}
+		return list
+	case thriftStruct:
+		return r.structure(t)
+	}
+	t.Fatalf("unexpected type %d", typ)
+	return nil
+}
+
+func (r thriftReader) structure(t *testing.T) map[int16]interface{} {
+	fields := map[int16]interface{}{}
+	var last int16
+	for {
+		h, err := r.ReadByte()
+		require.NoError(t, err)
+		if h == 0 {
+			return fields
+		}
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			id = int16(r.varint(t))
+		}
+		fields[id] = r.value(t, h&0x0f)
+		last = id
+	}
+}
+
+func Test_writeParquet(t *testing.T) {
+	columns := []parquetColumn{
+		{name: "time", kind: parquetTimestamp, values: []interface{}{time.Unix(1, 0), time.Unix(2, 0)}},
+		{name: "name", kind: parquetString, values: []interface{}{"a", "bc"}},
+		{name: "n", kind: parquetInt64, values: []interface{}{int64(-1), int64(2)}},
+		{name: "x", kind: parquetDouble, values: []interface{}{0.5, 1.5}},
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, writeParquet(buf, columns))
+	b := buf.Bytes()
+
+	require.Equal(t, "PAR1", string(b[:4]))
+	require.Equal(t, "PAR1", string(b[len(b)-4:]))
+	size := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	footer := thriftReader{bytes.NewReader(b[len(b)-8-size : len(b)-8])}.structure(t)
+
+	assert.Equal(t, int64(1), footer[1])
+	assert.Equal(t, int64(2), footer[3])
+	assert.Equal(t, "cob", footer[6])
+
+	schema := footer[2].([]interface{})
+	require.Len(t, schema, 5)
+	assert.Equal(t, map[int16]interface{}{4: "schema", 5: int64(4)}, schema[0])
+	assert.Equal(t, map[int16]interface{}{1: int64(parquetTypeInt64), 3: int64(0), 4: "time", 6: int64(parquetConvertedTimestampMillis)}, schema[1])
+	assert.Equal(t, map[int16]interface{}{1: int64(parquetTypeByteArray), 3: int64(0), 4: "name", 6: int64(parquetConvertedUTF8)}, schema[2])
+	assert.Equal(t, map[int16]interface{}{1: int64(parquetTypeDouble), 3: int64(0), 4: "x"}, schema[4])
+
+	rowGroups := footer[4].([]interface{})
+	require.Len(t, rowGroups, 1)
+	rowGroup := rowGroups[0].(map[int16]interface{})
+	assert.Equal(t, int64(2), rowGroup[3])
+	chunks := rowGroup[1].([]interface{})
+	require.Len(t, chunks, 4)
+
+	// Read the values of each column back from its page.
+	var total int64
+	var values [][]byte
+	for i, c := range chunks {
+		meta := c.(map[int16]interface{})[3].(map[int16]interface{})
+		assert.Equal(t, []interface{}{columns[i].name}, meta[3])
+		assert.Equal(t, int64(2), meta[5])
+		total += meta[6].(int64)
+
+		offset := meta[9].(int64)
+		r := thriftReader{bytes.NewReader(b[offset:])}
+		header := r.structure(t)
+		assert.Equal(t, int64(2), header[5].(map[int16]interface{})[1])
+		start := offset + r.Size() - int64(r.Len())
+		end := start + header[3].(int64)
+		assert.Equal(t, offset+meta[7].(int64), end)
+		values = append(values, b[start:end])
+	}
+	assert.Equal(t, total, rowGroup[2])
+
+	assert.Equal(t, uint64(1000), binary.LittleEndian.Uint64(values[0][:8]))
+	assert.Equal(t, []byte{1, 0, 0, 0, 'a', 2, 0, 0, 0, 'b', 'c'}, values[1])
+	assert.Equal(t, int64(-1), int64(binary.LittleEndian.Uint64(values[2][:8])))
+	assert.Equal(t, 1.5, math.Float64frombits(binary.LittleEndian.Uint64(values[3][8:])))
+}
+
+func Test_writeParquet_empty(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, writeParquet(buf, []parquetColumn{{name: "name", kind: parquetString}}))
+	b := buf.Bytes()
+	size := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	assert.Equal(t, len(b)-12, size)
+	footer := thriftReader{bytes.NewReader(b[4 : len(b)-8])}.structure(t)
+	assert.Equal(t, int64(0), footer[3])
+	assert.Empty(t, footer[4])
+}
+
+func Test_writeParquet_invalid(t *testing.T) {
+	err := writeParquet(&bytes.Buffer{}, []parquetColumn{
+		{name: "a", kind: parquetString, values: []interface{}{"a"}},
+		{name: "b", kind: parquetInt64, values: []interface{}{"b"}},
+	})
+	assert.EqualError(t, err, "invalid value of column b at row 0: b")
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/xerrors"
+)
+
+// perfEvents are the hardware events counted with -perf.
+var perfEvents = []string{"instructions", "cache-misses", "branch-misses"}
+
+// checkPerf returns an error if "perf stat" can't be used on this machine.
+func checkPerf(goos string) error {
+	if goos != "linux" {
+		return xerrors.New("--perf is only supported on Linux")
+	}
+	if _, err := exec.LookPath("perf"); err != nil {
+		return xerrors.Errorf("--perf requires perf: %w", err)
+	}
+	return nil
+}
+
+// countEvents runs each benchmark once more with the test binary wrapped in "perf stat", and returns the events
+// per op by benchmark. The worktree must be at the commit to measure. Like collectProfiles, benchmarks run as
+// many iterations as they did at HEAD, and a benchmark which can't be measured only prints a warning.
+func countEvents(benchmarks []report.Benchmark) map[string]*report.Events {
+	events := map[string]*report.Events{}
+	funcs, err := findBenchmarkFuncs(".")
+	if err != nil {
+		warnf("Failed to count the hardware events: %s", err)
+		return events
+	}
+	for _, b := range benchmarks {
+		fn, ok := funcs[benchmarkFuncName(b.Name)]
+		if !ok {
+			warnf("Failed to count the hardware events of %s: the benchmark function is not found", b.Name)
+			continue
+		}
+		e, err := countBenchmarkEvents(b, "./"+path.Dir(fn.Path))
+		if err != nil {
+			warnf("Failed to count the hardware events of %s: %s", b.Name, err)
+			continue
+		}
+		events[b.Name] = e
+	}
+	return events
+}
+
+func countBenchmarkEvents(b report.Benchmark, pkg string) (*report.Events, error) {
+	f, err := ioutil.TempFile("", "cob-perf")
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create the output file of perf: %w", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	// Only the test binary runs under perf, not the build.
+	exe := fmt.Sprintf("perf stat -x , -o %s -e %s --", f.Name(), strings.Join(perfEvents, ","))
+	args := []string{"test", "-run", "^$", "-bench", benchmarkRegexp(b.Name), "-count=1", "-exec", exe}
+	if b.Head.Iterations > 0 {
+		args = append(args, "-benchtime="+strconv.Itoa(b.Head.Iterations)+"x")
+	}
+	args = append(args, pkg)
+	debugf("exec: go %s", strings.Join(args, " "))
+	if out, err := exec.Command("go", args...).CombinedOutput(); err != nil {
+		return nil, xerrors.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	out, err := os.Open(f.Name())
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read the output of perf: %w", err)
+	}
+	defer out.Close()
+	counts, err := parsePerfStat(out)
+	if err != nil {
+		return nil, err
+	}
+	// The start-up of the test binary is counted as well, which is small next to the iterations of a benchmark.
+	ops := profiledOps(b.Head.Iterations)
+	return &report.Events{
+		Instructions: counts["instructions"] / ops,
+		CacheMisses:  counts["cache-misses"] / ops,
+		BranchMisses: counts["branch-misses"] / ops,
+	}, nil
+}
+
+// parsePerfStat returns the counts of the events in the CSV output of "perf stat -x ,". The counts of an event
+// on several PMUs, e.g. "cpu_core/instructions/u" and "cpu_atom/instructions/u" on hybrid CPUs, are summed.
+func parsePerfStat(r io.Reader) (map[string]float64, error) {
+	counts := map[string]float64{}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// e.g. "1234567,,instructions:u,1000200,100.00,1.23,insn per cycle"
+		fields := strings.Split(line, ",")
+		if len(fields) < 3 {
+			return nil, xerrors.Errorf("invalid line: %s", line)
+		}
+		name := perfEventName(fields[2])
+		if name == "" {
+			continue
+		}
+		// "<not counted>" and "<not supported>" are skipped.
+		count, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			debugf("perf: %s is not counted: %s", fields[2], fields[0])
+			continue
+		}
+		counts[name] += count
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(counts) == 0 {
+		return nil, xerrors.New("perf counted no events; the CPU or the virtual machine may not expose hardware counters")
+	}
+	return counts, nil
+}
+
+// perfEventName returns which of perfEvents the event of perf is, or "".
+func perfEventName(event string) string {
+	// "cpu_core/instructions/u" => "instructions", "instructions:u" => "instructions"
+	if parts := strings.Split(event, "/"); len(parts) >= 2 {
+		event = parts[1]
+	}
+	if i := strings.Index(event, ":"); i >= 0 {
+		event = event[:i]
+	}
+	for _, e := range perfEvents {
+		if event == e {
+			return e
+		}
+	}
+	return ""
+}
+
+// newHardwareCounters pairs the events of each benchmark at both commits.
+func newHardwareCounters(benchmarks []report.Benchmark, base, head map[string]*report.Events) []report.HardwareCounters {
+	var counters []report.HardwareCounters
+	for _, b := range benchmarks {
+		c := report.HardwareCounters{Name: b.Name, Base: base[b.Name], Head: head[b.Name]}
+		if c.Base == nil && c.Head == nil {
+			continue
+		}
+		counters = append(counters, c)
+	}
+	return counters
+}
+
+func showHardwareCounters(w io.Writer, counters []report.HardwareCounters, style string) {
+	if len(counters) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nHardware counters")
+	fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 17))
+
+	table := tablewriter.NewWriter(w)
+	table.SetAutoFormatHeaders(false)
+	applyTableStyle(table, style)
+	table.SetHeader([]string{"Name", "Base instructions/op", "HEAD instructions/op", "instructions", "cache-misses", "branch-misses"})
+	for _, c := range counters {
+		row := []string{c.Name, "-", "-", "-", "-", "-"}
+		if c.Base != nil {
+			row[1] = strconv.FormatFloat(c.Base.Instructions, 'f', 0, 64)
+		}
+		if c.Head != nil {
+			row[2] = strconv.FormatFloat(c.Head.Instructions, 'f', 0, 64)
+		}
+		if c.Base != nil && c.Head != nil {
+			row[3] = fmt.Sprintf("%+.2f%%", 100*calcRatio(c.Head.Instructions, c.Base.Instructions))
+			row[4] = fmt.Sprintf("%+.2f%%", 100*calcRatio(c.Head.CacheMisses, c.Base.CacheMisses))
+			row[5] = fmt.Sprintf("%+.2f%%", 100*calcRatio(c.Head.BranchMisses, c.Base.BranchMisses))
+		}
+		table.Append(row)
+	}
+	table.Render()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_checkPerf(t *testing.T) {
+	assert.EqualError(t, checkPerf("darwin"), "--perf is only supported on Linux")
+}
+
+func Test_parsePerfStat(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]float64
+		wantErr string
+	}{
+		{
+			name: "happy path",
+			input: `# started on Mon Oct 12 10:00:00 2026
+
+1234567,,instructions:u,1000200,100.00,1.23,insn per cycle
+2345,,cache-misses:u,1000200,100.00,,
+<not supported>,,branch-misses:u,0,100.00,,
+`,
+			want: map[string]float64{"instructions": 1234567, "cache-misses": 2345},
+		},
+		{
+			name: "hybrid CPU",
+			input: `1000,,cpu_core/instructions/u,1000200,60.00,,
+500,,cpu_atom/instructions/u,1000200,40.00,,
+10,,cpu_core/branch-misses/u,1000200,60.00,,
+`,
+			want: map[string]float64{"instructions": 1500, "branch-misses": 10},
+		},
+		{
+			name:    "no counters",
+			input:   "<not supported>,,instructions:u,0,100.00,,\n",
+			wantErr: "perf counted no events",
+		},
+		{
+			name:    "invalid line",
+			input:   "perf: command not found\n",
+			wantErr: "invalid line",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePerfStat(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_showHardwareCounters(t *testing.T) {
+	benchmarks := []report.Benchmark{{Name: "BenchmarkA-8"}, {Name: "BenchmarkB-8"}, {Name: "BenchmarkC-8"}}
+	base := map[string]*report.Events{"BenchmarkA-8": {Instructions: 1000, CacheMisses: 10, BranchMisses: 4}}
+	head := map[string]*report.Events{
+		"BenchmarkA-8": {Instructions: 1500, CacheMisses: 10, BranchMisses: 2},
+		"BenchmarkC-8": {Instructions: 200},
+	}
+	counters := newHardwareCounters(benchmarks, base, head)
+	require.Len(t, counters, 2)
+	assert.Nil(t, counters[1].Base)
+
+	w := &bytes.Buffer{}
+	showHardwareCounters(w, counters, "ascii")
+	got := w.String()
+	assert.Contains(t, got, "Hardware counters\n=================")
+	assert.Contains(t, got, "| BenchmarkA-8 |                 1000 |                 1500 | +50.00%      | +0.00%       | -50.00%       |")
+	assert.Contains(t, got, "| BenchmarkC-8 | -                    |                  200 | -            | -            | -             |")
+}
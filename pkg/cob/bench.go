@@ -0,0 +1,60 @@
+package cob
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/tools/benchmark/parse"
+	"golang.org/x/xerrors"
+)
+
+// RunBenchmarks runs the command in dir, e.g. "go test -run '^$' -bench . ./...", and parses the results of
+// benchmarks as they are printed. An empty dir is the current directory. If stop is not nil and returns true
+// for the benchmark whose result was just parsed, the command and the processes it started are killed, and
+// the results until then are returned with stopped true.
+func RunBenchmarks(dir, cmd string, args []string, stop func(s parse.Set, name string) bool) (set parse.Set, stopped bool, err error) {
+	command := exec.Command(cmd, args...)
+	command.Dir = dir
+	stdout, err := command.StdoutPipe()
+	if err != nil {
+		return nil, false, xerrors.Errorf("failed to run '%s %s' command: %w", cmd, strings.Join(args, " "), err)
+	}
+	if stop != nil {
+		startProcessGroup(command)
+	}
+	if err = command.Start(); err != nil {
+		return nil, false, xerrors.Errorf("failed to run '%s %s' command: %w", cmd, strings.Join(args, " "), err)
+	}
+
+	// Like parse.ParseSet, but a line at a time.
+	s := parse.Set{}
+	scanner := bufio.NewScanner(stdout)
+	ord := 0
+	for scanner.Scan() {
+		b, err := parse.ParseLine(scanner.Text())
+		if err != nil {
+			continue
+		}
+		b.Ord = ord
+		ord++
+		s[b.Name] = append(s[b.Name], b)
+		if stop != nil && stop(s, b.Name) {
+			stopped = true
+			_ = killProcessGroup(command)
+			break
+		}
+	}
+	scanErr := scanner.Err()
+	// The rest of the output is drained, so that the command doesn't block on writing it.
+	_, _ = io.Copy(ioutil.Discard, stdout)
+	if err = command.Wait(); err != nil && !stopped {
+		return nil, false, xerrors.Errorf("failed to run '%s %s' command: %w", cmd, strings.Join(args, " "), err)
+	}
+	if scanErr != nil {
+		return nil, false, xerrors.Errorf("failed to parse a result of benchmarks: %w", scanErr)
+	}
+	return s, stopped, nil
+}
@@ -0,0 +1,32 @@
+package cob
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/benchmark/parse"
+)
+
+func TestRunBenchmarks(t *testing.T) {
+	script := `echo "BenchmarkA-8 100 10 ns/op"; echo "BenchmarkB-8 100 20 ns/op"; sleep 10; echo "BenchmarkC-8 100 30 ns/op"`
+
+	started := time.Now()
+	set, stopped, err := RunBenchmarks("", "sh", []string{"-c", script}, func(s parse.Set, name string) bool {
+		return name == "BenchmarkB-8"
+	})
+	require.NoError(t, err)
+	assert.True(t, stopped)
+	assert.Len(t, set, 2)
+	assert.Equal(t, 1, set["BenchmarkB-8"][0].Ord)
+	assert.True(t, time.Since(started) < 5*time.Second, "the command is killed")
+
+	set, stopped, err = RunBenchmarks("", "sh", []string{"-c", `echo "BenchmarkA-8 100 10 ns/op"`}, nil)
+	require.NoError(t, err)
+	assert.False(t, stopped)
+	assert.Equal(t, 10.0, set["BenchmarkA-8"][0].NsPerOp)
+
+	_, _, err = RunBenchmarks("", "sh", []string{"-c", "exit 1"}, nil)
+	assert.Error(t, err)
+}
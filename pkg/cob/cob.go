@@ -0,0 +1,105 @@
+// Package cob benchmarks the base commit and HEAD of a Go repository and compares them, as the cob command
+// does, so that other tools, e.g. CI bots, can embed the comparison instead of running cob and parsing its
+// output.
+//
+// Run does all of it:
+//
+//	rep, err := cob.Run(cob.Options{Dir: ".", Base: "origin/main", Threshold: cob.DefaultThreshold})
+//	if err != nil {
+//		return err
+//	}
+//	if rep.Degression {
+//		// A benchmark got worse than the threshold.
+//	}
+//
+// The steps are available on their own, e.g. RunBenchmarks and Compare to compare results measured
+// elsewhere, or Worktree to benchmark other commits.
+package cob
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/tools/benchmark/parse"
+)
+
+// DefaultThreshold is the threshold of the cob command.
+const DefaultThreshold = 0.2
+
+// DefaultBenchArgs are the arguments of "go" which run the benchmarks by default.
+var DefaultBenchArgs = []string{"test", "-run", "^$", "-bench", ".", "-benchmem", "./..."}
+
+// Options are the options of Run.
+type Options struct {
+	// Dir is the repository, or the current directory if it is empty.
+	Dir string
+	// Base is the revision compared with HEAD, or HEAD~1 if it is empty.
+	Base string
+	// BenchCmd and BenchArgs run the benchmarks. BenchCmd is "go" if it is empty, and BenchArgs of "go" are
+	// DefaultBenchArgs if they are nil, e.g. to run the default benchmarks with another Go toolchain.
+	BenchCmd  string
+	BenchArgs []string
+	// Threshold is how much worse than the base commit a benchmark may get, e.g. 0.2 for 20%. 0 fails any
+	// benchmark which got worse.
+	Threshold float64
+	// Score is which values are compared with the threshold, or ns/op and B/op if it is the zero value.
+	Score Score
+	// Exclude leaves out the benchmarks matching any of the regular expressions.
+	Exclude []*regexp.Regexp
+	// Alpha is the level the significance of changes is tested at, e.g. 0.05 with -count=10 in BenchArgs, or 0
+	// not to test it.
+	Alpha float64
+}
+
+// withDefaults returns the options with the defaults of the options which are not set.
+func (opts Options) withDefaults() Options {
+	if opts.Dir == "" {
+		opts.Dir = "."
+	}
+	if opts.Base == "" {
+		opts.Base = "HEAD~1"
+	}
+	if opts.BenchCmd == "" {
+		opts.BenchCmd = "go"
+	}
+	if opts.BenchArgs == nil && strings.TrimSuffix(filepath.Base(opts.BenchCmd), ".exe") == "go" {
+		opts.BenchArgs = DefaultBenchArgs
+	}
+	if opts.Score == (Score{}) {
+		opts.Score = Score{NsPerOp: true, AllocedBytesPerOp: true}
+	}
+	return opts
+}
+
+// Run benchmarks the base commit and HEAD in the worktree, which must be clean, and compares them. The
+// worktree is reset to HEAD afterwards.
+func Run(opts Options) (report.Report, error) {
+	opts = opts.withDefaults()
+	w, err := OpenWorktree(opts.Dir)
+	if err != nil {
+		return report.Report{}, err
+	}
+	prev, err := w.Resolve(opts.Base)
+	if err != nil {
+		return report.Report{}, err
+	}
+	var prevSet parse.Set
+	err = w.At(prev, func() error {
+		prevSet, _, err = RunBenchmarks(opts.Dir, opts.BenchCmd, opts.BenchArgs, nil)
+		return err
+	})
+	if err != nil {
+		return report.Report{}, err
+	}
+	headSet, _, err := RunBenchmarks(opts.Dir, opts.BenchCmd, opts.BenchArgs, nil)
+	if err != nil {
+		return report.Report{}, err
+	}
+
+	results := Compare(prevSet, headSet, CompareOptions{Exclude: opts.Exclude, Alpha: opts.Alpha})
+	base := report.Commit{Ref: opts.Base, Hash: prev.String()}
+	head := report.Commit{Ref: "HEAD", Hash: w.Head().String()}
+	return NewReport(results, base, head, opts.Threshold, opts.Score), nil
+}
@@ -0,0 +1,81 @@
+package cob
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+func TestRun(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The "benchmark" prints the results committed to bench.txt.
+	commit := func(result string) string {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "bench.txt"), []byte(result+"\n"), 0644))
+		runGit(t, dir, "add", "bench.txt")
+		runGit(t, dir, "commit", "--quiet", "-m", result)
+		return runGit(t, dir, "rev-parse", "HEAD")
+	}
+	runGit(t, dir, "init", "--quiet")
+	base := commit("BenchmarkA-8 100 100 ns/op")
+	head := commit("BenchmarkA-8 100 150 ns/op")
+
+	opts := Options{Dir: dir, BenchCmd: "cat", BenchArgs: []string{"bench.txt"}, Threshold: DefaultThreshold}
+	rep, err := Run(opts)
+	require.NoError(t, err)
+	assert.Equal(t, base, rep.Base.Hash)
+	assert.Equal(t, head, rep.Head.Hash)
+	assert.True(t, rep.Degression)
+	require.Len(t, rep.Benchmarks, 1)
+	assert.Equal(t, 100.0, rep.Benchmarks[0].Base.NsPerOp)
+	assert.Equal(t, 150.0, rep.Benchmarks[0].Head.NsPerOp)
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "bench.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "BenchmarkA-8 100 150 ns/op\n", string(b), "the worktree is reset to HEAD")
+
+	opts.Threshold = 0.6
+	rep, err = Run(opts)
+	require.NoError(t, err)
+	assert.False(t, rep.Degression)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "bench.txt"), []byte("changed\n"), 0644))
+	_, err = Run(opts)
+	assert.True(t, xerrors.Is(err, ErrDirty))
+}
+
+func TestOptions_withDefaults(t *testing.T) {
+	opts := Options{}.withDefaults()
+	assert.Equal(t, "go", opts.BenchCmd)
+	assert.Equal(t, DefaultBenchArgs, opts.BenchArgs)
+	assert.Equal(t, Score{NsPerOp: true, AllocedBytesPerOp: true}, opts.Score)
+
+	args := []string{"test", "-run", "^$", "-bench", "Foo", "./foo"}
+	opts = Options{BenchArgs: args}.withDefaults()
+	assert.Equal(t, "go", opts.BenchCmd)
+	assert.Equal(t, args, opts.BenchArgs, "the arguments are kept without a command")
+
+	assert.Equal(t, DefaultBenchArgs, Options{BenchCmd: "/usr/local/go/bin/go"}.withDefaults().BenchArgs)
+	assert.Nil(t, Options{BenchCmd: "make"}.withDefaults().BenchArgs)
+}
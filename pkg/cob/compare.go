@@ -0,0 +1,194 @@
+package cob
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/tools/benchmark/parse"
+)
+
+// Result is the comparison of a benchmark between the base commit and HEAD. A ratio is the change relative to
+// the base commit, e.g. 0.1 for 10% more.
+type Result struct {
+	Name                   string
+	RatioNsPerOp           float64
+	RatioAllocedBytesPerOp float64
+	RatioAllocsPerOp       float64
+	// Head and Prev are the medians of the runs at HEAD and the base commit.
+	Head *parse.Benchmark
+	Prev *parse.Benchmark
+	// Threshold overrides the threshold for the benchmark, e.g. with a //cob:threshold annotation.
+	Threshold *float64
+	// PValue is how likely the changes are noise if the significance is tested, or nil if it is not or if there
+	// are too few runs. A change whose p-value is not below Alpha doesn't fail.
+	PValue *report.PValue
+	Alpha  float64
+}
+
+// Score is which values of the benchmarks are compared with the threshold.
+type Score struct {
+	NsPerOp           bool
+	AllocedBytesPerOp bool
+}
+
+// Status is whether a benchmark got worse.
+type Status int
+
+const (
+	// StatusOK is a benchmark which didn't get worse.
+	StatusOK Status = iota
+	// StatusWarn is a benchmark which got worse within the threshold.
+	StatusWarn
+	// StatusFail is a benchmark which got worse than the threshold.
+	StatusFail
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusFail:
+		return report.StatusFail
+	case StatusWarn:
+		return report.StatusWarn
+	default:
+		return report.StatusOK
+	}
+}
+
+// NewResult compares the runs of a benchmark at the base commit and HEAD. threshold overrides the threshold
+// for the benchmark if it is not nil. The significance is tested at the level alpha if it is greater than 0.
+func NewResult(name string, prevRuns, headRuns []*parse.Benchmark, threshold *float64, alpha float64) Result {
+	prev, head := Median(prevRuns), Median(headRuns)
+	return Result{
+		Name:                   name,
+		RatioNsPerOp:           Ratio(head.NsPerOp, prev.NsPerOp),
+		RatioAllocedBytesPerOp: Ratio(float64(head.AllocedBytesPerOp), float64(prev.AllocedBytesPerOp)),
+		RatioAllocsPerOp:       Ratio(float64(head.AllocsPerOp), float64(prev.AllocsPerOp)),
+		Head:                   head,
+		Prev:                   prev,
+		Threshold:              threshold,
+		PValue:                 pValue(prevRuns, headRuns, alpha),
+		Alpha:                  alpha,
+	}
+}
+
+// Ratio returns the change from prev to head relative to prev, or 0 if prev is 0.
+func Ratio(head, prev float64) float64 {
+	if prev == 0 {
+		return 0
+	}
+	return (head - prev) / prev
+}
+
+// IsRegression reports whether a compared value of the benchmark got worse than the threshold, unless the
+// change is not significant.
+func IsRegression(r Result, threshold float64, score Score) bool {
+	if r.Threshold != nil {
+		threshold = *r.Threshold
+	}
+	if score.NsPerOp && threshold < r.RatioNsPerOp && (r.PValue == nil || r.PValue.NsPerOp < r.Alpha) {
+		return true
+	}
+	return score.AllocedBytesPerOp && threshold < r.RatioAllocedBytesPerOp &&
+		(r.PValue == nil || r.PValue.AllocedBytesPerOp < r.Alpha)
+}
+
+// StatusOf returns the status of the benchmark.
+func StatusOf(r Result, threshold float64, score Score) Status {
+	if IsRegression(r, threshold, score) {
+		return StatusFail
+	}
+	if score.NsPerOp && r.RatioNsPerOp > 0 {
+		return StatusWarn
+	}
+	if score.AllocedBytesPerOp && r.RatioAllocedBytesPerOp > 0 {
+		return StatusWarn
+	}
+	return StatusOK
+}
+
+// CompareOptions are the options of Compare.
+type CompareOptions struct {
+	// Exclude leaves out the benchmarks matching any of the regular expressions.
+	Exclude []*regexp.Regexp
+	// Thresholds override the threshold of the benchmarks by name.
+	Thresholds map[string]float64
+	// Alpha is the level the significance of changes is tested at with -count, or 0 not to test it.
+	Alpha float64
+}
+
+// Compare compares the benchmarks which ran at both commits, sorted by name.
+func Compare(prevSet, headSet parse.Set, opts CompareOptions) []Result {
+	var names []string
+	for name, headRuns := range headSet {
+		if len(headRuns) == 0 || len(prevSet[name]) == 0 || excluded(name, opts.Exclude) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var results []Result
+	for _, name := range names {
+		var threshold *float64
+		if t, ok := opts.Thresholds[name]; ok {
+			threshold = &t
+		}
+		results = append(results, NewResult(name, prevSet[name], headSet[name], threshold, opts.Alpha))
+	}
+	return results
+}
+
+func excluded(name string, excludes []*regexp.Regexp) bool {
+	for _, re := range excludes {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewReport returns the report of the results. It is a regression if any benchmark got worse than the threshold.
+func NewReport(results []Result, base, head report.Commit, threshold float64, score Score) report.Report {
+	rep := report.Report{
+		SchemaVersion: report.SchemaVersion,
+		Base:          base,
+		Head:          head,
+		Threshold:     threshold,
+		Benchmarks:    []report.Benchmark{},
+	}
+	for _, r := range results {
+		s := StatusOf(r, threshold, score)
+		if s == StatusFail {
+			rep.Degression = true
+		}
+		rep.Benchmarks = append(rep.Benchmarks, report.Benchmark{
+			Name: r.Name,
+			Base: NewMeasurement(r.Prev),
+			Head: NewMeasurement(r.Head),
+			Ratio: report.Ratio{
+				NsPerOp:           r.RatioNsPerOp,
+				AllocedBytesPerOp: r.RatioAllocedBytesPerOp,
+				AllocsPerOp:       r.RatioAllocsPerOp,
+			},
+			Status:    s.String(),
+			Threshold: r.Threshold,
+			PValue:    r.PValue,
+		})
+	}
+	return rep
+}
+
+// NewMeasurement returns the values of the benchmark in a report.
+func NewMeasurement(b *parse.Benchmark) report.Measurement {
+	if b == nil {
+		return report.Measurement{}
+	}
+	return report.Measurement{
+		Iterations:        b.N,
+		NsPerOp:           b.NsPerOp,
+		AllocedBytesPerOp: b.AllocedBytesPerOp,
+		AllocsPerOp:       b.AllocsPerOp,
+		MBPerS:            b.MBPerS,
+	}
+}
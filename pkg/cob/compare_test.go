@@ -0,0 +1,69 @@
+package cob
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/benchmark/parse"
+)
+
+func TestCompare(t *testing.T) {
+	prevSet := parse.Set{
+		"BenchmarkA-8":        {{Name: "BenchmarkA-8", N: 100, NsPerOp: 100, AllocedBytesPerOp: 10}},
+		"BenchmarkB-8":        {{Name: "BenchmarkB-8", N: 100, NsPerOp: 100}},
+		"BenchmarkExcluded-8": {{Name: "BenchmarkExcluded-8", N: 100, NsPerOp: 100}},
+		"BenchmarkRemoved-8":  {{Name: "BenchmarkRemoved-8", N: 100, NsPerOp: 100}},
+	}
+	headSet := parse.Set{
+		"BenchmarkB-8":        {{Name: "BenchmarkB-8", N: 100, NsPerOp: 130}},
+		"BenchmarkA-8":        {{Name: "BenchmarkA-8", N: 100, NsPerOp: 150, AllocedBytesPerOp: 5}},
+		"BenchmarkExcluded-8": {{Name: "BenchmarkExcluded-8", N: 100, NsPerOp: 300}},
+		"BenchmarkNew-8":      {{Name: "BenchmarkNew-8", N: 100, NsPerOp: 100}},
+	}
+	results := Compare(prevSet, headSet, CompareOptions{
+		Exclude:    []*regexp.Regexp{regexp.MustCompile("Excluded")},
+		Thresholds: map[string]float64{"BenchmarkB-8": 0.5},
+	})
+	require.Len(t, results, 2)
+	assert.Equal(t, "BenchmarkA-8", results[0].Name)
+	assert.InDelta(t, 0.5, results[0].RatioNsPerOp, 1e-9)
+	assert.InDelta(t, -0.5, results[0].RatioAllocedBytesPerOp, 1e-9)
+	assert.Nil(t, results[0].Threshold)
+	assert.Equal(t, "BenchmarkB-8", results[1].Name)
+	require.NotNil(t, results[1].Threshold)
+	assert.Equal(t, 0.5, *results[1].Threshold)
+
+	score := Score{NsPerOp: true, AllocedBytesPerOp: true}
+	assert.Equal(t, StatusFail, StatusOf(results[0], DefaultThreshold, score))
+	assert.Equal(t, StatusWarn, StatusOf(results[1], DefaultThreshold, score), "within the threshold of the benchmark")
+	assert.Equal(t, StatusOK, StatusOf(results[0], DefaultThreshold, Score{AllocedBytesPerOp: true}))
+
+	rep := NewReport(results, report.Commit{Ref: "HEAD~1"}, report.Commit{Ref: "HEAD"}, DefaultThreshold, score)
+	assert.True(t, rep.Degression)
+	require.Len(t, rep.Benchmarks, 2)
+	assert.Equal(t, report.StatusFail, rep.Benchmarks[0].Status)
+	assert.Equal(t, report.Measurement{Iterations: 100, NsPerOp: 150, AllocedBytesPerOp: 5}, rep.Benchmarks[0].Head)
+	assert.Equal(t, report.StatusWarn, rep.Benchmarks[1].Status)
+}
+
+func TestIsRegression(t *testing.T) {
+	score := Score{NsPerOp: true}
+	r := Result{RatioNsPerOp: 0.3, Alpha: 0.05}
+	assert.True(t, IsRegression(r, 0.2, score))
+	assert.False(t, IsRegression(r, 0.5, score))
+	assert.False(t, IsRegression(r, 0.2, Score{AllocedBytesPerOp: true}))
+
+	r.PValue = &report.PValue{NsPerOp: 0.2}
+	assert.False(t, IsRegression(r, 0.2, score), "not significant")
+	r.PValue.NsPerOp = 0.01
+	assert.True(t, IsRegression(r, 0.2, score))
+}
+
+func TestRatio(t *testing.T) {
+	assert.InDelta(t, 0.5, Ratio(150, 100), 1e-9)
+	assert.InDelta(t, -0.25, Ratio(75, 100), 1e-9)
+	assert.Equal(t, 0.0, Ratio(10, 0))
+}
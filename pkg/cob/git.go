@@ -0,0 +1,54 @@
+package cob
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/xerrors"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// gitCommand runs the git command in a worktree.
+type gitCommand struct {
+	path string
+	dir  string
+	// env are environment variables in addition to those of cob.
+	env []string
+}
+
+// withEnv returns a copy which runs git with additional environment variables.
+func (g *gitCommand) withEnv(env ...string) *gitCommand {
+	c := *g
+	c.env = append(append([]string{}, g.env...), env...)
+	return &c
+}
+
+func (g *gitCommand) run(args ...string) ([]byte, error) {
+	cmd := exec.Command(g.path, args...)
+	cmd.Dir = g.dir
+	if len(g.env) > 0 {
+		cmd.Env = append(os.Environ(), g.env...)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, xerrors.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func (g *gitCommand) resolve(rev string) (plumbing.Hash, error) {
+	out, err := g.run("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return plumbing.ZeroHash, xerrors.Errorf("%s is not a commit: %w", rev, err)
+	}
+	return plumbing.NewHash(strings.TrimSpace(string(out))), nil
+}
+
+func (g *gitCommand) shallow() bool {
+	out, err := g.run("rev-parse", "--is-shallow-repository")
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
@@ -0,0 +1,23 @@
+package cob
+
+import (
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// pullLFS replaces the pointer files of Git LFS in the worktree with the files, downloading them if they aren't
+// in the local LFS cache. Nothing is downloaded if the commit checked out has no LFS files.
+func (g *gitCommand) pullLFS() error {
+	out, err := g.run("lfs", "ls-files", "--name-only")
+	if err != nil {
+		return xerrors.Errorf("failed to list the Git LFS files: %w", err)
+	}
+	if strings.TrimSpace(string(out)) == "" {
+		return nil
+	}
+	if _, err := g.run("lfs", "pull"); err != nil {
+		return xerrors.Errorf("failed to pull the Git LFS files: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package cob
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+// fakeGitLFS is run by "git lfs". It "downloads" the file of data.bin as the commit checked out.
+const fakeGitLFS = `#!/bin/sh
+echo "$@" >> "$LFS_LOG"
+case "$1" in
+version) echo "git-lfs/3.0.0" ;;
+ls-files) [ -f data.bin ] && echo data.bin ;;
+pull) echo "file at $(git log -1 --format=%s)" > data.bin ;;
+esac
+`
+
+func TestWorktree_LFS(t *testing.T) {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bin := filepath.Join(dir, "bin")
+	require.NoError(t, os.Mkdir(bin, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bin, "git-lfs"), []byte(fakeGitLFS), 0755))
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	log := filepath.Join(dir, "lfs.log")
+	defer os.Unsetenv("LFS_LOG")
+	os.Setenv("LFS_LOG", log)
+
+	repo := filepath.Join(dir, "repo")
+	require.NoError(t, os.Mkdir(repo, 0755))
+	data := filepath.Join(repo, "data.bin")
+	runGit(t, repo, "init", "--quiet")
+	require.NoError(t, ioutil.WriteFile(data, []byte("pointer of base\n"), 0644))
+	runGit(t, repo, "add", "data.bin")
+	runGit(t, repo, "commit", "--quiet", "-m", "base")
+	require.NoError(t, ioutil.WriteFile(data, []byte("pointer of head\n"), 0644))
+	runGit(t, repo, "commit", "--quiet", "-am", "head")
+
+	r, err := git.PlainOpen(repo)
+	require.NoError(t, err)
+	_, err = NewWorktree(r, WorktreeOptions{LFS: true})
+	assert.Error(t, err, "LFS needs git")
+
+	w, err := NewWorktree(r, WorktreeOptions{Git: gitPath, LFS: true})
+	require.NoError(t, err)
+	base, err := w.Resolve("HEAD~1")
+	require.NoError(t, err)
+	err = w.At(base, func() error {
+		b, err := ioutil.ReadFile(data)
+		require.NoError(t, err)
+		assert.Equal(t, "file at base\n", string(b))
+		return nil
+	})
+	require.NoError(t, err)
+	b, err := ioutil.ReadFile(data)
+	require.NoError(t, err)
+	assert.Equal(t, "file at head\n", string(b))
+
+	b, err = ioutil.ReadFile(log)
+	require.NoError(t, err)
+	assert.Equal(t, "ls-files --name-only\npull\nls-files --name-only\npull\n", string(b))
+}
@@ -0,0 +1,145 @@
+package cob
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// snapshot is a copy of the preserved paths of a worktree, which is copied back after each checkout.
+type snapshot struct {
+	root string
+	dir  string
+	// paths are the preserved paths matching the patterns when the snapshot was taken, relative to root.
+	paths []string
+}
+
+// takeSnapshot copies the paths in root matching the patterns, e.g. "gen" or "assets/*.pb.go", to a temporary
+// directory.
+func takeSnapshot(root string, patterns []string) (*snapshot, error) {
+	s := &snapshot{root: root}
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, xerrors.Errorf("invalid path to preserve %s: %w", pattern, err)
+		}
+		for _, m := range matches {
+			rel, err := filepath.Rel(root, m)
+			if err != nil {
+				return nil, err
+			}
+			s.paths = append(s.paths, rel)
+		}
+	}
+	if len(s.paths) == 0 {
+		return s, nil
+	}
+
+	dir, err := ioutil.TempDir("", "cob-preserve")
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create a directory for the preserved paths: %w", err)
+	}
+	s.dir = dir
+	for _, p := range s.paths {
+		if err := copyPath(filepath.Join(root, p), filepath.Join(dir, p)); err != nil {
+			s.remove()
+			return nil, xerrors.Errorf("failed to preserve %s: %w", p, err)
+		}
+	}
+	return s, nil
+}
+
+// restore replaces the preserved paths in the worktree with their copies.
+func (s *snapshot) restore() error {
+	for _, p := range s.paths {
+		dst := filepath.Join(s.root, p)
+		if err := os.RemoveAll(dst); err != nil {
+			return xerrors.Errorf("failed to restore %s: %w", p, err)
+		}
+		if err := copyPath(filepath.Join(s.dir, p), dst); err != nil {
+			return xerrors.Errorf("failed to restore %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+func (s *snapshot) remove() error {
+	if s.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(s.dir)
+}
+
+// covers returns whether the path, relative to the root and slash-separated as in "git status", is one of the
+// patterns or in one of them.
+func covers(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(pattern, "/")
+		for p := path; p != "." && p != "/" && p != ""; p = filepathDir(p) {
+			if ok, _ := filepath.Match(pattern, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func filepathDir(p string) string {
+	i := strings.LastIndex(p, "/")
+	if i < 0 {
+		return ""
+	}
+	return p[:i]
+}
+
+// copyPath copies a file, a symbolic link or a directory with what is in it.
+func copyPath(src, dst string) error {
+	fi, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	switch {
+	case fi.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	case fi.IsDir():
+		if err := os.MkdirAll(dst, fi.Mode().Perm()); err != nil {
+			return err
+		}
+		entries, err := ioutil.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := copyPath(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		in, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fi.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	}
+}
@@ -0,0 +1,116 @@
+package cob
+
+import (
+	"math"
+	"sort"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/tools/benchmark/parse"
+)
+
+// Median combines the runs of a benchmark with -count, taking the median of each value, so that a single noisy
+// run doesn't decide the comparison. A single run is returned as it is.
+func Median(runs []*parse.Benchmark) *parse.Benchmark {
+	if len(runs) == 1 {
+		return runs[0]
+	}
+	b := *runs[0]
+	b.N = int(median(runs, func(r *parse.Benchmark) float64 { return float64(r.N) }))
+	b.NsPerOp = median(runs, nsPerOp)
+	b.AllocedBytesPerOp = uint64(median(runs, allocedBytesPerOp))
+	b.AllocsPerOp = uint64(median(runs, func(r *parse.Benchmark) float64 { return float64(r.AllocsPerOp) }))
+	b.MBPerS = median(runs, func(r *parse.Benchmark) float64 { return r.MBPerS })
+	return &b
+}
+
+func median(runs []*parse.Benchmark, value func(*parse.Benchmark) float64) float64 {
+	values := samples(runs, value)
+	sort.Float64s(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+func samples(runs []*parse.Benchmark, value func(*parse.Benchmark) float64) []float64 {
+	values := make([]float64, len(runs))
+	for i, r := range runs {
+		values[i] = value(r)
+	}
+	return values
+}
+
+// mannWhitneyU returns the two-sided p-value of the Mann-Whitney U test, i.e. how likely the samples are if
+// both come from the same distribution. Unlike a t-test, it doesn't assume that benchmark results are normally
+// distributed, which they are not. The normal approximation with a correction for ties is used, which is close
+// enough from about 5 samples each; ok is false if there are less than 2 samples on either side.
+func mannWhitneyU(x, y []float64) (p float64, ok bool) {
+	n1, n2 := len(x), len(y)
+	if n1 < 2 || n2 < 2 {
+		return 0, false
+	}
+	type sample struct {
+		value float64
+		fromX bool
+	}
+	all := make([]sample, 0, n1+n2)
+	for _, v := range x {
+		all = append(all, sample{v, true})
+	}
+	for _, v := range y {
+		all = append(all, sample{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].value < all[j].value })
+
+	// Tied values get the mean of their ranks.
+	var rankSumX, ties float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].fromX {
+				rankSumX += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	n := float64(n1 + n2)
+	u := rankSumX - float64(n1*(n1+1))/2
+	mean := float64(n1*n2) / 2
+	variance := float64(n1*n2) / 12 * ((n + 1) - ties/(n*(n-1)))
+	if variance == 0 {
+		// All values are equal.
+		return 1, true
+	}
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		z = 0
+	}
+	return math.Erfc(z / math.Sqrt2), true
+}
+
+// pValue tests whether the runs of a benchmark with -count changed between both commits. It is nil if the
+// significance is not tested, or if there are too few runs to test it, in which case only the threshold
+// decides.
+func pValue(base, head []*parse.Benchmark, alpha float64) *report.PValue {
+	if alpha <= 0 {
+		return nil
+	}
+	ns, ok := mannWhitneyU(samples(base, nsPerOp), samples(head, nsPerOp))
+	if !ok {
+		return nil
+	}
+	bytes, _ := mannWhitneyU(samples(base, allocedBytesPerOp), samples(head, allocedBytesPerOp))
+	return &report.PValue{NsPerOp: ns, AllocedBytesPerOp: bytes}
+}
+
+func nsPerOp(b *parse.Benchmark) float64 { return b.NsPerOp }
+
+func allocedBytesPerOp(b *parse.Benchmark) float64 { return float64(b.AllocedBytesPerOp) }
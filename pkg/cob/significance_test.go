@@ -0,0 +1,57 @@
+package cob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/benchmark/parse"
+)
+
+func TestMedian(t *testing.T) {
+	runs := []*parse.Benchmark{
+		{Name: "BenchmarkA-8", N: 100, NsPerOp: 30, AllocedBytesPerOp: 16, AllocsPerOp: 1},
+		{Name: "BenchmarkA-8", N: 200, NsPerOp: 10, AllocedBytesPerOp: 16, AllocsPerOp: 1},
+		{Name: "BenchmarkA-8", N: 100, NsPerOp: 20, AllocedBytesPerOp: 48, AllocsPerOp: 3},
+	}
+	got := Median(runs)
+	assert.Equal(t, &parse.Benchmark{Name: "BenchmarkA-8", N: 100, NsPerOp: 20, AllocedBytesPerOp: 16, AllocsPerOp: 1}, got)
+	assert.Equal(t, 30.0, runs[0].NsPerOp, "the runs are not modified")
+
+	got = Median(runs[:2])
+	assert.Equal(t, 20.0, got.NsPerOp)
+	assert.True(t, runs[0] == Median(runs[:1]))
+}
+
+func TestMannWhitneyU(t *testing.T) {
+	tests := []struct {
+		name   string
+		x, y   []float64
+		want   float64
+		wantOK bool
+	}{
+		{name: "separated", x: []float64{1, 2, 3, 4, 5}, y: []float64{6, 7, 8, 9, 10}, want: 0.0122, wantOK: true},
+		{name: "interleaved", x: []float64{1, 3, 5, 7, 9}, y: []float64{2, 4, 6, 8, 10}, want: 0.6761, wantOK: true},
+		{name: "ties", x: []float64{1, 1, 2, 2}, y: []float64{2, 3, 3, 3}, want: 0.0471, wantOK: true},
+		{name: "equal", x: []float64{5, 5, 5}, y: []float64{5, 5, 5}, want: 1, wantOK: true},
+		{name: "too few runs", x: []float64{1}, y: []float64{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := mannWhitneyU(tt.x, tt.y)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.InDelta(t, tt.want, got, 0.0001)
+		})
+	}
+}
+
+func TestPValue(t *testing.T) {
+	base := []*parse.Benchmark{{NsPerOp: 1}, {NsPerOp: 2}, {NsPerOp: 3}}
+	head := []*parse.Benchmark{{NsPerOp: 4}, {NsPerOp: 5}, {NsPerOp: 6}}
+	assert.Nil(t, pValue(base, head, 0))
+	assert.Nil(t, pValue(base[:1], head, 0.05))
+	p := pValue(base, head, 0.05)
+	if assert.NotNil(t, p) {
+		assert.InDelta(t, 0.0809, p.NsPerOp, 0.0001)
+		assert.Equal(t, 1.0, p.AllocedBytesPerOp)
+	}
+}
@@ -0,0 +1,207 @@
+package cob
+
+import (
+	"os/exec"
+	"strings"
+
+	"golang.org/x/xerrors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// ErrDirty is returned by NewWorktree if the worktree has changes, which checking out another commit would
+// lose.
+var ErrDirty = xerrors.New("the repository is dirty")
+
+// Worktree checks out commits of a repository to benchmark them, and then HEAD again.
+type Worktree struct {
+	repo     *git.Repository
+	worktree *git.Worktree
+	head     plumbing.Hash
+	// git is the git command which checks the status, resolves revisions and resets the worktree, or nil to do
+	// it with go-git.
+	git      *gitCommand
+	opts     WorktreeOptions
+	snapshot *snapshot
+}
+
+// WorktreeOptions are the options of NewWorktree.
+type WorktreeOptions struct {
+	// Git is the path of the git command which checks the worktree and resets it instead of go-git: go-git's hard
+	// reset is very slow in large repositories, and it doesn't apply the filters of .gitattributes, e.g. Git LFS.
+	// Empty uses go-git.
+	Git string
+	// Preserve are paths in the worktree, or glob patterns of them, which are kept as they are at HEAD across
+	// checkouts, e.g. generated code which isn't committed. Changes in them don't make the worktree dirty.
+	Preserve []string
+	// LFS checks out the files of Git LFS at each commit with "git lfs pull" instead of their pointer files. It
+	// needs Git.
+	LFS bool
+	// PostCheckout is called after each checkout, e.g. to generate code for the commit.
+	PostCheckout func(commit plumbing.Hash) error
+}
+
+// OpenWorktree opens the worktree of the repository at dir. It uses the git command if it is in PATH.
+func OpenWorktree(dir string) (*Worktree, error) {
+	r, err := git.PlainOpen(dir)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to open the git repository: %w", err)
+	}
+	gitPath, _ := exec.LookPath("git")
+	return NewWorktree(r, WorktreeOptions{Git: gitPath})
+}
+
+// NewWorktree returns the worktree of the repository, which must be clean. Close removes the copy of the
+// preserved paths.
+func NewWorktree(r *git.Repository, opts WorktreeOptions) (*Worktree, error) {
+	head, err := r.Head()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get the reference where HEAD is pointing to: %w", err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get a worktree based on the given fs: %w", err)
+	}
+	wt := &Worktree{repo: r, worktree: w, head: head.Hash(), opts: opts}
+	if opts.LFS && opts.Git == "" {
+		return nil, xerrors.New("checking out Git LFS files needs the git command")
+	}
+	if opts.Git != "" {
+		wt.git = &gitCommand{path: opts.Git, dir: w.Filesystem.Root()}
+	}
+
+	clean, err := wt.clean()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get the working tree status: %w", err)
+	}
+	if !clean {
+		return nil, ErrDirty
+	}
+	if wt.snapshot, err = takeSnapshot(w.Filesystem.Root(), opts.Preserve); err != nil {
+		return nil, err
+	}
+	return wt, nil
+}
+
+// UsesGit returns whether the worktree is checked out by the git command.
+func (w *Worktree) UsesGit() bool {
+	return w.git != nil
+}
+
+// Close removes the copy of the preserved paths.
+func (w *Worktree) Close() error {
+	return w.snapshot.remove()
+}
+
+// clean returns whether the worktree has no changes outside the preserved paths.
+func (w *Worktree) clean() (bool, error) {
+	var changed []string
+	if w.git != nil {
+		out, err := w.git.run("status", "--porcelain")
+		if err != nil {
+			return false, err
+		}
+		for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+			if len(line) < 4 {
+				continue
+			}
+			// A renamed file is shown as "R  old -> new".
+			path := line[3:]
+			if i := strings.Index(path, " -> "); i >= 0 {
+				path = path[i+len(" -> "):]
+			}
+			changed = append(changed, strings.TrimSuffix(strings.Trim(path, `"`), "/"))
+		}
+	} else {
+		s, err := w.worktree.Status()
+		if err != nil {
+			return false, err
+		}
+		for path, st := range s {
+			if st.Staging != git.Unmodified || st.Worktree != git.Unmodified {
+				changed = append(changed, path)
+			}
+		}
+	}
+	for _, path := range changed {
+		if !covers(w.opts.Preserve, path) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Head returns the commit which HEAD pointed to when the worktree was opened.
+func (w *Worktree) Head() plumbing.Hash {
+	return w.head
+}
+
+// Resolve returns the commit of a revision, e.g. "HEAD~1" or "origin/main". With the git command, the history of a
+// shallow clone is fetched from origin if the revision isn't in it.
+func (w *Worktree) Resolve(rev string) (plumbing.Hash, error) {
+	if w.git != nil {
+		h, err := w.git.resolve(rev)
+		if err != nil && w.git.shallow() {
+			if _, fetchErr := w.git.run("fetch", "--quiet", "--unshallow", "origin"); fetchErr == nil {
+				h, err = w.git.resolve(rev)
+			}
+		}
+		if err != nil {
+			return plumbing.ZeroHash, xerrors.Errorf("unable to resolves revision to corresponding hash: %w", err)
+		}
+		return h, nil
+	}
+	h, err := w.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return plumbing.ZeroHash, xerrors.Errorf("unable to resolves revision to corresponding hash: %w", err)
+	}
+	return *h, nil
+}
+
+// Checkout resets the worktree to the commit, like "git reset --hard", and then checks out the Git LFS files,
+// copies back the preserved paths and calls PostCheckout.
+func (w *Worktree) Checkout(commit plumbing.Hash) error {
+	var err error
+	switch {
+	case w.git != nil && w.opts.LFS:
+		// The files are downloaded at once by "git lfs pull" instead of one by one by the smudge filter.
+		if _, err = w.git.withEnv("GIT_LFS_SKIP_SMUDGE=1").run("reset", "--quiet", "--hard", commit.String()); err == nil {
+			if err := w.git.pullLFS(); err != nil {
+				return xerrors.Errorf("failed to check out the Git LFS files of %s: %w", commit, err)
+			}
+		}
+	case w.git != nil:
+		_, err = w.git.run("reset", "--quiet", "--hard", commit.String())
+	default:
+		err = w.worktree.Reset(&git.ResetOptions{Commit: commit, Mode: git.HardReset})
+	}
+	if err != nil {
+		return xerrors.Errorf("failed to reset the worktree to %s: %w", commit, err)
+	}
+	if err := w.snapshot.restore(); err != nil {
+		return err
+	}
+	if w.opts.PostCheckout != nil {
+		if err := w.opts.PostCheckout(commit); err != nil {
+			return xerrors.Errorf("failed to run the hook after checking out %s: %w", commit, err)
+		}
+	}
+	return nil
+}
+
+// Restore resets the worktree to HEAD.
+func (w *Worktree) Restore() error {
+	return w.Checkout(w.head)
+}
+
+// At runs fn with the worktree at the commit, and resets it to HEAD afterwards, even if fn fails.
+func (w *Worktree) At(commit plumbing.Hash, fn func() error) error {
+	if err := w.Checkout(commit); err != nil {
+		return err
+	}
+	err := fn()
+	if restoreErr := w.Restore(); restoreErr != nil && err == nil {
+		err = restoreErr
+	}
+	return err
+}
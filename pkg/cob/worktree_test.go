@@ -0,0 +1,152 @@
+package cob
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+func TestWorktree(t *testing.T) {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is not installed")
+	}
+	for name, gitPath := range map[string]string{"go-git": "", "git": gitPath} {
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "cob")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			file := filepath.Join(dir, "a.txt")
+			runGit(t, dir, "init", "--quiet")
+			require.NoError(t, ioutil.WriteFile(file, []byte("base\n"), 0644))
+			runGit(t, dir, "add", "a.txt")
+			runGit(t, dir, "commit", "--quiet", "-m", "base")
+			require.NoError(t, ioutil.WriteFile(file, []byte("head\n"), 0644))
+			runGit(t, dir, "commit", "--quiet", "-am", "head")
+
+			r, err := git.PlainOpen(dir)
+			require.NoError(t, err)
+			w, err := NewWorktree(r, WorktreeOptions{Git: gitPath})
+			require.NoError(t, err)
+			assert.Equal(t, gitPath != "", w.UsesGit())
+			assert.Equal(t, runGit(t, dir, "rev-parse", "HEAD"), w.Head().String())
+
+			base, err := w.Resolve("HEAD~1")
+			require.NoError(t, err)
+			assert.Equal(t, runGit(t, dir, "rev-parse", "HEAD~1"), base.String())
+			_, err = w.Resolve("missing")
+			assert.Error(t, err)
+
+			err = w.At(base, func() error {
+				b, err := ioutil.ReadFile(file)
+				require.NoError(t, err)
+				assert.Equal(t, "base\n", string(b))
+				return nil
+			})
+			require.NoError(t, err)
+			b, err := ioutil.ReadFile(file)
+			require.NoError(t, err)
+			assert.Equal(t, "head\n", string(b))
+
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "untracked.txt"), nil, 0644))
+			_, err = NewWorktree(r, WorktreeOptions{Git: gitPath})
+			assert.True(t, xerrors.Is(err, ErrDirty))
+		})
+	}
+}
+
+func TestWorktree_ResolveShallow(t *testing.T) {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	origin := filepath.Join(dir, "origin")
+	require.NoError(t, os.Mkdir(origin, 0755))
+	runGit(t, origin, "init", "--quiet")
+	runGit(t, origin, "commit", "--quiet", "--allow-empty", "-m", "base")
+	runGit(t, origin, "commit", "--quiet", "--allow-empty", "-m", "head")
+	clone := filepath.Join(dir, "clone")
+	runGit(t, dir, "clone", "--quiet", "--depth=1", "file://"+origin, clone)
+
+	r, err := git.PlainOpen(clone)
+	require.NoError(t, err)
+	w, err := NewWorktree(r, WorktreeOptions{Git: gitPath})
+	require.NoError(t, err)
+	base, err := w.Resolve("HEAD~1")
+	require.NoError(t, err, "the history is fetched")
+	assert.Equal(t, runGit(t, origin, "rev-parse", "HEAD~1"), base.String())
+}
+
+func TestWorktree_Preserve(t *testing.T) {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is not installed")
+	}
+	for name, gitPath := range map[string]string{"go-git": "", "git": gitPath} {
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "cob")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			// The generated file was committed at the base commit, and is generated at HEAD.
+			generated := filepath.Join(dir, "gen", "a.pb.go")
+			runGit(t, dir, "init", "--quiet")
+			require.NoError(t, os.Mkdir(filepath.Join(dir, "gen"), 0755))
+			require.NoError(t, ioutil.WriteFile(generated, []byte("old\n"), 0644))
+			runGit(t, dir, "add", "gen")
+			runGit(t, dir, "commit", "--quiet", "-m", "base")
+			runGit(t, dir, "rm", "--quiet", "--cached", "gen/a.pb.go")
+			runGit(t, dir, "commit", "--quiet", "-m", "head")
+			require.NoError(t, ioutil.WriteFile(generated, []byte("new\n"), 0644))
+
+			r, err := git.PlainOpen(dir)
+			require.NoError(t, err)
+			_, err = NewWorktree(r, WorktreeOptions{Git: gitPath})
+			assert.True(t, xerrors.Is(err, ErrDirty), "the generated file is untracked")
+
+			var checkedOut []string
+			w, err := NewWorktree(r, WorktreeOptions{
+				Git:      gitPath,
+				Preserve: []string{"gen"},
+				PostCheckout: func(commit plumbing.Hash) error {
+					b, err := ioutil.ReadFile(generated)
+					require.NoError(t, err)
+					checkedOut = append(checkedOut, commit.String()+" "+string(b))
+					return nil
+				},
+			})
+			require.NoError(t, err)
+			base, err := w.Resolve("HEAD~1")
+			require.NoError(t, err)
+			require.NoError(t, w.At(base, func() error { return nil }))
+			require.NoError(t, w.Close())
+
+			assert.Equal(t, []string{base.String() + " new\n", w.Head().String() + " new\n"}, checkedOut)
+			b, err := ioutil.ReadFile(generated)
+			require.NoError(t, err)
+			assert.Equal(t, "new\n", string(b))
+		})
+	}
+}
+
+func TestCovers(t *testing.T) {
+	patterns := []string{"gen/", "assets/*.pb.go"}
+	assert.True(t, covers(patterns, "gen"))
+	assert.True(t, covers(patterns, "gen/a/b.go"))
+	assert.True(t, covers(patterns, "assets/a.pb.go"))
+	assert.False(t, covers(patterns, "assets/a.go"))
+	assert.False(t, covers(patterns, "generated/a.go"))
+}
@@ -0,0 +1,382 @@
+// Package report defines the machine-readable result of a cob run.
+//
+// The JSON representation is versioned by SchemaVersion. Fields may be added without bumping the
+// version, but renaming or removing a field, or changing its meaning, bumps it. Decode rejects
+// documents written with a newer schema than this package understands, so that tools built on
+// cob's output fail loudly instead of silently misreading it.
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"golang.org/x/xerrors"
+)
+
+// SchemaVersion is the version of the schema written by this package.
+const SchemaVersion = 1
+
+// Status values of a benchmark.
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// Report is the result of comparing benchmarks between two commits.
+type Report struct {
+	SchemaVersion int         `json:"schemaVersion"`
+	Base          Commit      `json:"base"`
+	Head          Commit      `json:"head"`
+	Threshold     float64     `json:"threshold"`
+	Degression    bool        `json:"degression"`
+	Benchmarks    []Benchmark `json:"benchmarks"`
+	Timings       []Timing    `json:"timings,omitempty"`
+	Creep         []Creep     `json:"creep,omitempty"`
+	// Partial is whether benchmarking HEAD stopped at the first benchmark which got worse with -fail-fast, so
+	// that the benchmarks after it are missing.
+	Partial bool `json:"partial,omitempty"`
+	// Added are the benchmarks which only ran at HEAD, and Removed those which only ran at the base commit.
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	// Environment is where the benchmarks at HEAD ran.
+	Environment *Environment `json:"environment,omitempty"`
+	// Cob is the build of cob which wrote the report.
+	Cob *BuildInfo `json:"cob,omitempty"`
+	// EnvironmentMismatches lists how the environment the baseline was measured in, e.g. a stored or downloaded
+	// one, differs from Environment. If there are any, the comparison is informational and Degression is false.
+	EnvironmentMismatches []string `json:"environmentMismatches,omitempty"`
+	// TestTime compares how long the tests took, if they were timed with -test-time.
+	TestTime *TestTime `json:"testTime,omitempty"`
+	// Coverage compares the statement coverage, if it was measured with -coverage.
+	Coverage *Coverage `json:"coverage,omitempty"`
+	// Profiles are the profiles of the benchmarks which got worse, if they were collected with -profile-dir.
+	Profiles []Profile `json:"profiles,omitempty"`
+	// Traces summarize the execution traces of the benchmarks which got worse, if they were captured with
+	// -trace-on-regression.
+	Traces []TraceSummary `json:"traces,omitempty"`
+	// Contention compares the contention of the parallel benchmarks, if it was profiled with -contention.
+	Contention []Contention `json:"contention,omitempty"`
+	// HardwareCounters are the hardware events of each benchmark, if they were counted with -perf.
+	HardwareCounters []HardwareCounters `json:"hardwareCounters,omitempty"`
+	// MaxRSS is the peak RSS of each benchmark, if it was measured with -max-rss.
+	MaxRSS []MaxRSS `json:"maxRSS,omitempty"`
+	// Energy is the energy per op of each benchmark, if it was measured with -energy.
+	Energy []Energy `json:"energy,omitempty"`
+	// Startup compares how long a binary takes to start, if it was measured with -startup.
+	Startup *Startup `json:"startup,omitempty"`
+	// Inlining are the functions which are no longer inlined, if the inlining was compared with -inline-diff.
+	Inlining []InlineChange `json:"inlining,omitempty"`
+	// Escapes are the values which newly escape to the heap, if the escape analysis was compared with -escape-diff.
+	Escapes []Escape `json:"escapes,omitempty"`
+	// Dependencies are the modules which changed between the commits, if go.mod or go.sum changed.
+	Dependencies []DependencyChange `json:"dependencies,omitempty"`
+	// ChangedFiles are the files which changed in the packages of the benchmarks which got worse.
+	ChangedFiles []ChangedFiles `json:"changedFiles,omitempty"`
+}
+
+// Commit identifies one side of the comparison.
+type Commit struct {
+	// Ref is the revision as given by the user, e.g. "HEAD~1".
+	Ref string `json:"ref"`
+	// Hash is the resolved commit hash.
+	Hash string `json:"hash"`
+	// Branch is the branch being built, if it is known.
+	Branch string `json:"branch,omitempty"`
+}
+
+// Benchmark is the comparison of a single benchmark.
+type Benchmark struct {
+	Name   string      `json:"name"`
+	Base   Measurement `json:"base"`
+	Head   Measurement `json:"head"`
+	Ratio  Ratio       `json:"ratio"`
+	Status string      `json:"status"`
+	// Threshold overrides the threshold of the report for the benchmark, if it has a //cob:threshold annotation.
+	Threshold *float64 `json:"threshold,omitempty"`
+	// PValue is how likely the changes are noise, if their significance was tested.
+	PValue *PValue `json:"pValue,omitempty"`
+}
+
+// Measurement is a benchmark result measured at one commit.
+type Measurement struct {
+	Iterations        int     `json:"iterations"`
+	NsPerOp           float64 `json:"nsPerOp"`
+	AllocedBytesPerOp uint64  `json:"allocedBytesPerOp"`
+	AllocsPerOp       uint64  `json:"allocsPerOp"`
+	MBPerS            float64 `json:"mbPerS"`
+}
+
+// Ratio is the relative change from base to head, e.g. 0.2 means 20% worse.
+type Ratio struct {
+	NsPerOp           float64 `json:"nsPerOp"`
+	AllocedBytesPerOp float64 `json:"allocedBytesPerOp"`
+	AllocsPerOp       float64 `json:"allocsPerOp"`
+}
+
+// PValue is the p-value of the Mann-Whitney U test of the runs at both commits. A change with a p-value below
+// the significance level, e.g. 0.05, is unlikely to be noise.
+type PValue struct {
+	NsPerOp           float64 `json:"nsPerOp"`
+	AllocedBytesPerOp float64 `json:"allocedBytesPerOp"`
+}
+
+// Creep is a benchmark which got gradually worse over the recorded history, although no single commit got
+// worse than the threshold.
+type Creep struct {
+	Name string `json:"name"`
+	// Since is the hash of the oldest commit in the window.
+	Since string `json:"since"`
+	// Commits is the number of commits in the window, including HEAD.
+	Commits int `json:"commits"`
+	// Ratio is the change of ns/op from Since to HEAD.
+	Ratio float64 `json:"ratio"`
+}
+
+// Environment describes the machine and the toolchain benchmarks ran with.
+type Environment struct {
+	Hostname  string `json:"hostname"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	CPUs      int    `json:"cpus"`
+	GoVersion string `json:"goVersion,omitempty"`
+	// Tag is the tag of the machine given with -machine.
+	Tag string `json:"tag,omitempty"`
+	// GOFLAGS and GOEXPERIMENT are the ones the benchmarks were built with, including those of the environment.
+	GOFLAGS      string `json:"goflags,omitempty"`
+	GOEXPERIMENT string `json:"goexperiment,omitempty"`
+	// GCFlags and LDFlags are -gcflags and -ldflags.
+	GCFlags string `json:"gcflags,omitempty"`
+	LDFlags string `json:"ldflags,omitempty"`
+}
+
+// BuildInfo identifies a build of cob. Commit and Date are empty if they are unknown, e.g. for a build outside of
+// a git repository.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"goVersion"`
+}
+
+// TestTime compares how long the tests took at both commits, in seconds. Base and Ratio are 0 if the base
+// commit wasn't tested, e.g. because the baseline came from a store.
+type TestTime struct {
+	Base     float64           `json:"base"`
+	Head     float64           `json:"head"`
+	Ratio    float64           `json:"ratio"`
+	Packages []PackageTestTime `json:"packages,omitempty"`
+}
+
+// PackageTestTime is how long the tests of a package took, as reported by "go test -json".
+type PackageTestTime struct {
+	Name  string  `json:"name"`
+	Base  float64 `json:"base"`
+	Head  float64 `json:"head"`
+	Ratio float64 `json:"ratio"`
+}
+
+// Coverage compares the statement coverage in percent at both commits. Base is nil if the base commit wasn't
+// tested, and Change is the difference in percentage points.
+type Coverage struct {
+	Base     *float64          `json:"base,omitempty"`
+	Head     float64           `json:"head"`
+	Change   float64           `json:"change"`
+	Packages []PackageCoverage `json:"packages,omitempty"`
+}
+
+// PackageCoverage is the statement coverage of a package. Base or Head is nil if the package has no tests at
+// the commit.
+type PackageCoverage struct {
+	Name   string   `json:"name"`
+	Base   *float64 `json:"base,omitempty"`
+	Head   *float64 `json:"head,omitempty"`
+	Change float64  `json:"change"`
+}
+
+// Profile is a profile of a benchmark at both commits. Base or Head is empty if the benchmark couldn't be
+// profiled at the commit.
+type Profile struct {
+	Name string `json:"name"`
+	// Kind is the kind of the profile, e.g. "cpu".
+	Kind string `json:"kind"`
+	// Base and Head are the paths of the profile files.
+	Base string `json:"base,omitempty"`
+	Head string `json:"head,omitempty"`
+	// BaseFlamegraph and HeadFlamegraph are the paths of the SVG flamegraphs of the profiles.
+	BaseFlamegraph string `json:"baseFlamegraph,omitempty"`
+	HeadFlamegraph string `json:"headFlamegraph,omitempty"`
+	// Functions are the functions whose CPU time changed the most, for a CPU profile.
+	Functions []CPUFunction `json:"functions,omitempty"`
+	// Sites are the allocation sites which changed the most, for a memory profile.
+	Sites []AllocationSite `json:"sites,omitempty"`
+}
+
+// CPUFunction is a function with the nanoseconds per op spent in it, not in its callees, at both commits.
+type CPUFunction struct {
+	Function string  `json:"function"`
+	Base     float64 `json:"base"`
+	Head     float64 `json:"head"`
+}
+
+// AllocationSite is a function which allocates memory, with the bytes it allocates per op at both commits.
+type AllocationSite struct {
+	Function string  `json:"function"`
+	Base     float64 `json:"base"`
+	Head     float64 `json:"head"`
+}
+
+// TraceSummary summarizes the execution traces of a benchmark at both commits. Base or Head is nil if the
+// benchmark couldn't be traced at the commit.
+type TraceSummary struct {
+	Name string      `json:"name"`
+	Base *TraceStats `json:"base,omitempty"`
+	Head *TraceStats `json:"head,omitempty"`
+}
+
+// TraceStats are the nanoseconds per op goroutines waited to be scheduled, and the garbage collections of a
+// traced run.
+type TraceStats struct {
+	SchedulerLatency float64 `json:"schedulerLatency"`
+	GCCycles         int     `json:"gcCycles"`
+	// GCPause is the stop-the-world time of the collections in nanoseconds per op.
+	GCPause float64 `json:"gcPause"`
+}
+
+// Contention is the contention per op of a parallel benchmark at both commits. Base or Head is nil if the
+// benchmark couldn't be profiled at the commit.
+type Contention struct {
+	Name string            `json:"name"`
+	Base *ContentionEvents `json:"base,omitempty"`
+	Head *ContentionEvents `json:"head,omitempty"`
+}
+
+// ContentionEvents are the blocking events and mutex contentions per op, and the nanoseconds per op goroutines
+// were delayed by them.
+type ContentionEvents struct {
+	BlockContentions float64 `json:"blockContentions"`
+	BlockDelay       float64 `json:"blockDelay"`
+	MutexContentions float64 `json:"mutexContentions"`
+	MutexDelay       float64 `json:"mutexDelay"`
+}
+
+// HardwareCounters are the hardware events per op of a benchmark at both commits. Base or Head is nil if the
+// events couldn't be counted at the commit.
+type HardwareCounters struct {
+	Name string  `json:"name"`
+	Base *Events `json:"base,omitempty"`
+	Head *Events `json:"head,omitempty"`
+}
+
+// Events are the hardware events per op counted by perf.
+type Events struct {
+	Instructions float64 `json:"instructions"`
+	CacheMisses  float64 `json:"cacheMisses"`
+	BranchMisses float64 `json:"branchMisses"`
+}
+
+// MaxRSS is the peak resident set size in bytes of the process of a benchmark at both commits. Base or Head
+// is 0 if it couldn't be measured at the commit.
+type MaxRSS struct {
+	Name  string  `json:"name"`
+	Base  int64   `json:"base"`
+	Head  int64   `json:"head"`
+	Ratio float64 `json:"ratio"`
+}
+
+// Energy is the energy in joules per op the CPU consumed while a benchmark ran at both commits. Base or Head is
+// 0 if it couldn't be measured at the commit.
+type Energy struct {
+	Name  string  `json:"name"`
+	Base  float64 `json:"base"`
+	Head  float64 `json:"head"`
+	Ratio float64 `json:"ratio"`
+}
+
+// Startup is the median time in seconds a binary took from its start until it was ready at both commits. Base
+// is 0 if the base commit wasn't measured.
+type Startup struct {
+	// Package is the main package of the binary, e.g. "./cmd/server".
+	Package string `json:"package"`
+	// Ready is when the binary was ready, e.g. "port:localhost:8080".
+	Ready string  `json:"ready"`
+	Base  float64 `json:"base"`
+	Head  float64 `json:"head"`
+	Ratio float64 `json:"ratio"`
+}
+
+// InlineChange is a function which the compiler inlined at the base commit, but can't inline at HEAD.
+type InlineChange struct {
+	// Package is the package of the function, e.g. "./pkg".
+	Package  string `json:"package"`
+	Function string `json:"function"`
+	// Position is where the function is declared at HEAD, e.g. "pkg/a.go:12:6".
+	Position string `json:"position"`
+	// Reason is why the compiler can't inline the function, e.g. "function too complex: cost 92 exceeds budget 80".
+	Reason string `json:"reason"`
+}
+
+// Escape is a value which the compiler allocates on the heap at HEAD, but didn't at the base commit.
+type Escape struct {
+	// Package is the package of the value, e.g. "./pkg".
+	Package string `json:"package"`
+	// Function is the function the value is allocated in, or "" if the compiler didn't tell.
+	Function string `json:"function,omitempty"`
+	// Value is the variable moved to the heap or the expression which escapes, e.g. "make([]byte, n)".
+	Value string `json:"value"`
+	// Position is where the value is at HEAD, e.g. "pkg/a.go:12:6".
+	Position string `json:"position"`
+}
+
+// DependencyChange is a module whose version in the build list changed between the commits. Base or Head is empty
+// if the module was added or removed.
+type DependencyChange struct {
+	Module string `json:"module"`
+	// Base and Head are the versions, e.g. "v1.2.3" or "v1.2.3 => ../fork" for a replaced module.
+	Base string `json:"base,omitempty"`
+	Head string `json:"head,omitempty"`
+	// Benchmarks are the benchmarks which got worse and whose packages import the module.
+	Benchmarks []string `json:"benchmarks,omitempty"`
+}
+
+// ChangedFiles are the files which changed between the commits in the package of a benchmark and in the packages it
+// imports directly.
+type ChangedFiles struct {
+	Name  string   `json:"name"`
+	Files []string `json:"files"`
+}
+
+// Timing is how long a phase of the run took.
+type Timing struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+}
+
+// Encode writes the report as indented JSON. SchemaVersion is filled in if it is not set.
+func Encode(w io.Writer, r Report) error {
+	if r.SchemaVersion == 0 {
+		r.SchemaVersion = SchemaVersion
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	if err := e.Encode(r); err != nil {
+		return xerrors.Errorf("failed to encode the report: %w", err)
+	}
+	return nil
+}
+
+// Decode reads a report and validates its schema version.
+func Decode(r io.Reader) (Report, error) {
+	var report Report
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return Report{}, xerrors.Errorf("failed to decode the report: %w", err)
+	}
+	if report.SchemaVersion == 0 {
+		return Report{}, xerrors.New("the report has no schemaVersion")
+	}
+	if report.SchemaVersion > SchemaVersion {
+		return Report{}, xerrors.Errorf("unsupported schemaVersion %d: this version of cob supports up to %d",
+			report.SchemaVersion, SchemaVersion)
+	}
+	return report, nil
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	want := Report{
+		SchemaVersion: SchemaVersion,
+		Base:          Commit{Ref: "HEAD~1", Hash: "4363944cbed3da7a8245cbcdc8d8240b8976eb24"},
+		Head:          Commit{Ref: "HEAD", Hash: "599a5523729d4d99a331b9d3f71dde9e1e6daef0"},
+		Threshold:     0.2,
+		Degression:    true,
+		Benchmarks: []Benchmark{
+			{
+				Name:   "BenchmarkA",
+				Base:   Measurement{Iterations: 1000, NsPerOp: 100, AllocedBytesPerOp: 200},
+				Head:   Measurement{Iterations: 1000, NsPerOp: 150, AllocedBytesPerOp: 200},
+				Ratio:  Ratio{NsPerOp: 0.5},
+				Status: StatusFail,
+			},
+		},
+		Timings:     []Timing{{Name: "bench base", Seconds: 1.5}},
+		Environment: &Environment{Hostname: "runner-1", OS: "linux", Arch: "amd64", CPUs: 8, GoVersion: "go1.13.8"},
+	}
+
+	w := &bytes.Buffer{}
+	require.NoError(t, Encode(w, want))
+	got, err := Decode(w)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:  "happy path",
+			input: `{"schemaVersion": 1, "benchmarks": []}`,
+		},
+		{
+			name:    "no schema version",
+			input:   `{"benchmarks": []}`,
+			wantErr: "the report has no schemaVersion",
+		},
+		{
+			name:    "newer schema version",
+			input:   `{"schemaVersion": 2}`,
+			wantErr: "unsupported schemaVersion 2: this version of cob supports up to 1",
+		},
+		{
+			name:    "invalid json",
+			input:   `{`,
+			wantErr: "failed to decode the report",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
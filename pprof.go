@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io/ioutil"
+
+	"golang.org/x/xerrors"
+)
+
+// pprofProfile is the part of a profile in the pprof format (profile.proto) which cob needs: the samples with
+// their stacks resolved to function names.
+type pprofProfile struct {
+	// sampleTypes are the types of the values of each sample, e.g. "alloc_space".
+	sampleTypes []string
+	samples     []pprofSample
+}
+
+// pprofSample is a sample of the profile. stack starts at the leaf function.
+type pprofSample struct {
+	stack  []string
+	values []int64
+}
+
+// valueIndex returns the index of the sample type in the values, or -1.
+func (p pprofProfile) valueIndex(sampleType string) int {
+	for i, t := range p.sampleTypes {
+		if t == sampleType {
+			return i
+		}
+	}
+	return -1
+}
+
+func readPprofFile(path string) (pprofProfile, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return pprofProfile{}, xerrors.Errorf("failed to read the profile: %w", err)
+	}
+	return parsePprof(b)
+}
+
+// parsePprof decodes a profile, which is gzipped as "go test" writes it or not.
+func parsePprof(b []byte) (pprofProfile, error) {
+	if len(b) >= 2 && b[0] == 0x1f && b[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return pprofProfile{}, xerrors.Errorf("invalid profile: %w", err)
+		}
+		if b, err = ioutil.ReadAll(zr); err != nil {
+			return pprofProfile{}, xerrors.Errorf("invalid profile: %w", err)
+		}
+	}
+
+	type sample struct {
+		locations []uint64
+		values    []int64
+	}
+	var (
+		sampleTypes [][2]int64
+		samples     []sample
+		stringTable []string
+		// locations are function ids of the lines of each location, from the inlined function to its caller.
+		locations = map[uint64][]uint64{}
+		functions = map[uint64]int64{}
+	)
+	err := protoFields(b, func(field int, wire int, v uint64, data []byte) error {
+		switch field {
+		case 1: // sample_type
+			var vt [2]int64
+			err := protoFields(data, func(field int, _ int, v uint64, _ []byte) error {
+				if field == 1 || field == 2 {
+					vt[field-1] = int64(v)
+				}
+				return nil
+			})
+			sampleTypes = append(sampleTypes, vt)
+			return err
+		case 2: // sample
+			var s sample
+			err := protoFields(data, func(field int, wire int, v uint64, data []byte) error {
+				switch field {
+				case 1:
+					ids, err := protoRepeated(wire, v, data)
+					s.locations = append(s.locations, ids...)
+					return err
+				case 2:
+					values, err := protoRepeated(wire, v, data)
+					for _, v := range values {
+						s.values = append(s.values, int64(v))
+					}
+					return err
+				}
+				return nil
+			})
+			samples = append(samples, s)
+			return err
+		case 4: // location
+			var id uint64
+			var funcs []uint64
+			err := protoFields(data, func(field int, _ int, v uint64, data []byte) error {
+				switch field {
+				case 1:
+					id = v
+				case 4:
+					return protoFields(data, func(field int, _ int, v uint64, _ []byte) error {
+						if field == 1 {
+							funcs = append(funcs, v)
+						}
+						return nil
+					})
+				}
+				return nil
+			})
+			locations[id] = funcs
+			return err
+		case 5: // function
+			var id uint64
+			var name int64
+			err := protoFields(data, func(field int, _ int, v uint64, _ []byte) error {
+				switch field {
+				case 1:
+					id = v
+				case 2:
+					name = int64(v)
+				}
+				return nil
+			})
+			functions[id] = name
+			return err
+		case 6: // string_table
+			stringTable = append(stringTable, string(data))
+		}
+		return nil
+	})
+	if err != nil {
+		return pprofProfile{}, xerrors.Errorf("invalid profile: %w", err)
+	}
+
+	str := func(i int64) string {
+		if i < 0 || int(i) >= len(stringTable) {
+			return ""
+		}
+		return stringTable[i]
+	}
+	var p pprofProfile
+	for _, vt := range sampleTypes {
+		p.sampleTypes = append(p.sampleTypes, str(vt[0]))
+	}
+	for _, s := range samples {
+		ps := pprofSample{values: s.values}
+		for _, loc := range s.locations {
+			for _, fn := range locations[loc] {
+				ps.stack = append(ps.stack, str(functions[fn]))
+			}
+		}
+		p.samples = append(p.samples, ps)
+	}
+	return p, nil
+}
+
+// Wire types of protocol buffers.
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// protoFields calls fn with each field of an encoded message: v is the value of a varint or fixed field, and
+// data is the content of a length-delimited one.
+func protoFields(b []byte, fn func(field int, wire int, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return xerrors.New("invalid field key")
+		}
+		b = b[n:]
+		field, wire := int(key>>3), int(key&7)
+		var v uint64
+		var data []byte
+		switch wire {
+		case protoVarint:
+			if v, n = binary.Uvarint(b); n <= 0 {
+				return xerrors.New("invalid varint")
+			}
+			b = b[n:]
+		case protoFixed64:
+			if len(b) < 8 {
+				return xerrors.New("truncated fixed64")
+			}
+			v, b = binary.LittleEndian.Uint64(b), b[8:]
+		case protoFixed32:
+			if len(b) < 4 {
+				return xerrors.New("truncated fixed32")
+			}
+			v, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		case protoBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return xerrors.New("truncated bytes")
+			}
+			data, b = b[n:n+int(l)], b[n+int(l):]
+		default:
+			return xerrors.Errorf("unsupported wire type %d", wire)
+		}
+		if err := fn(field, wire, v, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// protoRepeated returns the values of a repeated varint field, which is either packed or a single value.
+func protoRepeated(wire int, v uint64, data []byte) ([]uint64, error) {
+	if wire != protoBytes {
+		return []uint64{v}, nil
+	}
+	var values []uint64
+	for len(data) > 0 {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, xerrors.New("invalid packed varint")
+		}
+		values = append(values, v)
+		data = data[n:]
+	}
+	return values, nil
+}
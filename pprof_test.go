@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// protoMessage encodes fields of a protocol buffer message for tests.
+type protoMessage struct {
+	bytes.Buffer
+}
+
+func (m *protoMessage) varint(field int, v uint64) *protoMessage {
+	m.uvarint(uint64(field<<3 | protoVarint))
+	m.uvarint(v)
+	return m
+}
+
+func (m *protoMessage) bytes(field int, b []byte) *protoMessage {
+	m.uvarint(uint64(field<<3 | protoBytes))
+	m.uvarint(uint64(len(b)))
+	m.Write(b)
+	return m
+}
+
+func (m *protoMessage) packed(field int, values ...uint64) *protoMessage {
+	var p protoMessage
+	for _, v := range values {
+		p.uvarint(v)
+	}
+	return m.bytes(field, p.Bytes())
+}
+
+func (m *protoMessage) uvarint(v uint64) {
+	b := make([]byte, binary.MaxVarintLen64)
+	m.Write(b[:binary.PutUvarint(b, v)])
+}
+
+// testMemProfile returns a gzipped memory profile where each function allocates the bytes, called from main.
+func testMemProfile(t *testing.T, allocs map[string]int64) []byte {
+	return testProfile(t, "alloc_objects", "alloc_space", "bytes", allocs)
+}
+
+// testCPUProfile returns a gzipped CPU profile where each function runs for the nanoseconds, called from main.
+func testCPUProfile(t *testing.T, durations map[string]int64) []byte {
+	return testProfile(t, "samples", "cpu", "nanoseconds", durations)
+}
+
+// testProfile returns a gzipped profile with a sample of each function called from main. The count of each
+// sample is 1.
+func testProfile(t *testing.T, countType, valueType, unit string, values map[string]int64) []byte {
+	var p protoMessage
+	strs := []string{"", countType, "count", valueType, unit, "main"}
+	p.bytes(1, (&protoMessage{}).varint(1, 1).varint(2, 2).Bytes())
+	p.bytes(1, (&protoMessage{}).varint(1, 3).varint(2, 4).Bytes())
+	// Function and location 1 is main.
+	p.bytes(5, (&protoMessage{}).varint(1, 1).varint(2, 5).Bytes())
+	p.bytes(4, (&protoMessage{}).varint(1, 1).bytes(4, (&protoMessage{}).varint(1, 1).Bytes()).Bytes())
+	id := uint64(2)
+	for fn, v := range values {
+		strs = append(strs, fn)
+		p.bytes(5, (&protoMessage{}).varint(1, id).varint(2, uint64(len(strs)-1)).Bytes())
+		p.bytes(4, (&protoMessage{}).varint(1, id).bytes(4, (&protoMessage{}).varint(1, id).Bytes()).Bytes())
+		p.bytes(2, (&protoMessage{}).packed(1, id, 1).packed(2, 1, uint64(v)).Bytes())
+		id++
+	}
+	for _, s := range strs {
+		p.bytes(6, []byte(s))
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write(p.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func Test_parsePprof(t *testing.T) {
+	p, err := parsePprof(testMemProfile(t, map[string]int64{"pkg.grow": 1024}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alloc_objects", "alloc_space"}, p.sampleTypes)
+	assert.Equal(t, 1, p.valueIndex("alloc_space"))
+	assert.Equal(t, -1, p.valueIndex("cpu"))
+	assert.Equal(t, []pprofSample{{stack: []string{"pkg.grow", "main"}, values: []int64{1, 1024}}}, p.samples)
+
+	_, err = parsePprof([]byte{0x0a, 0x05, 0x01})
+	assert.Error(t, err)
+}
+
+func Test_diffAllocationSites(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	base := filepath.Join(dir, "base.pprof")
+	head := filepath.Join(dir, "head.pprof")
+	// 10 iterations plus the first run with b.N = 1.
+	require.NoError(t, ioutil.WriteFile(base, testMemProfile(t, map[string]int64{
+		"pkg.grow": 11 * 16, "pkg.removed": 11 * 8, "pkg.same": 11 * 100,
+	}), 0644))
+	require.NoError(t, ioutil.WriteFile(head, testMemProfile(t, map[string]int64{
+		"pkg.grow": 11 * 1024, "pkg.added": 11 * 32, "pkg.same": 11 * 100,
+	}), 0644))
+
+	sites, err := diffAllocationSites(base, head, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []report.AllocationSite{
+		{Function: "pkg.grow", Base: 16, Head: 1024},
+		{Function: "pkg.added", Head: 32},
+		{Function: "pkg.removed", Base: 8},
+	}, sites)
+
+	_, err = diffAllocationSites(base, filepath.Join(dir, "missing.pprof"), 10)
+	assert.Error(t, err)
+}
+
+func Test_diffCPUFunctions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	base := filepath.Join(dir, "base.pprof")
+	head := filepath.Join(dir, "head.pprof")
+	require.NoError(t, ioutil.WriteFile(base, testCPUProfile(t, map[string]int64{
+		"pkg.hash": 11 * 100, "pkg.same": 11 * 40,
+	}), 0644))
+	require.NoError(t, ioutil.WriteFile(head, testCPUProfile(t, map[string]int64{
+		"pkg.hash": 11 * 250, "pkg.sort": 11 * 30, "pkg.same": 11 * 40,
+	}), 0644))
+
+	funcs, err := diffCPUFunctions(base, head, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []report.CPUFunction{
+		{Function: "pkg.hash", Base: 100, Head: 250},
+		{Function: "pkg.sort", Head: 30},
+	}, funcs)
+
+	memProfile := filepath.Join(dir, "mem.pprof")
+	require.NoError(t, ioutil.WriteFile(memProfile, testMemProfile(t, map[string]int64{"pkg.grow": 1}), 0644))
+	_, err = diffCPUFunctions(base, memProfile, 10)
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+// preset bundles the settings of a kind of run, so that users don't need to know how many runs a benchmark
+// needs to be trusted.
+type preset struct {
+	// testFlags are added to the arguments of "go test" which don't have them.
+	testFlags []string
+	// significance is the default of -significance.
+	significance float64
+	// confirm is the default of -confirm.
+	confirm int
+}
+
+var presets = map[string]preset{
+	// quick gives an idea of the change in a fraction of the time, e.g. on every push.
+	"quick": {testFlags: []string{"-benchtime=100ms", "-count=1"}},
+	// thorough can be trusted to fail only on real changes, e.g. before a release.
+	"thorough": {testFlags: []string{"-count=10"}, significance: 0.05, confirm: 1},
+}
+
+// applyPreset sets the options of -preset which are not set otherwise, i.e. by flags, environment variables or
+// the configuration file.
+func applyPreset(c *cli.Context) error {
+	name := c.String("preset")
+	if name == "" {
+		return nil
+	}
+	p, ok := presets[name]
+	if !ok {
+		var names []string
+		for n := range presets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return xerrors.Errorf("invalid preset: '%s': it must be %s", name, strings.Join(names, " or "))
+	}
+	args := strings.Fields(c.String("bench-args"))
+	if isGoTest(c.String("bench-cmd"), args) {
+		for _, f := range p.testFlags {
+			if !hasGoTestFlag(args, strings.SplitN(strings.TrimLeft(f, "-"), "=", 2)[0]) {
+				args = append(args[:1], append([]string{f}, args[1:]...)...)
+			}
+		}
+		if err := setFlag(c, "bench-args", strings.Join(args, " ")); err != nil {
+			return xerrors.Errorf("failed to apply the preset: %w", err)
+		}
+	}
+	if p.significance > 0 && !c.IsSet("significance") {
+		if err := setFlag(c, "significance", strconv.FormatFloat(p.significance, 'f', -1, 64)); err != nil {
+			return xerrors.Errorf("failed to apply the preset: %w", err)
+		}
+	}
+	if p.confirm > 0 && !c.IsSet("confirm") {
+		if err := setFlag(c, "confirm", strconv.Itoa(p.confirm)); err != nil {
+			return xerrors.Errorf("failed to apply the preset: %w", err)
+		}
+	}
+	debugf("preset: %s", name)
+	return nil
+}
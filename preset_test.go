@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func Test_applyPreset(t *testing.T) {
+	tests := []struct {
+		name             string
+		args             []string
+		wantBenchArgs    string
+		wantSignificance float64
+		wantConfirm      int
+		wantErr          string
+	}{
+		{
+			name:          "none",
+			wantBenchArgs: "test -run ^$ -bench . ./...",
+		},
+		{
+			name:          "quick",
+			args:          []string{"-preset", "quick"},
+			wantBenchArgs: "test -count=1 -benchtime=100ms -run ^$ -bench . ./...",
+		},
+		{
+			name:             "thorough",
+			args:             []string{"-preset", "thorough"},
+			wantBenchArgs:    "test -count=10 -run ^$ -bench . ./...",
+			wantSignificance: 0.05,
+			wantConfirm:      1,
+		},
+		{
+			name:             "flags override the preset",
+			args:             []string{"-preset", "thorough", "-significance", "0.01", "-bench-args", "test -count 5 -bench . ./foo", "-confirm", "0"},
+			wantBenchArgs:    "test -count 5 -bench . ./foo",
+			wantSignificance: 0.01,
+		},
+		{
+			name:          "other command",
+			args:          []string{"-preset", "quick", "-bench-cmd", "make", "-bench-args", "bench"},
+			wantBenchArgs: "bench",
+		},
+		{
+			name:    "unknown",
+			args:    []string{"-preset", "fast"},
+			wantErr: "invalid preset: 'fast': it must be quick or thorough",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var benchArgs string
+			var significance float64
+			var confirm int
+			app := &cli.App{
+				Flags: []cli.Flag{
+					&cli.Float64Flag{Name: "significance"},
+					&cli.IntFlag{Name: "confirm"},
+					&cli.StringFlag{Name: "preset"},
+					&cli.StringFlag{Name: "bench-cmd", Value: "go"},
+					&cli.StringFlag{Name: "bench-args", Value: "test -run ^$ -bench . ./..."},
+				},
+				Action: func(c *cli.Context) error {
+					if err := applyPreset(c); err != nil {
+						return err
+					}
+					benchArgs, significance = c.String("bench-args"), c.Float64("significance")
+					confirm = c.Int("confirm")
+					return nil
+				},
+			}
+			err := app.Run(append([]string{"cob"}, tt.args...))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBenchArgs, benchArgs)
+			assert.Equal(t, tt.wantSignificance, significance)
+			assert.Equal(t, tt.wantConfirm, confirm)
+		})
+	}
+}
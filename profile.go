@@ -0,0 +1,337 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/xerrors"
+)
+
+// profileKind is a profile which "go test" writes for a benchmark.
+type profileKind struct {
+	name string
+	flag string
+	// args are other arguments the profile needs.
+	args []string
+	// sampleType is the value which the flamegraph of the profile shows, or "" if the file isn't a profile.
+	sampleType string
+	// ext is the extension of the file, ".pprof" by default.
+	ext string
+}
+
+func (k profileKind) fileExt() string {
+	if k.ext == "" {
+		return ".pprof"
+	}
+	return k.ext
+}
+
+var (
+	cpuProfile = profileKind{name: "cpu", flag: "-cpuprofile", sampleType: "cpu"}
+	// Every allocation is recorded, so that the allocations per op can be compared.
+	memProfile = profileKind{name: "mem", flag: "-memprofile", args: []string{"-memprofilerate=1"}, sampleType: "alloc_space"}
+	// Every blocking event and mutex contention is recorded, so that the contention per op can be compared.
+	blockProfile = profileKind{name: "block", flag: "-blockprofile", args: []string{"-blockprofilerate=1"}, sampleType: "delay"}
+	mutexProfile = profileKind{name: "mutex", flag: "-mutexprofile", args: []string{"-mutexprofilefraction=1"}, sampleType: "delay"}
+)
+
+// profileFiles are the profile files of benchmarks at a commit, by benchmark and kind.
+type profileFiles map[string]map[string]string
+
+// regressedBenchmarks returns the benchmarks which got worse than the threshold.
+func regressedBenchmarks(rep report.Report) []report.Benchmark {
+	var benchmarks []report.Benchmark
+	for _, b := range rep.Benchmarks {
+		if b.Status == report.StatusFail {
+			benchmarks = append(benchmarks, b)
+		}
+	}
+	return benchmarks
+}
+
+// benchmarkRegexp returns the -bench pattern which matches only the benchmark, e.g. "BenchmarkFoo/bar-8" =>
+// "^BenchmarkFoo$/^bar$".
+func benchmarkRegexp(name string) string {
+	var parts []string
+	for _, p := range strings.Split(procsSuffixRegexp.ReplaceAllString(name, ""), "/") {
+		parts = append(parts, "^"+regexp.QuoteMeta(p)+"$")
+	}
+	return strings.Join(parts, "/")
+}
+
+// profileBaseName returns the name of the files of the benchmark without the extension, e.g.
+// "BenchmarkFoo/bar-8" => "BenchmarkFoo_bar".
+func profileBaseName(name string) string {
+	return strings.Replace(procsSuffixRegexp.ReplaceAllString(name, ""), "/", "_", -1)
+}
+
+// collectProfiles runs each benchmark once more with the flags of kinds and writes the profiles to dir, e.g.
+// "/tmp/profiles/head". The worktree must be at the commit to profile. Benchmarks run as many iterations as
+// they did at HEAD, so that the totals in the profiles of both commits are comparable. A benchmark which
+// can't be profiled only prints a warning, because profiles only help to investigate a regression which is
+// already reported.
+func collectProfiles(benchmarks []report.Benchmark, dir string, kinds []profileKind) profileFiles {
+	files := profileFiles{}
+	funcs, err := findBenchmarkFuncs(".")
+	if err != nil {
+		warnf("Failed to profile the benchmarks: %s", err)
+		return files
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		warnf("Failed to profile the benchmarks: %s", err)
+		return files
+	}
+
+	for _, b := range benchmarks {
+		fn, ok := funcs[benchmarkFuncName(b.Name)]
+		if !ok {
+			warnf("Failed to profile %s: the benchmark function is not found", b.Name)
+			continue
+		}
+		kindFiles, _, err := runProfiled(b, "./"+path.Dir(fn.Path), dir, kinds)
+		if err != nil {
+			warnf("Failed to profile %s: %s", b.Name, err)
+			continue
+		}
+		files[b.Name] = kindFiles
+	}
+	return files
+}
+
+// runProfiled runs the benchmark of the package once with the flags of kinds, and returns the files it wrote to
+// dir by kind and its output.
+func runProfiled(b report.Benchmark, pkg, dir string, kinds []profileKind) (map[string]string, []byte, error) {
+	// The test binary is kept next to the profiles, so that it doesn't dirty the worktree and pprof can
+	// resolve symbols with it.
+	base := filepath.Join(dir, profileBaseName(b.Name))
+	args := []string{"test", "-run", "^$", "-bench", benchmarkRegexp(b.Name), "-benchmem", "-count=1", "-o", base + ".test"}
+	if b.Head.Iterations > 0 {
+		args = append(args, "-benchtime="+strconv.Itoa(b.Head.Iterations)+"x")
+	}
+	files := map[string]string{}
+	for _, k := range kinds {
+		files[k.name] = base + "." + k.name + k.fileExt()
+		args = append(args, k.flag+"="+files[k.name])
+		args = append(args, k.args...)
+	}
+	args = append(args, pkg)
+
+	debugf("exec: go %s", strings.Join(args, " "))
+	out, err := exec.Command("go", args...).CombinedOutput()
+	if err != nil {
+		return nil, nil, xerrors.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+	}
+	return files, out, nil
+}
+
+// newProfiles pairs the profiles of each benchmark and kind at both commits and renders their flamegraphs next
+// to them. CPU profiles of both commits are compared by function, and memory profiles by allocation site.
+func newProfiles(benchmarks []report.Benchmark, kinds []profileKind, base, head profileFiles) []report.Profile {
+	var profiles []report.Profile
+	for _, b := range benchmarks {
+		for _, k := range kinds {
+			p := report.Profile{Name: b.Name, Kind: k.name, Base: base[b.Name][k.name], Head: head[b.Name][k.name]}
+			if p.Base == "" && p.Head == "" {
+				continue
+			}
+			p.BaseFlamegraph = flamegraph(p.Base, b.Name, k, "base")
+			p.HeadFlamegraph = flamegraph(p.Head, b.Name, k, "HEAD")
+			if k.name == cpuProfile.name && p.Base != "" && p.Head != "" {
+				funcs, err := diffCPUFunctions(p.Base, p.Head, b.Head.Iterations)
+				if err != nil {
+					warnf("Failed to compare the CPU time of %s: %s", b.Name, err)
+				}
+				p.Functions = funcs
+			}
+			if k.name == memProfile.name && p.Base != "" && p.Head != "" {
+				sites, err := diffAllocationSites(p.Base, p.Head, b.Head.Iterations)
+				if err != nil {
+					warnf("Failed to compare the allocations of %s: %s", b.Name, err)
+				}
+				p.Sites = sites
+			}
+			profiles = append(profiles, p)
+		}
+	}
+	return profiles
+}
+
+// flamegraph renders the flamegraph of a profile and returns its path, or "" if it fails.
+func flamegraph(profile, name string, kind profileKind, commit string) string {
+	if profile == "" {
+		return ""
+	}
+	if kind.sampleType == "" {
+		return ""
+	}
+	svg := strings.TrimSuffix(profile, kind.fileExt()) + ".svg"
+	title := fmt.Sprintf("%s (%s, %s)", name, kind.name, commit)
+	if err := writeFlamegraph(profile, svg, kind.sampleType, title); err != nil {
+		warnf("Failed to render the flamegraph of %s: %s", name, err)
+		return ""
+	}
+	return svg
+}
+
+// topFunctions is the number of functions shown for a profile of a benchmark.
+const topFunctions = 10
+
+// leafDelta is the value per op of a leaf function in the profiles of both commits.
+type leafDelta struct {
+	function   string
+	base, head float64
+}
+
+// diffLeafValues compares the values per op of the leaf functions of two profiles, like
+// "go tool pprof -top -diff_base", and returns the functions which changed the most. Changes smaller than min
+// are ignored.
+func diffLeafValues(basePath, headPath, sampleType string, iterations int, min float64) ([]leafDelta, error) {
+	base, err := leafValues(basePath, sampleType, iterations)
+	if err != nil {
+		return nil, err
+	}
+	head, err := leafValues(headPath, sampleType, iterations)
+	if err != nil {
+		return nil, err
+	}
+	var deltas []leafDelta
+	for fn, h := range head {
+		deltas = append(deltas, leafDelta{function: fn, base: base[fn], head: h})
+	}
+	for fn, b := range base {
+		if _, ok := head[fn]; !ok {
+			deltas = append(deltas, leafDelta{function: fn, base: b})
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		di, dj := math.Abs(deltas[i].head-deltas[i].base), math.Abs(deltas[j].head-deltas[j].base)
+		if di != dj {
+			return di > dj
+		}
+		return deltas[i].function < deltas[j].function
+	})
+	var top []leafDelta
+	for _, d := range deltas {
+		if len(top) == topFunctions || math.Abs(d.head-d.base) <= min {
+			break
+		}
+		top = append(top, d)
+	}
+	return top, nil
+}
+
+// leafValues returns the values of the sample type per op by leaf function of the profile.
+func leafValues(path, sampleType string, iterations int) (map[string]float64, error) {
+	p, err := readPprofFile(path)
+	if err != nil {
+		return nil, err
+	}
+	i := p.valueIndex(sampleType)
+	if i < 0 {
+		return nil, xerrors.Errorf("%s has no %s samples", path, sampleType)
+	}
+	ops := profiledOps(iterations)
+	values := map[string]float64{}
+	for _, s := range p.samples {
+		if len(s.stack) == 0 || i >= len(s.values) {
+			continue
+		}
+		values[s.stack[0]] += float64(s.values[i]) / ops
+	}
+	return values, nil
+}
+
+// diffAllocationSites compares the bytes allocated per op by each function which allocates, and returns the
+// sites which changed the most.
+func diffAllocationSites(basePath, headPath string, iterations int) ([]report.AllocationSite, error) {
+	// Less than a byte per op is the noise of the testing package.
+	deltas, err := diffLeafValues(basePath, headPath, "alloc_space", iterations, 1)
+	if err != nil {
+		return nil, err
+	}
+	var sites []report.AllocationSite
+	for _, d := range deltas {
+		sites = append(sites, report.AllocationSite{Function: d.function, Base: d.base, Head: d.head})
+	}
+	return sites, nil
+}
+
+// diffCPUFunctions compares the CPU time per op spent in each function itself, not in its callees, and returns
+// the functions which changed the most. They are the likely culprits of an increase of ns/op.
+func diffCPUFunctions(basePath, headPath string, iterations int) ([]report.CPUFunction, error) {
+	deltas, err := diffLeafValues(basePath, headPath, "cpu", iterations, 0)
+	if err != nil {
+		return nil, err
+	}
+	var funcs []report.CPUFunction
+	for _, d := range deltas {
+		funcs = append(funcs, report.CPUFunction{Function: d.function, Base: d.base, Head: d.head})
+	}
+	return funcs, nil
+}
+
+// profiledOps returns how many ops a benchmark which is run again with -benchtime=Nx runs: it runs once with
+// b.N = 1 before it runs N iterations.
+func profiledOps(iterations int) float64 {
+	if iterations <= 1 {
+		return 1
+	}
+	return float64(iterations + 1)
+}
+
+// showProfileFunctions shows the functions which changed the most in each profile.
+func showProfileFunctions(w io.Writer, profiles []report.Profile, style string) {
+	for _, p := range profiles {
+		title, header, rows := profileFunctionTable(p)
+		if len(rows) == 0 {
+			continue
+		}
+		title += ": " + p.Name
+		fmt.Fprintln(w, "\n"+title)
+		fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", len(title)))
+
+		table := tablewriter.NewWriter(w)
+		table.SetAutoFormatHeaders(false)
+		applyTableStyle(table, style)
+		table.SetHeader(header)
+		table.AppendBulk(rows)
+		table.Render()
+	}
+}
+
+// profileFunctionTable returns the title, the header and the rows of the functions which changed the most in the
+// profile. There are no rows if the profile has none.
+func profileFunctionTable(p report.Profile) (string, []string, [][]string) {
+	var rows [][]string
+	if len(p.Functions) > 0 {
+		for _, f := range p.Functions {
+			rows = append(rows, []string{
+				f.Function,
+				strconv.FormatFloat(f.Base, 'f', 2, 64),
+				strconv.FormatFloat(f.Head, 'f', 2, 64),
+				fmt.Sprintf("%+.2f", f.Head-f.Base),
+			})
+		}
+		return "CPU time", []string{"Function", "Base ns/op", "HEAD ns/op", "Delta"}, rows
+	}
+	for _, s := range p.Sites {
+		rows = append(rows, []string{
+			s.Function,
+			strconv.FormatFloat(s.Base, 'f', 0, 64),
+			strconv.FormatFloat(s.Head, 'f', 0, 64),
+			fmt.Sprintf("%+.0f", s.Head-s.Base),
+		})
+	}
+	return "Allocation sites", []string{"Function", "Base B/op", "HEAD B/op", "Delta"}, rows
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_benchmarkRegexp(t *testing.T) {
+	tests := []struct {
+		name     string
+		want     string
+		wantBase string
+	}{
+		{name: "BenchmarkA-8", want: "^BenchmarkA$", wantBase: "BenchmarkA"},
+		{name: "BenchmarkA", want: "^BenchmarkA$", wantBase: "BenchmarkA"},
+		{name: "BenchmarkA/size=1.5-8", want: `^BenchmarkA$/^size=1\.5$`, wantBase: "BenchmarkA_size=1.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, benchmarkRegexp(tt.name))
+			assert.Equal(t, tt.wantBase, profileBaseName(tt.name))
+		})
+	}
+}
+
+func Test_newProfiles(t *testing.T) {
+	rep := report.Report{Benchmarks: []report.Benchmark{
+		{Name: "BenchmarkA-8", Status: report.StatusFail},
+		{Name: "BenchmarkB-8", Status: report.StatusWarn},
+		{Name: "BenchmarkC-8", Status: report.StatusFail},
+		{Name: "BenchmarkD-8", Status: report.StatusFail},
+	}}
+	regressed := regressedBenchmarks(rep)
+	require.Len(t, regressed, 3)
+	assert.Equal(t, "BenchmarkD-8", regressed[2].Name)
+
+	base := profileFiles{"BenchmarkA-8": {"cpu": "base/BenchmarkA.cpu.pprof"}}
+	head := profileFiles{
+		"BenchmarkA-8": {"cpu": "head/BenchmarkA.cpu.pprof"},
+		"BenchmarkC-8": {"cpu": "head/BenchmarkC.cpu.pprof"},
+	}
+	assert.Equal(t, []report.Profile{
+		{Name: "BenchmarkA-8", Kind: "cpu", Base: "base/BenchmarkA.cpu.pprof", Head: "head/BenchmarkA.cpu.pprof"},
+		{Name: "BenchmarkC-8", Kind: "cpu", Head: "head/BenchmarkC.cpu.pprof"},
+	}, newProfiles(regressed, []profileKind{cpuProfile}, base, head))
+}
+
+func Test_showProfileFunctions(t *testing.T) {
+	w := &bytes.Buffer{}
+	showProfileFunctions(w, []report.Profile{
+		{Name: "BenchmarkA-8", Kind: "cpu", Functions: []report.CPUFunction{{Function: "pkg.hash", Base: 100, Head: 250.5}}},
+		{Name: "BenchmarkA-8", Kind: "mem", Sites: []report.AllocationSite{{Function: "pkg.grow", Base: 16, Head: 1024}}},
+		{Name: "BenchmarkB-8", Kind: "cpu"},
+	}, "ascii")
+	got := w.String()
+	assert.Contains(t, got, "CPU time: BenchmarkA-8\n======================")
+	assert.Contains(t, got, "| pkg.hash |     100.00 |     250.50 | +150.50 |")
+	assert.Contains(t, got, "Allocation sites: BenchmarkA-8\n==============================")
+	assert.Contains(t, got, "| pkg.grow |        16 |      1024 | +1008 |")
+	assert.NotContains(t, got, "BenchmarkB-8")
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+var historyPruneCommand = &cli.Command{
+	Name:  "prune",
+	Usage: "Roll up or delete old runs in the store",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "store",
+			Usage: "Store the runs were recorded in with '-store'",
+			Value: defaultStore,
+		},
+		&cli.IntFlag{
+			Name:  "keep",
+			Usage: "Number of the latest runs to keep as they are (0 for no limit)",
+			Value: 500,
+		},
+		&cli.IntFlag{
+			Name:  "keep-days",
+			Usage: "Keep runs of the last n days as they are (0 for no limit)",
+			Value: 180,
+		},
+		&cli.BoolFlag{
+			Name:  "rollup",
+			Usage: "Aggregate older runs into one run per day instead of deleting them",
+			Value: true,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.String("store") == "" {
+			return xerrors.New("-store is required")
+		}
+		st, err := openStore(c.String("store"))
+		if err != nil {
+			return xerrors.Errorf("failed to open the store: %w", err)
+		}
+		var before, after int
+		changed := false
+		err = st.update(func(runs []historyRun) []historyRun {
+			pruned := pruneRuns(runs, c.Int("keep"), c.Int("keep-days"), c.Bool("rollup"), time.Now())
+			before, after, changed = len(runs), len(pruned), !reflect.DeepEqual(pruned, runs)
+			return pruned
+		})
+		if err != nil {
+			return xerrors.Errorf("failed to prune the history: %w", err)
+		}
+		if !changed {
+			infof("Nothing to prune in %d run(s)", before)
+			return nil
+		}
+		infof("Pruned the history from %d to %d run(s)", before, after)
+		return nil
+	},
+}
+
+// pruneRuns keeps the latest runs which are within both limits as they are. With rollup, older runs are
+// aggregated into one run per day and machine tag, together with a rollup of the same day if there is one;
+// otherwise they are dropped. Rollups are always kept.
+func pruneRuns(runs []historyRun, keep, keepDays int, rollup bool, now time.Time) []historyRun {
+	sorted := append([]historyRun{}, runs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	var kept, old []historyRun
+	for i, run := range sorted {
+		recent := (keep <= 0 || i >= len(sorted)-keep) &&
+			(keepDays <= 0 || run.Time.After(now.AddDate(0, 0, -keepDays)))
+		switch {
+		case run.Rollup > 0 && !rollup:
+			kept = append(kept, run)
+		case recent && run.Rollup == 0:
+			kept = append(kept, run)
+		default:
+			old = append(old, run)
+		}
+	}
+	if !rollup {
+		return kept
+	}
+
+	// Runs on differently tagged machines are not comparable, so they are rolled up separately.
+	days := map[string][]historyRun{}
+	for _, run := range old {
+		day := run.Time.UTC().Format("2006-01-02") + "/" + run.Machine.Tag
+		days[day] = append(days[day], run)
+	}
+	for _, d := range days {
+		kept = append(kept, rollupRuns(d))
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].Time.Before(kept[j].Time) })
+	return kept
+}
+
+// rollupRuns aggregates the runs, oldest first, into one which has the time, machine and commits of the last
+// one and the mean of each benchmark at HEAD, weighted by the number of runs already rolled up.
+func rollupRuns(runs []historyRun) historyRun {
+	last := runs[len(runs)-1]
+	type sum struct {
+		weight                                  int
+		nsPerOp, allocedBytesPerOp, allocsPerOp float64
+		status                                  string
+	}
+	sums := map[string]*sum{}
+	var names []string
+	total := 0
+	for _, run := range runs {
+		weight := run.Rollup
+		if weight == 0 {
+			weight = 1
+		}
+		total += weight
+		for _, b := range run.Report.Benchmarks {
+			s, ok := sums[b.Name]
+			if !ok {
+				s = &sum{status: report.StatusOK}
+				sums[b.Name] = s
+				names = append(names, b.Name)
+			}
+			s.weight += weight
+			s.nsPerOp += float64(weight) * b.Head.NsPerOp
+			s.allocedBytesPerOp += float64(weight) * float64(b.Head.AllocedBytesPerOp)
+			s.allocsPerOp += float64(weight) * float64(b.Head.AllocsPerOp)
+			// A day which had a regression still has it after the rollup.
+			if b.Status == report.StatusFail || (b.Status == report.StatusWarn && s.status == report.StatusOK) {
+				s.status = b.Status
+			}
+		}
+	}
+
+	rolled := last
+	rolled.Rollup = total
+	rolled.Report.Degression = false
+	rolled.Report.Timings = nil
+	rolled.Report.Creep = nil
+	rolled.Report.Benchmarks = nil
+	for _, name := range names {
+		s := sums[name]
+		w := float64(s.weight)
+		rolled.Report.Benchmarks = append(rolled.Report.Benchmarks, report.Benchmark{
+			Name: name,
+			Head: report.Measurement{
+				NsPerOp:           s.nsPerOp / w,
+				AllocedBytesPerOp: uint64(s.allocedBytesPerOp/w + 0.5),
+				AllocsPerOp:       uint64(s.allocsPerOp/w + 0.5),
+			},
+			Status: s.status,
+		})
+		if s.status == report.StatusFail {
+			rolled.Report.Degression = true
+		}
+	}
+	return rolled
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pruneTestRun(t time.Time, hash string, nsPerOp float64, status string) historyRun {
+	return historyRun{
+		Time: t,
+		Report: report.Report{
+			Head:       report.Commit{Hash: hash},
+			Benchmarks: []report.Benchmark{{Name: "BenchmarkA", Head: report.Measurement{NsPerOp: nsPerOp, AllocsPerOp: 1}, Status: status}},
+		},
+	}
+}
+
+func Test_pruneRuns(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	day1 := time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC)
+	day2 := time.Date(2020, 1, 2, 1, 0, 0, 0, time.UTC)
+	recent := time.Date(2020, 5, 31, 1, 0, 0, 0, time.UTC)
+	runs := []historyRun{
+		pruneTestRun(day1, "a", 100, report.StatusOK),
+		pruneTestRun(day1.Add(time.Hour), "b", 200, report.StatusFail),
+		pruneTestRun(day2, "c", 300, report.StatusOK),
+		pruneTestRun(recent, "d", 400, report.StatusOK),
+		pruneTestRun(recent.Add(time.Hour), "e", 500, report.StatusOK),
+	}
+
+	t.Run("keep-days with rollups", func(t *testing.T) {
+		got := pruneRuns(runs, 0, 30, true, now)
+		require.Len(t, got, 4)
+
+		assert.Equal(t, 2, got[0].Rollup)
+		assert.Equal(t, "b", got[0].Report.Head.Hash)
+		assert.Equal(t, day1.Add(time.Hour), got[0].Time)
+		assert.True(t, got[0].Report.Degression)
+		assert.Equal(t, []report.Benchmark{{Name: "BenchmarkA", Head: report.Measurement{NsPerOp: 150, AllocsPerOp: 1}, Status: report.StatusFail}},
+			got[0].Report.Benchmarks)
+		assert.Equal(t, 1, got[1].Rollup)
+		assert.Equal(t, runs[3:], got[2:])
+
+		// Pruning again changes nothing.
+		assert.Equal(t, got, pruneRuns(got, 0, 30, true, now))
+	})
+
+	t.Run("keep with rollups", func(t *testing.T) {
+		got := pruneRuns(runs, 1, 0, true, now)
+		require.Len(t, got, 4)
+		assert.Equal(t, []int{2, 1, 1, 0}, []int{got[0].Rollup, got[1].Rollup, got[2].Rollup, got[3].Rollup})
+		assert.Equal(t, runs[4], got[3])
+	})
+
+	t.Run("rollups are merged by weight", func(t *testing.T) {
+		rollup := pruneRuns(runs, 0, 30, true, now)[0]
+		// A rollup of 2 runs at 150 and a run at 300 average to 200.
+		merged := rollupRuns([]historyRun{rollup, pruneTestRun(day1.Add(2*time.Hour), "f", 300, report.StatusOK)})
+		assert.Equal(t, 3, merged.Rollup)
+		assert.Equal(t, "f", merged.Report.Head.Hash)
+		assert.Equal(t, 200.0, merged.Report.Benchmarks[0].Head.NsPerOp)
+		assert.Equal(t, report.StatusFail, merged.Report.Benchmarks[0].Status)
+	})
+
+	t.Run("without rollups", func(t *testing.T) {
+		assert.Equal(t, runs[3:], pruneRuns(runs, 2, 0, false, now))
+	})
+}
+
+func Test_fileStore_rewrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := newFileStore(filepath.Join(dir, "history.jsonl"))
+	runs := []historyRun{
+		pruneTestRun(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), "a", 100, report.StatusOK),
+		pruneTestRun(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), "b", 100, report.StatusOK),
+	}
+	for _, run := range runs {
+		require.NoError(t, s.put(run))
+	}
+	rollup := runs[1]
+	rollup.Rollup = 2
+	require.NoError(t, s.rewrite([]historyRun{rollup}))
+
+	got, err := s.runs()
+	require.NoError(t, err)
+	assert.Equal(t, []historyRun{rollup}, got)
+	latest, err := s.latest("b", "")
+	require.NoError(t, err)
+	assert.Nil(t, latest, "a rollup is not a baseline")
+}
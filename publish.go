@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+var publishCommand = &cli.Command{
+	Name:  "publish",
+	Usage: "Publish a JSON report written by '-format json' to a branch or a dashboard",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "branch",
+			Usage: "Commit the report to the branch, e.g. gh-pages, and push it",
+		},
+		&cli.StringFlag{
+			Name:  "remote",
+			Usage: "Remote to push the branch to (empty to only commit it)",
+			Value: "origin",
+		},
+		&cli.StringFlag{
+			Name:  "report",
+			Usage: "JSON report to publish ('-' for stdin)",
+			Value: "-",
+		},
+		&cli.BoolFlag{
+			Name:  "html",
+			Usage: "Also commit the report as index.html",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.String("branch") == "" {
+			return cli.ShowSubcommandHelp(c)
+		}
+		rep, err := readReport(c.String("report"))
+		if err != nil {
+			return err
+		}
+		g := newGitCLI(".")
+		remote := c.String("remote")
+		if remote != "" && !g.hasRemote(remote) {
+			return xerrors.Errorf("no such remote: %s", remote)
+		}
+		return publishBranch(g, c.String("branch"), remote, rep, c.Bool("html"))
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:  "grafana",
+			Usage: "Append the report to a JSON dataset for Grafana's JSON data sources",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "report",
+					Usage: "JSON report to publish ('-' for stdin)",
+					Value: "-",
+				},
+				&cli.StringFlag{
+					Name:  "dataset",
+					Usage: "JSON dataset to append the report to",
+					Value: "cob-grafana.json",
+				},
+				&cli.BoolFlag{
+					Name:  "from-store",
+					Usage: "Write the dataset from the runs recorded in '-store' instead of appending a report",
+				},
+				&cli.StringFlag{
+					Name:  "store",
+					Usage: "Store the runs were recorded in with '-store', for '-from-store'",
+					Value: defaultStore,
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if c.Bool("from-store") {
+					st, err := openStore(c.String("store"))
+					if err != nil {
+						return xerrors.Errorf("failed to open the store: %w", err)
+					}
+					runs, err := st.runs()
+					if err != nil {
+						return xerrors.Errorf("failed to read the history: %w", err)
+					}
+					rows := grafanaRowsOfRuns(runs)
+					if err = writeJSONFile(c.String("dataset"), rows); err != nil {
+						return err
+					}
+					infof("Published %d run(s) to %s", len(runs), c.String("dataset"))
+					return nil
+				}
+				rep, err := readReport(c.String("report"))
+				if err != nil {
+					return err
+				}
+				return publishGrafana(c.String("dataset"), rep, time.Now())
+			},
+		},
+	},
+}
+
+func readReport(path string) (report.Report, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return report.Report{}, xerrors.Errorf("failed to open the report: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	return report.Decode(r)
+}
+
+// grafanaRow is a flat row of the dataset, which Grafana's JSON data sources (e.g. Infinity) can read
+// without any transformation.
+type grafanaRow struct {
+	Time                   time.Time `json:"time"`
+	Benchmark              string    `json:"benchmark"`
+	Commit                 string    `json:"commit"`
+	Branch                 string    `json:"branch,omitempty"`
+	NsPerOp                float64   `json:"ns_per_op"`
+	AllocedBytesPerOp      uint64    `json:"alloced_bytes_per_op"`
+	AllocsPerOp            uint64    `json:"allocs_per_op"`
+	RatioNsPerOp           float64   `json:"ratio_ns_per_op"`
+	RatioAllocedBytesPerOp float64   `json:"ratio_alloced_bytes_per_op"`
+	RatioAllocsPerOp       float64   `json:"ratio_allocs_per_op"`
+	Status                 string    `json:"status"`
+}
+
+// appendGrafanaRows appends the HEAD results of the report. Rows of the same commit are replaced so that
+// re-running a job does not duplicate points.
+func appendGrafanaRows(rows []grafanaRow, rep report.Report, now time.Time) []grafanaRow {
+	var kept []grafanaRow
+	for _, row := range rows {
+		if row.Commit != rep.Head.Hash {
+			kept = append(kept, row)
+		}
+	}
+	for _, b := range rep.Benchmarks {
+		kept = append(kept, grafanaRow{
+			Time:                   now.UTC(),
+			Benchmark:              b.Name,
+			Commit:                 rep.Head.Hash,
+			Branch:                 rep.Head.Branch,
+			NsPerOp:                b.Head.NsPerOp,
+			AllocedBytesPerOp:      b.Head.AllocedBytesPerOp,
+			AllocsPerOp:            b.Head.AllocsPerOp,
+			RatioNsPerOp:           b.Ratio.NsPerOp,
+			RatioAllocedBytesPerOp: b.Ratio.AllocedBytesPerOp,
+			RatioAllocsPerOp:       b.Ratio.AllocsPerOp,
+			Status:                 b.Status,
+		})
+	}
+	return kept
+}
+
+// grafanaRowsOfRuns returns the dataset of the recorded runs, oldest first. A later run of a commit replaces the
+// rows of an earlier one, as publishing its report would.
+func grafanaRowsOfRuns(runs []historyRun) []grafanaRow {
+	rows := []grafanaRow{}
+	for _, run := range runs {
+		rows = appendGrafanaRows(rows, run.Report, run.Time)
+	}
+	return rows
+}
+
+func publishGrafana(dataset string, rep report.Report, now time.Time) error {
+	rows := []grafanaRow{}
+	b, err := ioutil.ReadFile(dataset)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return xerrors.Errorf("failed to read the dataset: %w", err)
+	default:
+		if err = json.Unmarshal(b, &rows); err != nil {
+			return xerrors.Errorf("failed to decode the dataset: %w", err)
+		}
+	}
+
+	if err = writeJSONFile(dataset, appendGrafanaRows(rows, rep, now)); err != nil {
+		return err
+	}
+	infof("Published %d benchmark(s) to %s", len(rep.Benchmarks), dataset)
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_publishGrafana(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dataset := filepath.Join(dir, "dataset.json")
+
+	first := report.Report{
+		Head:       report.Commit{Hash: "aaa", Branch: "main"},
+		Benchmarks: []report.Benchmark{{Name: "BenchmarkA", Head: report.Measurement{NsPerOp: 100}, Status: report.StatusOK}},
+	}
+	second := report.Report{
+		Head:       report.Commit{Hash: "bbb", Branch: "main"},
+		Benchmarks: []report.Benchmark{{Name: "BenchmarkA", Head: report.Measurement{NsPerOp: 150}, Status: report.StatusFail}},
+	}
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, publishGrafana(dataset, first, now))
+	require.NoError(t, publishGrafana(dataset, second, now))
+	// Publishing the same commit again replaces its rows.
+	require.NoError(t, publishGrafana(dataset, second, now.Add(time.Hour)))
+
+	b, err := ioutil.ReadFile(dataset)
+	require.NoError(t, err)
+	var rows []grafanaRow
+	require.NoError(t, json.Unmarshal(b, &rows))
+	assert.Equal(t, []grafanaRow{
+		{Time: now, Benchmark: "BenchmarkA", Commit: "aaa", Branch: "main", NsPerOp: 100, Status: report.StatusOK},
+		{Time: now.Add(time.Hour), Benchmark: "BenchmarkA", Commit: "bbb", Branch: "main", NsPerOp: 150, Status: report.StatusFail},
+	}, rows)
+}
+
+func Test_grafanaRowsOfRuns(t *testing.T) {
+	runs := testHistoryRuns()
+	runs[2].Report.Head.Hash = runs[1].Report.Head.Hash
+	rows := grafanaRowsOfRuns(runs)
+	require.Len(t, rows, 4, "the later run of the commit replaces the rows of the earlier one")
+	assert.Equal(t, grafanaRow{Time: runs[0].Time, Benchmark: "BenchmarkA", Commit: "aaaaaaaaaa", Branch: "main", NsPerOp: 100,
+		AllocedBytesPerOp: 16, AllocsPerOp: 1, Status: report.StatusOK}, rows[0])
+	assert.Equal(t, runs[2].Time, rows[2].Time)
+	assert.Equal(t, 102.0, rows[2].NsPerOp)
+	assert.Empty(t, grafanaRowsOfRuns(nil))
+}
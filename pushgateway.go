@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+// pushgatewayJob is the job label of the pushed metrics.
+const pushgatewayJob = "cob"
+
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// generatePrometheusMetrics renders the report in the Prometheus text exposition format.
+func generatePrometheusMetrics(rep report.Report) string {
+	byName := map[string][]metric{}
+	var names []string
+	for _, m := range benchmarkMetrics(rep) {
+		if _, ok := byName[m.Name]; !ok {
+			names = append(names, m.Name)
+		}
+		byName[m.Name] = append(byName[m.Name], m)
+	}
+	sort.Strings(names)
+
+	w := &bytes.Buffer{}
+	for _, name := range names {
+		fmt.Fprintf(w, "# TYPE cob_%s gauge\n", name)
+		for _, m := range byName[name] {
+			fmt.Fprintf(w, "cob_%s{benchmark=\"%s\",commit=\"%s\"} %g\n", name,
+				prometheusLabelEscaper.Replace(m.Benchmark), prometheusLabelEscaper.Replace(rep.Head.Hash), m.Value)
+		}
+	}
+	return w.String()
+}
+
+// pushgatewayPath returns the path of the group the metrics are pushed to. Metrics of each branch are kept
+// in their own group so that branches don't overwrite each other.
+func pushgatewayPath(branch string) string {
+	path := "/metrics/job/" + pushgatewayJob
+	if branch != "" {
+		// The base64 form allows slashes in the branch name.
+		path += "/branch@base64/" + base64.RawURLEncoding.EncodeToString([]byte(branch))
+	}
+	return path
+}
+
+// pushMetrics replaces the metrics of the branch group in the Pushgateway.
+func pushMetrics(client *http.Client, gatewayURL string, rep report.Report) error {
+	url := strings.TrimSuffix(gatewayURL, "/") + pushgatewayPath(rep.Head.Branch)
+	req, err := http.NewRequest(http.MethodPut, url, strings.NewReader(generatePrometheusMetrics(rep)))
+	if err != nil {
+		return xerrors.Errorf("failed to create a request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	req.Header.Set("User-Agent", "cob")
+
+	debugf("pushgateway: PUT %s", url)
+	resp, err := client.Do(req)
+	if err != nil {
+		return xerrors.Errorf("failed to push metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return xerrors.Errorf("the Pushgateway returned %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	infof("Pushed metrics to the Pushgateway")
+	return nil
+}
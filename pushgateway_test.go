@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var metricsReport = report.Report{
+	Head: report.Commit{Ref: "HEAD", Hash: "599a552", Branch: "feature/x"},
+	Benchmarks: []report.Benchmark{{
+		Name:  `Benchmark"A"`,
+		Head:  report.Measurement{NsPerOp: 150, AllocedBytesPerOp: 16, AllocsPerOp: 1},
+		Ratio: report.Ratio{NsPerOp: 0.5},
+	}},
+}
+
+func Test_generatePrometheusMetrics(t *testing.T) {
+	want := `# TYPE cob_alloced_bytes_per_op gauge
+cob_alloced_bytes_per_op{benchmark="Benchmark\"A\"",commit="599a552"} 16
+# TYPE cob_allocs_per_op gauge
+cob_allocs_per_op{benchmark="Benchmark\"A\"",commit="599a552"} 1
+# TYPE cob_ns_per_op gauge
+cob_ns_per_op{benchmark="Benchmark\"A\"",commit="599a552"} 150
+# TYPE cob_ratio_alloced_bytes_per_op gauge
+cob_ratio_alloced_bytes_per_op{benchmark="Benchmark\"A\"",commit="599a552"} 0
+# TYPE cob_ratio_allocs_per_op gauge
+cob_ratio_allocs_per_op{benchmark="Benchmark\"A\"",commit="599a552"} 0
+# TYPE cob_ratio_ns_per_op gauge
+cob_ratio_ns_per_op{benchmark="Benchmark\"A\"",commit="599a552"} 0.5
+`
+	assert.Equal(t, want, generatePrometheusMetrics(metricsReport))
+}
+
+func Test_pushMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/metrics/job/cob/branch@base64/ZmVhdHVyZS94", r.URL.Path)
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "cob_ns_per_op")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	require.NoError(t, pushMetrics(ts.Client(), ts.URL+"/", metricsReport))
+}
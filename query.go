@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+var historyCommand = &cli.Command{
+	Name:  "history",
+	Usage: "Show the recorded results of a benchmark",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "store",
+			Usage: "Store the runs were recorded in with '-store'",
+			Value: defaultStore,
+		},
+		&cli.StringFlag{
+			Name:  "bench",
+			Usage: "Name of the benchmark, e.g. BenchmarkA, or BenchmarkA-8 for the runs with GOMAXPROCS=8",
+		},
+		&cli.StringFlag{
+			Name:  "machine",
+			Usage: "Show only runs on machines with the tag given by '-machine'",
+		},
+		&cli.IntFlag{
+			Name:  "last",
+			Usage: "Show only the last n runs (0 for all)",
+			Value: 20,
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format (table, json, csv)",
+			Value: "table",
+		},
+	},
+	Subcommands: []*cli.Command{historyPruneCommand, historyImportCommand, historyExportCommand, historyChangesCommand},
+	Action: func(c *cli.Context) error {
+		// Not marked as required, which would make them required for subcommands too.
+		if c.String("store") == "" || c.String("bench") == "" {
+			return xerrors.New("-store and -bench are required")
+		}
+		format := c.String("format")
+		if format != "table" && format != "json" && format != "csv" {
+			return xerrors.Errorf("unknown output format: %s", format)
+		}
+		st, err := openStore(c.String("store"))
+		if err != nil {
+			return xerrors.Errorf("failed to open the store: %w", err)
+		}
+		runs, err := st.runs()
+		if err != nil {
+			return xerrors.Errorf("failed to read the history: %w", err)
+		}
+		if c.IsSet("machine") {
+			runs = runsOnMachine(runs, c.String("machine"))
+		}
+		points := benchmarkHistory(runs, c.String("bench"), c.Int("last"))
+		if len(points) == 0 {
+			return xerrors.Errorf("no results of %s in the store", c.String("bench"))
+		}
+		return writeBenchmarkHistory(os.Stdout, points, format)
+	},
+}
+
+// historyPoint is a result of a benchmark at HEAD of a recorded run.
+type historyPoint struct {
+	Time              time.Time `json:"time"`
+	Commit            string    `json:"commit"`
+	Branch            string    `json:"branch,omitempty"`
+	Machine           string    `json:"machine"`
+	NsPerOp           float64   `json:"nsPerOp"`
+	AllocedBytesPerOp uint64    `json:"allocedBytesPerOp"`
+	AllocsPerOp       uint64    `json:"allocsPerOp"`
+	Status            string    `json:"status"`
+}
+
+// benchmarkHistory returns the last n results of the benchmark, oldest first. The benchmark is named with or without
+// the GOMAXPROCS suffix, e.g. "BenchmarkA-8" or "BenchmarkA", so that the results of runs on machines with
+// different numbers of CPUs are shown together unless a suffix is given.
+func benchmarkHistory(runs []historyRun, bench string, last int) []historyPoint {
+	var points []historyPoint
+	for _, run := range runs {
+		for _, b := range run.Report.Benchmarks {
+			if b.Name != bench && withoutProcsSuffix(b.Name) != bench {
+				continue
+			}
+			points = append(points, historyPoint{
+				Time:              run.Time,
+				Commit:            run.Report.Head.Hash,
+				Branch:            run.Report.Head.Branch,
+				Machine:           run.Machine.Hostname,
+				NsPerOp:           b.Head.NsPerOp,
+				AllocedBytesPerOp: b.Head.AllocedBytesPerOp,
+				AllocsPerOp:       b.Head.AllocsPerOp,
+				Status:            b.Status,
+			})
+		}
+	}
+	if last > 0 && len(points) > last {
+		points = points[len(points)-last:]
+	}
+	return points
+}
+
+func writeBenchmarkHistory(w io.Writer, points []historyPoint, format string) error {
+	switch format {
+	case "json":
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		if err := e.Encode(points); err != nil {
+			return xerrors.Errorf("failed to encode the history: %w", err)
+		}
+	case "csv":
+		// Full hashes, as CSV is read by other tools rather than people.
+		cw := csv.NewWriter(w)
+		_ = cw.Write(historyHeader)
+		_ = cw.WriteAll(historyRows(points, func(commit string) string { return commit }))
+		if err := cw.Error(); err != nil {
+			return xerrors.Errorf("failed to write CSV: %w", err)
+		}
+	default:
+		table := tablewriter.NewWriter(w)
+		table.SetAutoFormatHeaders(false)
+		table.SetHeader(historyHeader)
+		table.AppendBulk(historyRows(points, shortHash))
+		table.Render()
+	}
+	return nil
+}
+
+var historyHeader = []string{"Time", "Commit", "Branch", "Machine", "NsPerOp", "AllocedBytesPerOp", "AllocsPerOp", "Status"}
+
+func historyRows(points []historyPoint, hash func(string) string) [][]string {
+	var rows [][]string
+	for _, p := range points {
+		rows = append(rows, []string{
+			p.Time.UTC().Format(time.RFC3339),
+			hash(p.Commit),
+			p.Branch,
+			p.Machine,
+			strconv.FormatFloat(p.NsPerOp, 'f', -1, 64),
+			strconv.FormatUint(p.AllocedBytesPerOp, 10),
+			strconv.FormatUint(p.AllocsPerOp, 10),
+			p.Status,
+		})
+	}
+	return rows
+}
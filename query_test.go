@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testHistoryRuns() []historyRun {
+	var runs []historyRun
+	for i, hash := range []string{"aaaaaaaaaa", "bbbbbbbbbb", "cccccccccc"} {
+		runs = append(runs, historyRun{
+			Time:    time.Date(2020, 1, 2+i, 3, 4, 5, 0, time.UTC),
+			Machine: machine{Hostname: "ci-1"},
+			Report: report.Report{
+				Head: report.Commit{Hash: hash, Branch: "main"},
+				Benchmarks: []report.Benchmark{
+					{Name: "BenchmarkA", Head: report.Measurement{NsPerOp: float64(100 + i), AllocedBytesPerOp: 16, AllocsPerOp: 1}, Status: report.StatusOK},
+					{Name: "BenchmarkB", Head: report.Measurement{NsPerOp: 1}, Status: report.StatusOK},
+				},
+			},
+		})
+	}
+	return runs
+}
+
+func Test_benchmarkHistory(t *testing.T) {
+	points := benchmarkHistory(testHistoryRuns(), "BenchmarkA", 2)
+	require.Len(t, points, 2)
+	assert.Equal(t, historyPoint{
+		Time:              time.Date(2020, 1, 3, 3, 4, 5, 0, time.UTC),
+		Commit:            "bbbbbbbbbb",
+		Branch:            "main",
+		Machine:           "ci-1",
+		NsPerOp:           101,
+		AllocedBytesPerOp: 16,
+		AllocsPerOp:       1,
+		Status:            report.StatusOK,
+	}, points[0])
+	assert.Equal(t, "cccccccccc", points[1].Commit)
+
+	assert.Len(t, benchmarkHistory(testHistoryRuns(), "BenchmarkA", 0), 3)
+	assert.Empty(t, benchmarkHistory(testHistoryRuns(), "BenchmarkC", 0))
+
+	runs := testHistoryRuns()
+	runs[0].Report.Benchmarks[0].Name = "BenchmarkA-4"
+	runs[1].Report.Benchmarks[0].Name = "BenchmarkA-8"
+	assert.Len(t, benchmarkHistory(runs, "BenchmarkA", 0), 3, "without the GOMAXPROCS suffix")
+	points = benchmarkHistory(runs, "BenchmarkA-8", 0)
+	require.Len(t, points, 1, "with the suffix")
+	assert.Equal(t, "bbbbbbbbbb", points[0].Commit)
+}
+
+func Test_writeBenchmarkHistory(t *testing.T) {
+	points := benchmarkHistory(testHistoryRuns(), "BenchmarkA", 1)
+
+	w := &bytes.Buffer{}
+	require.NoError(t, writeBenchmarkHistory(w, points, "csv"))
+	assert.Equal(t, "Time,Commit,Branch,Machine,NsPerOp,AllocedBytesPerOp,AllocsPerOp,Status\n"+
+		"2020-01-04T03:04:05Z,cccccccccc,main,ci-1,102,16,1,ok\n", w.String())
+
+	w.Reset()
+	require.NoError(t, writeBenchmarkHistory(w, points, "table"))
+	assert.Contains(t, w.String(), "| 2020-01-04T03:04:05Z | ccccccc | main   | ci-1    |     102 |")
+}
@@ -0,0 +1,646 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+// reporterPluginPrefix is the prefix of the executables in PATH which -reporter runs for names cob doesn't know.
+const reporterPluginPrefix = "cob-reporter-"
+
+// reportContext is the result of a run which reporters write or send somewhere.
+type reportContext struct {
+	config  config
+	report  report.Report
+	results []result
+	rows    [][]string
+	columns columns
+	score   comparedScore
+	// head is the hash of HEAD.
+	head        string
+	flamegraphs []flamegraphLink
+	// degression is whether the run fails because benchmarks got worse.
+	degression bool
+	startedAt  time.Time
+	// w is stdout, or the file of -reporter name=FILE.
+	w io.Writer
+}
+
+func (r reportContext) markdown() string {
+	rep := r.report
+	return generateMarkdown(r.rows, r.results, rep.Base.Ref, r.config.threshold, r.score, r.columns, rep.Creep,
+		rep.Added, rep.Removed, rep.Profiles, rep.Dependencies, rep.ChangedFiles, r.flamegraphs)
+}
+
+// reporter writes or sends the result of a run, e.g. as a table or a pull request comment.
+type reporter interface {
+	report(r reportContext) error
+}
+
+type reporterFunc func(r reportContext) error
+
+func (f reporterFunc) report(r reportContext) error {
+	return f(r)
+}
+
+type registeredReporter struct {
+	name string
+	// enabled reports whether the options enable the reporter without -reporter.
+	enabled func(c config) bool
+	// requires is the option which the reporter can't run without when -reporter enables it, if any.
+	requires string
+	// writes is whether the reporter writes to stdout, which -reporter name=FILE redirects to the file.
+	writes   bool
+	reporter reporter
+}
+
+// reporters are the built-in reporters, in the order they run.
+var reporters = []registeredReporter{
+	{
+		name:     "table",
+		enabled:  func(c config) bool { return c.format == "table" },
+		writes:   true,
+		reporter: reporterFunc(reportTable),
+	},
+	{
+		name:     "diff",
+		enabled:  func(c config) bool { return c.format == "diff" },
+		writes:   true,
+		reporter: reporterFunc(reportDiff),
+	},
+	{
+		name:    "json",
+		enabled: func(c config) bool { return c.format == "json" },
+		writes:  true,
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := report.Encode(r.w, r.report); err != nil {
+				return xerrors.Errorf("failed to write the report: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:    "github-actions",
+		enabled: func(config) bool { return os.Getenv("GITHUB_ACTIONS") == "true" },
+		reporter: reporterFunc(func(r reportContext) error {
+			rep := r.report
+			outputs := generateActionOutputs(r.results, r.config.threshold, r.score, rep.Added, rep.Removed)
+			if err := writeActionOutputs(outputs, r.markdown()); err != nil {
+				return xerrors.Errorf("failed to write the GitHub Actions outputs: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:    "github-pr-comment",
+		enabled: func(c config) bool { return c.githubPRComment },
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := postGitHubPRComment(r.head, r.markdown()); err != nil {
+				return xerrors.Errorf("failed to post the result to GitHub: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:    "github-check",
+		enabled: func(c config) bool { return c.githubCheck },
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := postGitHubCheckRun(r.head, r.markdown(), r.results, r.config.threshold, r.score); err != nil {
+				return xerrors.Errorf("failed to create a check run on GitHub: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:    "github-status",
+		enabled: func(c config) bool { return c.githubStatus },
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := postGitHubCommitStatus(r.head, r.results, r.config.threshold, r.score); err != nil {
+				return xerrors.Errorf("failed to set a commit status on GitHub: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:    "gitlab-mr-note",
+		enabled: func(c config) bool { return c.gitlabMRNote },
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := postGitLabMRNote(r.markdown()); err != nil {
+				return xerrors.Errorf("failed to post the result to GitLab: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:    "bitbucket-pr-comment",
+		enabled: func(c config) bool { return c.bitbucketPRComment },
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := postBitbucketPRComment(r.markdown()); err != nil {
+				return xerrors.Errorf("failed to post the result to Bitbucket: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:    "bitbucket-status",
+		enabled: func(c config) bool { return c.bitbucketStatus },
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := postBitbucketBuildStatus(r.head, r.results, r.config.threshold, r.score); err != nil {
+				return xerrors.Errorf("failed to set a build status on Bitbucket: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:    "gitea-pr-comment",
+		enabled: func(c config) bool { return c.giteaPRComment },
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := postGiteaPRComment(r.config, r.markdown()); err != nil {
+				return xerrors.Errorf("failed to post the result to Gitea: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:    "gitea-status",
+		enabled: func(c config) bool { return c.giteaStatus },
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := postGiteaCommitStatus(r.config, r.head, r.results, r.score); err != nil {
+				return xerrors.Errorf("failed to set a commit status on Gitea: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:    "azure-pr-thread",
+		enabled: func(c config) bool { return c.azurePRThread },
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := postAzurePRThread(r.markdown()); err != nil {
+				return xerrors.Errorf("failed to post the result to Azure DevOps: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:    "azure-status",
+		enabled: func(c config) bool { return c.azureStatus },
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := postAzureCommitStatus(r.head, r.results, r.config.threshold, r.score); err != nil {
+				return xerrors.Errorf("failed to set a commit status on Azure DevOps: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:    "gerrit-review",
+		enabled: func(c config) bool { return c.gerritReview },
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := postGerritReview(r.config, r.head, r.markdown(), r.results, r.score); err != nil {
+				return xerrors.Errorf("failed to post the result to Gerrit: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:    "buildkite-annotation",
+		enabled: func(c config) bool { return c.buildkiteAnnotation },
+		reporter: reporterFunc(func(r reportContext) error {
+			s, _ := verdict(r.results, r.config.threshold, r.score)
+			if err := annotateBuildkite("buildkite-agent", r.markdown(), s); err != nil {
+				return xerrors.Errorf("failed to annotate the Buildkite build: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "webhook",
+		enabled:  func(c config) bool { return c.webhook != "" },
+		requires: "--webhook",
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := postWebhook(newHTTPClient(), r.config.webhook, os.Getenv("COB_WEBHOOK_SECRET"), r.report); err != nil {
+				return xerrors.Errorf("failed to post the result to the webhook: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "slack",
+		enabled:  func(c config) bool { return c.slackWebhook != "" },
+		requires: "--slack-webhook",
+		reporter: reporterFunc(func(r reportContext) error {
+			if !r.degression && r.config.slackOnlyDegression {
+				return nil
+			}
+			msg := generateSlackMessage(r.results, r.head, r.config.threshold, r.score, ciRunURL())
+			if err := postSlackMessage(r.config.slackWebhook, msg); err != nil {
+				return xerrors.Errorf("failed to send the result to Slack: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "discord",
+		enabled:  func(c config) bool { return c.discordWebhook != "" },
+		requires: "--discord-webhook",
+		reporter: reporterFunc(func(r reportContext) error {
+			if !r.degression && r.config.discordOnlyDegression {
+				return nil
+			}
+			msg := generateDiscordMessage(r.results, r.head, r.config.threshold, r.score, ciRunURL())
+			if err := postDiscordMessage(r.config.discordWebhook, msg); err != nil {
+				return xerrors.Errorf("failed to send the result to Discord: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "teams",
+		enabled:  func(c config) bool { return c.teamsWebhook != "" },
+		requires: "--teams-webhook",
+		reporter: reporterFunc(func(r reportContext) error {
+			if !r.degression && r.config.teamsOnlyDegression {
+				return nil
+			}
+			reportURL := r.config.teamsReportURL
+			if reportURL == "" {
+				reportURL = ciRunURL()
+			}
+			msg := generateTeamsMessage(r.results, r.head, r.config.threshold, r.score, reportURL)
+			if err := postTeamsMessage(r.config.teamsWebhook, msg); err != nil {
+				return xerrors.Errorf("failed to send the result to Microsoft Teams: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "email",
+		enabled:  func(c config) bool { return c.emailTo != "" },
+		requires: "--email-to",
+		reporter: reporterFunc(func(r reportContext) error {
+			c := r.config
+			if !r.degression && c.emailOnlyDegression {
+				return nil
+			}
+			_, message := verdict(r.results, c.threshold, r.score)
+			html, err := generateHTML(r.report, message)
+			if err != nil {
+				return err
+			}
+			e := email{
+				From:    c.emailFrom,
+				To:      splitRecipients(c.emailTo),
+				Subject: generateEmailSubject(r.results, r.head, c.threshold, r.score),
+				HTML:    html,
+			}
+			if err = sendEmail(c.smtpServer, e); err != nil {
+				return xerrors.Errorf("failed to send the result by email: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "pushgateway",
+		enabled:  func(c config) bool { return c.pushgateway != "" },
+		requires: "--pushgateway",
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := pushMetrics(newHTTPClient(), r.config.pushgateway, r.report); err != nil {
+				return xerrors.Errorf("failed to push metrics to the Pushgateway: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "influxdb",
+		enabled:  func(c config) bool { return c.influxDBURL != "" || c.influxDBFile != "" },
+		requires: "--influxdb-url or --influxdb-file",
+		reporter: reporterFunc(func(r reportContext) error {
+			c := r.config
+			lines := generateInfluxLines(r.report, time.Now())
+			if c.influxDBFile != "" {
+				if err := ioutil.WriteFile(c.influxDBFile, []byte(lines), 0644); err != nil {
+					return xerrors.Errorf("failed to write the InfluxDB points: %w", err)
+				}
+			}
+			if c.influxDBURL != "" {
+				if err := writeInfluxLines(newHTTPClient(), c.influxDBURL, lines); err != nil {
+					return xerrors.Errorf("failed to write points to InfluxDB: %w", err)
+				}
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "otlp",
+		enabled:  func(c config) bool { return c.otlpEndpoint != "" },
+		requires: "--otlp-endpoint",
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := exportOTLPMetrics(r.config.otlpEndpoint, generateOTLPMetrics(r.report, time.Now())); err != nil {
+				return xerrors.Errorf("failed to export metrics over OTLP: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:    "datadog",
+		enabled: func(c config) bool { return c.datadog },
+		reporter: reporterFunc(func(r reportContext) error {
+			dd, err := newDatadogClient()
+			if err != nil {
+				return xerrors.Errorf("failed to set up a Datadog client: %w", err)
+			}
+			if err = postDatadog(dd, r.report, r.results, r.score); err != nil {
+				return xerrors.Errorf("failed to submit the result to Datadog: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "statsd",
+		enabled:  func(c config) bool { return c.statsd != "" },
+		requires: "--statsd",
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := sendUDP(r.config.statsd, generateStatsDLines(r.report)); err != nil {
+				return xerrors.Errorf("failed to send metrics to StatsD: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "graphite",
+		enabled:  func(c config) bool { return c.graphite != "" },
+		requires: "--graphite",
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := sendUDP(r.config.graphite, generateGraphiteLines(r.report, time.Now())); err != nil {
+				return xerrors.Errorf("failed to send metrics to Graphite: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "codespeed",
+		enabled:  func(c config) bool { return c.codespeedURL != "" },
+		requires: "--codespeed-url",
+		reporter: reporterFunc(func(r reportContext) error {
+			c := r.config
+			results := generateCodespeedResults(r.report, c.codespeedProject, c.codespeedExecutable, codespeedEnvironment(c.codespeedEnvironment))
+			if err := postCodespeedResults(newHTTPClient(), c.codespeedURL, results); err != nil {
+				return xerrors.Errorf("failed to submit the result to Codespeed: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "bencher-file",
+		enabled:  func(c config) bool { return c.bencherFile != "" },
+		requires: "--bencher-file",
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := writeJSONFile(r.config.bencherFile, generateBencherMetrics(r.report)); err != nil {
+				return xerrors.Errorf("failed to write the Bencher metrics: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "bencher",
+		enabled:  func(c config) bool { return c.bencher != "" },
+		requires: "--bencher",
+		reporter: reporterFunc(func(r reportContext) error {
+			bc, err := newBencherClient()
+			if err != nil {
+				return xerrors.Errorf("failed to set up a Bencher client: %w", err)
+			}
+			if err = postBencherReport(bc, r.config.bencher, r.config.bencherTestbed, r.report, r.startedAt, time.Now()); err != nil {
+				return xerrors.Errorf("failed to submit the result to Bencher: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "github-action-benchmark",
+		enabled:  func(c config) bool { return c.githubActionBenchmarkFile != "" },
+		requires: "--github-action-benchmark-file",
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := writeJSONFile(r.config.githubActionBenchmarkFile, generateBenchmarkActionResults(r.report)); err != nil {
+				return xerrors.Errorf("failed to write the github-action-benchmark results: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "gobenchdata",
+		enabled:  func(c config) bool { return c.gobenchdataFile != "" },
+		requires: "--gobenchdata-file",
+		reporter: reporterFunc(func(r reportContext) error {
+			runs, err := readGobenchdataRuns(r.config.gobenchdataFile)
+			if err != nil {
+				return err
+			}
+			runs = mergeGobenchdataRun(runs, newGobenchdataRun(r.report, runtime.GOOS, runtime.GOARCH, time.Now()))
+			if err = writeJSONFile(r.config.gobenchdataFile, runs); err != nil {
+				return xerrors.Errorf("failed to write the gobenchdata results: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "junit",
+		enabled:  func(c config) bool { return c.junitFile != "" },
+		requires: "--junit-file",
+		reporter: reporterFunc(func(r reportContext) error {
+			if err := writeJUnitFile(r.config.junitFile, r.report); err != nil {
+				return xerrors.Errorf("failed to write the JUnit report: %w", err)
+			}
+			return nil
+		}),
+	},
+	{
+		name:     "report-dir",
+		enabled:  func(c config) bool { return reportDir(c.reportDir) != "" },
+		requires: "--report-dir",
+		reporter: reporterFunc(func(r reportContext) error {
+			_, message := verdict(r.results, r.config.threshold, r.score)
+			if err := writeReports(reportDir(r.config.reportDir), r.report, message); err != nil {
+				return xerrors.Errorf("failed to write the reports: %w", err)
+			}
+			return nil
+		}),
+	},
+}
+
+func reporterNamed(name string) (registeredReporter, bool) {
+	for _, r := range reporters {
+		if r.name == name {
+			return r, true
+		}
+	}
+	return registeredReporter{}, false
+}
+
+func reportTable(r reportContext) error {
+	c := r.config
+	if !c.onlyDegression {
+		showResult(r.w, r.rows, r.columns, c.tableStyle)
+	}
+	showRatio(r.w, r.results, c.threshold, r.score, r.columns, c.onlyDegression, c.ascii, colorful(r.w), c.tableStyle)
+	showDetails(r)
+	return nil
+}
+
+func reportDiff(r reportContext) error {
+	c := r.config
+	showDiff(r.w, r.results, r.report.Base.Ref, c.threshold, r.score, r.columns, c.onlyDegression, c.ascii, colorful(r.w))
+	showDetails(r)
+	return nil
+}
+
+// showDetails shows the verdict and the other comparisons below the results.
+func showDetails(r reportContext) {
+	c := r.config
+	rep := r.report
+	showVerdict(r.w, r.results, c.threshold, r.score, c.ascii)
+	showCreep(r.w, rep.Creep)
+	showAddedAndRemoved(r.w, rep.Added, rep.Removed)
+	showChangedFiles(r.w, rep.ChangedFiles)
+	showDependencyChanges(r.w, rep.Dependencies, c.tableStyle)
+	showTestTime(r.w, rep.TestTime, c.tableStyle)
+	showCoverage(r.w, rep.Coverage, c.tableStyle)
+	showProfileFunctions(r.w, rep.Profiles, c.tableStyle)
+	showTraceSummaries(r.w, rep.Traces, c.tableStyle)
+	showContention(r.w, rep.Contention, c.tableStyle)
+	showHardwareCounters(r.w, rep.HardwareCounters, c.tableStyle)
+	showMaxRSS(r.w, rep.MaxRSS, c.tableStyle)
+	showEnergy(r.w, rep.Energy, c.tableStyle)
+	showStartup(r.w, rep.Startup, c.tableStyle)
+	showInlineChanges(r.w, rep.Inlining, c.tableStyle)
+	showEscapes(r.w, rep.Escapes, c.tableStyle)
+	if c.summaryLine {
+		showSummaryLine(r.w, r.results, c.threshold, r.score, rep.Added, rep.Removed)
+	}
+}
+
+// pluginReporter runs an executable with the JSON report on stdin. COB_DEGRESSION tells it whether the run
+// fails, as the report doesn't when the environments differ.
+type pluginReporter struct {
+	name string
+	path string
+}
+
+func (p pluginReporter) report(r reportContext) error {
+	var stdin strings.Builder
+	if err := report.Encode(&stdin, r.report); err != nil {
+		return xerrors.Errorf("failed to write the report: %w", err)
+	}
+	debugf("exec: %s", p.path)
+	cmd := exec.Command(p.path)
+	cmd.Stdin = strings.NewReader(stdin.String())
+	cmd.Stdout = r.w
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "COB_DEGRESSION="+strconv.FormatBool(r.degression))
+	if err := cmd.Run(); err != nil {
+		return xerrors.Errorf("reporter %s failed: %w", p.name, err)
+	}
+	return nil
+}
+
+// activeReporter is a reporter which runs, and the file which -reporter name=FILE redirects its output to.
+type activeReporter struct {
+	name     string
+	reporter reporter
+	file     string
+}
+
+// activeReporters returns the reporters enabled by their options, followed by the others of -reporter in
+// their order. -reporter name=FILE also enables a reporter which the options already enable, e.g.
+// "json=report.json" with "-format table". Names which cob doesn't know are looked up in PATH with lookPath
+// as "cob-reporter-<name>".
+func activeReporters(c config, lookPath func(string) (string, error)) ([]activeReporter, error) {
+	type spec struct{ name, file string }
+	var specs []spec
+	for _, s := range c.reporters {
+		name, file := s, ""
+		if i := strings.Index(s, "="); i >= 0 {
+			name, file = s[:i], s[i+1:]
+		}
+		if name == "" {
+			return nil, xerrors.Errorf("invalid --reporter: %s", s)
+		}
+		specs = append(specs, spec{name: name, file: file})
+	}
+
+	var active []activeReporter
+	known := map[string]bool{}
+	for _, r := range reporters {
+		known[r.name] = true
+		if r.enabled(c) {
+			active = append(active, activeReporter{name: r.name, reporter: r.reporter})
+		}
+		for _, s := range specs {
+			if s.name != r.name || (r.enabled(c) && s.file == "") {
+				continue
+			}
+			if s.file != "" && !r.writes {
+				return nil, xerrors.Errorf("--reporter %s doesn't write any output to %s", r.name, s.file)
+			}
+			if !r.enabled(c) && r.requires != "" {
+				return nil, xerrors.Errorf("--reporter %s requires %s", r.name, r.requires)
+			}
+			active = append(active, activeReporter{name: r.name, reporter: r.reporter, file: s.file})
+		}
+	}
+	for _, s := range specs {
+		if known[s.name] {
+			continue
+		}
+		path, err := lookPath(reporterPluginPrefix + s.name)
+		if err != nil {
+			return nil, xerrors.Errorf("unknown reporter %s: %s%s is not found in PATH", s.name, reporterPluginPrefix, s.name)
+		}
+		active = append(active, activeReporter{name: s.name, reporter: pluginReporter{name: s.name, path: path}, file: s.file})
+	}
+	return active, nil
+}
+
+// runReporters runs all the reporters in order, even if some of them fail, e.g. because a webhook is down, so
+// that the others still report the run. The errors of the failed reporters are returned together.
+func runReporters(active []activeReporter, r reportContext) error {
+	var failed []string
+	for _, a := range active {
+		if err := runReporter(a, r); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", a.name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return xerrors.Errorf("%d reporter(s) failed: %s", len(failed), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func runReporter(a activeReporter, r reportContext) error {
+	if a.file == "" {
+		r.w = os.Stdout
+		return a.reporter.report(r)
+	}
+	f, err := os.Create(a.file)
+	if err != nil {
+		return xerrors.Errorf("failed to write the output of reporter %s: %w", a.name, err)
+	}
+	r.w = f
+	if err = a.reporter.report(r); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return xerrors.Errorf("failed to write the output of reporter %s: %w", a.name, err)
+	}
+	return nil
+}
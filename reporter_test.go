@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+func Test_activeReporters(t *testing.T) {
+	lookPath := func(file string) (string, error) {
+		if file == "cob-reporter-sheets" {
+			return "/usr/local/bin/cob-reporter-sheets", nil
+		}
+		return "", exec.ErrNotFound
+	}
+	type active struct {
+		name string
+		file string
+	}
+	tests := []struct {
+		name    string
+		config  config
+		want    []active
+		wantErr string
+	}{
+		{
+			name:   "format",
+			config: config{format: "table"},
+			want:   []active{{name: "table"}},
+		},
+		{
+			name:   "options",
+			config: config{format: "json", slackWebhook: "https://hooks.slack.com/x", githubStatus: true},
+			want:   []active{{name: "json"}, {name: "github-status"}, {name: "slack"}},
+		},
+		{
+			name:   "reporter",
+			config: config{format: "table", reporters: []string{"github-pr-comment", "table"}},
+			want:   []active{{name: "table"}, {name: "github-pr-comment"}},
+		},
+		{
+			name:   "file",
+			config: config{format: "table", reporters: []string{"json=report.json", "table=table.txt"}},
+			want:   []active{{name: "table"}, {name: "table", file: "table.txt"}, {name: "json", file: "report.json"}},
+		},
+		{
+			name:   "plugin",
+			config: config{format: "table", reporters: []string{"sheets=sheets.log"}},
+			want:   []active{{name: "table"}, {name: "sheets", file: "sheets.log"}},
+		},
+		{
+			name:    "required option",
+			config:  config{format: "table", reporters: []string{"slack"}},
+			wantErr: "--reporter slack requires --slack-webhook",
+		},
+		{
+			name:    "no output",
+			config:  config{format: "table", githubStatus: true, reporters: []string{"github-status=status.txt"}},
+			wantErr: "--reporter github-status doesn't write any output to status.txt",
+		},
+		{
+			name:    "unknown",
+			config:  config{format: "table", reporters: []string{"sheet"}},
+			wantErr: "unknown reporter sheet: cob-reporter-sheet is not found in PATH",
+		},
+		{
+			name:    "empty name",
+			config:  config{format: "table", reporters: []string{"=out.txt"}},
+			wantErr: "invalid --reporter: =out.txt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Unsetenv("GITHUB_ACTIONS")
+			got, err := activeReporters(tt.config, lookPath)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			var names []active
+			for _, a := range got {
+				names = append(names, active{name: a.name, file: a.file})
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func Test_pluginReporter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the plugin is a shell script")
+	}
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cob-reporter-test")
+	script := "#!/bin/sh\necho \"degression=$COB_DEGRESSION\"\ncat\n"
+	require.NoError(t, ioutil.WriteFile(path, []byte(script), 0755))
+
+	out := filepath.Join(dir, "out.txt")
+	a := activeReporter{name: "test", reporter: pluginReporter{name: "test", path: path}, file: out}
+	rep := report.Report{SchemaVersion: report.SchemaVersion, Head: report.Commit{Ref: "HEAD", Hash: "aaaaaaaaaa"}}
+	require.NoError(t, runReporter(a, reportContext{report: rep, degression: true}))
+
+	b, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	lines := bytes.SplitN(b, []byte("\n"), 2)
+	assert.Equal(t, "degression=true", string(lines[0]))
+	got, err := report.Decode(bytes.NewReader(lines[1]))
+	require.NoError(t, err)
+	assert.Equal(t, "aaaaaaaaaa", got.Head.Hash)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\nexit 3\n"), 0755))
+	err = runReporter(a, reportContext{report: rep})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reporter test failed")
+}
+
+func Test_runReporters(t *testing.T) {
+	var ran []string
+	ok := func(name string) activeReporter {
+		return activeReporter{name: name, reporter: reporterFunc(func(reportContext) error {
+			ran = append(ran, name)
+			return nil
+		})}
+	}
+	failing := func(name string) activeReporter {
+		return activeReporter{name: name, reporter: reporterFunc(func(reportContext) error {
+			ran = append(ran, name)
+			return xerrors.New("500 Internal Server Error")
+		})}
+	}
+
+	err := runReporters([]activeReporter{ok("a"), failing("slack"), ok("b"), failing("webhook")}, reportContext{})
+	assert.Equal(t, []string{"a", "slack", "b", "webhook"}, ran, "the reporters after a failed one still run")
+	assert.EqualError(t, err, "2 reporter(s) failed: slack: 500 Internal Server Error; webhook: 500 Internal Server Error")
+	assert.Equal(t, exitError, exitCode(err))
+
+	assert.NoError(t, runReporters([]activeReporter{ok("a")}, reportContext{}))
+}
+
+func Test_regression(t *testing.T) {
+	assert.EqualError(t, regression(config{}, report.Report{}, true), "This commit makes benchmarks worse")
+	assert.EqualError(t, regression(config{strict: true}, report.Report{Removed: []string{"BenchmarkA"}}, false),
+		"This commit removes 1 benchmark(s)")
+	assert.NoError(t, regression(config{}, report.Report{Removed: []string{"BenchmarkA"}}, false))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// restClient sends JSON requests to a REST API.
+type restClient struct {
+	// name is the name of the service used in logs and errors, e.g. "GitHub".
+	name    string
+	baseURL string
+	header  http.Header
+	client  *http.Client
+}
+
+func newRESTClient(name, baseURL string, header http.Header) restClient {
+	return restClient{
+		name:    name,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		header:  header,
+		client:  newHTTPClient(),
+	}
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+func (c *restClient) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return xerrors.Errorf("failed to encode the request: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return xerrors.Errorf("failed to create a request: %w", err)
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	debugf("%s: %s %s", strings.ToLower(c.name), method, path)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return xerrors.Errorf("failed to send a request to %s: %w", c.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return xerrors.Errorf("%s returned %s for %s %s: %s", c.name, resp.Status, method, path, strings.TrimSpace(string(b)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return xerrors.Errorf("failed to decode the response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/xerrors"
+)
+
+// maxRSSWrapper is the source of the program which runs a test binary and writes the peak RSS of its process in
+// bytes to the file of the first argument. The RSS of a child can't be less than the RSS of its parent when it was
+// started, so a small program runs the test binary instead of cob.
+const maxRSSWrapper = `package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"syscall"
+)
+
+func main() {
+	cmd := exec.Command(os.Args[2], os.Args[3:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := cmd.Run()
+	if cmd.ProcessState == nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if usage, ok := cmd.ProcessState.SysUsage().(*syscall.Rusage); ok {
+		// ru_maxrss is in bytes on macOS and in kilobytes elsewhere.
+		rss := int64(usage.Maxrss)
+		if runtime.GOOS != "darwin" {
+			rss *= 1024
+		}
+		ioutil.WriteFile(os.Args[1], []byte(strconv.FormatInt(rss, 10)), 0644)
+	}
+	os.Exit(cmd.ProcessState.ExitCode())
+}
+`
+
+// checkMaxRSS returns an error if the peak RSS can't be measured on the platform.
+func checkMaxRSS(goos string) error {
+	if goos == "windows" || goos == "plan9" {
+		return xerrors.Errorf("--max-rss is not supported on %s", goos)
+	}
+	return nil
+}
+
+// measureMaxRSS runs each benchmark once more with the test binary wrapped in maxRSSWrapper, and returns the peak
+// RSS of its process in bytes by benchmark. Only the test binary is measured, not the build. The worktree must be
+// at the commit to measure. Like collectProfiles, benchmarks run as many iterations as they did at HEAD, and a
+// benchmark which can't be measured only prints a warning.
+func measureMaxRSS(benchmarks []report.Benchmark) map[string]int64 {
+	rss := map[string]int64{}
+	dir, err := ioutil.TempDir("", "cob-rss")
+	if err != nil {
+		warnf("Failed to measure the peak RSS: %s", err)
+		return rss
+	}
+	defer os.RemoveAll(dir)
+	wrapper, err := buildMaxRSSWrapper(dir)
+	if err != nil {
+		warnf("Failed to measure the peak RSS: %s", err)
+		return rss
+	}
+	funcs, err := findBenchmarkFuncs(".")
+	if err != nil {
+		warnf("Failed to measure the peak RSS: %s", err)
+		return rss
+	}
+
+	out := filepath.Join(dir, "rss")
+	for _, b := range benchmarks {
+		fn, ok := funcs[benchmarkFuncName(b.Name)]
+		if !ok {
+			warnf("Failed to measure the peak RSS of %s: the benchmark function is not found", b.Name)
+			continue
+		}
+		os.Remove(out)
+		args := []string{"test", "-run", "^$", "-bench", benchmarkRegexp(b.Name), "-count=1", "-exec", wrapper + " " + out}
+		if b.Head.Iterations > 0 {
+			args = append(args, "-benchtime="+strconv.Itoa(b.Head.Iterations)+"x")
+		}
+		args = append(args, "./"+path.Dir(fn.Path))
+		debugf("exec: go %s", strings.Join(args, " "))
+		if output, err := exec.Command("go", args...).CombinedOutput(); err != nil {
+			warnf("Failed to measure the peak RSS of %s: %s: %s", b.Name, err, strings.TrimSpace(string(output)))
+			continue
+		}
+		content, err := ioutil.ReadFile(out)
+		if err != nil {
+			warnf("Failed to measure the peak RSS of %s: the peak RSS is not reported on this platform", b.Name)
+			continue
+		}
+		if rss[b.Name], err = strconv.ParseInt(string(content), 10, 64); err != nil {
+			warnf("Failed to measure the peak RSS of %s: %s", b.Name, err)
+		}
+	}
+	return rss
+}
+
+// buildMaxRSSWrapper builds maxRSSWrapper in dir and returns the path of the binary. It is built outside of the
+// module under test, so that its go.mod and GOFLAGS don't apply.
+func buildMaxRSSWrapper(dir string) (string, error) {
+	src := filepath.Join(dir, "wrapper.go")
+	if err := ioutil.WriteFile(src, []byte(maxRSSWrapper), 0644); err != nil {
+		return "", xerrors.Errorf("failed to write the wrapper: %w", err)
+	}
+	bin := filepath.Join(dir, "wrapper")
+	cmd := exec.Command("go", "build", "-o", bin, "wrapper.go")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GO111MODULE=off", "GOFLAGS=")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", xerrors.Errorf("failed to build the wrapper: %s: %s", err, strings.TrimSpace(string(out)))
+	}
+	return bin, nil
+}
+
+// newMaxRSS pairs the peak RSS of each benchmark at both commits.
+func newMaxRSS(benchmarks []report.Benchmark, base, head map[string]int64) []report.MaxRSS {
+	var rss []report.MaxRSS
+	for _, b := range benchmarks {
+		r := report.MaxRSS{Name: b.Name, Base: base[b.Name], Head: head[b.Name]}
+		if r.Base == 0 && r.Head == 0 {
+			continue
+		}
+		r.Ratio = calcRatio(float64(r.Head), float64(r.Base))
+		rss = append(rss, r)
+	}
+	return rss
+}
+
+func showMaxRSS(w io.Writer, rss []report.MaxRSS, style string) {
+	if len(rss) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nPeak RSS")
+	fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 8))
+
+	table := tablewriter.NewWriter(w)
+	table.SetAutoFormatHeaders(false)
+	applyTableStyle(table, style)
+	table.SetHeader([]string{"Name", "Base", "HEAD", "Delta"})
+	for _, r := range rss {
+		row := []string{r.Name, "-", "-", "-"}
+		if r.Base > 0 {
+			row[1] = formatMegabytes(r.Base)
+		}
+		if r.Head > 0 {
+			row[2] = formatMegabytes(r.Head)
+		}
+		if r.Base > 0 && r.Head > 0 {
+			row[3] = fmt.Sprintf("%+.2f%%", 100*r.Ratio)
+		}
+		table.Append(row)
+	}
+	table.Render()
+}
+
+func formatMegabytes(b int64) string {
+	return strconv.FormatFloat(float64(b)/(1<<20), 'f', 1, 64) + " MiB"
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_checkMaxRSS(t *testing.T) {
+	assert.NoError(t, checkMaxRSS("linux"))
+	assert.NoError(t, checkMaxRSS("darwin"))
+	assert.EqualError(t, checkMaxRSS("windows"), "--max-rss is not supported on windows")
+}
+
+func Test_showMaxRSS(t *testing.T) {
+	benchmarks := []report.Benchmark{{Name: "BenchmarkA-8"}, {Name: "BenchmarkB-8"}, {Name: "BenchmarkC-8"}}
+	rss := newMaxRSS(benchmarks,
+		map[string]int64{"BenchmarkA-8": 8 << 20},
+		map[string]int64{"BenchmarkA-8": 12 << 20, "BenchmarkC-8": 5 << 20},
+	)
+	require.Len(t, rss, 2)
+	assert.Equal(t, report.MaxRSS{Name: "BenchmarkA-8", Base: 8 << 20, Head: 12 << 20, Ratio: 0.5}, rss[0])
+
+	w := &bytes.Buffer{}
+	showMaxRSS(w, rss, "ascii")
+	got := w.String()
+	assert.Contains(t, got, "Peak RSS\n========")
+	assert.Contains(t, got, "| BenchmarkA-8 | 8.0 MiB | 12.0 MiB | +50.00% |")
+	assert.Contains(t, got, "| BenchmarkC-8 | -       | 5.0 MiB  | -       |")
+}
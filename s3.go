@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// s3Storage is a bucket of Amazon S3 or an S3-compatible service such as MinIO.
+type s3Storage struct {
+	client *http.Client
+	// baseURL is the URL of the bucket, e.g. "https://bucket.s3.us-east-1.amazonaws.com".
+	baseURL string
+	region  string
+	creds   awsCredentials
+	now     func() time.Time
+}
+
+// newS3Storage resolves credentials with the standard chain. AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL overrides
+// the endpoint, e.g. for MinIO, in which case path-style URLs are used.
+func newS3Storage(client *http.Client, bucket string) (*s3Storage, error) {
+	creds, err := awsCredentialsFromChain(client)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get AWS credentials: %w", err)
+	}
+	region := awsRegion()
+	baseURL := "https://" + bucket + ".s3." + region + ".amazonaws.com"
+	for _, key := range []string{"AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"} {
+		if endpoint := os.Getenv(key); endpoint != "" {
+			baseURL = strings.TrimSuffix(endpoint, "/") + "/" + bucket
+			break
+		}
+	}
+	return &s3Storage{client: client, baseURL: baseURL, region: region, creds: creds, now: time.Now}, nil
+}
+
+func (s *s3Storage) do(method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	u, err := url.Parse(s.baseURL + "/" + key)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid key: %w", err)
+	}
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create a request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	signAWSRequest(req, body, s.creds, s.region, "s3", s.now())
+
+	debugf("s3: %s %s", method, u.Path)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to send a request to S3: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, errObjectNotFound
+	}
+	// S3 returns 409 if a conditional write races with another one.
+	if resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict && isConditional(header) {
+		resp.Body.Close()
+		return nil, errObjectChanged
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		return nil, xerrors.Errorf("S3 returned %s for %s %s: %s", resp.Status, method, u.Path, strings.TrimSpace(string(b)))
+	}
+	return resp, nil
+}
+
+func (s *s3Storage) putObject(key string, body []byte) error {
+	resp, err := s.do(http.MethodPut, key, nil, nil, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *s3Storage) putObjectIf(key string, body []byte, version string) error {
+	resp, err := s.do(http.MethodPut, key, nil, conditionalHeader(version), body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *s3Storage) getObject(key string) ([]byte, error) {
+	b, _, err := s.getObjectVersion(key)
+	return b, err
+}
+
+func (s *s3Storage) getObjectVersion(key string) ([]byte, string, error) {
+	resp, err := s.do(http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	return b, resp.Header.Get("ETag"), err
+}
+
+func (s *s3Storage) listObjects(prefix string) ([]string, error) {
+	var keys []string
+	var token string
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode the object list: %w", err)
+		}
+		for _, c := range result.Contents {
+			keys = append(keys, c.Key)
+		}
+		if !result.IsTruncated {
+			return keys, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *s3Storage) deleteObject(key string) error {
+	resp, err := s.do(http.MethodDelete, key, nil, nil, nil)
+	if xerrors.Is(err, errObjectNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+// selfUpdateRepo is the repository whose releases goreleaser publishes.
+const selfUpdateRepo = "knqyf263/cob"
+
+var selfUpdateCommand = &cli.Command{
+	Name:  "self-update",
+	Usage: "Replace the running binary with the latest release after verifying its checksum",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "tag",
+			Usage: "Release to install, e.g. v0.1.0 (default: the latest)",
+		},
+		&cli.BoolFlag{
+			Name:  "check",
+			Usage: "Only print whether the release differs from the running version",
+		},
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "Install the release even if it is the running version",
+		},
+		&cli.StringFlag{
+			Name:  "sha256",
+			Usage: "SHA-256 of the archive of the release, e.g. from a source you trust other than the release itself",
+		},
+		&cli.StringFlag{
+			Name:  "public-key",
+			Usage: "PEM file with the Ed25519 public key which signed the checksums of the release",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		exe, err := os.Executable()
+		if err != nil {
+			return xerrors.Errorf("failed to find the running binary: %w", err)
+		}
+		if exe, err = filepath.EvalSymlinks(exe); err != nil {
+			return xerrors.Errorf("failed to find the running binary: %w", err)
+		}
+		var key ed25519.PublicKey
+		if path := c.String("public-key"); path != "" {
+			if key, err = readPublicKey(path); err != nil {
+				return err
+			}
+		}
+		baseURL := os.Getenv("GITHUB_API_URL")
+		if baseURL == "" {
+			baseURL = defaultGitHubAPIURL
+		}
+		u := selfUpdate{
+			client:  newReleaseClient(baseURL, os.Getenv("GITHUB_TOKEN")),
+			repo:    selfUpdateRepo,
+			current: version,
+			exe:     exe,
+			goos:    runtime.GOOS,
+			goarch:  runtime.GOARCH,
+			sha256:  c.String("sha256"),
+			key:     key,
+		}
+		return u.run(c.String("tag"), c.Bool("check"), c.Bool("force"))
+	},
+}
+
+// newReleaseClient returns a GitHub client for public releases. The token is optional, but raises the rate limit
+// which CI runners sharing an IP address easily hit.
+func newReleaseClient(baseURL, token string) *githubClient {
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github.v3+json")
+	if token != "" {
+		header.Set("Authorization", "token "+token)
+	}
+	return &githubClient{restClient: newRESTClient("GitHub", baseURL, header)}
+}
+
+type githubRelease struct {
+	TagName string               `json:"tag_name"`
+	Assets  []githubReleaseAsset `json:"assets"`
+}
+
+type githubReleaseAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// findRelease returns the release with the tag, or the latest release if tag is empty.
+func (c *githubClient) findRelease(repo, tag string) (githubRelease, error) {
+	p := fmt.Sprintf("/repos/%s/releases/latest", repo)
+	if tag != "" {
+		p = fmt.Sprintf("/repos/%s/releases/tags/%s", repo, tag)
+	}
+	var release githubRelease
+	if err := c.do(http.MethodGet, p, nil, &release); err != nil {
+		return githubRelease{}, xerrors.Errorf("failed to find the release: %w", err)
+	}
+	return release, nil
+}
+
+func (r githubRelease) asset(name string) (githubReleaseAsset, error) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, nil
+		}
+	}
+	return githubReleaseAsset{}, xerrors.Errorf("release %s has no %s", r.TagName, name)
+}
+
+// releaseNames are the names of GOOS and GOARCH in the archives, as replaced in .goreleaser.yml.
+var releaseNames = map[string]string{
+	"386":     "32bit",
+	"amd64":   "64bit",
+	"arm":     "ARM",
+	"arm64":   "ARM64",
+	"darwin":  "macOS",
+	"freebsd": "FreeBSD",
+	"linux":   "Linux",
+	"openbsd": "OpenBSD",
+}
+
+// releaseArchive returns the name of the archive of the release for the platform, e.g.
+// "cob_0.1.0_Linux-64bit.tar.gz".
+func releaseArchive(v, goos, goarch string) (string, error) {
+	osName, osOK := releaseNames[goos]
+	archName, archOK := releaseNames[goarch]
+	if !osOK || !archOK || goos == goarch {
+		return "", xerrors.Errorf("no release is built for %s/%s: build cob from the source instead", goos, goarch)
+	}
+	return fmt.Sprintf("cob_%s_%s-%s.tar.gz", v, osName, archName), nil
+}
+
+// releaseChecksum returns the SHA-256 of the file in the checksums of goreleaser, which are lines of
+// "<hex>  <file>".
+func releaseChecksum(checksums []byte, file string) (string, error) {
+	s := bufio.NewScanner(bytes.NewReader(checksums))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 2 && fields[1] == file {
+			return fields[0], nil
+		}
+	}
+	return "", xerrors.Errorf("no checksum of %s", file)
+}
+
+// readPublicKey reads the Ed25519 public key in a PEM file, e.g. written by
+// "openssl pkey -in key.pem -pubout".
+func readPublicKey(path string) (ed25519.PublicKey, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read the public key: %w", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, xerrors.Errorf("invalid public key %s: no PEM block", path)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid public key %s: %w", path, err)
+	}
+	key, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return nil, xerrors.Errorf("invalid public key %s: it must be an Ed25519 key", path)
+	}
+	return key, nil
+}
+
+// extractBinary returns the cob binary in the tar.gz archive of a release.
+func extractBinary(archive []byte) ([]byte, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to open the archive: %w", err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil, xerrors.New("no cob binary in the archive")
+		} else if err != nil {
+			return nil, xerrors.Errorf("failed to read the archive: %w", err)
+		}
+		if h.Typeflag == tar.TypeReg && path.Base(h.Name) == "cob" {
+			b, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to read the archive: %w", err)
+			}
+			return b, nil
+		}
+	}
+}
+
+// replaceExecutable writes the binary next to exe and renames it over exe, so that exe is never left half
+// written. The running process keeps the old file open until it exits.
+func replaceExecutable(exe string, b []byte) error {
+	mode := os.FileMode(0755)
+	if fi, err := os.Stat(exe); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	f, err := ioutil.TempFile(filepath.Dir(exe), ".cob-update")
+	if err != nil {
+		return xerrors.Errorf("failed to write the new binary next to %s: %w", exe, err)
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(b); err != nil {
+		f.Close()
+		return xerrors.Errorf("failed to write the new binary: %w", err)
+	}
+	if err = f.Close(); err != nil {
+		return xerrors.Errorf("failed to write the new binary: %w", err)
+	}
+	if err = os.Chmod(f.Name(), mode); err != nil {
+		return xerrors.Errorf("failed to make the new binary executable: %w", err)
+	}
+	if err = os.Rename(f.Name(), exe); err != nil {
+		return xerrors.Errorf("failed to replace %s: %w", exe, err)
+	}
+	return nil
+}
+
+// selfUpdate replaces exe, the binary of the current version, for the platform.
+type selfUpdate struct {
+	client  *githubClient
+	repo    string
+	current string
+	exe     string
+	goos    string
+	goarch  string
+	// sha256 is the checksum of the archive which the user trusts, if given.
+	sha256 string
+	// key verifies the signature of the checksums of the release, if given.
+	key ed25519.PublicKey
+}
+
+func (u selfUpdate) run(tag string, check, force bool) error {
+	release, err := u.client.findRelease(u.repo, tag)
+	if err != nil {
+		return err
+	}
+	v := strings.TrimPrefix(release.TagName, "v")
+	upToDate := v == strings.TrimPrefix(u.current, "v")
+	if check {
+		if upToDate {
+			infof("cob %s is up to date", u.current)
+		} else {
+			infof("cob %s is available (running %s)", v, u.current)
+		}
+		return nil
+	}
+	if upToDate && !force {
+		infof("cob %s is up to date", u.current)
+		return nil
+	}
+
+	name, err := releaseArchive(v, u.goos, u.goarch)
+	if err != nil {
+		return err
+	}
+	// Anyone who can publish the release can also publish its checksums, so the archive is only trusted with a
+	// checksum or a key from elsewhere.
+	if u.sha256 == "" && u.key == nil {
+		return xerrors.New("cannot verify the release: give the SHA-256 of the archive with -sha256 or the key which signed the checksums with -public-key")
+	}
+	archive, err := u.downloadAsset(release, name)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(archive)
+	got := hex.EncodeToString(sum[:])
+	if u.sha256 != "" && got != strings.ToLower(u.sha256) {
+		return xerrors.Errorf("checksum mismatch of %s: got %s, want %s", name, got, u.sha256)
+	}
+	if u.key != nil {
+		want, err := u.signedChecksum(release, v, name)
+		if err != nil {
+			return err
+		}
+		if got != want {
+			return xerrors.Errorf("checksum mismatch of %s: got %s, want %s", name, got, want)
+		}
+	}
+	b, err := extractBinary(archive)
+	if err != nil {
+		return xerrors.Errorf("invalid %s: %w", name, err)
+	}
+	if err = replaceExecutable(u.exe, b); err != nil {
+		return err
+	}
+	infof("Updated %s from %s to %s", u.exe, u.current, v)
+	return nil
+}
+
+// signedChecksum returns the checksum of the file in the checksums of the release, after verifying their
+// signature, which goreleaser signs as "<checksums>.sig", with the key.
+func (u selfUpdate) signedChecksum(release githubRelease, v, file string) (string, error) {
+	name := fmt.Sprintf("cob_%s_checksums.txt", v)
+	checksums, err := u.downloadAsset(release, name)
+	if err != nil {
+		return "", err
+	}
+	sig, err := u.downloadAsset(release, name+".sig")
+	if err != nil {
+		return "", err
+	}
+	if !ed25519.Verify(u.key, checksums, sig) {
+		return "", xerrors.Errorf("invalid signature of %s: it was not signed with the public key", name)
+	}
+	return releaseChecksum(checksums, file)
+}
+
+func (u selfUpdate) downloadAsset(release githubRelease, name string) ([]byte, error) {
+	a, err := release.asset(name)
+	if err != nil {
+		return nil, err
+	}
+	b, err := u.client.download(a.BrowserDownloadURL)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to download %s: %w", name, err)
+	}
+	return b, nil
+}
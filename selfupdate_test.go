@@ -0,0 +1,252 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_releaseArchive(t *testing.T) {
+	tests := []struct {
+		goos    string
+		goarch  string
+		want    string
+		wantErr string
+	}{
+		{goos: "linux", goarch: "amd64", want: "cob_0.1.0_Linux-64bit.tar.gz"},
+		{goos: "darwin", goarch: "arm64", want: "cob_0.1.0_macOS-ARM64.tar.gz"},
+		{goos: "freebsd", goarch: "386", want: "cob_0.1.0_FreeBSD-32bit.tar.gz"},
+		{goos: "windows", goarch: "amd64", wantErr: "no release is built for windows/amd64"},
+		{goos: "linux", goarch: "linux", wantErr: "no release is built for linux/linux"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos+"/"+tt.goarch, func(t *testing.T) {
+			got, err := releaseArchive("0.1.0", tt.goos, tt.goarch)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_releaseChecksum(t *testing.T) {
+	checksums := []byte("aaaa  cob_0.1.0_Linux-64bit.tar.gz\nbbbb  cob_0.1.0_Linux-64bit.tar.gz.sbom\n")
+	got, err := releaseChecksum(checksums, "cob_0.1.0_Linux-64bit.tar.gz")
+	require.NoError(t, err)
+	assert.Equal(t, "aaaa", got)
+
+	_, err = releaseChecksum(checksums, "cob_0.1.0_macOS-64bit.tar.gz")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no checksum of cob_0.1.0_macOS-64bit.tar.gz")
+}
+
+func releaseTarball(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	gw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return b.Bytes()
+}
+
+func Test_selfUpdate(t *testing.T) {
+	archive := releaseTarball(t, map[string]string{"README.md": "readme", "cob": "new binary"})
+	sum := sha256.Sum256(archive)
+	checksum := fmt.Sprintf("%x", sum)
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer := priv
+	checksums := func() string { return fmt.Sprintf("%s  cob_0.2.0_Linux-64bit.tar.gz\n", checksum) }
+
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/knqyf263/cob/releases/latest":
+			fmt.Fprintf(w, `{"tag_name": "v0.2.0", "assets": [
+				{"name": "cob_0.2.0_Linux-64bit.tar.gz", "browser_download_url": "%[1]s/download/archive"},
+				{"name": "cob_0.2.0_checksums.txt", "browser_download_url": "%[1]s/download/checksums"},
+				{"name": "cob_0.2.0_checksums.txt.sig", "browser_download_url": "%[1]s/download/signature"}
+			]}`, ts.URL)
+		case "/repos/knqyf263/cob/releases/tags/v0.1.0":
+			fmt.Fprint(w, `{"tag_name": "v0.1.0", "assets": []}`)
+		case "/download/archive":
+			_, _ = w.Write(archive)
+		case "/download/checksums":
+			fmt.Fprint(w, checksums())
+		case "/download/signature":
+			_, _ = w.Write(ed25519.Sign(signer, []byte(checksums())))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	newUpdate := func(t *testing.T, current string) selfUpdate {
+		dir, err := ioutil.TempDir("", "cob")
+		require.NoError(t, err)
+		exe := filepath.Join(dir, "cob")
+		require.NoError(t, ioutil.WriteFile(exe, []byte("old binary"), 0700))
+		return selfUpdate{
+			client:  newReleaseClient(ts.URL, ""),
+			repo:    "knqyf263/cob",
+			current: current,
+			exe:     exe,
+			goos:    "linux",
+			goarch:  "amd64",
+			key:     pub,
+		}
+	}
+	binary := func(t *testing.T, u selfUpdate) string {
+		b, err := ioutil.ReadFile(u.exe)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	t.Run("update", func(t *testing.T) {
+		u := newUpdate(t, "0.1.0")
+		defer os.RemoveAll(filepath.Dir(u.exe))
+		require.NoError(t, u.run("", false, false))
+		assert.Equal(t, "new binary", binary(t, u))
+		fi, err := os.Stat(u.exe)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0700), fi.Mode().Perm())
+		// Nothing but the binary is left in its directory.
+		entries, err := ioutil.ReadDir(filepath.Dir(u.exe))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+	t.Run("up to date", func(t *testing.T) {
+		u := newUpdate(t, "0.2.0")
+		defer os.RemoveAll(filepath.Dir(u.exe))
+		require.NoError(t, u.run("", false, false))
+		assert.Equal(t, "old binary", binary(t, u))
+
+		require.NoError(t, u.run("", false, true))
+		assert.Equal(t, "new binary", binary(t, u))
+	})
+	t.Run("check", func(t *testing.T) {
+		u := newUpdate(t, "dev")
+		defer os.RemoveAll(filepath.Dir(u.exe))
+		require.NoError(t, u.run("", true, false))
+		assert.Equal(t, "old binary", binary(t, u))
+	})
+	t.Run("checksum mismatch", func(t *testing.T) {
+		u := newUpdate(t, "0.1.0")
+		defer os.RemoveAll(filepath.Dir(u.exe))
+		checksum = fmt.Sprintf("%x", sha256.Sum256([]byte("other")))
+		defer func() { checksum = fmt.Sprintf("%x", sum) }()
+		err := u.run("", false, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "checksum mismatch of cob_0.2.0_Linux-64bit.tar.gz")
+		assert.Equal(t, "old binary", binary(t, u))
+	})
+	t.Run("invalid signature", func(t *testing.T) {
+		u := newUpdate(t, "0.1.0")
+		defer os.RemoveAll(filepath.Dir(u.exe))
+		_, other, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		signer = other
+		defer func() { signer = priv }()
+		err = u.run("", false, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid signature of cob_0.2.0_checksums.txt")
+		assert.Equal(t, "old binary", binary(t, u))
+	})
+	t.Run("sha256", func(t *testing.T) {
+		u := newUpdate(t, "0.1.0")
+		defer os.RemoveAll(filepath.Dir(u.exe))
+		u.key = nil
+		u.sha256 = fmt.Sprintf("%x", sha256.Sum256([]byte("other")))
+		err := u.run("", false, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "checksum mismatch of cob_0.2.0_Linux-64bit.tar.gz")
+		assert.Equal(t, "old binary", binary(t, u))
+
+		u.sha256 = checksum
+		require.NoError(t, u.run("", false, false))
+		assert.Equal(t, "new binary", binary(t, u))
+	})
+	t.Run("nothing to verify with", func(t *testing.T) {
+		u := newUpdate(t, "0.1.0")
+		defer os.RemoveAll(filepath.Dir(u.exe))
+		u.key = nil
+		err := u.run("", false, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot verify the release")
+		assert.Equal(t, "old binary", binary(t, u))
+	})
+	t.Run("tag", func(t *testing.T) {
+		u := newUpdate(t, "0.2.0")
+		defer os.RemoveAll(filepath.Dir(u.exe))
+		err := u.run("v0.1.0", false, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "release v0.1.0 has no cob_0.1.0_Linux-64bit.tar.gz")
+	})
+}
+
+func Test_readPublicKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeKey := func(name string, key interface{}) string {
+		b, err := x509.MarshalPKIXPublicKey(key)
+		require.NoError(t, err)
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: b}), 0644))
+		return path
+	}
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	got, err := readPublicKey(writeKey("ed25519.pem", pub))
+	require.NoError(t, err)
+	assert.Equal(t, pub, got)
+
+	ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, err = readPublicKey(writeKey("ecdsa.pem", &ec.PublicKey))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "it must be an Ed25519 key")
+
+	path := filepath.Join(dir, "key.txt")
+	require.NoError(t, ioutil.WriteFile(path, []byte("not a key"), 0644))
+	_, err = readPublicKey(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no PEM block")
+}
+
+func Test_extractBinary(t *testing.T) {
+	_, err := extractBinary(releaseTarball(t, map[string]string{"README.md": "readme"}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no cob binary in the archive")
+
+	_, err = extractBinary([]byte("not gzip"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to open the archive")
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+var serveCommand = &cli.Command{
+	Name:  "serve",
+	Usage: "Serve a web dashboard over the recorded history",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "addr",
+			Usage: "Address to listen on",
+			Value: ":8080",
+		},
+		&cli.StringFlag{
+			Name:  "store",
+			Usage: "Store the runs were recorded in with '-store'",
+			Value: defaultStore,
+		},
+		&cli.StringFlag{
+			Name:  "machine",
+			Usage: "Show only runs on machines with the tag given by '-machine'",
+		},
+		&cli.StringFlag{
+			Name:  "commit-url",
+			Usage: "URL of a commit with %s for the hash",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.String("store") == "" {
+			return xerrors.New("-store is required")
+		}
+		st, err := openStore(c.String("store"))
+		if err != nil {
+			return xerrors.Errorf("failed to open the store: %w", err)
+		}
+		d := dashboard{store: st, commitURL: c.String("commit-url")}
+		if c.IsSet("machine") {
+			tag := c.String("machine")
+			d.machine = &tag
+		}
+		infof("Serving the dashboard on %s", c.String("addr"))
+		if err = http.ListenAndServe(c.String("addr"), d.handler()); err != nil {
+			return xerrors.Errorf("failed to serve the dashboard: %w", err)
+		}
+		return nil
+	},
+}
+
+// dashboard serves the history in the store. Runs are read again on each request, so that runs recorded while
+// serving show up on reload.
+type dashboard struct {
+	store store
+	// machine is the tag of the machines to show, or nil for all machines.
+	machine   *string
+	commitURL string
+}
+
+func (d dashboard) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", d.index)
+	mux.HandleFunc("/bench", d.bench)
+	mux.HandleFunc("/compare", d.compare)
+	mux.HandleFunc("/download", d.download)
+	return mux
+}
+
+func (d dashboard) runs(w http.ResponseWriter) ([]historyRun, bool) {
+	runs, err := d.store.runs()
+	if err != nil {
+		warnf("Failed to read the history: %s", err)
+		http.Error(w, "failed to read the history", http.StatusInternalServerError)
+		return nil, false
+	}
+	if d.machine != nil {
+		runs = runsOnMachine(runs, *d.machine)
+	}
+	return runs, true
+}
+
+func (d dashboard) render(w http.ResponseWriter, name string, data map[string]interface{}) {
+	data["CommitURL"] = d.commitURL
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplate.ExecuteTemplate(w, name, data); err != nil {
+		warnf("Failed to render %s: %s", name, err)
+	}
+}
+
+type dashboardBenchmark struct {
+	Name    string
+	Results int
+	Latest  historyPoint
+}
+
+func (d dashboard) index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	runs, ok := d.runs(w)
+	if !ok {
+		return
+	}
+	latest := map[string]*dashboardBenchmark{}
+	for _, run := range runs {
+		for _, p := range benchmarkPoints(run) {
+			b, ok := latest[p.name]
+			if !ok {
+				b = &dashboardBenchmark{Name: p.name}
+				latest[p.name] = b
+			}
+			b.Results++
+			b.Latest = p.historyPoint
+		}
+	}
+	var benchmarks []dashboardBenchmark
+	for _, b := range latest {
+		benchmarks = append(benchmarks, *b)
+	}
+	sort.Slice(benchmarks, func(i, j int) bool { return benchmarks[i].Name < benchmarks[j].Name })
+
+	// Newest first, as the latest runs are the interesting ones.
+	var recent []historyRun
+	for i := len(runs) - 1; i >= 0; i-- {
+		recent = append(recent, runs[i])
+	}
+	d.render(w, "index", map[string]interface{}{"Benchmarks": benchmarks, "Runs": recent})
+}
+
+func (d dashboard) bench(w http.ResponseWriter, r *http.Request) {
+	runs, ok := d.runs(w)
+	if !ok {
+		return
+	}
+	name := r.URL.Query().Get("name")
+	points := benchmarkHistory(runs, name, 0)
+	if len(points) == 0 {
+		http.Error(w, "no results of the benchmark", http.StatusNotFound)
+		return
+	}
+	d.render(w, "bench", map[string]interface{}{
+		"Name":   name,
+		"Chart":  newSiteChart("chart", name, points, d.commitURL),
+		"Points": points,
+		"Changes": detectChangepoints(name, points, defaultChangeWindow, defaultChangeZScore,
+			defaultChangeMinChange),
+		"Width":  siteChartWidth,
+		"Height": siteChartHeight,
+		"Bottom": siteChartHeight - siteChartPadding,
+		"Right":  siteChartWidth - siteChartPadding,
+	})
+}
+
+type dashboardComparison struct {
+	Name        string
+	Base, Head  *historyPoint
+	NsPerOp     float64
+	AllocsPerOp float64
+	Status      string
+}
+
+func (d dashboard) compare(w http.ResponseWriter, r *http.Request) {
+	runs, ok := d.runs(w)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	base, head := findRunOfCommit(runs, q.Get("base")), findRunOfCommit(runs, q.Get("head"))
+	if base == nil || head == nil {
+		http.Error(w, "no runs of the commits", http.StatusNotFound)
+		return
+	}
+	d.render(w, "compare", map[string]interface{}{
+		"Base":        base,
+		"Head":        head,
+		"Comparisons": compareRuns(*base, *head),
+	})
+}
+
+// findRunOfCommit returns the latest run at the commit, which may be abbreviated.
+func findRunOfCommit(runs []historyRun, commit string) *historyRun {
+	if commit == "" {
+		return nil
+	}
+	for i := len(runs) - 1; i >= 0; i-- {
+		if strings.HasPrefix(runs[i].Report.Head.Hash, commit) {
+			return &runs[i]
+		}
+	}
+	return nil
+}
+
+// compareRuns compares the results at HEAD of two runs. Benchmarks in only one of them are listed without
+// ratios. The threshold of the head run decides whether a benchmark got worse.
+func compareRuns(base, head historyRun) []dashboardComparison {
+	comparisons := map[string]*dashboardComparison{}
+	get := func(name string) *dashboardComparison {
+		if c, ok := comparisons[name]; ok {
+			return c
+		}
+		c := &dashboardComparison{Name: name}
+		comparisons[name] = c
+		return c
+	}
+	for _, p := range benchmarkPoints(base) {
+		p := p
+		get(p.name).Base = &p.historyPoint
+	}
+	for _, p := range benchmarkPoints(head) {
+		p := p
+		get(p.name).Head = &p.historyPoint
+	}
+
+	var sorted []dashboardComparison
+	for _, c := range comparisons {
+		if c.Base != nil && c.Head != nil {
+			c.NsPerOp = calcRatio(c.Head.NsPerOp, c.Base.NsPerOp)
+			c.AllocsPerOp = calcRatio(float64(c.Head.AllocsPerOp), float64(c.Base.AllocsPerOp))
+			if head.Report.Threshold > 0 && c.NsPerOp > head.Report.Threshold {
+				c.Status = report.StatusFail
+			}
+		}
+		sorted = append(sorted, *c)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+type namedHistoryPoint struct {
+	historyPoint
+	name string
+}
+
+func benchmarkPoints(run historyRun) []namedHistoryPoint {
+	var points []namedHistoryPoint
+	for _, b := range run.Report.Benchmarks {
+		points = append(points, namedHistoryPoint{
+			historyPoint: historyPoint{
+				Time:              run.Time,
+				Commit:            run.Report.Head.Hash,
+				NsPerOp:           b.Head.NsPerOp,
+				AllocedBytesPerOp: b.Head.AllocedBytesPerOp,
+				AllocsPerOp:       b.Head.AllocsPerOp,
+				Status:            b.Status,
+			},
+			name: b.Name,
+		})
+	}
+	return points
+}
+
+func (d dashboard) download(w http.ResponseWriter, r *http.Request) {
+	runs, ok := d.runs(w)
+	if !ok {
+		return
+	}
+	var err error
+	switch format := r.URL.Query().Get("format"); format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="history.csv"`)
+		err = writeExportCSV(w, exportColumns(runs))
+	case "parquet":
+		w.Header().Set("Content-Type", "application/vnd.apache.parquet")
+		w.Header().Set("Content-Disposition", `attachment; filename="history.parquet"`)
+		err = writeParquet(w, exportColumns(runs))
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="history.json"`)
+		err = json.NewEncoder(w).Encode(runs)
+	default:
+		http.Error(w, "unknown format: "+format, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		warnf("Failed to write the history: %s", err)
+	}
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"short":   shortHash,
+	"percent": func(ratio float64) string { return fmt.Sprintf("%+.2f%%", 100*ratio) },
+	"dec":     func(i int) int { return i - 1 },
+	"dict":    func(url, hash string) map[string]string { return map[string]string{"URL": url, "Hash": hash} },
+	"commit":  func(commitURL, hash string) template.URL { return template.URL(commitLink(commitURL, hash)) },
+}).Parse(`
+{{- define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>cob: {{.}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; }
+td.number { text-align: right; }
+tr.warn { background: #fff8e1; }
+tr.fail { background: #ffebee; }
+svg { background: #fafafa; border: 1px solid #ccc; }
+polyline { fill: none; stroke: #1976d2; stroke-width: 1.5; }
+circle { fill: #1976d2; }
+circle.warn { fill: #ffa000; }
+circle.fail { fill: #d32f2f; }
+text { font-size: 11px; fill: #666; }
+</style>
+</head>
+<body>
+<p><a href="/">cob</a></p>
+{{- end}}
+
+{{- define "footer"}}
+</body>
+</html>
+{{end}}
+
+{{- define "hash"}}{{if .URL}}<a href="{{commit .URL .Hash}}"><code>{{short .Hash}}</code></a>{{else}}<code>{{short .Hash}}</code>{{end}}{{end}}
+
+{{- define "index"}}{{template "header" "benchmark history"}}
+<h1>Benchmarks</h1>
+<table>
+<tr><th>Name</th><th>Results</th><th>Latest ns/op</th><th>Status</th></tr>
+{{- range .Benchmarks}}
+<tr class="{{.Latest.Status}}"><td><a href="/bench?name={{.Name}}">{{.Name}}</a></td><td class="number">{{.Results}}</td><td class="number">{{printf "%.2f" .Latest.NsPerOp}}</td><td>{{.Latest.Status}}</td></tr>
+{{- end}}
+</table>
+<h2>Compare commits</h2>
+<form action="/compare">
+<label>Base <select name="base">{{range .Runs}}<option value="{{.Report.Head.Hash}}">{{short .Report.Head.Hash}} {{.Time.UTC.Format "2006-01-02 15:04"}}</option>{{end}}</select></label>
+<label>HEAD <select name="head">{{range .Runs}}<option value="{{.Report.Head.Hash}}">{{short .Report.Head.Hash}} {{.Time.UTC.Format "2006-01-02 15:04"}}</option>{{end}}</select></label>
+<input type="submit" value="Compare">
+</form>
+<h2>Runs</h2>
+<p>Download: <a href="/download?format=json">JSON</a>, <a href="/download?format=csv">CSV</a>, <a href="/download?format=parquet">Parquet</a></p>
+<table>
+<tr><th>Time</th><th>Commit</th><th>Branch</th><th>Machine</th><th>Benchmarks</th></tr>
+{{- range .Runs}}
+<tr><td>{{.Time.UTC.Format "2006-01-02T15:04:05Z07:00"}}</td><td>{{template "hash" (dict $.CommitURL .Report.Head.Hash)}}</td><td>{{.Report.Head.Branch}}</td><td>{{.Machine.Hostname}}{{if .Machine.Tag}} ({{.Machine.Tag}}){{end}}</td><td class="number">{{len .Report.Benchmarks}}</td></tr>
+{{- end}}
+</table>
+{{template "footer"}}{{end}}
+
+{{- define "bench"}}{{template "header" .Name}}
+<h1>{{.Name}}</h1>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}">
+<text x="2" y="12">{{.Chart.Max}} ns/op</text>
+<text x="2" y="{{.Bottom}}">{{.Chart.Min}} ns/op</text>
+<text x="20" y="{{.Height}}" dy="-2">{{.Chart.From}}</text>
+<text x="{{.Right}}" y="{{.Height}}" dy="-2" text-anchor="end">{{.Chart.To}}</text>
+<polyline points="{{.Chart.Line}}"/>
+{{- range .Chart.Points}}
+{{if .URL}}<a href="{{.URL}}">{{end}}<circle class="{{.Status}}" cx="{{printf "%.1f" .X}}" cy="{{printf "%.1f" .Y}}" r="3"><title>{{.Title}}</title></circle>{{if .URL}}</a>{{end}}
+{{- end}}
+</svg>
+{{- if .Changes}}
+<p>Shifted at:</p>
+<ul>
+{{- range .Changes}}
+<li>{{template "hash" (dict $.CommitURL .Commit)}} at {{.Time.UTC.Format "2006-01-02T15:04:05Z07:00"}}: {{printf "%.2f" .Before}} to {{printf "%.2f" .After}} ns/op ({{percent .Change}})</li>
+{{- end}}
+</ul>
+{{- end}}
+<table>
+<tr><th>Time</th><th>Commit</th><th>Branch</th><th>Machine</th><th>ns/op</th><th>B/op</th><th>allocs/op</th><th>Status</th><th></th></tr>
+{{- range $i, $p := .Points}}
+<tr class="{{.Status}}"><td>{{.Time.UTC.Format "2006-01-02T15:04:05Z07:00"}}</td><td>{{template "hash" (dict $.CommitURL .Commit)}}</td><td>{{.Branch}}</td><td>{{.Machine}}</td><td class="number">{{printf "%.2f" .NsPerOp}}</td><td class="number">{{.AllocedBytesPerOp}}</td><td class="number">{{.AllocsPerOp}}</td><td>{{.Status}}</td><td>{{if $i}}<a href="/compare?base={{(index $.Points (dec $i)).Commit}}&head={{.Commit}}">compare with previous</a>{{end}}</td></tr>
+{{- end}}
+</table>
+{{template "footer"}}{{end}}
+
+{{- define "compare"}}{{template "header" "compare commits"}}
+<h1>{{short .Head.Report.Head.Hash}} vs {{short .Base.Report.Head.Hash}}</h1>
+<p>Base: {{template "hash" (dict .CommitURL .Base.Report.Head.Hash)}} at {{.Base.Time.UTC.Format "2006-01-02T15:04:05Z07:00"}}<br>HEAD: {{template "hash" (dict .CommitURL .Head.Report.Head.Hash)}} at {{.Head.Time.UTC.Format "2006-01-02T15:04:05Z07:00"}}</p>
+<table>
+<tr><th>Name</th><th>Base ns/op</th><th>HEAD ns/op</th><th>ns/op</th><th>Base allocs/op</th><th>HEAD allocs/op</th><th>allocs/op</th></tr>
+{{- range .Comparisons}}
+<tr class="{{.Status}}"><td><a href="/bench?name={{.Name}}">{{.Name}}</a></td>
+<td class="number">{{with .Base}}{{printf "%.2f" .NsPerOp}}{{end}}</td><td class="number">{{with .Head}}{{printf "%.2f" .NsPerOp}}{{end}}</td><td class="number">{{if and .Base .Head}}{{percent .NsPerOp}}{{end}}</td>
+<td class="number">{{with .Base}}{{.AllocsPerOp}}{{end}}</td><td class="number">{{with .Head}}{{.AllocsPerOp}}{{end}}</td><td class="number">{{if and .Base .Head}}{{percent .AllocsPerOp}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{template "footer"}}{{end}}
+`))
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_dashboard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	st := newFileStore(filepath.Join(dir, "history.jsonl"))
+	runs := testHistoryRuns()
+	runs[2].Report.Threshold = 0.01
+	runs[2].Report.Benchmarks[0].Head.NsPerOp = 110
+	for _, run := range runs {
+		require.NoError(t, st.put(run))
+	}
+	d := dashboard{store: st, commitURL: "https://github.com/knqyf263/cob/commit/%s"}
+	server := httptest.NewServer(d.handler())
+	defer server.Close()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(b)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		want   []string
+	}{
+		{
+			name:   "index",
+			path:   "/",
+			status: http.StatusOK,
+			want: []string{
+				`<tr class="ok"><td><a href="/bench?name=BenchmarkA">BenchmarkA</a></td><td class="number">3</td><td class="number">110.00</td><td>ok</td></tr>`,
+				`<option value="cccccccccc">ccccccc 2020-01-04 03:04</option>`,
+				`<a href="https://github.com/knqyf263/cob/commit/cccccccccc"><code>ccccccc</code></a>`,
+				`<a href="/download?format=parquet">Parquet</a>`,
+			},
+		},
+		{
+			name:   "benchmark",
+			path:   "/bench?name=BenchmarkA",
+			status: http.StatusOK,
+			want: []string{
+				`<h1>BenchmarkA</h1>`,
+				`<polyline points=`,
+				`<a href="/compare?base=aaaaaaaaaa&head=bbbbbbbbbb">compare with previous</a>`,
+			},
+		},
+		{
+			name:   "compare",
+			path:   "/compare?base=aaaa&head=cccc",
+			status: http.StatusOK,
+			want: []string{
+				`<h1>ccccccc vs aaaaaaa</h1>`,
+				`<tr class="fail"><td><a href="/bench?name=BenchmarkA">BenchmarkA</a></td>`,
+				`<td class="number">100.00</td><td class="number">110.00</td><td class="number">&#43;10.00%</td>`,
+			},
+		},
+		{
+			name:   "unknown benchmark",
+			path:   "/bench?name=BenchmarkC",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "unknown commit",
+			path:   "/compare?base=aaaa&head=dddd",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "csv",
+			path:   "/download?format=csv",
+			status: http.StatusOK,
+			want:   []string{"2020-01-04T03:04:05Z,cccccccccc,main,"},
+		},
+		{
+			name:   "unknown format",
+			path:   "/download?format=xml",
+			status: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := get(tt.path)
+			assert.Equal(t, tt.status, status)
+			for _, want := range tt.want {
+				assert.Contains(t, body, want)
+			}
+		})
+	}
+
+	_, body := get("/download?format=json")
+	var downloaded []historyRun
+	require.NoError(t, json.Unmarshal([]byte(body), &downloaded))
+	assert.Len(t, downloaded, 3)
+}
+
+func Test_compareRuns(t *testing.T) {
+	runs := testHistoryRuns()
+	runs[1].Report.Benchmarks = append(runs[1].Report.Benchmarks, report.Benchmark{Name: "BenchmarkC"})
+	comparisons := compareRuns(runs[0], runs[1])
+	require.Len(t, comparisons, 3)
+	assert.Equal(t, "BenchmarkA", comparisons[0].Name)
+	assert.Equal(t, 0.01, comparisons[0].NsPerOp)
+	assert.Equal(t, "", comparisons[0].Status)
+	assert.Nil(t, comparisons[2].Base)
+	assert.NotNil(t, comparisons[2].Head)
+}
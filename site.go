@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+// Size of a chart of the trend dashboard in pixels.
+const (
+	siteChartWidth   = 720
+	siteChartHeight  = 200
+	siteChartPadding = 20
+)
+
+var reportCommand = &cli.Command{
+	Name:  "report",
+	Usage: "Render a JSON report, or the recorded history, as a static HTML site",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "history",
+			Usage: "Render the runs recorded in the store as a trend dashboard",
+		},
+		&cli.StringFlag{
+			Name:  "store",
+			Usage: "Store the runs were recorded in with '-store', for '-history'",
+			Value: defaultStore,
+		},
+		&cli.StringFlag{
+			Name:  "report",
+			Usage: "JSON report to render without '-history' ('-' for stdin)",
+			Value: "-",
+		},
+		&cli.StringFlag{
+			Name:  "machine",
+			Usage: "Render only runs on machines with the tag given by '-machine', for '-history'",
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "Directory to write index.html to",
+			Value: "site",
+		},
+		&cli.StringFlag{
+			Name:  "commit-url",
+			Usage: "URL of a commit with %s for the hash (default: the commit page on GitHub or GitLab CI)",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		var page []byte
+		if c.Bool("history") {
+			if c.String("store") == "" {
+				return xerrors.New("-history requires -store")
+			}
+			st, err := openStore(c.String("store"))
+			if err != nil {
+				return xerrors.Errorf("failed to open the store: %w", err)
+			}
+			runs, err := st.runs()
+			if err != nil {
+				return xerrors.Errorf("failed to read the history: %w", err)
+			}
+			if c.IsSet("machine") {
+				runs = runsOnMachine(runs, c.String("machine"))
+			}
+			commitURL := c.String("commit-url")
+			if commitURL == "" {
+				commitURL = defaultCommitURL()
+			}
+			if page, err = generateSite(runs, commitURL, time.Now()); err != nil {
+				return err
+			}
+		} else {
+			rep, err := readReport(c.String("report"))
+			if err != nil {
+				return err
+			}
+			_, message := reportVerdict(rep)
+			s, err := generateHTML(rep, message)
+			if err != nil {
+				return err
+			}
+			page = []byte(s)
+		}
+
+		dir := c.String("output")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return xerrors.Errorf("failed to create %s: %w", dir, err)
+		}
+		path := filepath.Join(dir, "index.html")
+		if err := ioutil.WriteFile(path, page, 0644); err != nil {
+			return xerrors.Errorf("failed to write %s: %w", path, err)
+		}
+		infof("Wrote %s", path)
+		return nil
+	},
+}
+
+// defaultCommitURL links commits to GitHub or GitLab when running on their CI.
+func defaultCommitURL() string {
+	if server, repo := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"); server != "" && repo != "" {
+		return server + "/" + repo + "/commit/%s"
+	}
+	if project := os.Getenv("CI_PROJECT_URL"); project != "" {
+		return project + "/-/commit/%s"
+	}
+	return ""
+}
+
+// commitLink returns the URL of the commit by replacing the first %s of commitURL with its hash, or "" without
+// commitURL. Other verbs in commitURL are kept as they are, unlike with fmt.Sprintf.
+func commitLink(commitURL, hash string) string {
+	if commitURL == "" {
+		return ""
+	}
+	return strings.Replace(commitURL, "%s", hash, 1)
+}
+
+type siteChart struct {
+	Name     string
+	ID       string
+	Line     string
+	Points   []sitePoint
+	Min, Max string
+	From, To string
+	Failures int
+}
+
+type sitePoint struct {
+	X, Y   float64
+	URL    string
+	Title  string
+	Status string
+}
+
+var siteTemplate = template.Must(template.New("site").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>cob: benchmark history</title>
+<style>
+body { font-family: sans-serif; }
+svg { background: #fafafa; border: 1px solid #ccc; }
+polyline { fill: none; stroke: #1976d2; stroke-width: 1.5; }
+circle { fill: #1976d2; }
+circle.warn { fill: #ffa000; }
+circle.fail { fill: #d32f2f; }
+text { font-size: 11px; fill: #666; }
+</style>
+</head>
+<body>
+<h1>Benchmark history</h1>
+<p>{{len .Charts}} benchmark(s), generated at {{.Generated}}. Points are ns/op at HEAD of each run; red points got worse than the threshold.</p>
+<ul>
+{{- range .Charts}}
+<li><a href="#{{.ID}}">{{.Name}}</a>{{if .Failures}} ({{.Failures}} regression(s)){{end}}</li>
+{{- end}}
+</ul>
+{{- range .Charts}}
+<h2 id="{{.ID}}">{{.Name}}</h2>
+<svg width="{{$.Width}}" height="{{$.Height}}" viewBox="0 0 {{$.Width}} {{$.Height}}">
+<text x="2" y="12">{{.Max}} ns/op</text>
+<text x="2" y="{{$.Bottom}}">{{.Min}} ns/op</text>
+<text x="{{$.Padding}}" y="{{$.Height}}" dy="-2">{{.From}}</text>
+<text x="{{$.Right}}" y="{{$.Height}}" dy="-2" text-anchor="end">{{.To}}</text>
+<polyline points="{{.Line}}"/>
+{{- range .Points}}
+{{if .URL}}<a href="{{.URL}}">{{end}}<circle class="{{.Status}}" cx="{{printf "%.1f" .X}}" cy="{{printf "%.1f" .Y}}" r="3"><title>{{.Title}}</title></circle>{{if .URL}}</a>{{end}}
+{{- end}}
+</svg>
+{{- end}}
+</body>
+</html>
+`))
+
+// generateSite renders a page with a chart of ns/op over the recorded runs for each benchmark.
+func generateSite(runs []historyRun, commitURL string, now time.Time) ([]byte, error) {
+	var charts []siteChart
+	for i, name := range benchmarkNames(runs) {
+		charts = append(charts, newSiteChart(fmt.Sprintf("bench-%d", i), name, benchmarkHistory(runs, name, 0), commitURL))
+	}
+
+	w := &bytes.Buffer{}
+	err := siteTemplate.Execute(w, map[string]interface{}{
+		"Charts":    charts,
+		"Generated": now.UTC().Format(time.RFC3339),
+		"Width":     siteChartWidth,
+		"Height":    siteChartHeight,
+		"Padding":   siteChartPadding,
+		"Right":     siteChartWidth - siteChartPadding,
+		"Bottom":    siteChartHeight - siteChartPadding,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to render the site: %w", err)
+	}
+	return w.Bytes(), nil
+}
+
+func newSiteChart(id, name string, points []historyPoint, commitURL string) siteChart {
+	chart := siteChart{Name: name, ID: id}
+	min, max := points[0].NsPerOp, points[0].NsPerOp
+	for _, p := range points {
+		if p.NsPerOp < min {
+			min = p.NsPerOp
+		}
+		if p.NsPerOp > max {
+			max = p.NsPerOp
+		}
+	}
+	chart.Min, chart.Max = fmt.Sprintf("%.2f", min), fmt.Sprintf("%.2f", max)
+	chart.From, chart.To = points[0].Time.UTC().Format("2006-01-02"), points[len(points)-1].Time.UTC().Format("2006-01-02")
+
+	width := float64(siteChartWidth - 2*siteChartPadding)
+	height := float64(siteChartHeight - 2*siteChartPadding)
+	var line []string
+	for i, p := range points {
+		x := float64(siteChartPadding) + width/2
+		if len(points) > 1 {
+			x = float64(siteChartPadding) + width*float64(i)/float64(len(points)-1)
+		}
+		y := float64(siteChartPadding) + height/2
+		if max > min {
+			y = float64(siteChartPadding) + height*(max-p.NsPerOp)/(max-min)
+		}
+		sp := sitePoint{
+			X:      x,
+			Y:      y,
+			Title:  fmt.Sprintf("%s %s: %.2f ns/op (%s)", p.Time.UTC().Format(time.RFC3339), shortHash(p.Commit), p.NsPerOp, p.Status),
+			Status: p.Status,
+			URL:    commitLink(commitURL, p.Commit),
+		}
+		if p.Status == report.StatusFail {
+			chart.Failures++
+		}
+		chart.Points = append(chart.Points, sp)
+		line = append(line, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+	chart.Line = strings.Join(line, " ")
+	return chart
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_generateSite(t *testing.T) {
+	runs := testHistoryRuns()
+	runs[2].Report.Benchmarks[0].Status = report.StatusFail
+
+	b, err := generateSite(runs, "https://github.com/knqyf263/cob/commit/%s", time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	page := string(b)
+	assert.Contains(t, page, `<li><a href="#bench-0">BenchmarkA</a> (1 regression(s))</li>`)
+	assert.Contains(t, page, `<li><a href="#bench-1">BenchmarkB</a></li>`)
+	assert.Contains(t, page, `<polyline points="20.0,180.0 360.0,100.0 700.0,20.0"/>`)
+	assert.Contains(t, page, `<a href="https://github.com/knqyf263/cob/commit/cccccccccc"><circle class="fail" cx="700.0" cy="20.0" r="3">`+
+		`<title>2020-01-04T03:04:05Z ccccccc: 102.00 ns/op (fail)</title></circle></a>`)
+	// A flat line is drawn in the middle.
+	assert.Contains(t, page, `<polyline points="20.0,100.0 360.0,100.0 700.0,100.0"/>`)
+}
+
+func Test_defaultCommitURL(t *testing.T) {
+	os.Setenv("GITHUB_SERVER_URL", "https://github.com")
+	os.Setenv("GITHUB_REPOSITORY", "knqyf263/cob")
+	assert.Equal(t, "https://github.com/knqyf263/cob/commit/%s", defaultCommitURL())
+	os.Unsetenv("GITHUB_SERVER_URL")
+	os.Unsetenv("GITHUB_REPOSITORY")
+
+	os.Setenv("CI_PROJECT_URL", "https://gitlab.com/group/project")
+	defer os.Unsetenv("CI_PROJECT_URL")
+	assert.Equal(t, "https://gitlab.com/group/project/-/commit/%s", defaultCommitURL())
+}
+
+func Test_commitLink(t *testing.T) {
+	assert.Equal(t, "https://example.com/c/abc?x=%20", commitLink("https://example.com/c/%s?x=%20", "abc"))
+	assert.Equal(t, "", commitLink("", "abc"))
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+type slackMessage struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+type slackAttachment struct {
+	Color string `json:"color"`
+	Text  string `json:"text"`
+}
+
+func generateSlackMessage(results []result, commit string, threshold float64, comparedScore comparedScore, runURL string) slackMessage {
+	s, message := verdict(results, threshold, comparedScore)
+	text := fmt.Sprintf("%s *cob* `%s`: %s", s.marker(false), shortHash(commit), message)
+	if runURL != "" {
+		text += fmt.Sprintf(" (<%s|CI run>)", runURL)
+	}
+
+	msg := slackMessage{Text: text}
+	regressions := topRegressions(results, comparedScore, maxNotifiedRegressions)
+	if len(regressions) == 0 {
+		return msg
+	}
+
+	var lines []string
+	for _, r := range regressions {
+		lines = append(lines, "• "+generateRegressionLine(r, comparedScore))
+	}
+	color := map[status]string{statusOK: "good", statusWarn: "warning", statusFail: "danger"}[s]
+	msg.Attachments = []slackAttachment{{Color: color, Text: "*Worst regressions*\n" + strings.Join(lines, "\n")}}
+	return msg
+}
+
+func shortHash(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+func postSlackMessage(webhookURL string, msg slackMessage) error {
+	c := newRESTClient("Slack", webhookURL, nil)
+	if err := c.do(http.MethodPost, "", msg, nil); err != nil {
+		return xerrors.Errorf("failed to send a Slack message: %w", err)
+	}
+	infof("Sent the result to Slack")
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_generateSlackMessage(t *testing.T) {
+	compare := comparedScore{nsPerOp: true, allocedBytesPerOp: true}
+	tests := []struct {
+		name    string
+		results []result
+		runURL  string
+		want    slackMessage
+	}{
+		{
+			name:    "pass",
+			results: []result{{Name: "BenchmarkA", RatioNsPerOp: -0.1}},
+			want:    slackMessage{Text: "✅ *cob* `599a552`: PASS: no benchmarks got worse"},
+		},
+		{
+			name: "fail",
+			results: []result{
+				{Name: "BenchmarkA", RatioNsPerOp: 0.1},
+				{Name: "BenchmarkB", RatioNsPerOp: -0.1},
+				{Name: "BenchmarkC", RatioAllocedBytesPerOp: 0.5},
+			},
+			runURL: "https://github.com/knqyf263/cob/actions/runs/1",
+			want: slackMessage{
+				Text: "❌ *cob* `599a552`: FAIL: 1 benchmark(s) got worse than the threshold (20.00%) (<https://github.com/knqyf263/cob/actions/runs/1|CI run>)",
+				Attachments: []slackAttachment{{
+					Color: "danger",
+					Text: "*Worst regressions*\n" +
+						"• BenchmarkC: ns/op +0.00%, B/op +50.00%\n" +
+						"• BenchmarkA: ns/op +10.00%, B/op +0.00%",
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := generateSlackMessage(tt.results, "599a5523729d4d99a331b9d3f71dde9e1e6daef0", 0.2, compare, tt.runURL)
+			assert.Equal(t, tt.want, got, tt.name)
+		})
+	}
+}
+
+func Test_postSlackMessage(t *testing.T) {
+	var got slackMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/services/T000/B000/XXX", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	msg := slackMessage{Text: "result"}
+	require.NoError(t, postSlackMessage(ts.URL+"/services/T000/B000/XXX", msg))
+	assert.Equal(t, msg, got)
+}
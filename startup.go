@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/xerrors"
+)
+
+// readiness is when a started binary is considered up.
+type readiness struct {
+	// kind is "exit", "port" or "log".
+	kind string
+	// addr is the TCP address which accepts connections when a "port" binary is up.
+	addr string
+	// pattern matches the line a "log" binary prints when it is up.
+	pattern *regexp.Regexp
+}
+
+// parseReadiness parses -startup-ready, e.g. "exit", "port:localhost:8080" or "log:listening on".
+func parseReadiness(s string) (readiness, error) {
+	kind, arg := s, ""
+	if i := strings.Index(s, ":"); i >= 0 {
+		kind, arg = s[:i], s[i+1:]
+	}
+	switch {
+	case kind == "exit" && arg == "":
+		return readiness{kind: kind}, nil
+	case kind == "port" && arg != "":
+		return readiness{kind: kind, addr: arg}, nil
+	case kind == "log" && arg != "":
+		pattern, err := regexp.Compile(arg)
+		if err != nil {
+			return readiness{}, xerrors.Errorf("invalid readiness: '%s': %w", s, err)
+		}
+		return readiness{kind: kind, pattern: pattern}, nil
+	}
+	return readiness{}, xerrors.Errorf("invalid readiness: '%s': it must be exit, port:ADDR or log:REGEXP", s)
+}
+
+// buildStartupBinary builds the binary of the package at the current commit.
+func buildStartupBinary(pkg, out string) error {
+	args := []string{"build", "-o", out, pkg}
+	debugf("exec: go %s", strings.Join(args, " "))
+	if output, err := exec.Command("go", args...).CombinedOutput(); err != nil {
+		return xerrors.Errorf("failed to build %s: %s: %s", pkg, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// measureStartup starts each binary runs times, alternating between them so that a change of the machine's load
+// affects both alike, and returns the median time until each was ready. An empty path is skipped.
+func measureStartup(bins []string, args []string, ready readiness, runs int, timeout time.Duration) ([]time.Duration, error) {
+	durations := make([][]time.Duration, len(bins))
+	for i := 0; i < runs; i++ {
+		for j, bin := range bins {
+			if bin == "" {
+				continue
+			}
+			d, err := startOnce(bin, args, ready, timeout)
+			if err != nil {
+				return nil, err
+			}
+			durations[j] = append(durations[j], d)
+		}
+	}
+	medians := make([]time.Duration, len(bins))
+	for j, ds := range durations {
+		if len(ds) == 0 {
+			continue
+		}
+		sort.Slice(ds, func(a, b int) bool { return ds[a] < ds[b] })
+		medians[j] = ds[len(ds)/2]
+	}
+	return medians, nil
+}
+
+// startupPollInterval is how often the port of a starting binary is tried.
+const startupPollInterval = time.Millisecond
+
+// startOnce starts the binary and returns how long it took to be ready. A binary which waits for a port or a
+// log line is killed once it is ready.
+func startOnce(bin string, args []string, ready readiness, timeout time.Duration) (time.Duration, error) {
+	if ready.kind == "port" {
+		if conn, err := net.Dial("tcp", ready.addr); err == nil {
+			conn.Close()
+			return 0, xerrors.Errorf("%s already accepts connections before %s is started", ready.addr, bin)
+		}
+	}
+	cmd := exec.Command(bin, args...)
+	var output strings.Builder
+	var logged chan struct{}
+	var pw *io.PipeWriter
+	if ready.kind == "log" {
+		var pr *io.PipeReader
+		pr, pw = io.Pipe()
+		cmd.Stdout, cmd.Stderr = pw, pw
+		logged = make(chan struct{})
+		go func() {
+			s := bufio.NewScanner(pr)
+			found := false
+			for s.Scan() {
+				if !found && ready.pattern.MatchString(s.Text()) {
+					found = true
+					close(logged)
+				}
+			}
+			// Drain the output, so that the binary doesn't block on writing it.
+			_, _ = io.Copy(ioutil.Discard, pr)
+		}()
+	} else {
+		cmd.Stdout, cmd.Stderr = &output, &output
+	}
+
+	debugf("exec: %s %s", bin, strings.Join(args, " "))
+	started := time.Now()
+	if err := cmd.Start(); err != nil {
+		return 0, xerrors.Errorf("failed to start %s: %w", bin, err)
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+		if pw != nil {
+			pw.Close()
+		}
+	}()
+	deadline := time.After(timeout)
+
+	stop := func() {
+		_ = cmd.Process.Kill()
+		<-exited
+	}
+	switch ready.kind {
+	case "exit":
+		select {
+		case err := <-exited:
+			if err != nil {
+				return 0, xerrors.Errorf("%s failed: %s: %s", bin, err, strings.TrimSpace(output.String()))
+			}
+			return time.Since(started), nil
+		case <-deadline:
+			stop()
+			return 0, xerrors.Errorf("%s didn't exit in %s", bin, timeout)
+		}
+	case "port":
+		ticker := time.NewTicker(startupPollInterval)
+		defer ticker.Stop()
+		for {
+			if conn, err := net.Dial("tcp", ready.addr); err == nil {
+				elapsed := time.Since(started)
+				conn.Close()
+				stop()
+				return elapsed, nil
+			}
+			select {
+			case err := <-exited:
+				return 0, xerrors.Errorf("%s exited before %s accepted connections: %v: %s", bin, ready.addr, err, strings.TrimSpace(output.String()))
+			case <-deadline:
+				stop()
+				return 0, xerrors.Errorf("%s didn't accept connections on %s in %s", bin, ready.addr, timeout)
+			case <-ticker.C:
+			}
+		}
+	default:
+		select {
+		case <-logged:
+			elapsed := time.Since(started)
+			stop()
+			return elapsed, nil
+		case err := <-exited:
+			return 0, xerrors.Errorf("%s exited before it printed a line matching '%s': %v", bin, ready.pattern, err)
+		case <-deadline:
+			stop()
+			return 0, xerrors.Errorf("%s didn't print a line matching '%s' in %s", bin, ready.pattern, timeout)
+		}
+	}
+}
+
+// newStartup compares the startup times. base is 0 if the base commit wasn't measured.
+func newStartup(pkg, ready string, base, head time.Duration) *report.Startup {
+	s := &report.Startup{Package: pkg, Ready: ready, Base: base.Seconds(), Head: head.Seconds()}
+	s.Ratio = calcRatio(s.Head, s.Base)
+	return s
+}
+
+func showStartup(w io.Writer, s *report.Startup, style string) {
+	if s == nil {
+		return
+	}
+	fmt.Fprintln(w, "\nStartup time")
+	fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 12))
+
+	table := tablewriter.NewWriter(w)
+	table.SetAutoFormatHeaders(false)
+	applyTableStyle(table, style)
+	table.SetHeader([]string{"Package", "Ready", "Base", "HEAD", "Delta"})
+	row := []string{s.Package, s.Ready, "-", formatMilliseconds(s.Head), "-"}
+	if s.Base > 0 {
+		row[2] = formatMilliseconds(s.Base)
+		row[4] = fmt.Sprintf("%+.2f%%", 100*s.Ratio)
+	}
+	table.Append(row)
+	table.Render()
+}
+
+func formatMilliseconds(s float64) string {
+	return fmt.Sprintf("%.2fms", s*1000)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseReadiness(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    readiness
+		wantErr string
+	}{
+		{input: "exit", want: readiness{kind: "exit"}},
+		{input: "port:localhost:8080", want: readiness{kind: "port", addr: "localhost:8080"}},
+		{input: "port:", wantErr: "invalid readiness: 'port:': it must be exit, port:ADDR or log:REGEXP"},
+		{input: "log:(", wantErr: "invalid readiness: 'log:(': error parsing regexp"},
+		{input: "ready", wantErr: "invalid readiness: 'ready'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseReadiness(tt.input)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	got, err := parseReadiness("log:^listening on :\\d+")
+	require.NoError(t, err)
+	assert.True(t, got.pattern.MatchString("listening on :8080"))
+}
+
+func Test_startOnce(t *testing.T) {
+	_, err := startOnce("/bin/sh", []string{"-c", "exit 0"}, readiness{kind: "exit"}, time.Second)
+	assert.NoError(t, err)
+	_, err = startOnce("/bin/sh", []string{"-c", "echo broken; exit 1"}, readiness{kind: "exit"}, time.Second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "broken")
+
+	ready, err := parseReadiness("log:^ready$")
+	require.NoError(t, err)
+	d, err := startOnce("/bin/sh", []string{"-c", "echo starting; echo ready; exec sleep 10"}, ready, 5*time.Second)
+	require.NoError(t, err)
+	assert.True(t, d < 5*time.Second)
+	_, err = startOnce("/bin/sh", []string{"-c", "echo starting"}, ready, 5*time.Second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exited before it printed a line matching '^ready$'")
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	_, err = startOnce("/bin/sh", []string{"-c", "exec sleep 10"}, readiness{kind: "port", addr: l.Addr().String()}, time.Second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already accepts connections")
+}
+
+func Test_showStartup(t *testing.T) {
+	w := &bytes.Buffer{}
+	showStartup(w, newStartup("./cmd/server", "exit", 20*time.Millisecond, 30*time.Millisecond), "ascii")
+	got := w.String()
+	assert.Contains(t, got, "Startup time\n============")
+	assert.Contains(t, got, "| ./cmd/server | exit  | 20.00ms | 30.00ms | +50.00% |")
+}
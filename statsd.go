@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+// maxUDPPayload keeps datagrams below the typical MTU so that they are not fragmented.
+const maxUDPPayload = 1432
+
+var metricPathEscaper = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// metricPath returns a dot-separated metric path, e.g. "cob.BenchmarkA_n_1.ns_per_op".
+func metricPath(m metric) string {
+	return "cob." + metricPathEscaper.ReplaceAllString(m.Benchmark, "_") + "." + m.Name
+}
+
+// generateStatsDLines renders the metrics as StatsD gauges.
+func generateStatsDLines(rep report.Report) []string {
+	var lines []string
+	for _, m := range benchmarkMetrics(rep) {
+		lines = append(lines, fmt.Sprintf("%s:%g|g", metricPath(m), m.Value))
+	}
+	return lines
+}
+
+// generateGraphiteLines renders the metrics in the Graphite plaintext protocol.
+func generateGraphiteLines(rep report.Report, now time.Time) []string {
+	var lines []string
+	for _, m := range benchmarkMetrics(rep) {
+		lines = append(lines, fmt.Sprintf("%s %g %d", metricPath(m), m.Value, now.Unix()))
+	}
+	return lines
+}
+
+// sendUDP sends the lines to addr, packing as many lines as fit into each datagram.
+func sendUDP(addr string, lines []string) error {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return xerrors.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	var packet []byte
+	flush := func() error {
+		if len(packet) == 0 {
+			return nil
+		}
+		if _, err := conn.Write(packet); err != nil {
+			return xerrors.Errorf("failed to send metrics to %s: %w", addr, err)
+		}
+		packet = packet[:0]
+		return nil
+	}
+	for _, line := range lines {
+		if len(packet) > 0 && len(packet)+len(line)+1 > maxUDPPayload {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+		packet = append(packet, line...)
+		packet = append(packet, '\n')
+	}
+	if err = flush(); err != nil {
+		return err
+	}
+	debugf("udp: sent %d metrics to %s", len(lines), addr)
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var udpReport = report.Report{
+	Benchmarks: []report.Benchmark{{Name: "BenchmarkA/n=1", Head: report.Measurement{NsPerOp: 150}}},
+}
+
+func Test_generateStatsDLines(t *testing.T) {
+	got := generateStatsDLines(udpReport)
+	require.Len(t, got, 6)
+	assert.Equal(t, "cob.BenchmarkA_n_1.ns_per_op:150|g", got[0])
+}
+
+func Test_generateGraphiteLines(t *testing.T) {
+	got := generateGraphiteLines(udpReport, time.Unix(100, 0))
+	require.Len(t, got, 6)
+	assert.Equal(t, "cob.BenchmarkA_n_1.ns_per_op 150 100", got[0])
+}
+
+func Test_sendUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	line := strings.Repeat("a", 1420)
+	require.NoError(t, sendUDP(conn.LocalAddr().String(), []string{"x:1|g", line, "y:2|g"}))
+
+	var packets []string
+	buf := make([]byte, 2*maxUDPPayload)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	for i := 0; i < 2; i++ {
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		packets = append(packets, string(buf[:n]))
+	}
+	assert.Equal(t, []string{"x:1|g\n" + line + "\n", "y:2|g\n"}, packets)
+}
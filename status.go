@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/knqyf263/cob/pkg/cob"
+	"github.com/knqyf263/cob/pkg/report"
+)
+
+type status int
+
+const (
+	statusOK status = iota
+	statusWarn
+	statusFail
+)
+
+// benchmarkStatus returns statusFail if the benchmark gets worse than the threshold,
+// statusWarn if it gets worse within the threshold and statusOK otherwise.
+func benchmarkStatus(r result, threshold float64, comparedScore comparedScore) status {
+	switch cob.StatusOf(r, threshold, comparedScore.cobScore()) {
+	case cob.StatusFail:
+		return statusFail
+	case cob.StatusWarn:
+		return statusWarn
+	default:
+		return statusOK
+	}
+}
+
+func (s status) String() string {
+	switch s {
+	case statusFail:
+		return report.StatusFail
+	case statusWarn:
+		return report.StatusWarn
+	default:
+		return report.StatusOK
+	}
+}
+
+func (s status) marker(ascii bool) string {
+	switch s {
+	case statusFail:
+		if ascii {
+			return "FAIL"
+		}
+		return "❌"
+	case statusWarn:
+		if ascii {
+			return "WARN"
+		}
+		return "⚠️"
+	default:
+		if ascii {
+			return "OK"
+		}
+		return "✅"
+	}
+}
+
+// verdict summarizes whether the benchmarks passed.
+func verdict(results []result, threshold float64, comparedScore comparedScore) (status, string) {
+	var failed, warned int
+	for _, r := range results {
+		switch benchmarkStatus(r, threshold, comparedScore) {
+		case statusFail:
+			failed++
+		case statusWarn:
+			warned++
+		}
+	}
+	return verdictOf(failed, warned, threshold)
+}
+
+// verdictOf is the verdict of the numbers of benchmarks which failed and which got worse within the threshold.
+func verdictOf(failed, warned int, threshold float64) (status, string) {
+	switch {
+	case failed > 0:
+		return statusFail, fmt.Sprintf("FAIL: %d benchmark(s) got worse than the threshold (%.2f%%)", failed, 100*threshold)
+	case warned > 0:
+		return statusWarn, fmt.Sprintf("PASS: %d benchmark(s) got worse within the threshold (%.2f%%)", warned, 100*threshold)
+	default:
+		return statusOK, "PASS: no benchmarks got worse"
+	}
+}
+
+// showVerdict prints a final banner summarizing whether the benchmarks passed.
+func showVerdict(w io.Writer, results []result, threshold float64, comparedScore comparedScore, ascii bool) {
+	s, message := verdict(results, threshold, comparedScore)
+	if ascii {
+		fmt.Fprintln(w, message)
+		return
+	}
+	fmt.Fprintf(w, "%s %s\n", s.marker(ascii), message)
+}
+
+// worstRatio returns the largest ratio among the compared scores.
+func worstRatio(r result, comparedScore comparedScore) float64 {
+	var ratios []float64
+	if comparedScore.nsPerOp {
+		ratios = append(ratios, r.RatioNsPerOp)
+	}
+	if comparedScore.allocedBytesPerOp {
+		ratios = append(ratios, r.RatioAllocedBytesPerOp)
+	}
+	if len(ratios) == 0 {
+		return 0
+	}
+	worst := ratios[0]
+	for _, ratio := range ratios[1:] {
+		if ratio > worst {
+			worst = ratio
+		}
+	}
+	return worst
+}
+
+func isImprovement(r result, comparedScore comparedScore) bool {
+	return (comparedScore.nsPerOp && r.RatioNsPerOp <= -0.0001) ||
+		(comparedScore.allocedBytesPerOp && r.RatioAllocedBytesPerOp <= -0.0001)
+}
+
+// showSummaryLine prints a single line summarizing the comparison so that it can be parsed by scripts.
+func showSummaryLine(w io.Writer, results []result, threshold float64, comparedScore comparedScore, added, removed []string) {
+	var regressed, improved, unchanged int
+	var worst *result
+	for i, r := range results {
+		ratio := worstRatio(r, comparedScore)
+		switch {
+		case isDegression(r, threshold, comparedScore):
+			regressed++
+		case ratio < 0.0001 && isImprovement(r, comparedScore):
+			improved++
+		default:
+			unchanged++
+		}
+		if worst == nil || ratio > worstRatio(*worst, comparedScore) {
+			worst = &results[i]
+		}
+	}
+
+	line := fmt.Sprintf("cob: %d regressed, %d improved, %d unchanged", regressed, improved, unchanged)
+	// The counts are only added if there are any, so that the line of a run without any stays the same.
+	if len(added) > 0 || len(removed) > 0 {
+		line += fmt.Sprintf(", %d new, %d removed", len(added), len(removed))
+	}
+	if worst != nil {
+		line += fmt.Sprintf(", worst=%+.1f%% (%s)", 100*worstRatio(*worst, comparedScore), worst.Name)
+	}
+	fmt.Fprintln(w, line)
+}
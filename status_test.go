@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_benchmarkStatus(t *testing.T) {
+	compare := comparedScore{nsPerOp: true, allocedBytesPerOp: true}
+	tests := []struct {
+		name    string
+		result  result
+		compare comparedScore
+		want    status
+	}{
+		{
+			name:    "better",
+			result:  result{RatioNsPerOp: -0.1, RatioAllocedBytesPerOp: 0},
+			compare: compare,
+			want:    statusOK,
+		},
+		{
+			name:    "worse within the threshold",
+			result:  result{RatioNsPerOp: 0.1, RatioAllocedBytesPerOp: -0.1},
+			compare: compare,
+			want:    statusWarn,
+		},
+		{
+			name:    "worse than the threshold",
+			result:  result{RatioNsPerOp: -0.1, RatioAllocedBytesPerOp: 0.3},
+			compare: compare,
+			want:    statusFail,
+		},
+		{
+			name:    "within the threshold of the annotation",
+			result:  result{RatioNsPerOp: 0.3, Threshold: floatPtr(0.5)},
+			compare: compare,
+			want:    statusWarn,
+		},
+		{
+			name:    "worse than the threshold of the annotation",
+			result:  result{RatioNsPerOp: 0.1, Threshold: floatPtr(0.05)},
+			compare: compare,
+			want:    statusFail,
+		},
+		{
+			name:    "worse than the threshold, but not significantly",
+			result:  result{RatioNsPerOp: 0.3, PValue: &report.PValue{NsPerOp: 0.2, AllocedBytesPerOp: 1}, Alpha: 0.05},
+			compare: compare,
+			want:    statusWarn,
+		},
+		{
+			name:    "significantly worse than the threshold",
+			result:  result{RatioNsPerOp: 0.3, PValue: &report.PValue{NsPerOp: 0.001, AllocedBytesPerOp: 1}, Alpha: 0.05},
+			compare: compare,
+			want:    statusFail,
+		},
+		{
+			name:    "not compared",
+			result:  result{RatioNsPerOp: 0.3},
+			compare: comparedScore{allocedBytesPerOp: true},
+			want:    statusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := benchmarkStatus(tt.result, 0.2, tt.compare)
+			assert.Equal(t, tt.want, got, tt.name)
+		})
+	}
+}
+
+func Test_showVerdict(t *testing.T) {
+	compare := comparedScore{nsPerOp: true, allocedBytesPerOp: true}
+	tests := []struct {
+		name    string
+		results []result
+		ascii   bool
+		want    string
+	}{
+		{
+			name:    "pass",
+			results: []result{{RatioNsPerOp: -0.1}},
+			want:    "✅ PASS: no benchmarks got worse\n",
+		},
+		{
+			name:    "warn",
+			results: []result{{RatioNsPerOp: 0.1}, {RatioNsPerOp: -0.1}},
+			want:    "⚠️ PASS: 1 benchmark(s) got worse within the threshold (20.00%)\n",
+		},
+		{
+			name:    "fail ascii",
+			results: []result{{RatioNsPerOp: 0.3}, {RatioAllocedBytesPerOp: 0.5}},
+			ascii:   true,
+			want:    "FAIL: 2 benchmark(s) got worse than the threshold (20.00%)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			showVerdict(w, tt.results, 0.2, compare, tt.ascii)
+			assert.Equal(t, tt.want, w.String(), tt.name)
+		})
+	}
+}
+
+func Test_showSummaryLine(t *testing.T) {
+	compare := comparedScore{nsPerOp: true, allocedBytesPerOp: true}
+	tests := []struct {
+		name    string
+		results []result
+		added   []string
+		removed []string
+		want    string
+	}{
+		{
+			name: "happy path",
+			results: []result{
+				{Name: "BenchmarkA", RatioNsPerOp: 0.184, RatioAllocedBytesPerOp: 0.3},
+				{Name: "BenchmarkB", RatioNsPerOp: -0.1},
+				{Name: "BenchmarkC", RatioNsPerOp: 0.05},
+				{Name: "BenchmarkD"},
+			},
+			want: "cob: 1 regressed, 1 improved, 2 unchanged, worst=+30.0% (BenchmarkA)\n",
+		},
+		{
+			name:    "new and removed benchmarks",
+			results: []result{{Name: "BenchmarkA", RatioNsPerOp: 0.05}},
+			added:   []string{"BenchmarkB"},
+			removed: []string{"BenchmarkC", "BenchmarkD"},
+			want:    "cob: 0 regressed, 0 improved, 1 unchanged, 1 new, 2 removed, worst=+5.0% (BenchmarkA)\n",
+		},
+		{
+			name: "no benchmark",
+			want: "cob: 0 regressed, 0 improved, 0 unchanged\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			showSummaryLine(w, tt.results, 0.2, compare, tt.added, tt.removed)
+			assert.Equal(t, tt.want, w.String(), tt.name)
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/tools/benchmark/parse"
+	"golang.org/x/xerrors"
+)
+
+// store records runs so that they can be shared and used as baselines.
+type store interface {
+	put(run historyRun) error
+	// runs returns the recorded runs, oldest first.
+	runs() ([]historyRun, error)
+	// latest returns the latest run on machines with the tag which measured the commit at HEAD, or nil if
+	// there is none.
+	latest(commit, tag string) (*historyRun, error)
+	// update replaces the runs with those returned by fn, e.g. for pruning, without losing runs recorded
+	// concurrently by other processes.
+	update(fn func(runs []historyRun) []historyRun) error
+}
+
+// rewritableStore is a store which can replace all its runs.
+type rewritableStore interface {
+	runs() ([]historyRun, error)
+	rewrite(runs []historyRun) error
+}
+
+// updateRuns rewrites the runs of the store if fn changed them.
+func updateRuns(s rewritableStore, fn func(runs []historyRun) []historyRun) error {
+	runs, err := s.runs()
+	if err != nil {
+		return err
+	}
+	updated := fn(runs)
+	if reflect.DeepEqual(updated, runs) {
+		return nil
+	}
+	return s.rewrite(updated)
+}
+
+var (
+	errObjectNotFound = xerrors.New("object not found")
+	errObjectChanged  = xerrors.New("object changed")
+)
+
+// conditionalHeader returns the HTTP headers of a write which S3 and Blob Storage only accept if the object
+// still has the ETag, or if it doesn't exist for an empty ETag.
+func conditionalHeader(etag string) http.Header {
+	if etag == "" {
+		return http.Header{"If-None-Match": {"*"}}
+	}
+	return http.Header{"If-Match": {etag}}
+}
+
+func isConditional(header http.Header) bool {
+	return header.Get("If-Match") != "" || header.Get("If-None-Match") != ""
+}
+
+// objectStorePutAttempts is how many times a baseline is written when runs of the same commit race.
+const objectStorePutAttempts = 5
+
+// objectStorage is a bucket of a cloud storage service.
+type objectStorage interface {
+	putObject(key string, body []byte) error
+	// getObject returns errObjectNotFound if the key doesn't exist.
+	getObject(key string) ([]byte, error)
+	// getObjectVersion is getObject which also returns the version of the object, e.g. its ETag.
+	getObjectVersion(key string) ([]byte, string, error)
+	// putObjectIf writes the object only if it still has the version, or if it doesn't exist for an empty
+	// version. Otherwise, it returns errObjectChanged.
+	putObjectIf(key string, body []byte, version string) error
+	listObjects(prefix string) ([]string, error)
+	deleteObject(key string) error
+}
+
+// objectStore records each run as an object under "runs/", named by the time so that listing returns
+// them in order, and the latest run of each commit under "commits/" for looking up baselines.
+type objectStore struct {
+	storage objectStorage
+	prefix  string
+}
+
+func (s objectStore) key(elem ...string) string {
+	return strings.TrimPrefix(strings.TrimSuffix(s.prefix, "/")+"/"+strings.Join(elem, "/"), "/")
+}
+
+func (s objectStore) runKey(run historyRun) string {
+	name := fmt.Sprintf("%s-%s", run.Time.UTC().Format("20060102T150405.000000000Z"), shortHash(run.Report.Head.Hash))
+	if run.Rollup > 0 {
+		name += "-rollup"
+	}
+	return s.key("runs", name+".json")
+}
+
+// commitKey is "commits/<hash>.json", or "commits/<tag>/<hash>.json" for runs on tagged machines.
+func (s objectStore) commitKey(commit, tag string) string {
+	if tag == "" {
+		return s.key("commits", commit+".json")
+	}
+	return s.key("commits", tag, commit+".json")
+}
+
+func (s objectStore) put(run historyRun) error {
+	b, err := json.Marshal(run)
+	if err != nil {
+		return xerrors.Errorf("failed to encode the run: %w", err)
+	}
+	// Keys of runs are unique, so a run never overwrites another one.
+	if err = s.storage.putObjectIf(s.runKey(run), b, ""); err != nil {
+		return err
+	}
+	return s.putBaseline(run, b)
+}
+
+// putBaseline makes the run the baseline of its commit, unless a later run of the commit already is. Runners
+// measuring the same commit may finish at the same time, so the baseline is only written if it didn't change
+// since it was read.
+func (s objectStore) putBaseline(run historyRun, b []byte) error {
+	key := s.commitKey(run.Report.Head.Hash, run.Machine.Tag)
+	for attempt := 1; ; attempt++ {
+		current, version, err := s.storage.getObjectVersion(key)
+		if xerrors.Is(err, errObjectNotFound) {
+			version = ""
+		} else if err != nil {
+			return err
+		} else {
+			var stored historyRun
+			if json.Unmarshal(current, &stored) == nil && stored.Time.After(run.Time) {
+				debugf("store: keeping the later baseline of %s", run.Report.Head.Hash)
+				return nil
+			}
+		}
+		err = s.storage.putObjectIf(key, b, version)
+		if !xerrors.Is(err, errObjectChanged) {
+			return err
+		}
+		if attempt == objectStorePutAttempts {
+			return xerrors.Errorf("failed to update the baseline of %s: %w", run.Report.Head.Hash, err)
+		}
+		debugf("store: the baseline of %s changed, retrying", run.Report.Head.Hash)
+	}
+}
+
+// update replaces the runs which fn was given with those it returns. Only the objects fn saw can be deleted, so
+// runs which other runners record meanwhile are left alone.
+func (s objectStore) update(fn func(runs []historyRun) []historyRun) error {
+	runs, keys, err := s.readRuns()
+	if err != nil {
+		return err
+	}
+	updated := fn(runs)
+	if reflect.DeepEqual(updated, runs) {
+		return nil
+	}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		seen[key] = true
+	}
+	return s.replace(seen, updated)
+}
+
+// replace writes new runs and rollups, and then deletes the objects in seen which aren't among runs together with
+// the baselines which refer to them. Nothing is deleted until all the objects are written, so a failure leaves the
+// history as it was, with at most some rollups next to the runs they replace. Rollups are not written to
+// "commits/", as they can't be a baseline.
+func (s objectStore) replace(seen map[string]bool, runs []historyRun) error {
+	keep := map[string]bool{}
+	for _, run := range runs {
+		key := s.runKey(run)
+		keep[key] = true
+		if seen[key] && run.Rollup == 0 {
+			continue
+		}
+		b, err := json.Marshal(run)
+		if err != nil {
+			return xerrors.Errorf("failed to encode the run: %w", err)
+		}
+		if err = s.storage.putObject(key, b); err != nil {
+			return err
+		}
+	}
+	deleted := map[string]bool{}
+	for key := range seen {
+		if keep[key] {
+			continue
+		}
+		if err := s.storage.deleteObject(key); err != nil {
+			return err
+		}
+		deleted[key] = true
+	}
+
+	keys, err := s.storage.listObjects(s.key("commits") + "/")
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		b, err := s.storage.getObject(key)
+		if xerrors.Is(err, errObjectNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		var run historyRun
+		if err = json.Unmarshal(b, &run); err != nil {
+			return xerrors.Errorf("failed to decode %s: %w", key, err)
+		}
+		if !deleted[s.runKey(run)] {
+			continue
+		}
+		if err = s.storage.deleteObject(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s objectStore) runs() ([]historyRun, error) {
+	runs, _, err := s.readRuns()
+	return runs, err
+}
+
+// readRuns returns the runs together with the keys of their objects.
+func (s objectStore) readRuns() ([]historyRun, []string, error) {
+	keys, err := s.storage.listObjects(s.key("runs") + "/")
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(keys)
+	var runs []historyRun
+	for _, key := range keys {
+		b, err := s.storage.getObject(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		var run historyRun
+		if err = json.Unmarshal(b, &run); err != nil {
+			return nil, nil, xerrors.Errorf("failed to decode %s: %w", key, err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, keys, nil
+}
+
+func (s objectStore) latest(commit, tag string) (*historyRun, error) {
+	b, err := s.storage.getObject(s.commitKey(commit, tag))
+	if xerrors.Is(err, errObjectNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var run historyRun
+	if err = json.Unmarshal(b, &run); err != nil {
+		return nil, xerrors.Errorf("failed to decode the run of %s: %w", commit, err)
+	}
+	return &run, nil
+}
+
+// openStore opens the store at the URI: "git-notes", "s3://bucket/prefix", "gs://bucket/prefix",
+// "azblob://container/prefix", a local path, or "<scheme>://..." of a cob-store-<scheme> plugin.
+func openStore(uri string) (store, error) {
+	if uri == "git-notes" {
+		return newGitNotesStore(".")
+	}
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 { // "C:\..." is a local path
+		return newFileStore(uri), nil
+	}
+	switch u.Scheme {
+	case "s3", "gs", "azblob":
+		storage, err := newObjectStorage(u.Scheme, u.Host)
+		if err != nil {
+			return nil, err
+		}
+		return objectStore{storage: storage, prefix: strings.TrimPrefix(u.Path, "/")}, nil
+	case "file":
+		return newFileStore(u.Path), nil
+	default:
+		return openPluginStore(uri, u.Scheme, exec.LookPath)
+	}
+}
+
+// newObjectStorage returns the storage of the "s3", "gs" or "azblob" scheme for the bucket or container.
+func newObjectStorage(scheme, bucket string) (objectStorage, error) {
+	switch scheme {
+	case "s3":
+		return newS3Storage(newHTTPClient(), bucket)
+	case "gs":
+		return newGCSStorage(newHTTPClient(), bucket)
+	default:
+		return newAzureBlobStorage(newHTTPClient(), bucket)
+	}
+}
+
+// baselineSet converts the HEAD results of a stored run into benchmark results.
+func baselineSet(run historyRun) parse.Set {
+	set := parse.Set{}
+	for _, b := range run.Report.Benchmarks {
+		set[b.Name] = append(set[b.Name], newBenchmark(b.Name, b.Head))
+	}
+	return set
+}
+
+func newBenchmark(name string, m report.Measurement) *parse.Benchmark {
+	return &parse.Benchmark{
+		Name:              name,
+		N:                 m.Iterations,
+		NsPerOp:           m.NsPerOp,
+		AllocedBytesPerOp: m.AllocedBytesPerOp,
+		AllocsPerOp:       m.AllocsPerOp,
+		MBPerS:            m.MBPerS,
+		Measured:          parse.NsPerOp | parse.AllocedBytesPerOp | parse.AllocsPerOp | parse.MBPerS,
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// storePluginPrefix is the prefix of the executables in PATH which -store runs for schemes cob doesn't know.
+const storePluginPrefix = "cob-store-"
+
+// pluginStore records runs with an executable, so that runs can be stored in systems which cob doesn't
+// support. The executable is run with an operation and its arguments, and the URI of -store in COB_STORE:
+//
+//	put                  reads a run as JSON on stdin and records it
+//	runs                 writes the recorded runs, oldest first, as JSON Lines
+//	latest COMMIT TAG    writes the latest run of the commit on machines with the tag (empty for none), or nothing
+//	rewrite              reads the runs as JSON Lines on stdin and replaces the recorded runs with them
+//
+// A non-zero exit status fails the operation with the stderr of the executable.
+type pluginStore struct {
+	path string
+	uri  string
+}
+
+// openPluginStore returns the store of "cob-store-<scheme>", which is looked up in PATH with lookPath.
+func openPluginStore(uri, scheme string, lookPath func(string) (string, error)) (pluginStore, error) {
+	path, err := lookPath(storePluginPrefix + scheme)
+	if err != nil {
+		return pluginStore{}, xerrors.Errorf("unsupported store: %s (%s%s is not found in PATH)", uri, storePluginPrefix, scheme)
+	}
+	return pluginStore{path: path, uri: uri}, nil
+}
+
+func (s pluginStore) exec(stdin []byte, args ...string) ([]byte, error) {
+	debugf("exec: %s %s", s.path, strings.Join(args, " "))
+	cmd := exec.Command(s.path, args...)
+	cmd.Env = append(os.Environ(), "COB_STORE="+s.uri)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, xerrors.Errorf("failed to run '%s %s': %w: %s", s.path, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+func (s pluginStore) put(run historyRun) error {
+	b, err := json.Marshal(run)
+	if err != nil {
+		return xerrors.Errorf("failed to encode the run: %w", err)
+	}
+	_, err = s.exec(b, "put")
+	return err
+}
+
+func (s pluginStore) runs() ([]historyRun, error) {
+	out, err := s.exec(nil, "runs")
+	if err != nil {
+		return nil, err
+	}
+	return readRuns(bytes.NewReader(out))
+}
+
+func (s pluginStore) latest(commit, tag string) (*historyRun, error) {
+	out, err := s.exec(nil, "latest", commit, tag)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	var run historyRun
+	if err = json.Unmarshal(out, &run); err != nil {
+		return nil, xerrors.Errorf("failed to decode the run of %s: %w", commit, err)
+	}
+	return &run, nil
+}
+
+func (s pluginStore) update(fn func(runs []historyRun) []historyRun) error {
+	return updateRuns(s, fn)
+}
+
+func (s pluginStore) rewrite(runs []historyRun) error {
+	var b bytes.Buffer
+	for _, run := range runs {
+		line, err := json.Marshal(run)
+		if err != nil {
+			return xerrors.Errorf("failed to encode the run: %w", err)
+		}
+		b.Write(append(line, '\n'))
+	}
+	_, err := s.exec(b.Bytes(), "rewrite")
+	return err
+}
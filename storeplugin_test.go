@@ -0,0 +1,87 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storePluginScript is a plugin which records runs in the file of COB_STORE.
+const storePluginScript = `#!/bin/sh
+f=${COB_STORE#test://}
+case $1 in
+put) cat >> "$f"; echo >> "$f" ;;
+runs) [ ! -f "$f" ] || cat "$f" ;;
+latest) [ ! -f "$f" ] || grep "\"head\":{\"ref\":\"HEAD\",\"hash\":\"$2\"" "$f" | tail -n 1 ;;
+rewrite) cat > "$f" ;;
+*) echo "unknown operation: $1" >&2; exit 1 ;;
+esac
+`
+
+func Test_pluginStore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the plugin is a shell script")
+	}
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cob-store-test")
+	require.NoError(t, ioutil.WriteFile(path, []byte(storePluginScript), 0755))
+
+	lookPath := func(file string) (string, error) {
+		if file == "cob-store-test" {
+			return path, nil
+		}
+		return "", exec.ErrNotFound
+	}
+	_, err = openPluginStore("sqlite:///tmp/cob.db", "sqlite", lookPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported store: sqlite:///tmp/cob.db (cob-store-sqlite is not found in PATH)")
+
+	s, err := openPluginStore("test://"+filepath.Join(dir, "runs.jsonl"), "test", lookPath)
+	require.NoError(t, err)
+
+	runs, err := s.runs()
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	newRun := func(hash string, day int) historyRun {
+		return historyRun{
+			Time:   time.Date(2020, 1, day, 0, 0, 0, 0, time.UTC),
+			Report: report.Report{Head: report.Commit{Ref: "HEAD", Hash: hash}},
+		}
+	}
+	require.NoError(t, s.put(newRun("aaaaaaaaaa", 1)))
+	require.NoError(t, s.put(newRun("bbbbbbbbbb", 2)))
+	require.NoError(t, s.put(newRun("aaaaaaaaaa", 3)))
+
+	runs, err = s.runs()
+	require.NoError(t, err)
+	assert.Equal(t, []historyRun{newRun("aaaaaaaaaa", 1), newRun("bbbbbbbbbb", 2), newRun("aaaaaaaaaa", 3)}, runs)
+
+	latest, err := s.latest("aaaaaaaaaa", "")
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, newRun("aaaaaaaaaa", 3), *latest)
+
+	latest, err = s.latest("cccccccccc", "")
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+
+	require.NoError(t, s.update(func(runs []historyRun) []historyRun { return runs[1:] }))
+	runs, err = s.runs()
+	require.NoError(t, err)
+	assert.Equal(t, []historyRun{newRun("bbbbbbbbbb", 2), newRun("aaaaaaaaaa", 3)}, runs)
+
+	_, err = s.exec(nil, "delete")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown operation: delete")
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/olekukonko/tablewriter"
+)
+
+func isTableStyle(style string) bool {
+	switch style {
+	case "ascii", "unicode", "compact", "markdown":
+		return true
+	}
+	return false
+}
+
+// applyTableStyle changes borders and separators of the table. "ascii" keeps the tablewriter defaults.
+func applyTableStyle(table *tablewriter.Table, style string) {
+	switch style {
+	case "unicode":
+		table.SetCenterSeparator("┼")
+		table.SetColumnSeparator("│")
+		table.SetRowSeparator("─")
+	case "compact":
+		table.SetBorder(false)
+		table.SetHeaderLine(false)
+		table.SetRowLine(false)
+		table.SetColumnSeparator(" ")
+	case "markdown":
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.SetCenterSeparator("|")
+		table.SetRowLine(false)
+		table.SetAutoMergeCells(false)
+	}
+}
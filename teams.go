@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"golang.org/x/xerrors"
+)
+
+var teamsColors = map[status]string{
+	statusOK:   "Good",
+	statusWarn: "Warning",
+	statusFail: "Attention",
+}
+
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string      `json:"contentType"`
+	Content     interface{} `json:"content"`
+}
+
+// generateTeamsMessage creates a message with an Adaptive Card. The card links to reportURL, or to the CI run
+// if reportURL is empty.
+func generateTeamsMessage(results []result, commit string, threshold float64, comparedScore comparedScore, reportURL string) teamsMessage {
+	s, message := verdict(results, threshold, comparedScore)
+	body := []map[string]interface{}{
+		{
+			"type":   "TextBlock",
+			"text":   fmt.Sprintf("%s cob: %s", s.marker(false), shortHash(commit)),
+			"weight": "Bolder",
+			"size":   "Medium",
+		},
+		{
+			"type":  "TextBlock",
+			"text":  message,
+			"color": teamsColors[s],
+			"wrap":  true,
+		},
+	}
+
+	regressions := topRegressions(results, comparedScore, maxNotifiedRegressions)
+	if len(regressions) > 0 {
+		var facts []map[string]string
+		for _, r := range regressions {
+			facts = append(facts, map[string]string{
+				"title": r.Name,
+				"value": generateDiffRatio(r, comparedScore, columns{nsPerOp: true, allocedBytesPerOp: true}),
+			})
+		}
+		body = append(body,
+			map[string]interface{}{"type": "TextBlock", "text": "Worst regressions", "weight": "Bolder"},
+			map[string]interface{}{"type": "FactSet", "facts": facts},
+		)
+	}
+
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	if reportURL != "" {
+		card["actions"] = []map[string]string{{"type": "Action.OpenUrl", "title": "Full report", "url": reportURL}}
+	}
+
+	return teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content:     card,
+		}},
+	}
+}
+
+func postTeamsMessage(webhookURL string, msg teamsMessage) error {
+	c := newRESTClient("Teams", webhookURL, nil)
+	if err := c.do(http.MethodPost, "", msg, nil); err != nil {
+		return xerrors.Errorf("failed to send a Teams message: %w", err)
+	}
+	infof("Sent the result to Microsoft Teams")
+	return nil
+}
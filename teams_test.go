@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_generateTeamsMessage(t *testing.T) {
+	compare := comparedScore{nsPerOp: true, allocedBytesPerOp: true}
+	results := []result{{Name: "BenchmarkA", RatioNsPerOp: 0.5}}
+	msg := generateTeamsMessage(results, "599a5523729d4d99a331b9d3f71dde9e1e6daef0", 0.2, compare, "https://ci.example.com/report.html")
+
+	b, err := json.Marshal(msg)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "type": "message",
+  "attachments": [{
+    "contentType": "application/vnd.microsoft.card.adaptive",
+    "content": {
+      "$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+      "type": "AdaptiveCard",
+      "version": "1.4",
+      "body": [
+        {"type": "TextBlock", "text": "❌ cob: 599a552", "weight": "Bolder", "size": "Medium"},
+        {"type": "TextBlock", "text": "FAIL: 1 benchmark(s) got worse than the threshold (20.00%)", "color": "Attention", "wrap": true},
+        {"type": "TextBlock", "text": "Worst regressions", "weight": "Bolder"},
+        {"type": "FactSet", "facts": [{"title": "BenchmarkA", "value": "ns/op +50.00%, B/op +0.00%"}]}
+      ],
+      "actions": [{"type": "Action.OpenUrl", "title": "Full report", "url": "https://ci.example.com/report.html"}]
+    }
+  }]
+}`, string(b))
+}
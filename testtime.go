@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/xerrors"
+)
+
+// testTimeArgs runs only the tests, bypassing the test cache so that every package is timed.
+var testTimeArgs = []string{"test", "-count=1", "-json", "./..."}
+
+// testTiming is how long the tests took at a commit.
+type testTiming struct {
+	total time.Duration
+	// packages is the elapsed seconds of each package reported by "go test -json".
+	packages map[string]float64
+}
+
+// runTestTiming runs the tests and times them. Failing tests are still timed, so that a commit which breaks
+// tests doesn't hide the change of their duration.
+func runTestTiming() (*testTiming, error) {
+	debugf("exec: go %s", strings.Join(testTimeArgs, " "))
+	started := time.Now()
+	out, err := exec.Command("go", testTimeArgs...).Output()
+	total := time.Since(started)
+	if _, ok := err.(*exec.ExitError); err != nil && !ok {
+		return nil, xerrors.Errorf("failed to run 'go %s' command: %w", strings.Join(testTimeArgs, " "), err)
+	}
+	packages, perr := parseTestEvents(bytes.NewReader(out))
+	if perr != nil {
+		return nil, xerrors.Errorf("failed to parse the output of tests: %w", perr)
+	}
+	if err != nil {
+		if len(packages) == 0 {
+			return nil, xerrors.Errorf("failed to run 'go %s' command: %w", strings.Join(testTimeArgs, " "), err)
+		}
+		warnf("Some tests failed, but they are timed anyway")
+	}
+	return &testTiming{total: total, packages: packages}, nil
+}
+
+// parseTestEvents returns the elapsed seconds of each package which passed or failed in the output of
+// "go test -json". Lines which are not JSON, e.g. build errors, are skipped.
+func parseTestEvents(r io.Reader) (map[string]float64, error) {
+	packages := map[string]float64{}
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for s.Scan() {
+		var e struct {
+			Action  string
+			Package string
+			Test    string
+			Elapsed float64
+		}
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			continue
+		}
+		if e.Test == "" && e.Package != "" && (e.Action == "pass" || e.Action == "fail") {
+			packages[e.Package] = e.Elapsed
+		}
+	}
+	return packages, s.Err()
+}
+
+// newTestTime compares the test durations. base is nil if the base commit wasn't tested.
+func newTestTime(base, head *testTiming) *report.TestTime {
+	tt := &report.TestTime{Head: head.total.Seconds()}
+	if base != nil {
+		tt.Base = base.total.Seconds()
+		tt.Ratio = calcRatio(tt.Head, tt.Base)
+	}
+	var names []string
+	for name := range head.packages {
+		names = append(names, name)
+	}
+	if base != nil {
+		for name := range base.packages {
+			if _, ok := head.packages[name]; !ok {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := report.PackageTestTime{Name: name, Head: head.packages[name]}
+		if base != nil {
+			p.Base = base.packages[name]
+			p.Ratio = calcRatio(p.Head, p.Base)
+		}
+		tt.Packages = append(tt.Packages, p)
+	}
+	return tt
+}
+
+func showTestTime(w io.Writer, tt *report.TestTime, style string) {
+	if tt == nil {
+		return
+	}
+	fmt.Fprintln(w, "\nTest time")
+	fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 9))
+
+	table := tablewriter.NewWriter(w)
+	table.SetAutoFormatHeaders(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	applyTableStyle(table, style)
+	table.SetHeader([]string{"Package", "Base", "HEAD", "Delta"})
+	for _, p := range tt.Packages {
+		table.Append(testTimeRow(p.Name, p.Base, p.Head, p.Ratio))
+	}
+	table.SetFooter(testTimeRow("total", tt.Base, tt.Head, tt.Ratio))
+	table.Render()
+}
+
+func testTimeRow(name string, base, head, ratio float64) []string {
+	row := []string{name, "-", formatSeconds(head), "-"}
+	if base > 0 {
+		row[1] = formatSeconds(base)
+		row[3] = fmt.Sprintf("%+.2f%%", 100*ratio)
+	}
+	return row
+}
+
+func formatSeconds(s float64) string {
+	return strconv.FormatFloat(s, 'f', 2, 64) + "s"
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseTestEvents(t *testing.T) {
+	out := `{"Action":"run","Package":"github.com/knqyf263/cob","Test":"Test_calcRatio"}
+{"Action":"pass","Package":"github.com/knqyf263/cob","Test":"Test_calcRatio","Elapsed":0.01}
+{"Action":"pass","Package":"github.com/knqyf263/cob","Elapsed":1.5}
+{"Action":"fail","Package":"github.com/knqyf263/cob/pkg/report","Elapsed":0.25}
+{"Action":"skip","Package":"github.com/knqyf263/cob/grafana","Elapsed":0}
+# github.com/knqyf263/cob/broken
+broken/broken.go:3:1: syntax error
+`
+	got, err := parseTestEvents(strings.NewReader(out))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{
+		"github.com/knqyf263/cob":            1.5,
+		"github.com/knqyf263/cob/pkg/report": 0.25,
+	}, got)
+}
+
+func Test_newTestTime(t *testing.T) {
+	base := &testTiming{total: 2 * time.Second, packages: map[string]float64{"a": 1, "b": 0.5}}
+	head := &testTiming{total: 3 * time.Second, packages: map[string]float64{"a": 2, "c": 0.5}}
+
+	assert.Equal(t, &report.TestTime{
+		Base:  2,
+		Head:  3,
+		Ratio: 0.5,
+		Packages: []report.PackageTestTime{
+			{Name: "a", Base: 1, Head: 2, Ratio: 1},
+			{Name: "b", Base: 0.5, Ratio: -1},
+			{Name: "c", Head: 0.5},
+		},
+	}, newTestTime(base, head))
+
+	assert.Equal(t, &report.TestTime{
+		Head:     3,
+		Packages: []report.PackageTestTime{{Name: "a", Head: 2}, {Name: "c", Head: 0.5}},
+	}, newTestTime(nil, head))
+}
+
+func Test_showTestTime(t *testing.T) {
+	w := &bytes.Buffer{}
+	showTestTime(w, nil, "ascii")
+	assert.Empty(t, w.String())
+
+	showTestTime(w, &report.TestTime{
+		Base: 2, Head: 3, Ratio: 0.5,
+		Packages: []report.PackageTestTime{{Name: "a", Base: 1, Head: 2, Ratio: 1}, {Name: "c", Head: 0.5}},
+	}, "ascii")
+	got := w.String()
+	assert.Contains(t, got, "Test time\n=========")
+	assert.Contains(t, got, "| a       | 1.00s | 2.00s | +100.00% |")
+	assert.Contains(t, got, "| c       | -     | 0.50s | -        |")
+	assert.Contains(t, got, "total")
+	assert.Contains(t, got, "+50.00%")
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type phaseTiming struct {
+	Name     string
+	Duration time.Duration
+}
+
+// phaseTimer records how long each phase of a run takes. Starting a phase finishes the previous one.
+type phaseTimer struct {
+	phases  []phaseTiming
+	current string
+	started time.Time
+	now     func() time.Time
+}
+
+func newPhaseTimer() *phaseTimer {
+	return &phaseTimer{now: time.Now}
+}
+
+func (t *phaseTimer) start(name string) {
+	t.stop()
+	t.current = name
+	t.started = t.now()
+}
+
+func (t *phaseTimer) stop() {
+	if t.current == "" {
+		return
+	}
+	t.phases = append(t.phases, phaseTiming{Name: t.current, Duration: t.now().Sub(t.started)})
+	t.current = ""
+}
+
+func (t *phaseTimer) String() string {
+	var items []string
+	var total time.Duration
+	for _, p := range t.phases {
+		items = append(items, fmt.Sprintf("%s %s", p.Name, p.Duration.Round(time.Millisecond)))
+		total += p.Duration
+	}
+	items = append(items, fmt.Sprintf("total %s", total.Round(time.Millisecond)))
+	return strings.Join(items, ", ")
+}
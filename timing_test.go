@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_phaseTimer(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	timer := newPhaseTimer()
+	timer.now = func() time.Time {
+		now = now.Add(1500 * time.Millisecond)
+		return now
+	}
+
+	timer.start("checkout base")
+	timer.start("bench base")
+	timer.stop()
+	timer.stop()
+
+	assert.Equal(t, []phaseTiming{
+		{Name: "checkout base", Duration: 1500 * time.Millisecond},
+		{Name: "bench base", Duration: 1500 * time.Millisecond},
+	}, timer.phases)
+	assert.Equal(t, "checkout base 1.5s, bench base 1.5s, total 3s", timer.String())
+}
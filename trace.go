@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/xerrors"
+)
+
+// traceProfile is the execution trace of a benchmark. It is not a pprof profile, so it has no flamegraph.
+var traceProfile = profileKind{name: "trace", flag: "-trace", ext: ".out"}
+
+// gcStats are the garbage collections of a benchmark run, from GODEBUG=gctrace=1.
+type gcStats struct {
+	cycles int
+	// pause is the stop-the-world time of all cycles in nanoseconds.
+	pause float64
+}
+
+// collectTraces runs each benchmark once more with -trace and writes the traces to dir, e.g.
+// "/tmp/profiles/head". The garbage collections of the run are counted as well. The test binary runs with
+// GODEBUG=gctrace=1 through -exec, so that only its collections are counted and not those of the build. Like
+// collectProfiles, a benchmark which can't be traced only prints a warning.
+func collectTraces(benchmarks []report.Benchmark, dir string) (profileFiles, map[string]gcStats) {
+	files, stats := profileFiles{}, map[string]gcStats{}
+	funcs, err := findBenchmarkFuncs(".")
+	if err != nil {
+		warnf("Failed to trace the benchmarks: %s", err)
+		return files, stats
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		warnf("Failed to trace the benchmarks: %s", err)
+		return files, stats
+	}
+
+	godebug := "gctrace=1"
+	if v := os.Getenv("GODEBUG"); v != "" {
+		godebug = v + "," + godebug
+	}
+	kind := traceProfile
+	kind.args = []string{"-exec", "env GODEBUG=" + godebug}
+	for _, b := range benchmarks {
+		fn, ok := funcs[benchmarkFuncName(b.Name)]
+		if !ok {
+			warnf("Failed to trace %s: the benchmark function is not found", b.Name)
+			continue
+		}
+		kindFiles, out, err := runProfiled(b, "./"+path.Dir(fn.Path), dir, []profileKind{kind})
+		if err != nil {
+			warnf("Failed to trace %s: %s", b.Name, err)
+			continue
+		}
+		files[b.Name] = kindFiles
+		stats[b.Name] = parseGCTrace(bytes.NewReader(out))
+	}
+	return files, stats
+}
+
+// gcTraceRegexp matches a line of GODEBUG=gctrace=1, e.g.
+// "gc 1 @0.015s 4%: 0.016+3.8+0.006 ms clock, ...". The first and the last clock times are the pauses.
+var gcTraceRegexp = regexp.MustCompile(`^gc \d+ @[\d.]+s \d+%: ([\d.]+)\+[\d.]+\+([\d.]+) ms clock`)
+
+func parseGCTrace(r io.Reader) gcStats {
+	var stats gcStats
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		m := gcTraceRegexp.FindStringSubmatch(s.Text())
+		if m == nil {
+			continue
+		}
+		stats.cycles++
+		for _, ms := range m[1:] {
+			v, _ := strconv.ParseFloat(ms, 64)
+			stats.pause += v * 1e6
+		}
+	}
+	return stats
+}
+
+// schedulerLatency returns the nanoseconds goroutines waited to run after they were runnable in the trace,
+// from "go tool trace -pprof=sched".
+func schedulerLatency(trace string) (float64, error) {
+	args := []string{"tool", "trace", "-pprof=sched", trace}
+	debugf("exec: go %s", strings.Join(args, " "))
+	out, err := exec.Command("go", args...).Output()
+	if err != nil {
+		return 0, xerrors.Errorf("failed to run 'go %s' command: %w", strings.Join(args, " "), err)
+	}
+	p, err := parsePprof(out)
+	if err != nil {
+		return 0, err
+	}
+	i := p.valueIndex("delay")
+	if i < 0 {
+		return 0, xerrors.New("no delay in the scheduler latency profile")
+	}
+	var delay float64
+	for _, s := range p.samples {
+		if i < len(s.values) {
+			delay += float64(s.values[i])
+		}
+	}
+	return delay, nil
+}
+
+// newTraceSummaries summarizes the scheduler latency and the garbage collections in the traces of each
+// benchmark at both commits.
+func newTraceSummaries(benchmarks []report.Benchmark, baseFiles, headFiles profileFiles, baseGC, headGC map[string]gcStats) []report.TraceSummary {
+	var summaries []report.TraceSummary
+	for _, b := range benchmarks {
+		s := report.TraceSummary{
+			Name: b.Name,
+			Base: traceStats(b, baseFiles[b.Name][traceProfile.name], baseGC[b.Name]),
+			Head: traceStats(b, headFiles[b.Name][traceProfile.name], headGC[b.Name]),
+		}
+		if s.Base == nil && s.Head == nil {
+			continue
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries
+}
+
+func traceStats(b report.Benchmark, trace string, gc gcStats) *report.TraceStats {
+	if trace == "" {
+		return nil
+	}
+	ops := profiledOps(b.Head.Iterations)
+	stats := &report.TraceStats{GCCycles: gc.cycles, GCPause: gc.pause / ops}
+	latency, err := schedulerLatency(trace)
+	if err != nil {
+		warnf("Failed to summarize the trace of %s: %s", b.Name, err)
+	}
+	stats.SchedulerLatency = latency / ops
+	return stats
+}
+
+func showTraceSummaries(w io.Writer, summaries []report.TraceSummary, style string) {
+	if len(summaries) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nExecution traces")
+	fmt.Fprintf(w, "%s\n\n", strings.Repeat("=", 16))
+
+	table := tablewriter.NewWriter(w)
+	table.SetAutoFormatHeaders(false)
+	applyTableStyle(table, style)
+	table.SetHeader([]string{"Name", "Base sched ns/op", "HEAD sched ns/op", "Base GCs", "HEAD GCs", "Base GC pause ns/op", "HEAD GC pause ns/op"})
+	for _, s := range summaries {
+		row := []string{s.Name, "-", "-", "-", "-", "-", "-"}
+		if s.Base != nil {
+			row[1] = strconv.FormatFloat(s.Base.SchedulerLatency, 'f', 2, 64)
+			row[3] = strconv.Itoa(s.Base.GCCycles)
+			row[5] = strconv.FormatFloat(s.Base.GCPause, 'f', 2, 64)
+		}
+		if s.Head != nil {
+			row[2] = strconv.FormatFloat(s.Head.SchedulerLatency, 'f', 2, 64)
+			row[4] = strconv.Itoa(s.Head.GCCycles)
+			row[6] = strconv.FormatFloat(s.Head.GCPause, 'f', 2, 64)
+		}
+		table.Append(row)
+	}
+	table.Render()
+}
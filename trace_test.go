@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseGCTrace(t *testing.T) {
+	got := parseGCTrace(strings.NewReader(`goos: linux
+gc 1 @0.015s 4%: 0.016+3.8+0.004 ms clock, 0.016+0.92/0/0+0.006 ms cpu, 3->4->1 MB, 4 MB goal, 0 MB stacks, 0 MB globals, 1 P
+BenchmarkA-8   	     100	     12000 ns/op
+gc 2 @0.025s 8%: 0.030+3.8+0.010 ms clock, 0.014+0.53/1.0/0+0.004 ms cpu, 3->4->2 MB, 4 MB goal, 0 MB stacks, 0 MB globals, 1 P
+PASS
+`))
+	assert.Equal(t, 2, got.cycles)
+	assert.InDelta(t, 60000, got.pause, 1e-6)
+}
+
+func Test_showTraceSummaries(t *testing.T) {
+	benchmarks := []report.Benchmark{{Name: "BenchmarkA-8", Head: report.Measurement{Iterations: 9}}}
+	assert.Nil(t, newTraceSummaries(benchmarks, nil, nil, nil, nil))
+
+	w := &bytes.Buffer{}
+	showTraceSummaries(w, []report.TraceSummary{{
+		Name: "BenchmarkA-8",
+		Base: &report.TraceStats{SchedulerLatency: 120, GCCycles: 2, GCPause: 8},
+		Head: &report.TraceStats{SchedulerLatency: 2500, GCCycles: 7, GCPause: 31.5},
+	}}, "ascii")
+	got := w.String()
+	assert.Contains(t, got, "Execution traces\n================")
+	assert.Contains(t, got, "| BenchmarkA-8 |           120.00 |          2500.00 |        2 |        7 |                8.00 |               31.50 |")
+}
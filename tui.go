@@ -0,0 +1,509 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/cob"
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh/terminal"
+	"golang.org/x/tools/benchmark/parse"
+	"golang.org/x/xerrors"
+)
+
+var tuiCommand = &cli.Command{
+	Name:      "tui",
+	Usage:     "Browse a JSON report in the terminal: expand sub-benchmarks, sort, filter, show the history and re-run benchmarks",
+	ArgsUsage: "REPORT",
+	Flags: append(flagsNamed(runFlags, "compare", "bench-cmd", "bench-args"),
+		&cli.StringFlag{
+			Name:  "store",
+			Usage: "Store the runs were recorded in with '-store', for the history of each benchmark",
+			Value: defaultStore,
+		},
+		&cli.StringFlag{
+			Name:  "machine",
+			Usage: "Show the history only of runs on machines with the tag given by '-machine'",
+		},
+		&cli.IntFlag{
+			Name:  "count",
+			Usage: "Number of times a benchmark runs when it is re-run",
+			Value: 1,
+		},
+	),
+	Action: func(c *cli.Context) error {
+		if c.Args().Len() != 1 {
+			return cli.ShowSubcommandHelp(c)
+		}
+		rep, err := readReport(c.Args().First())
+		if err != nil {
+			return err
+		}
+		m := newTUIModel(rep, whichScoreToCompare(strings.Split(c.String("compare"), ",")))
+		if c.String("store") != "" {
+			st, err := openStore(c.String("store"))
+			if err != nil {
+				return xerrors.Errorf("failed to open the store: %w", err)
+			}
+			runs, err := st.runs()
+			if err != nil {
+				return xerrors.Errorf("failed to read the history: %w", err)
+			}
+			if c.IsSet("machine") {
+				runs = runsOnMachine(runs, c.String("machine"))
+			}
+			m.history = tuiHistory(runs, rep, tuiHistoryLength)
+		}
+		// The benchmarks are re-run built like those of the report.
+		if rep.Environment != nil {
+			if err := buildFlagsOf(*rep.Environment).apply(); err != nil {
+				return xerrors.Errorf("failed to set the build flags of the report: %w", err)
+			}
+		}
+		benchCmd, benchArgs, count := c.String("bench-cmd"), strings.Fields(c.String("bench-args")), c.Int("count")
+		m.rerun = func(funcName string) (parse.Set, error) { return rerunBenchmark(benchCmd, benchArgs, funcName, count) }
+		return runTUI(m, os.Stdin, os.Stdout)
+	},
+}
+
+// tuiHistoryLength is the number of recorded runs in the sparkline of a benchmark.
+const tuiHistoryLength = 20
+
+// tuiHistory returns the ns/op at HEAD of the last n recorded runs of the benchmarks in the report, oldest first.
+func tuiHistory(runs []historyRun, rep report.Report, n int) map[string][]float64 {
+	history := map[string][]float64{}
+	for _, b := range rep.Benchmarks {
+		for _, p := range benchmarkHistory(runs, b.Name, n) {
+			history[b.Name] = append(history[b.Name], p.NsPerOp)
+		}
+	}
+	return history
+}
+
+// rerunBenchmark runs the benchmark function again at HEAD with -bench-cmd and -bench-args, which must run
+// "go test", so that the other flags, e.g. -benchtime or -tags, are those of the run which wrote the report.
+func rerunBenchmark(benchCmd string, benchArgs []string, funcName string, count int) (parse.Set, error) {
+	if !isGoTest(benchCmd, benchArgs) {
+		return nil, xerrors.New("re-running a benchmark needs 'go test' in -bench-cmd and -bench-args")
+	}
+	funcs, err := findBenchmarkFuncs(".")
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := funcs[funcName]; !ok {
+		return nil, xerrors.Errorf("%s is not found in the test files", funcName)
+	}
+	return runBenchmark(benchCmd, rerunArgs(benchArgs, funcName, count))
+}
+
+// rerunArgs returns the arguments of "go test" which run only the benchmark function and its sub-benchmarks count
+// times.
+func rerunArgs(benchArgs []string, funcName string, count int) []string {
+	args := withGoTestFlag(benchArgs, "bench", "^"+funcName+"$")
+	return withGoTestFlag(args, "count", strconv.Itoa(count))
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws the values from low to high.
+func sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	min, max := values[0], values[0]
+	for _, v := range values {
+		min, max = math.Min(min, v), math.Max(max, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if max > min {
+			i = int((v - min) / (max - min) * float64(len(sparks)-1))
+		}
+		b.WriteRune(sparks[i])
+	}
+	return b.String()
+}
+
+// tuiSortKeys are the orders which "s" cycles through. The ratios are sorted from the worst.
+var tuiSortKeys = []string{"name", "ns/op", "B/op", "status"}
+
+// tuiGroup is a benchmark function with its sub-benchmarks, or a single benchmark.
+type tuiGroup struct {
+	name       string
+	benchmarks []report.Benchmark
+}
+
+func (g tuiGroup) single() bool {
+	return len(g.benchmarks) == 1 && !strings.Contains(g.benchmarks[0].Name, "/")
+}
+
+// summary returns the worst ratios and status of the sub-benchmarks.
+func (g tuiGroup) summary() report.Benchmark {
+	if g.single() {
+		return g.benchmarks[0]
+	}
+	s := report.Benchmark{Name: g.name, Status: report.StatusOK}
+	for i, b := range g.benchmarks {
+		if i == 0 || b.Ratio.NsPerOp > s.Ratio.NsPerOp {
+			s.Ratio.NsPerOp = b.Ratio.NsPerOp
+		}
+		if i == 0 || b.Ratio.AllocedBytesPerOp > s.Ratio.AllocedBytesPerOp {
+			s.Ratio.AllocedBytesPerOp = b.Ratio.AllocedBytesPerOp
+		}
+		if statusRank(b.Status) > statusRank(s.Status) {
+			s.Status = b.Status
+		}
+	}
+	return s
+}
+
+func statusRank(s string) int {
+	switch s {
+	case report.StatusFail:
+		return 2
+	case report.StatusWarn:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// tuiRow is a line of the table: a group, or a sub-benchmark of an expanded group.
+type tuiRow struct {
+	group     *tuiGroup
+	benchmark report.Benchmark
+	sub       bool
+}
+
+// tuiModel is the state of "cob tui". Keys update it, and view renders it, so that it can be tested without a
+// terminal.
+type tuiModel struct {
+	rep   report.Report
+	score comparedScore
+	// history is the ns/op of the recorded runs of each benchmark, oldest first.
+	history  map[string][]float64
+	expanded map[string]bool
+	sortKey  int
+	filter   string
+	// filtering is whether keys are typed into the filter.
+	filtering bool
+	cursor    int
+	offset    int
+	height    int
+	message   string
+	// rerun runs a benchmark function at HEAD.
+	rerun func(funcName string) (parse.Set, error)
+	quit  bool
+}
+
+func newTUIModel(rep report.Report, score comparedScore) *tuiModel {
+	return &tuiModel{rep: rep, score: score, expanded: map[string]bool{}, height: 24}
+}
+
+func (m *tuiModel) groups() []tuiGroup {
+	byName := map[string]*tuiGroup{}
+	var groups []*tuiGroup
+	filter := strings.ToLower(m.filter)
+	for _, b := range m.rep.Benchmarks {
+		name := benchmarkFuncName(b.Name)
+		if filter != "" && !strings.Contains(strings.ToLower(b.Name), filter) {
+			continue
+		}
+		g, ok := byName[name]
+		if !ok {
+			g = &tuiGroup{name: name}
+			byName[name] = g
+			groups = append(groups, g)
+		}
+		g.benchmarks = append(g.benchmarks, b)
+	}
+	sorted := make([]tuiGroup, len(groups))
+	for i, g := range groups {
+		m.sortBenchmarks(g.benchmarks)
+		sorted[i] = *g
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return m.less(sorted[i].summary(), sorted[j].summary())
+	})
+	return sorted
+}
+
+func (m *tuiModel) sortBenchmarks(benchmarks []report.Benchmark) {
+	sort.SliceStable(benchmarks, func(i, j int) bool { return m.less(benchmarks[i], benchmarks[j]) })
+}
+
+func (m *tuiModel) less(a, b report.Benchmark) bool {
+	switch tuiSortKeys[m.sortKey] {
+	case "ns/op":
+		if a.Ratio.NsPerOp != b.Ratio.NsPerOp {
+			return a.Ratio.NsPerOp > b.Ratio.NsPerOp
+		}
+	case "B/op":
+		if a.Ratio.AllocedBytesPerOp != b.Ratio.AllocedBytesPerOp {
+			return a.Ratio.AllocedBytesPerOp > b.Ratio.AllocedBytesPerOp
+		}
+	case "status":
+		if statusRank(a.Status) != statusRank(b.Status) {
+			return statusRank(a.Status) > statusRank(b.Status)
+		}
+	}
+	return a.Name < b.Name
+}
+
+func (m *tuiModel) rows() []tuiRow {
+	var rows []tuiRow
+	for _, g := range m.groups() {
+		g := g
+		rows = append(rows, tuiRow{group: &g, benchmark: g.summary()})
+		if g.single() || !(m.expanded[g.name] || m.filter != "") {
+			continue
+		}
+		for _, b := range g.benchmarks {
+			rows = append(rows, tuiRow{group: &g, benchmark: b, sub: true})
+		}
+	}
+	return rows
+}
+
+// update applies a key: "up", "down", "left", "right", "enter", "esc", "backspace", "ctrl+c" or a character.
+func (m *tuiModel) update(key string) {
+	if m.filtering {
+		switch key {
+		case "enter":
+			m.filtering = false
+		case "esc":
+			m.filtering = false
+			m.filter = ""
+		case "backspace":
+			if r := []rune(m.filter); len(r) > 0 {
+				m.filter = string(r[:len(r)-1])
+			}
+		case "ctrl+c":
+			m.quit = true
+		default:
+			if len([]rune(key)) == 1 {
+				m.filter += key
+			}
+		}
+		m.cursor = 0
+		return
+	}
+
+	rows := m.rows()
+	switch key {
+	case "q", "ctrl+c":
+		m.quit = true
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(rows)-1 {
+			m.cursor++
+		}
+	case "enter", " ", "right", "left":
+		if m.cursor < len(rows) && !rows[m.cursor].group.single() {
+			name := rows[m.cursor].group.name
+			switch key {
+			case "right":
+				m.expanded[name] = true
+			case "left":
+				m.expanded[name] = false
+			default:
+				m.expanded[name] = !m.expanded[name]
+			}
+			// Collapsing a group from a sub-benchmark moves to the group.
+			for i, r := range m.rows() {
+				if r.group.name == name && !r.sub && !m.expanded[name] {
+					m.cursor = i
+				}
+			}
+		}
+	case "s":
+		m.sortKey = (m.sortKey + 1) % len(tuiSortKeys)
+		m.message = "Sorted by " + tuiSortKeys[m.sortKey]
+	case "/":
+		m.filtering = true
+		m.message = ""
+	case "esc":
+		m.filter = ""
+		m.cursor = 0
+	case "r":
+		if m.cursor < len(rows) {
+			m.rerunGroup(rows[m.cursor].group.name)
+		}
+	}
+	m.scroll()
+}
+
+// rerunGroup runs the benchmark function again, and compares the new results at HEAD with the base commit.
+func (m *tuiModel) rerunGroup(funcName string) {
+	if m.rerun == nil {
+		return
+	}
+	set, err := m.rerun(funcName)
+	if err != nil {
+		m.message = fmt.Sprintf("Failed to re-run %s: %s", funcName, err)
+		return
+	}
+	updated := 0
+	for i, b := range m.rep.Benchmarks {
+		runs, ok := set[b.Name]
+		if !ok || benchmarkFuncName(b.Name) != funcName {
+			continue
+		}
+		r := cob.NewResult(b.Name, []*parse.Benchmark{newBenchmark(b.Name, b.Base)}, runs, b.Threshold, 0)
+		m.rep.Benchmarks[i].Head = cob.NewMeasurement(r.Head)
+		m.rep.Benchmarks[i].Ratio = report.Ratio{
+			NsPerOp:           r.RatioNsPerOp,
+			AllocedBytesPerOp: r.RatioAllocedBytesPerOp,
+			AllocsPerOp:       r.RatioAllocsPerOp,
+		}
+		m.rep.Benchmarks[i].Status = benchmarkStatus(r, m.rep.Threshold, m.score).String()
+		m.rep.Benchmarks[i].PValue = nil
+		updated++
+	}
+	m.message = fmt.Sprintf("Re-ran %d benchmark(s) of %s at HEAD", updated, funcName)
+}
+
+// scroll keeps the cursor in the rows which fit between the header and the footer.
+func (m *tuiModel) scroll() {
+	visible := m.height - 4
+	if visible < 1 {
+		visible = 1
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+visible {
+		m.offset = m.cursor - visible + 1
+	}
+}
+
+func (m *tuiModel) view() string {
+	var b strings.Builder
+	rep := m.rep
+	fmt.Fprintf(&b, "%s (%s) vs %s (%s), threshold %.2f%%, sorted by %s\r\n",
+		rep.Head.Ref, shortHash(rep.Head.Hash), rep.Base.Ref, shortHash(rep.Base.Hash), 100*rep.Threshold, tuiSortKeys[m.sortKey])
+	fmt.Fprintf(&b, "  %-50s %10s %10s %-6s %s\r\n", "Name", "NsPerOp", "B/op", "Status", "History")
+
+	rows := m.rows()
+	visible := m.height - 4
+	if visible < 1 {
+		visible = 1
+	}
+	for i := m.offset; i < len(rows) && i < m.offset+visible; i++ {
+		r := rows[i]
+		marker := "  "
+		switch {
+		case r.sub:
+			marker = "    "
+		case !r.group.single() && (m.expanded[r.group.name] || m.filter != ""):
+			marker = "▾ "
+		case !r.group.single():
+			marker = "▸ "
+		}
+		name := marker + r.benchmark.Name
+		line := fmt.Sprintf("%-52s %10s %10s %-6s %s", name, formatRatio(r.benchmark.Ratio.NsPerOp, m.score.nsPerOp),
+			formatRatio(r.benchmark.Ratio.AllocedBytesPerOp, m.score.allocedBytesPerOp), strings.ToUpper(r.benchmark.Status),
+			sparkline(m.history[r.benchmark.Name]))
+		if i == m.cursor {
+			line = "\x1b[7m" + line + "\x1b[0m"
+		}
+		b.WriteString(line + "\r\n")
+	}
+	if len(rows) == 0 {
+		b.WriteString("  No benchmarks match the filter\r\n")
+	}
+
+	if m.filtering {
+		fmt.Fprintf(&b, "\r\nFilter: %s█", m.filter)
+	} else {
+		help := "↑/↓ move  enter expand  s sort  / filter  r re-run  q quit"
+		if m.filter != "" {
+			help = fmt.Sprintf("filter %q (esc to clear)  %s", m.filter, help)
+		}
+		if m.message != "" {
+			help = m.message + "  |  " + help
+		}
+		b.WriteString("\r\n" + help)
+	}
+	return b.String()
+}
+
+func formatRatio(ratio float64, compared bool) string {
+	if !compared {
+		return "-"
+	}
+	return fmt.Sprintf("%+.2f%%", 100*ratio)
+}
+
+// readKey reads a key from a terminal in raw mode, in the names of tuiModel.update.
+func readKey(r io.Reader) (string, error) {
+	buf := make([]byte, 16)
+	n, err := r.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return parseKey(buf[:n]), nil
+}
+
+func parseKey(b []byte) string {
+	switch string(b) {
+	case "\x1b[A", "\x1bOA":
+		return "up"
+	case "\x1b[B", "\x1bOB":
+		return "down"
+	case "\x1b[C", "\x1bOC":
+		return "right"
+	case "\x1b[D", "\x1bOD":
+		return "left"
+	case "\r", "\n":
+		return "enter"
+	case "\x1b":
+		return "esc"
+	case "\x7f", "\b":
+		return "backspace"
+	case "\x03":
+		return "ctrl+c"
+	}
+	return string(b)
+}
+
+// runTUI runs the model on the terminal until it quits.
+func runTUI(m *tuiModel, in *os.File, out io.Writer) error {
+	fd := int(in.Fd())
+	if !terminal.IsTerminal(fd) {
+		return xerrors.New("cob tui needs a terminal")
+	}
+	state, err := terminal.MakeRaw(fd)
+	if err != nil {
+		return xerrors.Errorf("failed to set up the terminal: %w", err)
+	}
+	defer terminal.Restore(fd, state)
+	// The alternate screen keeps the shell's scrollback intact.
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	for !m.quit {
+		if _, h, err := terminal.GetSize(fd); err == nil {
+			m.height = h
+		}
+		fmt.Fprint(out, "\x1b[H\x1b[2J"+m.view())
+		key, err := readKey(in)
+		if err != nil {
+			return xerrors.Errorf("failed to read a key: %w", err)
+		}
+		if key == "r" && !m.filtering {
+			fmt.Fprint(out, "\r\x1b[2KRe-running the benchmark...")
+		}
+		m.update(key)
+	}
+	return nil
+}
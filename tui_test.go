@@ -0,0 +1,154 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/benchmark/parse"
+	"golang.org/x/xerrors"
+)
+
+func tuiTestReport() report.Report {
+	return report.Report{
+		Threshold: 0.2,
+		Base:      report.Commit{Ref: "HEAD~1", Hash: "1111111111"},
+		Head:      report.Commit{Ref: "HEAD", Hash: "2222222222"},
+		Benchmarks: []report.Benchmark{
+			{Name: "BenchmarkB-8", Status: report.StatusOK, Ratio: report.Ratio{NsPerOp: 0.05},
+				Base: report.Measurement{Iterations: 100, NsPerOp: 100}, Head: report.Measurement{Iterations: 100, NsPerOp: 105}},
+			{Name: "BenchmarkA/small-8", Status: report.StatusOK, Ratio: report.Ratio{NsPerOp: 0.1, AllocedBytesPerOp: 0.5},
+				Base: report.Measurement{Iterations: 100, NsPerOp: 100}, Head: report.Measurement{Iterations: 100, NsPerOp: 110}},
+			{Name: "BenchmarkA/large-8", Status: report.StatusFail, Ratio: report.Ratio{NsPerOp: 0.4},
+				Base: report.Measurement{Iterations: 100, NsPerOp: 100}, Head: report.Measurement{Iterations: 100, NsPerOp: 140}},
+		},
+	}
+}
+
+func tuiRowNames(m *tuiModel) []string {
+	var names []string
+	for _, r := range m.rows() {
+		names = append(names, r.benchmark.Name)
+	}
+	return names
+}
+
+func Test_tuiModel(t *testing.T) {
+	m := newTUIModel(tuiTestReport(), comparedScore{nsPerOp: true, allocedBytesPerOp: true})
+	assert.Equal(t, []string{"BenchmarkA", "BenchmarkB-8"}, tuiRowNames(m))
+	a := m.rows()[0].benchmark
+	assert.Equal(t, report.StatusFail, a.Status, "the worst status of the sub-benchmarks")
+	assert.Equal(t, 0.4, a.Ratio.NsPerOp)
+	assert.Equal(t, 0.5, a.Ratio.AllocedBytesPerOp)
+
+	m.update("enter")
+	assert.Equal(t, []string{"BenchmarkA", "BenchmarkA/large-8", "BenchmarkA/small-8", "BenchmarkB-8"}, tuiRowNames(m))
+	m.update("down")
+	m.update("j")
+	assert.Equal(t, 2, m.cursor)
+	m.update("left")
+	assert.Equal(t, []string{"BenchmarkA", "BenchmarkB-8"}, tuiRowNames(m))
+	assert.Equal(t, 0, m.cursor, "collapsing moves to the group")
+	m.update("up")
+	assert.Equal(t, 0, m.cursor)
+
+	m.update("s")
+	assert.Equal(t, "ns/op", tuiSortKeys[m.sortKey])
+	m.update("right")
+	assert.Equal(t, []string{"BenchmarkA", "BenchmarkA/large-8", "BenchmarkA/small-8", "BenchmarkB-8"}, tuiRowNames(m))
+	m.update("s")
+	assert.Equal(t, []string{"BenchmarkA", "BenchmarkA/small-8", "BenchmarkA/large-8", "BenchmarkB-8"}, tuiRowNames(m))
+
+	m.update("/")
+	for _, k := range []string{"s", "m", "x"} {
+		m.update(k)
+	}
+	m.update("backspace")
+	m.update("enter")
+	assert.Equal(t, "sm", m.filter)
+	assert.Equal(t, []string{"BenchmarkA", "BenchmarkA/small-8"}, tuiRowNames(m), "groups matching the filter are expanded")
+	m.update("esc")
+	assert.Equal(t, "", m.filter)
+
+	m.update("q")
+	assert.True(t, m.quit)
+}
+
+func Test_tuiModel_rerun(t *testing.T) {
+	m := newTUIModel(tuiTestReport(), comparedScore{nsPerOp: true})
+	var ran string
+	m.rerun = func(funcName string) (parse.Set, error) {
+		ran = funcName
+		return parse.Set{
+			"BenchmarkA/large-8": {{Name: "BenchmarkA/large-8", N: 100, NsPerOp: 95}},
+		}, nil
+	}
+	m.update("r")
+	assert.Equal(t, "BenchmarkA", ran)
+	large := m.rep.Benchmarks[2]
+	assert.Equal(t, 95.0, large.Head.NsPerOp)
+	assert.InDelta(t, -0.05, large.Ratio.NsPerOp, 1e-9)
+	assert.Equal(t, report.StatusOK, large.Status)
+	assert.Equal(t, 110.0, m.rep.Benchmarks[1].Head.NsPerOp, "benchmarks which didn't run are kept")
+	assert.Contains(t, m.message, "Re-ran 1 benchmark(s) of BenchmarkA")
+
+	m.rerun = func(string) (parse.Set, error) { return nil, xerrors.New("exit status 1") }
+	m.update("r")
+	assert.Equal(t, "Failed to re-run BenchmarkA: exit status 1", m.message)
+}
+
+func Test_tuiModel_view(t *testing.T) {
+	m := newTUIModel(tuiTestReport(), comparedScore{nsPerOp: true})
+	m.history = map[string][]float64{"BenchmarkB-8": {100, 110, 120}}
+	v := m.view()
+	lines := strings.Split(v, "\r\n")
+	require.True(t, len(lines) > 4)
+	assert.Contains(t, lines[0], "HEAD (2222222) vs HEAD~1 (1111111)")
+	assert.Contains(t, lines[2], "\x1b[7m▸ BenchmarkA")
+	assert.Contains(t, lines[2], "+40.00%")
+	assert.Contains(t, lines[2], "FAIL")
+	assert.Contains(t, lines[3], "BenchmarkB-8")
+	assert.Contains(t, lines[3], "▁▄█")
+	assert.NotContains(t, lines[3], "+0.00%", "B/op isn't compared")
+
+	m.height = 5
+	m.update("down")
+	lines = strings.Split(m.view(), "\r\n")
+	assert.Contains(t, lines[2], "BenchmarkB-8", "the table scrolls to the cursor")
+}
+
+func Test_sparkline(t *testing.T) {
+	assert.Equal(t, "", sparkline(nil))
+	assert.Equal(t, "▁▁", sparkline([]float64{5, 5}))
+	assert.Equal(t, "▁▅█▁", sparkline([]float64{10, 15, 18, 10}))
+}
+
+func Test_parseKey(t *testing.T) {
+	tests := map[string]string{
+		"\x1b[A": "up",
+		"\x1b[B": "down",
+		"\x1b[C": "right",
+		"\x1b[D": "left",
+		"\r":     "enter",
+		"\x1b":   "esc",
+		"\x7f":   "backspace",
+		"\x03":   "ctrl+c",
+		"s":      "s",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, parseKey([]byte(in)), "%q", in)
+	}
+}
+
+func Test_rerunArgs(t *testing.T) {
+	args := strings.Fields("test -run ^$ -bench . -benchmem -benchtime 2s -count=10 -tags integration ./...")
+	assert.Equal(t, strings.Fields("test -run ^$ -bench ^BenchmarkA$ -benchmem -benchtime 2s -count 3 -tags integration ./..."),
+		rerunArgs(args, "BenchmarkA", 3))
+}
+
+func Test_rerunBenchmark(t *testing.T) {
+	_, err := rerunBenchmark("make", []string{"bench"}, "BenchmarkA", 1)
+	assert.EqualError(t, err, "re-running a benchmark needs 'go test' in -bench-cmd and -bench-args")
+}
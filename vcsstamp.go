@@ -0,0 +1,22 @@
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import "runtime/debug"
+
+// vcsStampOf returns the VCS stamp in the settings of the build info, which Go 1.18 added.
+func vcsStampOf(info *debug.BuildInfo) vcsStamp {
+	var stamp vcsStamp
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			stamp.revision = s.Value
+		case "vcs.time":
+			stamp.time = s.Value
+		case "vcs.modified":
+			stamp.modified = s.Value == "true"
+		}
+	}
+	return stamp
+}
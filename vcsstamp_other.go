@@ -0,0 +1,11 @@
+//go:build !go1.18
+// +build !go1.18
+
+package main
+
+import "runtime/debug"
+
+// vcsStampOf returns no VCS stamp, because the go command before Go 1.18 doesn't stamp binaries.
+func vcsStampOf(*debug.BuildInfo) vcsStamp {
+	return vcsStamp{}
+}
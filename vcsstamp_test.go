@@ -0,0 +1,28 @@
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newBuildInfo_vcsStamp(t *testing.T) {
+	stamped := &debug.BuildInfo{
+		Main: debug.Module{Path: "github.com/knqyf263/cob", Version: "v0.2.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0fd1ee6"},
+			{Key: "vcs.time", Value: "2020-01-09T03:04:05Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	assert.Equal(t, report.BuildInfo{Version: "0.2.0", Commit: "0fd1ee6-dirty", Date: "2020-01-09T03:04:05Z", GoVersion: runtime.Version()},
+		newBuildInfo("dev", "", "", stamped))
+	assert.Equal(t, report.BuildInfo{Version: "0.1.0", Commit: "2c335e6", Date: "2020-01-02T03:04:05Z", GoVersion: runtime.Version()},
+		newBuildInfo("0.1.0", "2c335e6", "2020-01-02T03:04:05Z", stamped), "goreleaser takes precedence")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// vendorManifest is the file which "go mod vendor" writes with the modules in vendor.
+const vendorManifest = "vendor/modules.txt"
+
+// vendored returns whether the worktree at dir vendors its dependencies.
+func vendored(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(vendorManifest)))
+	return err == nil
+}
+
+// vendorFlags builds each commit checked out from its vendor directory if it has one. Without -mod=vendor, go
+// before 1.14, or a go.mod with a go directive before 1.14, ignores the vendor directory.
+type vendorFlags struct {
+	// dir is the root of the worktree, or "" for the current directory.
+	dir string
+	// goflags is GOFLAGS of cob without -mod=vendor.
+	goflags string
+}
+
+// apply sets GOFLAGS for the worktree as it is, and returns whether it is built from the vendor directory. A
+// -mod flag in GOFLAGS or -goflags is kept as it is.
+func (v vendorFlags) apply() (bool, error) {
+	flags := v.goflags
+	vendor := vendored(v.dir) && !hasModFlag(v.goflags)
+	if vendor {
+		flags = strings.TrimSpace(flags + " -mod=vendor")
+	}
+	if err := os.Setenv("GOFLAGS", flags); err != nil {
+		return false, xerrors.Errorf("failed to set GOFLAGS: %w", err)
+	}
+	return vendor, nil
+}
+
+func hasModFlag(goflags string) bool {
+	for _, f := range strings.Fields(goflags) {
+		if strings.HasPrefix(f, "-mod=") || strings.HasPrefix(f, "--mod=") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_vendorFlags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer os.Setenv("GOFLAGS", os.Getenv("GOFLAGS"))
+
+	v := vendorFlags{dir: dir, goflags: "-trimpath"}
+	vendor, err := v.apply()
+	require.NoError(t, err)
+	assert.False(t, vendor)
+	assert.Equal(t, "-trimpath", os.Getenv("GOFLAGS"))
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "vendor"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "vendor", "modules.txt"), nil, 0644))
+	vendor, err = v.apply()
+	require.NoError(t, err)
+	assert.True(t, vendor)
+	assert.Equal(t, "-trimpath -mod=vendor", os.Getenv("GOFLAGS"))
+
+	v.goflags = "-mod=mod"
+	vendor, err = v.apply()
+	require.NoError(t, err)
+	assert.False(t, vendor, "-mod of the user is kept")
+	assert.Equal(t, "-mod=mod", os.Getenv("GOFLAGS"))
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+// version, commit and date are set by goreleaser with -X main.version, -X main.commit and -X main.date.
+var (
+	version = "dev"
+	commit  string
+	date    string
+)
+
+var versionCommand = &cli.Command{
+	Name:  "version",
+	Usage: "Print the version of cob, the commit and date it was built from, and the Go version it was built with",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format (text, json)",
+			Value: "text",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		return writeBuildInfo(os.Stdout, currentBuildInfo(), c.String("format"))
+	},
+}
+
+func currentBuildInfo() report.BuildInfo {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		info = nil
+	}
+	return newBuildInfo(version, commit, date, info)
+}
+
+// newBuildInfo returns the build info set by goreleaser. Builds without it, e.g. with "go install", fall back to
+// the module version and the VCS stamp of the go command. The commit of a build with uncommitted changes ends
+// with "-dirty".
+func newBuildInfo(version, commit, date string, info *debug.BuildInfo) report.BuildInfo {
+	b := report.BuildInfo{Version: version, Commit: commit, Date: date, GoVersion: runtime.Version()}
+	if info == nil {
+		return b
+	}
+	if b.Version == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		b.Version = strings.TrimPrefix(info.Main.Version, "v")
+	}
+	if b.Commit != "" {
+		return b
+	}
+	stamp := vcsStampOf(info)
+	b.Commit = stamp.revision
+	if b.Date == "" {
+		b.Date = stamp.time
+	}
+	if stamp.modified && b.Commit != "" {
+		b.Commit += "-dirty"
+	}
+	return b
+}
+
+// vcsStamp is the VCS information the go command stamps into binaries built in a repository.
+type vcsStamp struct {
+	revision string
+	time     string
+	modified bool
+}
+
+func writeBuildInfo(w io.Writer, b report.BuildInfo, format string) error {
+	switch format {
+	case "text":
+		orUnknown := func(s string) string {
+			if s == "" {
+				return "unknown"
+			}
+			return s
+		}
+		_, err := fmt.Fprintf(w, "Version:    %s\nCommit:     %s\nBuilt:      %s\nGo version: %s %s/%s\n",
+			b.Version, orUnknown(b.Commit), orUnknown(b.Date), b.GoVersion, runtime.GOOS, runtime.GOARCH)
+		return err
+	case "json":
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(b)
+	}
+	return xerrors.Errorf("unknown output format: %s", format)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_newBuildInfo(t *testing.T) {
+	stamped := &debug.BuildInfo{Main: debug.Module{Path: "github.com/knqyf263/cob", Version: "v0.2.0"}}
+	tests := []struct {
+		name    string
+		version string
+		commit  string
+		date    string
+		info    *debug.BuildInfo
+		want    report.BuildInfo
+	}{
+		{
+			name:    "goreleaser",
+			version: "0.1.0",
+			commit:  "2c335e6",
+			date:    "2020-01-02T03:04:05Z",
+			info:    stamped,
+			want:    report.BuildInfo{Version: "0.1.0", Commit: "2c335e6", Date: "2020-01-02T03:04:05Z"},
+		},
+		{
+			name:    "go install",
+			version: "dev",
+			info:    stamped,
+			want:    report.BuildInfo{Version: "0.2.0"},
+		},
+		{
+			name:    "go build",
+			version: "dev",
+			info:    &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}},
+			want:    report.BuildInfo{Version: "dev"},
+		},
+		{
+			name:    "no build info",
+			version: "dev",
+			want:    report.BuildInfo{Version: "dev"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.GoVersion = runtime.Version()
+			assert.Equal(t, tt.want, newBuildInfo(tt.version, tt.commit, tt.date, tt.info))
+		})
+	}
+}
+
+func Test_writeBuildInfo(t *testing.T) {
+	b := report.BuildInfo{Version: "0.1.0", Commit: "2c335e6", GoVersion: "go1.14"}
+
+	var text bytes.Buffer
+	require.NoError(t, writeBuildInfo(&text, b, "text"))
+	assert.Equal(t, "Version:    0.1.0\nCommit:     2c335e6\nBuilt:      unknown\nGo version: go1.14 "+runtime.GOOS+"/"+runtime.GOARCH+"\n", text.String())
+
+	var json bytes.Buffer
+	require.NoError(t, writeBuildInfo(&json, b, "json"))
+	assert.JSONEq(t, `{"version": "0.1.0", "commit": "2c335e6", "goVersion": "go1.14"}`, json.String())
+
+	err := writeBuildInfo(&json, b, "yaml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown output format: yaml")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"golang.org/x/xerrors"
+)
+
+// webhookSignatureHeader carries the HMAC-SHA256 of the body, e.g. "sha256=<hex>".
+const webhookSignatureHeader = "X-Cob-Signature-256"
+
+func signWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// postWebhook sends the report as JSON. If the secret is not empty, the body is signed with it.
+func postWebhook(client *http.Client, u, secret string, rep report.Report) error {
+	body := &bytes.Buffer{}
+	if err := report.Encode(body, rep); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return xerrors.Errorf("failed to create a request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cob")
+	if secret != "" {
+		req.Header.Set(webhookSignatureHeader, signWebhookPayload(secret, body.Bytes()))
+	}
+
+	// Only the host is shown, because the URL of a webhook, e.g. of Slack or Discord, is its secret.
+	debugf("webhook: POST %s", req.URL.Host)
+	resp, err := client.Do(req)
+	if err != nil {
+		if ue, ok := err.(*url.Error); ok {
+			err = ue.Err
+		}
+		return xerrors.Errorf("failed to send the webhook to %s: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return xerrors.Errorf("the webhook returned %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	infof("Sent the result to the webhook")
+	return nil
+}
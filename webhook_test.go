@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/knqyf263/cob/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_postWebhook(t *testing.T) {
+	tests := []struct {
+		name       string
+		secret     string
+		statusCode int
+		wantErr    string
+	}{
+		{
+			name:       "signed",
+			secret:     "secret",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "unsigned",
+			statusCode: http.StatusNoContent,
+		},
+		{
+			name:       "error",
+			statusCode: http.StatusInternalServerError,
+			wantErr:    "the webhook returned 500 Internal Server Error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := ioutil.ReadAll(r.Body)
+				require.NoError(t, err)
+				if tt.secret == "" {
+					assert.Empty(t, r.Header.Get(webhookSignatureHeader))
+				} else {
+					assert.Equal(t, signWebhookPayload(tt.secret, body), r.Header.Get(webhookSignatureHeader))
+				}
+				assert.Contains(t, string(body), `"schemaVersion": 1`)
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer ts.Close()
+
+			err := postWebhook(ts.Client(), ts.URL, tt.secret, report.Report{Benchmarks: []report.Benchmark{}})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_postWebhook_secretURL(t *testing.T) {
+	w := &bytes.Buffer{}
+	defaultLogger.mu.Lock()
+	defaultLogger.w, defaultLogger.level = w, levelDebug
+	defaultLogger.mu.Unlock()
+	defer func() {
+		defaultLogger.mu.Lock()
+		defaultLogger.w, defaultLogger.level = os.Stderr, levelInfo
+		defaultLogger.mu.Unlock()
+	}()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	u := ts.URL + "/services/T000/B000/secret"
+	require.NoError(t, postWebhook(ts.Client(), u, "", report.Report{Benchmarks: []report.Benchmark{}}))
+	ts.Close()
+	err := postWebhook(ts.Client(), u, "", report.Report{Benchmarks: []report.Benchmark{}})
+	require.Error(t, err)
+
+	host := strings.TrimPrefix(ts.URL, "http://")
+	assert.Contains(t, w.String(), "webhook: POST "+host)
+	assert.Contains(t, err.Error(), "failed to send the webhook to "+host)
+	assert.NotContains(t, w.String(), "secret")
+	assert.NotContains(t, err.Error(), "secret")
+}
+
+func Test_signWebhookPayload(t *testing.T) {
+	// echo -n '{}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=77325902caca812dc259733aacd046b73817372c777b8d95b402647474516e13",
+		signWebhookPayload("secret", []byte("{}")))
+}